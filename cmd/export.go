@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/cmd/utils"
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/services"
+	internalUtils "github.com/stellar/wallet-backend/internal/utils"
+)
+
+type exportPaymentsConfigOptions struct {
+	DatabaseURL string
+	LogLevel    logrus.Level
+	Address     string
+	From        string
+	To          string
+	Format      string
+	Output      string
+}
+
+type exportCmd struct{}
+
+func (c *exportCmd) Command() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export data straight from the database",
+	}
+	exportCmd.AddCommand(c.paymentsCommand())
+	return exportCmd
+}
+
+func (c *exportCmd) paymentsCommand() *cobra.Command {
+	cfg := exportPaymentsConfigOptions{}
+	cfgOpts := config.ConfigOptions{
+		utils.DatabaseURLOption(&cfg.DatabaseURL),
+		utils.LogLevelOption(&cfg.LogLevel),
+		{
+			Name:           "address",
+			Usage:          "The account whose payments are exported.",
+			OptType:        types.String,
+			CustomSetValue: utils.SetConfigOptionStellarPublicKey,
+			ConfigKey:      &cfg.Address,
+			Required:       true,
+		},
+		{
+			Name:        "from",
+			Usage:       "RFC 3339 timestamp of the earliest payment exported. Empty exports from the first payment.",
+			OptType:     types.String,
+			ConfigKey:   &cfg.From,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "to",
+			Usage:       "RFC 3339 timestamp the payments exported were created before. Empty exports up to the latest payment.",
+			OptType:     types.String,
+			ConfigKey:   &cfg.To,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "format",
+			Usage:       `The export format, "csv" or "jsonl".`,
+			OptType:     types.String,
+			ConfigKey:   &cfg.Format,
+			FlagDefault: string(services.PaymentExportCSV),
+			Required:    true,
+		},
+		{
+			Name:        "output",
+			Usage:       `The file the payments are written to, "-" writes them to the standard output.`,
+			OptType:     types.String,
+			ConfigKey:   &cfg.Output,
+			FlagDefault: "-",
+			Required:    true,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:               "payments",
+		Short:             "Export the successful payments of an account as CSV or JSON Lines",
+		Long:              "Export payments writes the whole successful payment history of an account within the time range, oldest first, with decimal amounts and CODE:ISSUER assets. It's the CLI equivalent of GET /payments/export.",
+		PersistentPreRunE: utils.DefaultPersistentPreRunE(cfgOpts),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return c.RunExportPayments(cmd.Context(), cfg)
+		},
+	}
+
+	if err := cfgOpts.Init(cmd); err != nil {
+		log.Fatalf("Error initializing a config option: %s", err.Error())
+	}
+
+	return cmd
+}
+
+func (c *exportCmd) RunExportPayments(ctx context.Context, cfg exportPaymentsConfigOptions) error {
+	format := services.PaymentExportFormat(cfg.Format)
+	if !format.IsValid() {
+		return fmt.Errorf("invalid format %q, expected csv or jsonl", cfg.Format)
+	}
+	filter := data.PaymentsFilter{Address: cfg.Address}
+	var err error
+	if filter.From, err = parseExportTime(cfg.From); err != nil {
+		return fmt.Errorf("parsing from: %w", err)
+	}
+	if filter.To, err = parseExportTime(cfg.To); err != nil {
+		return fmt.Errorf("parsing to: %w", err)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fmt.Errorf("to must be after from")
+	}
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("connecting to the database: %w", err)
+	}
+	defer internalUtils.DeferredClose(ctx, dbConnectionPool, "closing the database connection pool")
+	sqlxDB, err := dbConnectionPool.SqlxDB(ctx)
+	if err != nil {
+		return fmt.Errorf("getting sqlx db: %w", err)
+	}
+	models, err := data.NewModels(dbConnectionPool, metrics.NewMetricsService(sqlxDB))
+	if err != nil {
+		return fmt.Errorf("creating models: %w", err)
+	}
+	exportService, err := services.NewPaymentExportService(models)
+	if err != nil {
+		return fmt.Errorf("instantiating payment export service: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if cfg.Output != "-" {
+		file, err := os.Create(cfg.Output)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", cfg.Output, err)
+		}
+		defer internalUtils.DeferredClose(ctx, file, "closing the export file")
+		w = file
+	}
+
+	exported, err := exportService.ExportPayments(ctx, w, filter, format)
+	if err != nil {
+		return fmt.Errorf("exporting payments after %d were written: %w", exported, err)
+	}
+	log.Ctx(ctx).Infof("exported %d payments of %s", exported, cfg.Address)
+	return nil
+}
+
+func parseExportTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q as an RFC 3339 timestamp: %w", value, err)
+	}
+	return &t, nil
+}
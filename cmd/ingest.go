@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/types"
 	"net/http"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -14,6 +15,7 @@ import (
 	"github.com/stellar/wallet-backend/cmd/utils"
 	"github.com/stellar/wallet-backend/internal/apptracker/sentry"
 	"github.com/stellar/wallet-backend/internal/ingest"
+	"github.com/stellar/wallet-backend/internal/services"
 	tsschannels "github.com/stellar/wallet-backend/internal/tss/channels"
 )
 
@@ -35,9 +37,59 @@ func (c *ingestCmd) Command() *cobra.Command {
 		utils.WebhookHandlerChannelMaxWorkersOptions(&cfg.WebhookChannelMaxWorkers),
 		utils.WebhookHandlerChannelMaxRetriesOption(&cfg.WebhookChannelMaxRetries),
 		utils.WebhookHandlerChannelMinWaitBtwnRetriesMSOption(&cfg.WebhookChannelWaitBtwnTriesMS),
+		utils.NetworkPassphraseOption(&cfg.NetworkPassphrase),
+		{
+			Name:        "ledger-backend",
+			Usage:       `Where ledgers are read from. "rpc" reads them from the RPC, so only ledgers within its retention window can be ingested. "files" reads the LedgerCloseMeta files exported by galexie to --ledger-files-dir.`,
+			OptType:     types.String,
+			ConfigKey:   &cfg.LedgerBackend,
+			FlagDefault: ingest.RPCLedgerBackend,
+			Required:    true,
+		},
+		{
+			Name:        "ledger-files-dir",
+			Usage:       "Local directory with the LedgerCloseMeta files exported by galexie. Required when --ledger-backend is files.",
+			OptType:     types.String,
+			ConfigKey:   &cfg.LedgerFilesDir,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "ledgers-per-file",
+			Usage:       "Number of ledgers in each file of --ledger-files-dir. It must match the galexie configuration.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.LedgersPerFile,
+			FlagDefault: 1,
+			Required:    false,
+		},
+		{
+			Name:        "files-per-partition",
+			Usage:       "Number of files in each partition directory of --ledger-files-dir. It must match the galexie configuration.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.FilesPerPartition,
+			FlagDefault: 64000,
+			Required:    false,
+		},
+		{
+			Name:           "ledger-processors",
+			Usage:          `Comma separated list of the processors run on every ingested ledger, in order. Available processors: "payment", "operations", "balance_changes", "fees", "account_webhooks" and "tss". Backfills skip "account_webhooks" and "tss".`,
+			OptType:        types.String,
+			CustomSetValue: utils.SetConfigOptionStringList,
+			ConfigKey:      &cfg.LedgerProcessors,
+			FlagDefault:    strings.Join(services.DefaultLedgerProcessors, ","),
+			Required:       true,
+		},
+		{
+			Name:        "distribution-account-public-key",
+			Usage:       "The Distribution Account public key. When set, the fees it pays are ingested even though it isn't a registered account.",
+			OptType:     types.String,
+			ConfigKey:   &cfg.DistributionAccountPublicKey,
+			FlagDefault: "",
+			Required:    false,
+		},
 		{
 			Name:        "ledger-cursor-name",
-			Usage:       "Name of last synced ledger cursor, used to keep track of the last ledger ingested by the service. When starting up, ingestion will resume from the ledger number stored in this record. It should be an unique name per container as different containers would overwrite the cursor value of its peers when using the same cursor name.",
+			Usage:       "Name of last synced ledger cursor, used to keep track of the last ledger ingested by the service. When starting up, ingestion will resume from the ledger number stored in this record. Containers using the same cursor name elect a leader through a database lock: only the leader ingests and advances the cursor, while the others wait as standbys and take over when it dies.",
 			OptType:     types.String,
 			ConfigKey:   &cfg.LedgerCursorName,
 			FlagDefault: "live_ingest_cursor",
@@ -51,7 +103,33 @@ func (c *ingestCmd) Command() *cobra.Command {
 			FlagDefault: 0,
 			Required:    false,
 		},
+		{
+			Name:           "prune-interval",
+			Usage:          "How often rows older than the configured retention are pruned.",
+			OptType:        types.String,
+			CustomSetValue: utils.SetConfigOptionDuration,
+			ConfigKey:      &cfg.PruneInterval,
+			FlagDefault:    "1h",
+			Required:       true,
+		},
+		{
+			Name:        "payment-partitions-ahead",
+			Usage:       "Number of ingest_payments partitions created ahead of the live ledger. Each partition holds the payments of 500,000 ledgers.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.PaymentPartitionsAhead,
+			FlagDefault: 1,
+			Required:    true,
+		},
+		{
+			Name:        "payment-partitions-retained",
+			Usage:       "Number of the most recent ingest_payments partitions kept attached. Older partitions are detached, so their payments are no longer served, and kept as standalone tables. Zero keeps every partition attached.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.PaymentPartitionsRetained,
+			FlagDefault: 0,
+			Required:    false,
+		},
 	}
+	cfgOpts = append(cfgOpts, retentionOptions(&cfg)...)
 
 	cmd := &cobra.Command{
 		Use:   "ingest",
@@ -89,9 +167,121 @@ func (c *ingestCmd) Command() *cobra.Command {
 		log.Fatalf("Error initializing a config option: %s", err.Error())
 	}
 
+	backfillCfgOpts := append(config.ConfigOptions{
+		utils.StartLedgerOption(&cfg.StartLedger),
+		utils.EndLedgerOption(&cfg.EndLedger),
+	}, backfillWorkersOptions(&cfg)...)
+
+	backfillCmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Backfill a range of historical ledgers in parallel",
+		Long:  "Backfill ingests the payments of a range of ledgers still within the RPC retention window. It can run alongside the live ingestion since it never updates the live ledger cursor.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := backfillCfgOpts.RequireE(); err != nil {
+				return fmt.Errorf("requiring values of config options: %w", err)
+			}
+			if err := backfillCfgOpts.SetValues(); err != nil {
+				return fmt.Errorf("setting values of config options: %w", err)
+			}
+			return c.RunBackfill(cfg)
+		},
+	}
+
+	if err := backfillCfgOpts.Init(backfillCmd); err != nil {
+		log.Fatalf("Error initializing a config option: %s", err.Error())
+	}
+	cmd.AddCommand(backfillCmd)
+
+	verifyCfgOpts := verifyLedgerRangeOptions(&cfg)
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Report gaps and overlaps in the ingested ledger ranges",
+		Long:  "Verify compares the ledger ranges recorded by the live ingestion, backfills and repairs. It exits with an error when some ledgers were never ingested.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := verifyCfgOpts.RequireE(); err != nil {
+				return fmt.Errorf("requiring values of config options: %w", err)
+			}
+			if err := verifyCfgOpts.SetValues(); err != nil {
+				return fmt.Errorf("setting values of config options: %w", err)
+			}
+			return c.RunVerify(cfg)
+		},
+	}
+
+	if err := verifyCfgOpts.Init(verifyCmd); err != nil {
+		log.Fatalf("Error initializing a config option: %s", err.Error())
+	}
+	cmd.AddCommand(verifyCmd)
+
+	repairCfgOpts := append(verifyLedgerRangeOptions(&cfg), backfillWorkersOptions(&cfg)...)
+
+	repairCmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Re-ingest the gaps in the ingested ledger ranges",
+		Long:  "Repair backfills the gaps reported by verify. Ledgers are upserted, so it's safe to run it again after an interruption or alongside the live ingestion.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := repairCfgOpts.RequireE(); err != nil {
+				return fmt.Errorf("requiring values of config options: %w", err)
+			}
+			if err := repairCfgOpts.SetValues(); err != nil {
+				return fmt.Errorf("setting values of config options: %w", err)
+			}
+			return c.RunRepair(cfg)
+		},
+	}
+
+	if err := repairCfgOpts.Init(repairCmd); err != nil {
+		log.Fatalf("Error initializing a config option: %s", err.Error())
+	}
+	cmd.AddCommand(repairCmd)
+
 	return cmd
 }
 
+// backfillWorkersOptions returns new options on every call since an option can only be bound to a single command.
+func backfillWorkersOptions(cfg *ingest.Configs) config.ConfigOptions {
+	return config.ConfigOptions{
+		{
+			Name:        "workers",
+			Usage:       "Number of workers ingesting ledger chunks concurrently.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.BackfillWorkers,
+			FlagDefault: 4,
+			Required:    true,
+		},
+		{
+			Name:        "chunk-size",
+			Usage:       "Number of ledgers in each chunk. Every chunk keeps its own cursor, so an interrupted backfill resumes from where each chunk stopped when it's run again with the same range.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.BackfillChunkSize,
+			FlagDefault: 10_000,
+			Required:    true,
+		},
+	}
+}
+
+func verifyLedgerRangeOptions(cfg *ingest.Configs) config.ConfigOptions {
+	return config.ConfigOptions{
+		{
+			Name:        "start-ledger",
+			Usage:       "First ledger to verify. Defaults to the oldest ingested ledger.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.StartLedger,
+			FlagDefault: 0,
+			Required:    false,
+		},
+		{
+			Name:        "end-ledger",
+			Usage:       "Last ledger to verify. Defaults to the latest ingested ledger.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.EndLedger,
+			FlagDefault: 0,
+			Required:    false,
+		},
+	}
+}
+
 func (c *ingestCmd) Run(cfg ingest.Configs) error {
 	err := ingest.Ingest(cfg)
 	if err != nil {
@@ -99,3 +289,27 @@ func (c *ingestCmd) Run(cfg ingest.Configs) error {
 	}
 	return nil
 }
+
+func (c *ingestCmd) RunBackfill(cfg ingest.Configs) error {
+	err := ingest.Backfill(cfg)
+	if err != nil {
+		return fmt.Errorf("running backfill: %w", err)
+	}
+	return nil
+}
+
+func (c *ingestCmd) RunVerify(cfg ingest.Configs) error {
+	err := ingest.Verify(cfg)
+	if err != nil {
+		return fmt.Errorf("running verify: %w", err)
+	}
+	return nil
+}
+
+func (c *ingestCmd) RunRepair(cfg ingest.Configs) error {
+	err := ingest.Repair(cfg)
+	if err != nil {
+		return fmt.Errorf("running repair: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"go/types"
+
+	_ "github.com/lib/pq"
+	"github.com/spf13/cobra"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/cmd/utils"
+	"github.com/stellar/wallet-backend/internal/ingest"
+)
+
+type pruneCmd struct{}
+
+func (c *pruneCmd) Command() *cobra.Command {
+	cfg := ingest.Configs{}
+	cfgOpts := append(config.ConfigOptions{
+		utils.DatabaseURLOption(&cfg.DatabaseURL),
+		utils.LogLevelOption(&cfg.LogLevel),
+	}, retentionOptions(&cfg)...)
+
+	cmd := &cobra.Command{
+		Use:               "prune",
+		Short:             "Delete the rows older than the configured retention once",
+		Long:              "Prune deletes the rows older than the configured retention in batches. The live ingestion runs it periodically with the same options, this command is meant for one-off runs.",
+		PersistentPreRunE: utils.DefaultPersistentPreRunE(cfgOpts),
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return c.Run(cfg)
+		},
+	}
+
+	if err := cfgOpts.Init(cmd); err != nil {
+		log.Fatalf("Error initializing a config option: %s", err.Error())
+	}
+
+	return cmd
+}
+
+func (c *pruneCmd) Run(cfg ingest.Configs) error {
+	err := ingest.Prune(cfg)
+	if err != nil {
+		return fmt.Errorf("running prune: %w", err)
+	}
+	return nil
+}
+
+// retentionOptions returns new options on every call since an option can only be bound to a single command. Durations
+// are Go durations like "12h" or numbers of days like "30d", and zero keeps the rows forever.
+func retentionOptions(cfg *ingest.Configs) config.ConfigOptions {
+	return config.ConfigOptions{
+		{
+			Name:           "payments-retention",
+			Usage:          "How long payments are kept.",
+			OptType:        types.String,
+			CustomSetValue: utils.SetConfigOptionDuration,
+			ConfigKey:      &cfg.Retention.Payments,
+			FlagDefault:    "0",
+			Required:       false,
+		},
+		{
+			Name:           "unregistered-payments-retention",
+			Usage:          "How long the payments of accounts that are no longer registered are kept, counting from both the payment and the deregistration.",
+			OptType:        types.String,
+			CustomSetValue: utils.SetConfigOptionDuration,
+			ConfigKey:      &cfg.Retention.UnregisteredPayments,
+			FlagDefault:    "0",
+			Required:       false,
+		},
+		{
+			Name:           "tss-transactions-retention",
+			Usage:          "How long TSS transactions are kept after their webhook was sent.",
+			OptType:        types.String,
+			CustomSetValue: utils.SetConfigOptionDuration,
+			ConfigKey:      &cfg.Retention.TSSTransactions,
+			FlagDefault:    "0",
+			Required:       false,
+		},
+		{
+			Name:           "tss-submission-tries-retention",
+			Usage:          "How long TSS transaction submission tries are kept. The tries of transactions whose webhook wasn't sent yet are always kept.",
+			OptType:        types.String,
+			CustomSetValue: utils.SetConfigOptionDuration,
+			ConfigKey:      &cfg.Retention.TSSTransactionSubmissionTries,
+			FlagDefault:    "0",
+			Required:       false,
+		},
+		{
+			Name:        "prune-batch-size",
+			Usage:       "Number of rows deleted by each pruning statement.",
+			OptType:     types.Int,
+			ConfigKey:   &cfg.PruneBatchSize,
+			FlagDefault: 1000,
+			Required:    true,
+		},
+	}
+}
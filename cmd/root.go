@@ -49,6 +49,8 @@ func SetupCLI(cfg RootConfig) {
 	rootCmd.AddCommand((&serveCmd{}).Command())
 	rootCmd.AddCommand((&ingestCmd{}).Command())
 	rootCmd.AddCommand((&migrateCmd{}).Command())
+	rootCmd.AddCommand((&pruneCmd{}).Command())
+	rootCmd.AddCommand((&exportCmd{}).Command())
 	rootCmd.AddCommand((&channelAccountCmd{}).Command(&ChAccCmdService{}))
 	rootCmd.AddCommand((&distributionAccountCmd{}).Command())
 	rootCmd.AddCommand((&integrationTestsCmd{}).Command())
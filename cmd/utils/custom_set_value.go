@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -95,6 +97,61 @@ func SetConfigOptionStellarPublicKeyList(co *config.ConfigOption) error {
 	return nil
 }
 
+// SetConfigOptionStringList parses a comma separated list, keeping the order of its items.
+func SetConfigOptionStringList(co *config.ConfigOption) error {
+	listStr := viper.GetString(co.Name)
+
+	list := []string{}
+	for _, item := range strings.Split(listStr, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 && co.Required {
+		return fmt.Errorf("%s cannot be empty", co.Name)
+	}
+
+	key, ok := co.ConfigKey.(*[]string)
+	if !ok {
+		return unexpectedTypeError(key, co)
+	}
+	*key = list
+
+	return nil
+}
+
+// SetConfigOptionDuration parses a Go duration like "12h", or a number of days like "30d". Empty means zero.
+func SetConfigOptionDuration(co *config.ConfigOption) error {
+	durationStr := strings.TrimSpace(viper.GetString(co.Name))
+
+	var duration time.Duration
+	if days, ok := strings.CutSuffix(durationStr, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return fmt.Errorf("couldn't parse duration in %s: invalid number of days %q", co.Name, days)
+		}
+		duration = time.Duration(n) * 24 * time.Hour
+	} else if durationStr != "" {
+		var err error
+		duration, err = time.ParseDuration(durationStr)
+		if err != nil {
+			return fmt.Errorf("couldn't parse duration in %s: %w", co.Name, err)
+		}
+	}
+	if duration < 0 {
+		return fmt.Errorf("%s cannot be negative", co.Name)
+	}
+
+	key, ok := co.ConfigKey.(*time.Duration)
+	if !ok {
+		return unexpectedTypeError(key, co)
+	}
+	*key = duration
+
+	return nil
+}
+
 func SetConfigOptionStellarPrivateKey(co *config.ConfigOption) error {
 	privateKey := viper.GetString(co.Name)
 
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -196,6 +197,93 @@ func TestSetConfigOptionStellarPublicKeyList(t *testing.T) {
 	}
 }
 
+func TestSetConfigOptionDuration(t *testing.T) {
+	opts := struct{ duration time.Duration }{}
+
+	co := config.ConfigOption{
+		Name:           "payments-retention",
+		OptType:        types.String,
+		CustomSetValue: SetConfigOptionDuration,
+		ConfigKey:      &opts.duration,
+	}
+
+	testCases := []customSetterTestCase[time.Duration]{
+		{
+			name:            "🔴returns_an_error_if_the_duration_is_invalid",
+			args:            []string{"--payments-retention", "a week"},
+			wantErrContains: "couldn't parse duration in payments-retention",
+		},
+		{
+			name:            "🔴returns_an_error_if_the_days_are_invalid",
+			args:            []string{"--payments-retention", "xd"},
+			wantErrContains: `couldn't parse duration in payments-retention: invalid number of days "x"`,
+		},
+		{
+			name:            "🔴returns_an_error_if_the_duration_is_negative",
+			args:            []string{"--payments-retention", "-1h"},
+			wantErrContains: "payments-retention cannot be negative",
+		},
+		{
+			name:       "🟢empty_is_zero",
+			wantResult: 0,
+		},
+		{
+			name:       "🟢handles_days_through_the_CLI_flag",
+			args:       []string{"--payments-retention", "30d"},
+			wantResult: 30 * 24 * time.Hour,
+		},
+		{
+			name:       "🟢handles_go_durations_through_the_ENV_flag",
+			envValue:   "36h",
+			wantResult: 36 * time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts.duration = 0
+			customSetterTester(t, tc, co)
+		})
+	}
+}
+
+func TestSetConfigOptionStringList(t *testing.T) {
+	opts := struct{ list []string }{}
+
+	co := config.ConfigOption{
+		Name:           "ledger-processors",
+		OptType:        types.String,
+		CustomSetValue: SetConfigOptionStringList,
+		ConfigKey:      &opts.list,
+		Required:       true,
+	}
+
+	testCases := []customSetterTestCase[[]string]{
+		{
+			name:            "🔴returns_an_error_if_the_list_is_empty",
+			args:            []string{"--ledger-processors", " , "},
+			wantErrContains: "ledger-processors cannot be empty",
+		},
+		{
+			name:       "🟢handles_the_list_through_the_CLI_flag",
+			args:       []string{"--ledger-processors", "tss, payment ,,operations"},
+			wantResult: []string{"tss", "payment", "operations"},
+		},
+		{
+			name:       "🟢handles_the_list_through_the_ENV_flag",
+			envValue:   "payment,balance_changes",
+			wantResult: []string{"payment", "balance_changes"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts.list = nil
+			customSetterTester(t, tc, co)
+		})
+	}
+}
+
 func TestSetConfigOptionStellarPrivateKey(t *testing.T) {
 	opts := struct{ distributionPrivateKey string }{}
 
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+type AccountBackfillJobStatus string
+
+const (
+	AccountBackfillJobPending   AccountBackfillJobStatus = "pending"
+	AccountBackfillJobRunning   AccountBackfillJobStatus = "running"
+	AccountBackfillJobCompleted AccountBackfillJobStatus = "completed"
+	AccountBackfillJobFailed    AccountBackfillJobStatus = "failed"
+)
+
+var ErrAccountBackfillJobNotFound = errors.New("account backfill job not found")
+
+type AccountBackfillJobModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+// AccountBackfillJob tracks the ingestion of the history of a single account. The ledger range is only known once a
+// worker picks the job up, and LastLedger is the last ledger of that range already ingested.
+type AccountBackfillJob struct {
+	ID          int64                    `db:"id" json:"id"`
+	Address     string                   `db:"stellar_address" json:"address"`
+	Status      AccountBackfillJobStatus `db:"status" json:"status"`
+	StartLedger *uint32                  `db:"start_ledger" json:"startLedger"`
+	EndLedger   *uint32                  `db:"end_ledger" json:"endLedger"`
+	LastLedger  *uint32                  `db:"last_ledger" json:"lastLedger"`
+	Error       *string                  `db:"error" json:"error"`
+	CreatedAt   time.Time                `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time                `db:"updated_at" json:"updatedAt"`
+}
+
+func (m *AccountBackfillJobModel) Insert(ctx context.Context, address string) (*AccountBackfillJob, error) {
+	const query = `INSERT INTO account_backfill_jobs (stellar_address) VALUES ($1) RETURNING *`
+	var job AccountBackfillJob
+	start := time.Now()
+	err := m.DB.GetContext(ctx, &job, query, address)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "account_backfill_jobs", duration)
+	if err != nil {
+		return nil, fmt.Errorf("inserting backfill job for address %s: %w", address, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "account_backfill_jobs")
+	return &job, nil
+}
+
+// GetLatestByAddress returns the most recent backfill job of the account, or ErrAccountBackfillJobNotFound when the
+// account was never backfilled.
+func (m *AccountBackfillJobModel) GetLatestByAddress(ctx context.Context, address string) (*AccountBackfillJob, error) {
+	const query = `SELECT * FROM account_backfill_jobs WHERE stellar_address = $1 ORDER BY id DESC LIMIT 1`
+	var job AccountBackfillJob
+	start := time.Now()
+	err := m.DB.GetContext(ctx, &job, query, address)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "account_backfill_jobs", duration)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountBackfillJobNotFound
+		}
+		return nil, fmt.Errorf("getting latest backfill job for address %s: %w", address, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "account_backfill_jobs")
+	return &job, nil
+}
+
+// ClaimNext marks the oldest pending job as running and returns it. Running jobs that weren't updated for longer than
+// staleAfter are assumed to belong to a worker that died and are claimed again. It returns nil when there's nothing to
+// do, and concurrent workers never claim the same job.
+func (m *AccountBackfillJobModel) ClaimNext(ctx context.Context, staleAfter time.Duration) (*AccountBackfillJob, error) {
+	const query = `
+		UPDATE account_backfill_jobs
+		SET status = 'running', updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM account_backfill_jobs
+			WHERE status = 'pending' OR (status = 'running' AND updated_at < NOW() - make_interval(secs => $1))
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+	var job AccountBackfillJob
+	start := time.Now()
+	err := m.DB.GetContext(ctx, &job, query, staleAfter.Seconds())
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("UPDATE", "account_backfill_jobs", duration)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claiming backfill job: %w", err)
+	}
+	m.MetricsService.IncDBQuery("UPDATE", "account_backfill_jobs")
+	return &job, nil
+}
+
+func (m *AccountBackfillJobModel) SetRange(ctx context.Context, id int64, startLedger, endLedger uint32) error {
+	const query = `UPDATE account_backfill_jobs SET start_ledger = $2, end_ledger = $3, updated_at = NOW() WHERE id = $1`
+	return m.update(ctx, m.DB, query, id, startLedger, endLedger)
+}
+
+// UpdateProgress records the last ledger ingested by the job, which also tells other workers the job is still alive.
+func (m *AccountBackfillJobModel) UpdateProgress(ctx context.Context, sqlExec db.SQLExecuter, id int64, lastLedger uint32) error {
+	const query = `UPDATE account_backfill_jobs SET last_ledger = $2, updated_at = NOW() WHERE id = $1`
+	return m.update(ctx, sqlExec, query, id, lastLedger)
+}
+
+func (m *AccountBackfillJobModel) Complete(ctx context.Context, id int64) error {
+	const query = `UPDATE account_backfill_jobs SET status = 'completed', error = NULL, updated_at = NOW() WHERE id = $1`
+	return m.update(ctx, m.DB, query, id)
+}
+
+func (m *AccountBackfillJobModel) Fail(ctx context.Context, id int64, errMsg string) error {
+	const query = `UPDATE account_backfill_jobs SET status = 'failed', error = $2, updated_at = NOW() WHERE id = $1`
+	return m.update(ctx, m.DB, query, id, errMsg)
+}
+
+func (m *AccountBackfillJobModel) update(ctx context.Context, sqlExec db.SQLExecuter, query string, id int64, args ...any) error {
+	start := time.Now()
+	_, err := sqlExec.ExecContext(ctx, query, append([]any{id}, args...)...)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("UPDATE", "account_backfill_jobs", duration)
+	if err != nil {
+		return fmt.Errorf("updating backfill job %d: %w", id, err)
+	}
+	m.MetricsService.IncDBQuery("UPDATE", "account_backfill_jobs")
+	return nil
+}
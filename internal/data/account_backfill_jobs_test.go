@@ -0,0 +1,92 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestAccountBackfillJobModel(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, "account_backfill_jobs", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", mock.Anything, "account_backfill_jobs").Return()
+
+	m := &AccountBackfillJobModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+	address := keypair.MustRandom().Address()
+
+	_, err = m.GetLatestByAddress(ctx, address)
+	require.ErrorIs(t, err, ErrAccountBackfillJobNotFound)
+
+	job, err := m.Insert(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, address, job.Address)
+	assert.Equal(t, AccountBackfillJobPending, job.Status)
+	assert.Nil(t, job.StartLedger)
+
+	t.Run("claim_next", func(t *testing.T) {
+		claimed, err := m.ClaimNext(ctx, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, claimed)
+		assert.Equal(t, job.ID, claimed.ID)
+		assert.Equal(t, AccountBackfillJobRunning, claimed.Status)
+
+		// running jobs aren't claimed again until they go stale
+		claimed, err = m.ClaimNext(ctx, time.Minute)
+		require.NoError(t, err)
+		assert.Nil(t, claimed)
+
+		_, err = dbConnectionPool.ExecContext(ctx, `UPDATE account_backfill_jobs SET updated_at = NOW() - interval '2 minutes' WHERE id = $1`, job.ID)
+		require.NoError(t, err)
+		claimed, err = m.ClaimNext(ctx, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, claimed)
+		assert.Equal(t, job.ID, claimed.ID)
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		require.NoError(t, m.SetRange(ctx, job.ID, 10, 20))
+		require.NoError(t, m.UpdateProgress(ctx, dbConnectionPool, job.ID, 15))
+
+		latest, err := m.GetLatestByAddress(ctx, address)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(10), *latest.StartLedger)
+		assert.Equal(t, uint32(20), *latest.EndLedger)
+		assert.Equal(t, uint32(15), *latest.LastLedger)
+
+		require.NoError(t, m.Fail(ctx, job.ID, "rpc unavailable"))
+		latest, err = m.GetLatestByAddress(ctx, address)
+		require.NoError(t, err)
+		assert.Equal(t, AccountBackfillJobFailed, latest.Status)
+		assert.Equal(t, "rpc unavailable", *latest.Error)
+
+		require.NoError(t, m.Complete(ctx, job.ID))
+		latest, err = m.GetLatestByAddress(ctx, address)
+		require.NoError(t, err)
+		assert.Equal(t, AccountBackfillJobCompleted, latest.Status)
+		assert.Nil(t, latest.Error)
+
+		claimed, err := m.ClaimNext(ctx, time.Minute)
+		require.NoError(t, err)
+		assert.Nil(t, claimed)
+	})
+}
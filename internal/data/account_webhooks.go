@@ -0,0 +1,326 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+type AccountWebhookEventType string
+
+const (
+	AccountWebhookPaymentEvent   AccountWebhookEventType = "payment"
+	AccountWebhookOperationEvent AccountWebhookEventType = "operation"
+)
+
+type AccountWebhookDeliveryStatus string
+
+const (
+	AccountWebhookDeliveryPending AccountWebhookDeliveryStatus = "pending"
+	AccountWebhookDeliverySent    AccountWebhookDeliveryStatus = "sent"
+	AccountWebhookDeliveryFailed  AccountWebhookDeliveryStatus = "failed"
+)
+
+var (
+	ErrAccountWebhookNotFound             = errors.New("account webhook not found")
+	ErrAccountWebhookAddressNotRegistered = errors.New("account webhook address is not registered")
+)
+
+type AccountWebhookModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+// AccountWebhook is a URL posted the payments and operations involving any of its registered addresses.
+type AccountWebhook struct {
+	ID        int64          `db:"id" json:"id"`
+	URL       string         `db:"url" json:"url"`
+	Addresses pq.StringArray `db:"addresses" json:"addresses"`
+	CreatedAt time.Time      `db:"created_at" json:"createdAt"`
+}
+
+// AccountWebhookEvent is the body posted to the webhooks, Addresses are the addresses of the webhook involved.
+type AccountWebhookEvent struct {
+	Type      AccountWebhookEventType `json:"type"`
+	Addresses []string                `json:"addresses"`
+	Payment   *Payment                `json:"payment,omitempty"`
+	Operation *Operation              `json:"operation,omitempty"`
+}
+
+// AccountWebhookDelivery is an event to post to a webhook. Pending deliveries are retried from NextAttemptAt on, and
+// failed ones are only retried once they're replayed.
+type AccountWebhookDelivery struct {
+	ID            int64                        `db:"id" json:"id"`
+	WebhookID     int64                        `db:"webhook_id" json:"webhookId"`
+	EventType     AccountWebhookEventType      `db:"event_type" json:"eventType"`
+	EventID       string                       `db:"event_id" json:"eventId"`
+	Payload       json.RawMessage              `db:"payload" json:"payload"`
+	Status        AccountWebhookDeliveryStatus `db:"status" json:"status"`
+	Attempts      int                          `db:"attempts" json:"attempts"`
+	LastError     *string                      `db:"last_error" json:"lastError"`
+	NextAttemptAt time.Time                    `db:"next_attempt_at" json:"nextAttemptAt"`
+	CreatedAt     time.Time                    `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time                    `db:"updated_at" json:"updatedAt"`
+	// URL is the webhook URL, it's only set on claimed deliveries.
+	URL string `db:"url" json:"-"`
+}
+
+// Insert subscribes the URL to the addresses, which must be registered.
+func (m *AccountWebhookModel) Insert(ctx context.Context, url string, addresses []string) (*AccountWebhook, error) {
+	start := time.Now()
+	webhook, err := db.RunInTransactionWithResult(ctx, m.DB, nil, func(dbTx db.Transaction) (*AccountWebhook, error) {
+		var webhook AccountWebhook
+		err := dbTx.GetContext(ctx, &webhook, `INSERT INTO account_webhooks (url) VALUES ($1) RETURNING id, url, created_at`, url)
+		if err != nil {
+			return nil, fmt.Errorf("inserting webhook: %w", err)
+		}
+		_, err = dbTx.ExecContext(ctx, `
+			INSERT INTO account_webhook_addresses (webhook_id, stellar_address)
+			SELECT DISTINCT $1::bigint, a FROM UNNEST($2::text[]) AS a
+		`, webhook.ID, pq.Array(addresses))
+		if err != nil {
+			var pqError *pq.Error
+			if errors.As(err, &pqError) && pqError.Constraint == "account_webhook_addresses_stellar_address_fkey" {
+				return nil, ErrAccountWebhookAddressNotRegistered
+			}
+			return nil, fmt.Errorf("inserting webhook addresses: %w", err)
+		}
+		webhook.Addresses = addresses
+		return &webhook, nil
+	})
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "account_webhooks", duration)
+	if err != nil {
+		if errors.Is(err, ErrAccountWebhookAddressNotRegistered) {
+			return nil, ErrAccountWebhookAddressNotRegistered
+		}
+		return nil, fmt.Errorf("subscribing %s to %d addresses: %w", url, len(addresses), err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "account_webhooks")
+	return webhook, nil
+}
+
+// Get returns the webhook with its addresses, or ErrAccountWebhookNotFound.
+func (m *AccountWebhookModel) Get(ctx context.Context, id int64) (*AccountWebhook, error) {
+	const query = `
+		SELECT
+			w.id, w.url, w.created_at,
+			COALESCE(array_agg(a.stellar_address ORDER BY a.stellar_address) FILTER (WHERE a.stellar_address IS NOT NULL), '{}') AS addresses
+		FROM account_webhooks w
+		LEFT JOIN account_webhook_addresses a ON a.webhook_id = w.id
+		WHERE w.id = $1
+		GROUP BY w.id
+	`
+	var webhook AccountWebhook
+	start := time.Now()
+	err := m.DB.GetContext(ctx, &webhook, query, id)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "account_webhooks", duration)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountWebhookNotFound
+		}
+		return nil, fmt.Errorf("getting webhook %d: %w", id, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "account_webhooks")
+	return &webhook, nil
+}
+
+// Delete removes the webhook along with its deliveries, or returns ErrAccountWebhookNotFound.
+func (m *AccountWebhookModel) Delete(ctx context.Context, id int64) error {
+	start := time.Now()
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM account_webhooks WHERE id = $1`, id)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("DELETE", "account_webhooks", duration)
+	if err != nil {
+		return fmt.Errorf("deleting webhook %d: %w", id, err)
+	}
+	m.MetricsService.IncDBQuery("DELETE", "account_webhooks")
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting the number of webhooks deleted: %w", err)
+	}
+	if deleted == 0 {
+		return ErrAccountWebhookNotFound
+	}
+	return nil
+}
+
+// GetWebhookIDsByAddresses returns the IDs of the webhooks subscribed to each of the addresses, leaving the addresses
+// without webhooks out.
+func (m *AccountWebhookModel) GetWebhookIDsByAddresses(ctx context.Context, sqlExec db.SQLExecuter, addresses []string) (map[string][]int64, error) {
+	webhookIDs := map[string][]int64{}
+	if len(addresses) == 0 {
+		return webhookIDs, nil
+	}
+
+	var rows []struct {
+		WebhookID int64  `db:"webhook_id"`
+		Address   string `db:"stellar_address"`
+	}
+	start := time.Now()
+	err := sqlExec.SelectContext(ctx, &rows, `
+		SELECT webhook_id, stellar_address FROM account_webhook_addresses WHERE stellar_address = ANY($1) ORDER BY webhook_id
+	`, pq.Array(addresses))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "account_webhook_addresses", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting the webhooks of %d addresses: %w", len(addresses), err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "account_webhook_addresses")
+
+	for _, row := range rows {
+		webhookIDs[row.Address] = append(webhookIDs[row.Address], row.WebhookID)
+	}
+	return webhookIDs, nil
+}
+
+// BatchInsertDeliveries enqueues the deliveries, skipping the ones already enqueued so reingesting a ledger doesn't
+// post its events twice.
+func (m *AccountWebhookModel) BatchInsertDeliveries(ctx context.Context, tx db.Transaction, deliveries []AccountWebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	const query = `
+		INSERT INTO account_webhook_deliveries (webhook_id, event_type, event_id, payload)
+		SELECT d.webhook_id, d.event_type, d.event_id, d.payload
+		FROM UNNEST($1::bigint[], $2::text[], $3::text[], $4::jsonb[]) AS d(webhook_id, event_type, event_id, payload)
+		ON CONFLICT (webhook_id, event_type, event_id) DO NOTHING
+	`
+	n := len(deliveries)
+	var (
+		webhookIDs = make([]int64, n)
+		eventTypes = make([]string, n)
+		eventIDs   = make([]string, n)
+		payloads   = make([]string, n)
+	)
+	for i, delivery := range deliveries {
+		webhookIDs[i] = delivery.WebhookID
+		eventTypes[i] = string(delivery.EventType)
+		eventIDs[i] = delivery.EventID
+		payloads[i] = string(delivery.Payload)
+	}
+
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, query, pq.Array(webhookIDs), pq.Array(eventTypes), pq.Array(eventIDs), pq.Array(payloads))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "account_webhook_deliveries", duration)
+	if err != nil {
+		return fmt.Errorf("batch inserting %d webhook deliveries: %w", n, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "account_webhook_deliveries")
+	return nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries whose next attempt is due, oldest first, along with their
+// webhook URL. Their next attempt is pushed lease away, so concurrent dispatchers don't claim them too and they're
+// claimed again when the dispatcher dies before resolving them.
+func (m *AccountWebhookModel) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]AccountWebhookDelivery, error) {
+	const query = `
+		WITH claimed AS (
+			UPDATE account_webhook_deliveries
+			SET next_attempt_at = NOW() + make_interval(secs => $2), updated_at = NOW()
+			WHERE id IN (
+				SELECT id FROM account_webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT claimed.*, w.url FROM claimed JOIN account_webhooks w ON w.id = claimed.webhook_id ORDER BY claimed.id
+	`
+	var deliveries []AccountWebhookDelivery
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &deliveries, query, limit, lease.Seconds())
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("UPDATE", "account_webhook_deliveries", duration)
+	if err != nil {
+		return nil, fmt.Errorf("claiming webhook deliveries: %w", err)
+	}
+	m.MetricsService.IncDBQuery("UPDATE", "account_webhook_deliveries")
+	return deliveries, nil
+}
+
+func (m *AccountWebhookModel) MarkDeliverySent(ctx context.Context, id int64, attempts int) error {
+	const query = `
+		UPDATE account_webhook_deliveries SET status = 'sent', attempts = attempts + $2, last_error = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	return m.updateDelivery(ctx, query, id, attempts)
+}
+
+func (m *AccountWebhookModel) MarkDeliveryFailed(ctx context.Context, id int64, attempts int, errMsg string) error {
+	const query = `
+		UPDATE account_webhook_deliveries SET status = 'failed', attempts = attempts + $2, last_error = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+	return m.updateDelivery(ctx, query, id, attempts, errMsg)
+}
+
+func (m *AccountWebhookModel) updateDelivery(ctx context.Context, query string, id int64, args ...any) error {
+	start := time.Now()
+	_, err := m.DB.ExecContext(ctx, query, append([]any{id}, args...)...)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("UPDATE", "account_webhook_deliveries", duration)
+	if err != nil {
+		return fmt.Errorf("updating webhook delivery %d: %w", id, err)
+	}
+	m.MetricsService.IncDBQuery("UPDATE", "account_webhook_deliveries")
+	return nil
+}
+
+// GetDeliveries returns the most recent deliveries of the webhook, only the ones with the status when it's set.
+func (m *AccountWebhookModel) GetDeliveries(ctx context.Context, webhookID int64, status AccountWebhookDeliveryStatus, limit int) ([]AccountWebhookDelivery, error) {
+	const query = `
+		SELECT
+			id, webhook_id, event_type, event_id, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at
+		FROM account_webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+	deliveries := make([]AccountWebhookDelivery, 0)
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &deliveries, query, webhookID, string(status), limit)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "account_webhook_deliveries", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting deliveries of webhook %d: %w", webhookID, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "account_webhook_deliveries")
+	return deliveries, nil
+}
+
+// ReplayDeliveries makes the deliveries of the webhook created from the given time on pending again, so they're posted
+// again right away, and returns how many there were. Only the failed ones are replayed when failedOnly is set.
+func (m *AccountWebhookModel) ReplayDeliveries(ctx context.Context, webhookID int64, from time.Time, failedOnly bool) (int64, error) {
+	const query = `
+		UPDATE account_webhook_deliveries
+		SET status = 'pending', next_attempt_at = NOW(), updated_at = NOW()
+		WHERE webhook_id = $1 AND created_at >= $2 AND (NOT $3 OR status = 'failed')
+	`
+	start := time.Now()
+	result, err := m.DB.ExecContext(ctx, query, webhookID, from, failedOnly)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("UPDATE", "account_webhook_deliveries", duration)
+	if err != nil {
+		return 0, fmt.Errorf("replaying deliveries of webhook %d: %w", webhookID, err)
+	}
+	m.MetricsService.IncDBQuery("UPDATE", "account_webhook_deliveries")
+	replayed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("getting the number of deliveries replayed: %w", err)
+	}
+	return replayed, nil
+}
@@ -0,0 +1,152 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestAccountWebhookModel(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything).Return()
+
+	ctx := context.Background()
+	m := &AccountWebhookModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	address := keypair.MustRandom().Address()
+	otherAddress := keypair.MustRandom().Address()
+	for _, a := range []string{address, otherAddress} {
+		_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, a)
+		require.NoError(t, err)
+	}
+
+	t.Run("🔴unregistered_address", func(t *testing.T) {
+		_, err := m.Insert(ctx, "https://example.com/hook", []string{address, keypair.MustRandom().Address()})
+		assert.ErrorIs(t, err, ErrAccountWebhookAddressNotRegistered)
+	})
+
+	webhook, err := m.Insert(ctx, "https://example.com/hook", []string{otherAddress, address, address})
+	require.NoError(t, err)
+	otherWebhook, err := m.Insert(ctx, "https://example.com/other", []string{otherAddress})
+	require.NoError(t, err)
+
+	t.Run("🟢get", func(t *testing.T) {
+		got, err := m.Get(ctx, webhook.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/hook", got.URL)
+		assert.ElementsMatch(t, []string{address, otherAddress}, []string(got.Addresses))
+
+		_, err = m.Get(ctx, otherWebhook.ID+1)
+		assert.ErrorIs(t, err, ErrAccountWebhookNotFound)
+	})
+
+	t.Run("🟢webhook_ids_by_addresses", func(t *testing.T) {
+		got, err := m.GetWebhookIDsByAddresses(ctx, dbConnectionPool, []string{address, otherAddress, keypair.MustRandom().Address()})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]int64{
+			address:      {webhook.ID},
+			otherAddress: {webhook.ID, otherWebhook.ID},
+		}, got)
+	})
+
+	payload := json.RawMessage(`{"type":"payment"}`)
+	err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+		return m.BatchInsertDeliveries(ctx, dbTx, []AccountWebhookDelivery{
+			{WebhookID: webhook.ID, EventType: AccountWebhookPaymentEvent, EventID: "1", Payload: payload},
+			{WebhookID: webhook.ID, EventType: AccountWebhookOperationEvent, EventID: "1", Payload: payload},
+			{WebhookID: otherWebhook.ID, EventType: AccountWebhookPaymentEvent, EventID: "1", Payload: payload},
+		})
+	})
+	require.NoError(t, err)
+
+	t.Run("🟢enqueuing_twice_is_a_noop", func(t *testing.T) {
+		err := db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+			return m.BatchInsertDeliveries(ctx, dbTx, []AccountWebhookDelivery{
+				{WebhookID: webhook.ID, EventType: AccountWebhookPaymentEvent, EventID: "1", Payload: payload},
+			})
+		})
+		require.NoError(t, err)
+		deliveries, err := m.GetDeliveries(ctx, webhook.ID, "", 10)
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 2)
+	})
+
+	t.Run("🟢claim_and_resolve", func(t *testing.T) {
+		claimed, err := m.ClaimDueDeliveries(ctx, 2, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		assert.Equal(t, "https://example.com/hook", claimed[0].URL)
+		assert.JSONEq(t, string(payload), string(claimed[0].Payload))
+
+		// the claimed deliveries aren't due anymore
+		claimedAgain, err := m.ClaimDueDeliveries(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimedAgain, 1)
+		assert.Equal(t, otherWebhook.ID, claimedAgain[0].WebhookID)
+
+		require.NoError(t, m.MarkDeliverySent(ctx, claimed[0].ID, 2))
+		require.NoError(t, m.MarkDeliveryFailed(ctx, claimed[1].ID, 3, "webhook responded with status 500"))
+
+		sent, err := m.GetDeliveries(ctx, webhook.ID, AccountWebhookDeliverySent, 10)
+		require.NoError(t, err)
+		require.Len(t, sent, 1)
+		assert.Equal(t, 2, sent[0].Attempts)
+		assert.Nil(t, sent[0].LastError)
+
+		failed, err := m.GetDeliveries(ctx, webhook.ID, AccountWebhookDeliveryFailed, 10)
+		require.NoError(t, err)
+		require.Len(t, failed, 1)
+		assert.Equal(t, 3, failed[0].Attempts)
+		require.NotNil(t, failed[0].LastError)
+		assert.Equal(t, "webhook responded with status 500", *failed[0].LastError)
+	})
+
+	t.Run("🟢replay", func(t *testing.T) {
+		from := time.Now().Add(-time.Hour)
+		replayed, err := m.ReplayDeliveries(ctx, webhook.ID, from, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), replayed)
+
+		claimed, err := m.ClaimDueDeliveries(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, AccountWebhookOperationEvent, claimed[0].EventType)
+
+		replayed, err = m.ReplayDeliveries(ctx, webhook.ID, from, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), replayed)
+
+		replayed, err = m.ReplayDeliveries(ctx, webhook.ID, time.Now().Add(time.Hour), false)
+		require.NoError(t, err)
+		assert.Zero(t, replayed)
+	})
+
+	t.Run("🟢delete", func(t *testing.T) {
+		require.NoError(t, m.Delete(ctx, webhook.ID))
+		assert.ErrorIs(t, m.Delete(ctx, webhook.ID), ErrAccountWebhookNotFound)
+
+		deliveries, err := m.GetDeliveries(ctx, webhook.ID, "", 10)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+	})
+}
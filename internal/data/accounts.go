@@ -2,9 +2,12 @@ package data
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/stellar/wallet-backend/internal/db"
 	"github.com/stellar/wallet-backend/internal/metrics"
 )
@@ -14,6 +17,28 @@ type AccountModel struct {
 	MetricsService metrics.MetricsService
 }
 
+// Account is a registered account, tied to the client's own users by its metadata.
+type Account struct {
+	StellarAddress string `db:"stellar_address" json:"address"`
+	AccountMetadata
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+// AccountMetadata is what clients store along with an account: the ID of the user owning it in their system, labels to
+// group accounts by, and free-form metadata, which is a JSON object.
+type AccountMetadata struct {
+	ExternalUserID *string         `db:"external_user_id" json:"externalUserId"`
+	Labels         pq.StringArray  `db:"labels" json:"labels"`
+	Metadata       json.RawMessage `db:"metadata" json:"metadata"`
+}
+
+// AccountsFilter restricts the accounts returned by List, the zero value matches every account.
+type AccountsFilter struct {
+	ExternalUserID string
+	// Labels matches the accounts with all of the labels.
+	Labels []string
+}
+
 func (m *AccountModel) Insert(ctx context.Context, address string) error {
 	const query = `INSERT INTO accounts (stellar_address) VALUES ($1) ON CONFLICT DO NOTHING`
 	start := time.Now()
@@ -28,8 +53,70 @@ func (m *AccountModel) Insert(ctx context.Context, address string) error {
 	return nil
 }
 
+// Upsert registers the account with the metadata, replacing the metadata of an account already registered.
+func (m *AccountModel) Upsert(ctx context.Context, address string, metadata AccountMetadata) error {
+	const query = `
+		INSERT INTO accounts (stellar_address, external_user_id, labels, metadata) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (stellar_address) DO UPDATE SET
+			external_user_id = EXCLUDED.external_user_id,
+			labels = EXCLUDED.labels,
+			metadata = EXCLUDED.metadata
+	`
+	labels := metadata.Labels
+	if labels == nil {
+		labels = pq.StringArray{}
+	}
+	accountMetadata := metadata.Metadata
+	if len(accountMetadata) == 0 {
+		accountMetadata = json.RawMessage(`{}`)
+	}
+	start := time.Now()
+	_, err := m.DB.ExecContext(ctx, query, address, metadata.ExternalUserID, labels, accountMetadata)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "accounts", duration)
+	if err != nil {
+		return fmt.Errorf("upserting address %s: %w", address, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "accounts")
+	return nil
+}
+
+// List returns up to limit accounts matching the filter, ordered by address and starting after afterAddress when it's
+// set.
+func (m *AccountModel) List(ctx context.Context, filter AccountsFilter, afterAddress string, limit int) ([]Account, error) {
+	const query = `
+		SELECT stellar_address, external_user_id, labels, metadata, created_at
+		FROM accounts
+		WHERE ($1 = '' OR external_user_id = $1) AND labels @> $2::text[] AND stellar_address > $3
+		ORDER BY stellar_address
+		LIMIT $4
+	`
+	labels := filter.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	accounts := make([]Account, 0)
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &accounts, query, filter.ExternalUserID, pq.StringArray(labels), afterAddress, limit)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "accounts", duration)
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "accounts")
+	return accounts, nil
+}
+
+// Delete deregisters the account, recording when it was deregistered.
 func (m *AccountModel) Delete(ctx context.Context, address string) error {
-	const query = `DELETE FROM accounts WHERE stellar_address = $1`
+	const query = `
+		WITH deleted AS (
+			DELETE FROM accounts WHERE stellar_address = $1 RETURNING stellar_address
+		)
+		INSERT INTO deregistered_accounts (stellar_address)
+		SELECT stellar_address FROM deleted
+		ON CONFLICT (stellar_address) DO UPDATE SET deregistered_at = NOW()
+	`
 	start := time.Now()
 	_, err := m.DB.ExecContext(ctx, query, address)
 	duration := time.Since(start).Seconds()
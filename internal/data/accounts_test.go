@@ -3,8 +3,11 @@ package data
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/stellar/go/keypair"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,6 +16,7 @@ import (
 	"github.com/stellar/wallet-backend/internal/db"
 	"github.com/stellar/wallet-backend/internal/db/dbtest"
 	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/utils"
 )
 
 func TestAccountModelInsert(t *testing.T) {
@@ -76,6 +80,11 @@ func TestAccountModelDelete(t *testing.T) {
 	var dbAddress sql.NullString
 	err = m.DB.GetContext(ctx, &dbAddress, "SELECT stellar_address FROM accounts LIMIT 1")
 	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	var deregisteredAt time.Time
+	err = m.DB.GetContext(ctx, &deregisteredAt, "SELECT deregistered_at FROM deregistered_accounts WHERE stellar_address = $1", address)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), deregisteredAt, time.Minute)
 }
 
 func TestAccountModelIsAccountFeeBumpEligible(t *testing.T) {
@@ -112,3 +121,104 @@ func TestAccountModelIsAccountFeeBumpEligible(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, isFeeBumpEligible)
 }
+
+func TestAccountModelUpsert(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, "accounts", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", mock.Anything, "accounts").Return()
+
+	m := &AccountModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	ctx := context.Background()
+	address := keypair.MustRandom().Address()
+	err = m.Upsert(ctx, address, AccountMetadata{
+		ExternalUserID: utils.PointOf("user-1"),
+		Labels:         pq.StringArray{"savings", "vip"},
+		Metadata:       json.RawMessage(`{"tier": 2}`),
+	})
+	require.NoError(t, err)
+
+	accounts, err := m.List(ctx, AccountsFilter{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, address, accounts[0].StellarAddress)
+	assert.Equal(t, utils.PointOf("user-1"), accounts[0].ExternalUserID)
+	assert.Equal(t, pq.StringArray{"savings", "vip"}, accounts[0].Labels)
+	assert.JSONEq(t, `{"tier": 2}`, string(accounts[0].Metadata))
+
+	// registering the account again replaces its metadata
+	err = m.Upsert(ctx, address, AccountMetadata{})
+	require.NoError(t, err)
+
+	accounts, err = m.List(ctx, AccountsFilter{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Nil(t, accounts[0].ExternalUserID)
+	assert.Empty(t, accounts[0].Labels)
+	assert.JSONEq(t, `{}`, string(accounts[0].Metadata))
+}
+
+func TestAccountModelList(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "accounts", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "SELECT", "accounts").Return()
+
+	m := &AccountModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	ctx := context.Background()
+	_, err = dbConnectionPool.ExecContext(ctx, `
+		INSERT INTO accounts (stellar_address, external_user_id, labels) VALUES
+			('GA1', 'user-1', '{savings,vip}'),
+			('GA2', 'user-1', '{savings}'),
+			('GA3', 'user-2', '{vip}'),
+			('GA4', NULL, '{}')
+	`)
+	require.NoError(t, err)
+
+	addresses := func(accounts []Account) []string {
+		result := []string{}
+		for _, account := range accounts {
+			result = append(result, account.StellarAddress)
+		}
+		return result
+	}
+	testCases := []struct {
+		name         string
+		filter       AccountsFilter
+		afterAddress string
+		limit        int
+		want         []string
+	}{
+		{name: "all", limit: 10, want: []string{"GA1", "GA2", "GA3", "GA4"}},
+		{name: "external_user_id", filter: AccountsFilter{ExternalUserID: "user-1"}, limit: 10, want: []string{"GA1", "GA2"}},
+		{name: "label", filter: AccountsFilter{Labels: []string{"vip"}}, limit: 10, want: []string{"GA1", "GA3"}},
+		{name: "all_labels", filter: AccountsFilter{Labels: []string{"vip", "savings"}}, limit: 10, want: []string{"GA1"}},
+		{name: "external_user_id_and_label", filter: AccountsFilter{ExternalUserID: "user-2", Labels: []string{"savings"}}, limit: 10, want: []string{}},
+		{name: "page", afterAddress: "GA1", limit: 2, want: []string{"GA2", "GA3"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			accounts, err := m.List(ctx, tc.filter, tc.afterAddress, tc.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, addresses(accounts))
+		})
+	}
+}
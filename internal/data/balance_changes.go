@@ -0,0 +1,245 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+var ErrInvalidBalanceChangeCursor = errors.New("invalid balance change cursor")
+
+type BalanceChangeModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+// BalanceChange is the net change of an account balance in one asset caused by an operation. Transaction level
+// changes, like the fee, use the transaction ID as operation ID.
+type BalanceChange struct {
+	Account         string    `db:"account" json:"account"`
+	OperationID     string    `db:"operation_id" json:"operationId"`
+	Asset           string    `db:"asset" json:"asset"`
+	TransactionID   string    `db:"transaction_id" json:"transactionId"`
+	TransactionHash string    `db:"transaction_hash" json:"transactionHash"`
+	LedgerNumber    uint32    `db:"ledger_number" json:"ledgerNumber"`
+	Reason          string    `db:"reason" json:"reason"`
+	Amount          int64     `db:"amount" json:"amount"`
+	Balance         *int64    `db:"balance" json:"balance"`
+	CreatedAt       time.Time `db:"created_at" json:"createdAt"`
+}
+
+// Cursor returns the pagination cursor of the balance change, which is unique for a given account.
+func (b BalanceChange) Cursor() string {
+	return b.OperationID + "-" + b.Asset
+}
+
+// ParseBalanceChangeCursor splits a cursor returned by BalanceChange.Cursor into its operation ID and asset.
+func ParseBalanceChangeCursor(cursor string) (string, string, error) {
+	operationID, asset, found := strings.Cut(cursor, "-")
+	if !found || operationID == "" || asset == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidBalanceChangeCursor, cursor)
+	}
+	for _, c := range operationID {
+		if c < '0' || c > '9' {
+			return "", "", fmt.Errorf("%w: %q", ErrInvalidBalanceChangeCursor, cursor)
+		}
+	}
+	return operationID, asset, nil
+}
+
+// BatchAddBalanceChanges upserts the balance changes of registered accounts in a single round trip, the changes of
+// any other account are ignored.
+func (m *BalanceChangeModel) BatchAddBalanceChanges(ctx context.Context, tx db.Transaction, balanceChanges []BalanceChange) error {
+	if len(balanceChanges) == 0 {
+		return nil
+	}
+
+	const query = `
+		INSERT INTO ingest_balance_changes (
+			account, operation_id, asset, transaction_id, transaction_hash, ledger_number, reason, amount, balance, created_at
+		)
+		SELECT
+			b.account, b.operation_id, b.asset, b.transaction_id, b.transaction_hash, b.ledger_number, b.reason, b.amount, b.balance, b.created_at
+		FROM UNNEST(
+			$1::text[], $2::bigint[], $3::text[], $4::bigint[], $5::text[], $6::integer[], $7::text[], $8::bigint[], $9::bigint[], $10::timestamptz[]
+		) AS b(
+			account, operation_id, asset, transaction_id, transaction_hash, ledger_number, reason, amount, balance, created_at
+		)
+		WHERE EXISTS (
+			SELECT 1 FROM accounts WHERE stellar_address = b.account
+		)
+		ON CONFLICT (account, operation_id, asset) DO UPDATE SET
+			transaction_id = EXCLUDED.transaction_id,
+			transaction_hash = EXCLUDED.transaction_hash,
+			ledger_number = EXCLUDED.ledger_number,
+			reason = EXCLUDED.reason,
+			amount = EXCLUDED.amount,
+			balance = EXCLUDED.balance,
+			created_at = EXCLUDED.created_at
+		;
+	`
+
+	n := len(balanceChanges)
+	var (
+		accounts          = make([]string, n)
+		operationIDs      = make([]string, n)
+		assets            = make([]string, n)
+		transactionIDs    = make([]string, n)
+		transactionHashes = make([]string, n)
+		ledgerNumbers     = make([]int64, n)
+		reasons           = make([]string, n)
+		amounts           = make([]int64, n)
+		balances          = make([]*int64, n)
+		createdAts        = make([]string, n)
+	)
+	for i, balanceChange := range balanceChanges {
+		accounts[i] = balanceChange.Account
+		operationIDs[i] = balanceChange.OperationID
+		assets[i] = balanceChange.Asset
+		transactionIDs[i] = balanceChange.TransactionID
+		transactionHashes[i] = balanceChange.TransactionHash
+		ledgerNumbers[i] = int64(balanceChange.LedgerNumber)
+		reasons[i] = balanceChange.Reason
+		amounts[i] = balanceChange.Amount
+		balances[i] = balanceChange.Balance
+		createdAts[i] = balanceChange.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, query,
+		pq.Array(accounts), pq.Array(operationIDs), pq.Array(assets), pq.Array(transactionIDs), pq.Array(transactionHashes),
+		pq.Array(ledgerNumbers), pq.Array(reasons), pq.Array(amounts), pq.Array(balances), pq.Array(createdAts))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_balance_changes", duration)
+	if err != nil {
+		return fmt.Errorf("batch inserting %d balance changes: %w", n, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "ingest_balance_changes")
+	return nil
+}
+
+// GetBalanceChangesPaginated returns the balance changes of an account ordered by operation and asset. The before and
+// after cursors are the ones returned by BalanceChange.Cursor.
+func (m *BalanceChangeModel) GetBalanceChangesPaginated(ctx context.Context, address string, beforeCursor, afterCursor string, sort SortOrder, limit int) ([]BalanceChange, bool, bool, error) {
+	if !sort.IsValid() {
+		return nil, false, false, fmt.Errorf("invalid sort value: %s", sort)
+	}
+
+	if beforeCursor != "" && afterCursor != "" {
+		return nil, false, false, errors.New("at most one cursor may be provided, got afterId and beforeId")
+	}
+
+	argumentsMap := map[string]interface{}{
+		"address": address,
+		"limit":   limit,
+	}
+	cursor := beforeCursor + afterCursor
+	if cursor != "" {
+		operationID, asset, err := ParseBalanceChangeCursor(cursor)
+		if err != nil {
+			return nil, false, false, err
+		}
+		argumentsMap["cursor_operation_id"] = operationID
+		argumentsMap["cursor_asset"] = asset
+	}
+
+	const filteredSetCTE = `
+		WITH filtered_set AS (
+			SELECT * FROM ingest_balance_changes WHERE account = :address
+		)
+	`
+
+	var selectQ string
+	if beforeCursor != "" && sort == DESC {
+		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE (operation_id, asset) > (:cursor_operation_id, :cursor_asset) ORDER BY operation_id ASC, asset ASC LIMIT :limit) AS reverse_set ORDER BY operation_id DESC, asset DESC"
+	} else if beforeCursor != "" && sort == ASC {
+		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE (operation_id, asset) < (:cursor_operation_id, :cursor_asset) ORDER BY operation_id DESC, asset DESC LIMIT :limit) AS reverse_set ORDER BY operation_id ASC, asset ASC"
+	} else if afterCursor != "" && sort == DESC {
+		selectQ = "SELECT * FROM filtered_set WHERE (operation_id, asset) < (:cursor_operation_id, :cursor_asset) ORDER BY operation_id DESC, asset DESC LIMIT :limit"
+	} else if afterCursor != "" && sort == ASC {
+		selectQ = "SELECT * FROM filtered_set WHERE (operation_id, asset) > (:cursor_operation_id, :cursor_asset) ORDER BY operation_id ASC, asset ASC LIMIT :limit"
+	} else if sort == ASC {
+		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id ASC, asset ASC LIMIT :limit"
+	} else {
+		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id DESC, asset DESC LIMIT :limit"
+	}
+
+	balanceChanges := make([]BalanceChange, 0)
+	query := fmt.Sprintf("%s %s", filteredSetCTE, selectQ)
+	query, args, err := PrepareNamedQuery(ctx, m.DB, query, argumentsMap)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("preparing named query: %w", err)
+	}
+	start := time.Now()
+	err = m.DB.SelectContext(ctx, &balanceChanges, query, args...)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_balance_changes", duration)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("fetching balance changes: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_balance_changes")
+
+	prevExists, nextExists, err := m.existsPrevNext(ctx, filteredSetCTE, address, sort, balanceChanges)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("checking prev and next pages: %w", err)
+	}
+	return balanceChanges, prevExists, nextExists, nil
+}
+
+func (m *BalanceChangeModel) existsPrevNext(ctx context.Context, filteredSetCTE string, address string, sort SortOrder, balanceChanges []BalanceChange) (bool, bool, error) {
+	if len(balanceChanges) == 0 {
+		return false, false, nil
+	}
+
+	first := balanceChanges[0]
+	last := balanceChanges[len(balanceChanges)-1]
+
+	query := fmt.Sprintf(`
+		%s
+		SELECT
+			EXISTS(
+				SELECT 1 FROM filtered_set WHERE CASE
+					WHEN :sort = 'ASC' THEN (operation_id, asset) < (:first_operation_id, :first_asset)
+					WHEN :sort = 'DESC' THEN (operation_id, asset) > (:first_operation_id, :first_asset)
+				END LIMIT 1
+			) AS prev_exists,
+			EXISTS(
+				SELECT 1 FROM filtered_set WHERE CASE
+					WHEN :sort = 'ASC' THEN (operation_id, asset) > (:last_operation_id, :last_asset)
+					WHEN :sort = 'DESC' THEN (operation_id, asset) < (:last_operation_id, :last_asset)
+				END LIMIT 1
+			) AS next_exists
+	`, filteredSetCTE)
+
+	argumentsMap := map[string]interface{}{
+		"address":            address,
+		"first_operation_id": first.OperationID,
+		"first_asset":        first.Asset,
+		"last_operation_id":  last.OperationID,
+		"last_asset":         last.Asset,
+		"sort":               sort,
+	}
+
+	query, args, err := PrepareNamedQuery(ctx, m.DB, query, argumentsMap)
+	if err != nil {
+		return false, false, fmt.Errorf("preparing named query: %w", err)
+	}
+
+	var prevExists, nextExists bool
+	start := time.Now()
+	err = m.DB.QueryRowxContext(ctx, query, args...).Scan(&prevExists, &nextExists)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_balance_changes", duration)
+	if err != nil {
+		return false, false, fmt.Errorf("fetching prev and next exists: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_balance_changes")
+	return prevExists, nextExists, nil
+}
@@ -0,0 +1,181 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestParseBalanceChangeCursor(t *testing.T) {
+	operationID, asset, err := ParseBalanceChangeCursor("2120562792996865-USDC:GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5")
+	require.NoError(t, err)
+	assert.Equal(t, "2120562792996865", operationID)
+	assert.Equal(t, "USDC:GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5", asset)
+
+	for _, cursor := range []string{"", "2120562792996865", "-native", "2120562792996865-", "abc-native"} {
+		_, _, err := ParseBalanceChangeCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidBalanceChangeCursor, cursor)
+	}
+}
+
+func TestBalanceChangeModelBatchAddBalanceChanges(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_balance_changes", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_balance_changes").Return()
+	defer mockMetricsService.AssertExpectations(t)
+
+	m := &BalanceChangeModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	registeredAccount := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, registeredAccount)
+	require.NoError(t, err)
+
+	balance := int64(990_0000000)
+	balanceChanges := []BalanceChange{
+		{
+			Account:         registeredAccount,
+			OperationID:     "2120562792996865",
+			Asset:           "native",
+			TransactionID:   "2120562792996864",
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			LedgerNumber:    493741,
+			Reason:          "OperationTypePayment",
+			Amount:          -10_0000000,
+			Balance:         &balance,
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			Account:         registeredAccount,
+			OperationID:     "2120562792996864",
+			Asset:           "native",
+			TransactionID:   "2120562792996864",
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			LedgerNumber:    493741,
+			Reason:          "fee",
+			Amount:          -100,
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			Account:         keypair.MustRandom().Address(),
+			OperationID:     "2120562792996865",
+			Asset:           "native",
+			TransactionID:   "2120562792996864",
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			LedgerNumber:    493741,
+			Reason:          "OperationTypePayment",
+			Amount:          10_0000000,
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	// ingesting the same ledger twice must not fail
+	for range 2 {
+		err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+			return m.BatchAddBalanceChanges(ctx, dbTx, balanceChanges)
+		})
+		require.NoError(t, err)
+	}
+
+	var dbBalanceChanges []BalanceChange
+	err = dbConnectionPool.SelectContext(ctx, &dbBalanceChanges, `SELECT * FROM ingest_balance_changes ORDER BY operation_id`)
+	require.NoError(t, err)
+	require.Len(t, dbBalanceChanges, 2)
+	assert.Equal(t, balanceChanges[1], dbBalanceChanges[0])
+	assert.Equal(t, balanceChanges[0], dbBalanceChanges[1])
+}
+
+func TestBalanceChangeModelGetBalanceChangesPaginated(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+
+	address := keypair.MustRandom().Address()
+	otherAddress := keypair.MustRandom().Address()
+	for _, row := range []struct {
+		account     string
+		operationID int
+		asset       string
+	}{
+		{address, 1, "native"},
+		{address, 2, "USDC:GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5"},
+		{address, 2, "native"},
+		{otherAddress, 2, "native"},
+		{address, 3, "native"},
+	} {
+		_, err = dbConnectionPool.ExecContext(ctx, `
+			INSERT INTO ingest_balance_changes (account, operation_id, asset, transaction_id, transaction_hash, ledger_number, reason, amount, created_at)
+			VALUES ($1, $2, $3, $2, 'hash', 1, 'OperationTypePayment', 1, NOW())
+		`, row.account, row.operationID, row.asset)
+		require.NoError(t, err)
+	}
+
+	newModel := func(t *testing.T) *BalanceChangeModel {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_balance_changes", mock.Anything).Return().Times(2)
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_balance_changes").Return().Times(2)
+		t.Cleanup(func() { mockMetricsService.AssertExpectations(t) })
+		return &BalanceChangeModel{DB: dbConnectionPool, MetricsService: mockMetricsService}
+	}
+
+	cursors := func(balanceChanges []BalanceChange) []string {
+		result := make([]string, 0, len(balanceChanges))
+		for _, balanceChange := range balanceChanges {
+			result = append(result, balanceChange.Cursor())
+		}
+		return result
+	}
+
+	t.Run("desc", func(t *testing.T) {
+		balanceChanges, prevExists, nextExists, err := newModel(t).GetBalanceChangesPaginated(ctx, address, "", "", DESC, 2)
+		require.NoError(t, err)
+		assert.False(t, prevExists)
+		assert.True(t, nextExists)
+		assert.Equal(t, []string{"3-native", "2-native"}, cursors(balanceChanges))
+	})
+
+	t.Run("desc_after_cursor", func(t *testing.T) {
+		balanceChanges, prevExists, nextExists, err := newModel(t).GetBalanceChangesPaginated(ctx, address, "", "2-native", DESC, 2)
+		require.NoError(t, err)
+		assert.True(t, prevExists)
+		assert.False(t, nextExists)
+		assert.Equal(t, []string{"2-USDC:GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5", "1-native"}, cursors(balanceChanges))
+	})
+
+	t.Run("asc_before_cursor", func(t *testing.T) {
+		balanceChanges, prevExists, nextExists, err := newModel(t).GetBalanceChangesPaginated(ctx, address, "2-native", "", ASC, 1)
+		require.NoError(t, err)
+		assert.True(t, prevExists)
+		assert.True(t, nextExists)
+		assert.Equal(t, []string{"2-USDC:GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5"}, cursors(balanceChanges))
+	})
+
+	t.Run("invalid_cursor", func(t *testing.T) {
+		m := &BalanceChangeModel{DB: dbConnectionPool, MetricsService: metrics.NewMockMetricsService()}
+		_, _, _, err := m.GetBalanceChangesPaginated(ctx, address, "", "native", DESC, 2)
+		assert.ErrorIs(t, err, ErrInvalidBalanceChangeCursor)
+	})
+}
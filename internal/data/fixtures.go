@@ -12,7 +12,7 @@ import (
 func InsertTestPayments(t *testing.T, ctx context.Context, payments []Payment, connectionPool db.ConnectionPool) {
 	t.Helper()
 
-	const query = `INSERT INTO ingest_payments (operation_id, operation_type, transaction_id, transaction_hash, from_address, to_address, src_asset_code, src_asset_issuer, src_asset_type, src_amount, dest_asset_code, dest_asset_issuer, dest_asset_type, dest_amount, created_at, memo, memo_type) VALUES (:operation_id, :operation_type, :transaction_id, :transaction_hash, :from_address, :to_address, :src_asset_code, :src_asset_issuer, :src_asset_type, :src_amount, :dest_asset_code, :dest_asset_issuer, :dest_asset_type, :dest_amount, :created_at, :memo, :memo_type);`
+	const query = `INSERT INTO ingest_payments (operation_id, operation_type, transaction_id, transaction_hash, from_address, to_address, src_asset_code, src_asset_issuer, src_asset_type, src_amount, dest_asset_code, dest_asset_issuer, dest_asset_type, dest_amount, created_at, memo, memo_type, contract_event_type, from_muxed_id, from_muxed_address, to_muxed_id, to_muxed_address, successful, transaction_result_code, operation_result_code, event_index) VALUES (:operation_id, :operation_type, :transaction_id, :transaction_hash, :from_address, :to_address, :src_asset_code, :src_asset_issuer, :src_asset_type, :src_amount, :dest_asset_code, :dest_asset_issuer, :dest_asset_type, :dest_amount, :created_at, :memo, :memo_type, :contract_event_type, :from_muxed_id, :from_muxed_address, :to_muxed_id, :to_muxed_address, :successful, :transaction_result_code, :operation_result_code, :event_index);`
 	_, err := connectionPool.NamedExecContext(ctx, query, payments)
 	require.NoError(t, err)
 }
@@ -0,0 +1,79 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+type LedgerRangeSource string
+
+const (
+	LiveLedgerRangeSource     LedgerRangeSource = "live"
+	BackfillLedgerRangeSource LedgerRangeSource = "backfill"
+	RepairLedgerRangeSource   LedgerRangeSource = "repair"
+)
+
+type IngestedLedgerRangeModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+// IngestedLedgerRange is an inclusive range of ledgers fully ingested by the same source.
+type IngestedLedgerRange struct {
+	ID          int64             `db:"id" json:"id"`
+	Source      LedgerRangeSource `db:"source" json:"source"`
+	StartLedger uint32            `db:"start_ledger" json:"startLedger"`
+	EndLedger   uint32            `db:"end_ledger" json:"endLedger"`
+	CreatedAt   time.Time         `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time         `db:"updated_at" json:"updatedAt"`
+}
+
+// Record marks the ledger as ingested by the source. The range of the source ending right before the ledger is
+// extended, as is the one already ending at it since a restarted ingestion begins by re-ingesting its cursor ledger.
+// Any other ledger starts a new range, so ingesting a ledger twice shows up as an overlap instead of being merged.
+func (m *IngestedLedgerRangeModel) Record(ctx context.Context, sqlExec db.SQLExecuter, source LedgerRangeSource, ledger uint32) error {
+	const query = `
+		WITH extended AS (
+			UPDATE ingested_ledger_ranges
+			SET end_ledger = $2::integer, updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM ingested_ledger_ranges
+				WHERE source = $1::text AND end_ledger IN ($2::integer - 1, $2::integer)
+				ORDER BY end_ledger DESC, id DESC
+				LIMIT 1
+			)
+			RETURNING id
+		)
+		INSERT INTO ingested_ledger_ranges (source, start_ledger, end_ledger)
+		SELECT $1::text, $2::integer, $2::integer
+		WHERE NOT EXISTS (SELECT 1 FROM extended)
+	`
+	start := time.Now()
+	_, err := sqlExec.ExecContext(ctx, query, source, ledger)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingested_ledger_ranges", duration)
+	if err != nil {
+		return fmt.Errorf("recording ledger %d ingested by %s: %w", ledger, source, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "ingested_ledger_ranges")
+	return nil
+}
+
+// GetAll returns every recorded range ordered by start ledger.
+func (m *IngestedLedgerRangeModel) GetAll(ctx context.Context) ([]IngestedLedgerRange, error) {
+	const query = `SELECT * FROM ingested_ledger_ranges ORDER BY start_ledger, end_ledger, id`
+	ranges := make([]IngestedLedgerRange, 0)
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &ranges, query)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingested_ledger_ranges", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting ingested ledger ranges: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingested_ledger_ranges")
+	return ranges, nil
+}
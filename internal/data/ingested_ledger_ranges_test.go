@@ -0,0 +1,64 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestIngestedLedgerRangeModel(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, "ingested_ledger_ranges", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", mock.Anything, "ingested_ledger_ranges").Return()
+
+	m := &IngestedLedgerRangeModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	ranges, err := m.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, ranges)
+
+	// contiguous ledgers extend the same range, and re-ingesting the last one after a restart doesn't split it
+	for _, ledger := range []uint32{10, 11, 12, 12, 13} {
+		require.NoError(t, m.Record(ctx, dbConnectionPool, LiveLedgerRangeSource, ledger))
+	}
+	// a ledger that was already ingested and skipping ledgers both start new ranges
+	require.NoError(t, m.Record(ctx, dbConnectionPool, LiveLedgerRangeSource, 11))
+	require.NoError(t, m.Record(ctx, dbConnectionPool, LiveLedgerRangeSource, 20))
+	// ranges of different sources are never merged
+	require.NoError(t, m.Record(ctx, dbConnectionPool, BackfillLedgerRangeSource, 14))
+
+	ranges, err = m.GetAll(ctx)
+	require.NoError(t, err)
+	type sourceRange struct {
+		source     LedgerRangeSource
+		start, end uint32
+	}
+	var got []sourceRange
+	for _, r := range ranges {
+		got = append(got, sourceRange{source: r.Source, start: r.StartLedger, end: r.EndLedger})
+	}
+	assert.Equal(t, []sourceRange{
+		{source: LiveLedgerRangeSource, start: 10, end: 13},
+		{source: LiveLedgerRangeSource, start: 11, end: 11},
+		{source: BackfillLedgerRangeSource, start: 14, end: 14},
+		{source: LiveLedgerRangeSource, start: 20, end: 20},
+	}, got)
+}
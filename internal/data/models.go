@@ -8,8 +8,16 @@ import (
 )
 
 type Models struct {
-	Payments *PaymentModel
-	Account  *AccountModel
+	Payments        *PaymentModel
+	Account         *AccountModel
+	Transactions    *TransactionModel
+	Operations      *OperationModel
+	BalanceChanges  *BalanceChangeModel
+	BackfillJobs    *AccountBackfillJobModel
+	IngestedRanges  *IngestedLedgerRangeModel
+	Fees            *TransactionFeeModel
+	AccountWebhooks *AccountWebhookModel
+	PaymentVolumes  *PaymentVolumeModel
 }
 
 func NewModels(db db.ConnectionPool, metricsService metrics.MetricsService) (*Models, error) {
@@ -18,7 +26,15 @@ func NewModels(db db.ConnectionPool, metricsService metrics.MetricsService) (*Mo
 	}
 
 	return &Models{
-		Payments: &PaymentModel{DB: db, MetricsService: metricsService},
-		Account:  &AccountModel{DB: db, MetricsService: metricsService},
+		Payments:        &PaymentModel{DB: db, MetricsService: metricsService},
+		Account:         &AccountModel{DB: db, MetricsService: metricsService},
+		Transactions:    &TransactionModel{DB: db, MetricsService: metricsService},
+		Operations:      &OperationModel{DB: db, MetricsService: metricsService},
+		BalanceChanges:  &BalanceChangeModel{DB: db, MetricsService: metricsService},
+		BackfillJobs:    &AccountBackfillJobModel{DB: db, MetricsService: metricsService},
+		IngestedRanges:  &IngestedLedgerRangeModel{DB: db, MetricsService: metricsService},
+		Fees:            &TransactionFeeModel{DB: db, MetricsService: metricsService},
+		AccountWebhooks: &AccountWebhookModel{DB: db, MetricsService: metricsService},
+		PaymentVolumes:  &PaymentVolumeModel{DB: db, MetricsService: metricsService},
 	}, nil
 }
@@ -0,0 +1,245 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+type OperationModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+type Operation struct {
+	OperationID     string          `db:"operation_id" json:"operationId"`
+	OperationType   string          `db:"operation_type" json:"operationType"`
+	TransactionID   string          `db:"transaction_id" json:"transactionId"`
+	TransactionHash string          `db:"transaction_hash" json:"transactionHash"`
+	SourceAccount   string          `db:"source_account" json:"sourceAccount"`
+	Details         json.RawMessage `db:"details" json:"details"`
+	Participants    pq.StringArray  `db:"participants" json:"-"`
+	CreatedAt       time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// BatchAddOperations upserts the given operations in a single round trip. Only operations with at least one
+// registered account among their participants are stored.
+func (m *OperationModel) BatchAddOperations(ctx context.Context, tx db.Transaction, operations []Operation) error {
+	if len(operations) == 0 {
+		return nil
+	}
+
+	const query = `
+		INSERT INTO ingest_operations (
+			operation_id, operation_type, transaction_id, transaction_hash, source_account, details, participants, created_at
+		)
+		SELECT
+			o.operation_id, o.operation_type, o.transaction_id, o.transaction_hash, o.source_account, o.details,
+			string_to_array(o.participants, ','), o.created_at
+		FROM UNNEST(
+			$1::bigint[], $2::text[], $3::bigint[], $4::text[], $5::text[], $6::jsonb[], $7::text[], $8::timestamptz[]
+		) AS o(
+			operation_id, operation_type, transaction_id, transaction_hash, source_account, details, participants, created_at
+		)
+		WHERE EXISTS (
+			SELECT 1 FROM accounts WHERE stellar_address = ANY(string_to_array(o.participants, ','))
+		)
+		ON CONFLICT (operation_id) DO UPDATE SET
+			operation_type = EXCLUDED.operation_type,
+			transaction_id = EXCLUDED.transaction_id,
+			transaction_hash = EXCLUDED.transaction_hash,
+			source_account = EXCLUDED.source_account,
+			details = EXCLUDED.details,
+			participants = EXCLUDED.participants,
+			created_at = EXCLUDED.created_at
+		;
+	`
+
+	n := len(operations)
+	var (
+		operationIDs      = make([]string, n)
+		operationTypes    = make([]string, n)
+		transactionIDs    = make([]string, n)
+		transactionHashes = make([]string, n)
+		sourceAccounts    = make([]string, n)
+		details           = make([]string, n)
+		participants      = make([]string, n)
+		createdAts        = make([]string, n)
+	)
+	for i, operation := range operations {
+		operationIDs[i] = operation.OperationID
+		operationTypes[i] = operation.OperationType
+		transactionIDs[i] = operation.TransactionID
+		transactionHashes[i] = operation.TransactionHash
+		sourceAccounts[i] = operation.SourceAccount
+		details[i] = string(operation.Details)
+		// participants are flattened since UNNEST can't return a different array per row
+		participants[i] = strings.Join(operation.Participants, ",")
+		createdAts[i] = operation.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, query,
+		pq.Array(operationIDs), pq.Array(operationTypes), pq.Array(transactionIDs), pq.Array(transactionHashes),
+		pq.Array(sourceAccounts), pq.Array(details), pq.Array(participants), pq.Array(createdAts))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_operations", duration)
+	if err != nil {
+		return fmt.Errorf("batch inserting %d operations: %w", n, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "ingest_operations")
+	return nil
+}
+
+// GetLedgerOperations returns the operations of the ledger ordered by operation ID.
+func (m *OperationModel) GetLedgerOperations(ctx context.Context, sqlExec db.SQLExecuter, ledger uint32) ([]Operation, error) {
+	const query = `SELECT * FROM ingest_operations WHERE operation_id >= $1 AND operation_id < $2 ORDER BY operation_id`
+	var operations []Operation
+	start := time.Now()
+	err := sqlExec.SelectContext(ctx, &operations, query, int64(ledger)<<32, (int64(ledger)+1)<<32)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_operations", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting the operations of ledger %d: %w", ledger, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_operations")
+	return operations, nil
+}
+
+// GetTransactionOperations returns the ingested operations of the transaction with the hash, ordered by operation ID.
+func (m *OperationModel) GetTransactionOperations(ctx context.Context, hash string) ([]Operation, error) {
+	operations := make([]Operation, 0)
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &operations, `SELECT * FROM ingest_operations WHERE transaction_hash = $1 ORDER BY operation_id`, hash)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_operations", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting the operations of transaction %s: %w", hash, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_operations")
+	return operations, nil
+}
+
+func (m *OperationModel) GetOperationsPaginated(ctx context.Context, address string, beforeID, afterID string, sort SortOrder, limit int) ([]Operation, bool, bool, error) {
+	if !sort.IsValid() {
+		return nil, false, false, fmt.Errorf("invalid sort value: %s", sort)
+	}
+
+	if beforeID != "" && afterID != "" {
+		return nil, false, false, errors.New("at most one cursor may be provided, got afterId and beforeId")
+	}
+
+	const filteredSetCTE = `
+		WITH filtered_set AS (
+			SELECT * FROM ingest_operations WHERE participants @> ARRAY[:address]::text[]
+		)
+	`
+
+	var selectQ string
+	if beforeID != "" && sort == DESC {
+		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE operation_id > :before_id ORDER BY operation_id ASC LIMIT :limit) AS reverse_set ORDER BY operation_id DESC"
+	} else if beforeID != "" && sort == ASC {
+		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE operation_id < :before_id ORDER BY operation_id DESC LIMIT :limit) AS reverse_set ORDER BY operation_id ASC"
+	} else if afterID != "" && sort == DESC {
+		selectQ = "SELECT * FROM filtered_set WHERE operation_id < :after_id ORDER BY operation_id DESC LIMIT :limit"
+	} else if afterID != "" && sort == ASC {
+		selectQ = "SELECT * FROM filtered_set WHERE operation_id > :after_id ORDER BY operation_id ASC LIMIT :limit"
+	} else if sort == ASC {
+		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id ASC LIMIT :limit"
+	} else {
+		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id DESC LIMIT :limit"
+	}
+
+	argumentsMap := map[string]interface{}{
+		"address":   address,
+		"limit":     limit,
+		"before_id": beforeID,
+		"after_id":  afterID,
+	}
+
+	operations := make([]Operation, 0)
+	query := fmt.Sprintf("%s %s", filteredSetCTE, selectQ)
+	query, args, err := PrepareNamedQuery(ctx, m.DB, query, argumentsMap)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("preparing named query: %w", err)
+	}
+	start := time.Now()
+	err = m.DB.SelectContext(ctx, &operations, query, args...)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_operations", duration)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("fetching operations: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_operations")
+
+	prevExists, nextExists, err := m.existsPrevNext(ctx, filteredSetCTE, address, sort, operations)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("checking prev and next pages: %w", err)
+	}
+	return operations, prevExists, nextExists, nil
+}
+
+func (m *OperationModel) existsPrevNext(ctx context.Context, filteredSetCTE string, address string, sort SortOrder, operations []Operation) (bool, bool, error) {
+	if len(operations) == 0 {
+		return false, false, nil
+	}
+
+	firstElementID := FirstOperationID(operations)
+	lastElementID := LastOperationID(operations)
+
+	query := fmt.Sprintf(`
+		%s
+		SELECT
+			EXISTS(
+				SELECT 1 FROM filtered_set WHERE CASE WHEN :sort = 'ASC' THEN operation_id < :first_element_id WHEN :sort = 'DESC' THEN operation_id > :first_element_id END LIMIT 1
+			) AS prev_exists,
+			EXISTS(
+				SELECT 1 FROM filtered_set WHERE CASE WHEN :sort = 'ASC' THEN operation_id > :last_element_id WHEN :sort = 'DESC' THEN operation_id < :last_element_id END LIMIT 1
+			) AS next_exists
+	`, filteredSetCTE)
+
+	argumentsMap := map[string]interface{}{
+		"address":          address,
+		"first_element_id": firstElementID,
+		"last_element_id":  lastElementID,
+		"sort":             sort,
+	}
+
+	query, args, err := PrepareNamedQuery(ctx, m.DB, query, argumentsMap)
+	if err != nil {
+		return false, false, fmt.Errorf("preparing named query: %w", err)
+	}
+
+	var prevExists, nextExists bool
+	start := time.Now()
+	err = m.DB.QueryRowxContext(ctx, query, args...).Scan(&prevExists, &nextExists)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_operations", duration)
+	if err != nil {
+		return false, false, fmt.Errorf("fetching prev and next exists: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_operations")
+	return prevExists, nextExists, nil
+}
+
+func FirstOperationID(operations []Operation) string {
+	if len(operations) > 0 {
+		return operations[0].OperationID
+	}
+	return ""
+}
+
+func LastOperationID(operations []Operation) string {
+	if len(operations) > 0 {
+		return operations[len(operations)-1].OperationID
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestOperationModelBatchAddOperations(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_operations", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_operations").Return()
+	defer mockMetricsService.AssertExpectations(t)
+
+	m := &OperationModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	registeredAccount := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, registeredAccount)
+	require.NoError(t, err)
+
+	operations := []Operation{
+		{
+			OperationID:     "2120562792996865",
+			OperationType:   xdr.OperationTypeChangeTrust.String(),
+			TransactionID:   "2120562792996864",
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			SourceAccount:   registeredAccount,
+			Details:         json.RawMessage(`{"asset": "USDC:GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5", "limit": "922337203685.4775807"}`),
+			Participants:    []string{registeredAccount},
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			OperationID:     "2120562792996866",
+			OperationType:   xdr.OperationTypeSetOptions.String(),
+			TransactionID:   "2120562792996864",
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			SourceAccount:   keypair.MustRandom().Address(),
+			Details:         json.RawMessage(`{"homeDomain": "example.com"}`),
+			Participants:    []string{keypair.MustRandom().Address()},
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+		return m.BatchAddOperations(ctx, dbTx, operations)
+	})
+	require.NoError(t, err)
+
+	var dbOperations []Operation
+	err = dbConnectionPool.SelectContext(ctx, &dbOperations, `SELECT * FROM ingest_operations ORDER BY operation_id`)
+	require.NoError(t, err)
+	require.Len(t, dbOperations, 1)
+
+	expected := operations[0]
+	assert.JSONEq(t, string(expected.Details), string(dbOperations[0].Details))
+	expected.Details = dbOperations[0].Details
+	assert.Equal(t, expected, dbOperations[0])
+}
+
+func TestOperationModelGetOperationsPaginated(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+
+	address := keypair.MustRandom().Address()
+	otherAddress := keypair.MustRandom().Address()
+	for i, participants := range [][]string{{address}, {otherAddress}, {otherAddress, address}, {address}} {
+		_, err = dbConnectionPool.ExecContext(ctx, `
+			INSERT INTO ingest_operations (operation_id, operation_type, transaction_id, transaction_hash, source_account, details, participants, created_at)
+			VALUES ($1, 'OperationTypeBumpSequence', $1, 'hash', $2, '{}', $3, NOW())
+		`, i+1, participants[0], participants)
+		require.NoError(t, err)
+	}
+
+	newModel := func(t *testing.T) *OperationModel {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_operations", mock.Anything).Return().Times(2)
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_operations").Return().Times(2)
+		t.Cleanup(func() { mockMetricsService.AssertExpectations(t) })
+		return &OperationModel{DB: dbConnectionPool, MetricsService: mockMetricsService}
+	}
+
+	operationIDs := func(operations []Operation) []string {
+		ids := make([]string, 0, len(operations))
+		for _, operation := range operations {
+			ids = append(ids, operation.OperationID)
+		}
+		return ids
+	}
+
+	t.Run("desc", func(t *testing.T) {
+		operations, prevExists, nextExists, err := newModel(t).GetOperationsPaginated(ctx, address, "", "", DESC, 2)
+		require.NoError(t, err)
+		assert.False(t, prevExists)
+		assert.True(t, nextExists)
+		assert.Equal(t, []string{"4", "3"}, operationIDs(operations))
+	})
+
+	t.Run("asc_after_id", func(t *testing.T) {
+		operations, prevExists, nextExists, err := newModel(t).GetOperationsPaginated(ctx, address, "", "1", ASC, 2)
+		require.NoError(t, err)
+		assert.True(t, prevExists)
+		assert.False(t, nextExists)
+		assert.Equal(t, []string{"3", "4"}, operationIDs(operations))
+	})
+
+	t.Run("desc_before_id", func(t *testing.T) {
+		operations, prevExists, nextExists, err := newModel(t).GetOperationsPaginated(ctx, address, "3", "", DESC, 2)
+		require.NoError(t, err)
+		assert.False(t, prevExists)
+		assert.True(t, nextExists)
+		assert.Equal(t, []string{"4"}, operationIDs(operations))
+	})
+
+	t.Run("other_address", func(t *testing.T) {
+		operations, prevExists, nextExists, err := newModel(t).GetOperationsPaginated(ctx, otherAddress, "", "", ASC, 10)
+		require.NoError(t, err)
+		assert.False(t, prevExists)
+		assert.False(t, nextExists)
+		assert.Equal(t, []string{"2", "3"}, operationIDs(operations))
+	})
+}
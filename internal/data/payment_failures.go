@@ -0,0 +1,96 @@
+package data
+
+import (
+	"strings"
+)
+
+// failureReasons describes the result codes of the transactions and operations stored as payments. Codes missing from
+// here fall back to a description built from the code itself.
+var failureReasons = map[string]string{
+	// transaction result codes
+	"tx_failed":                 "One of the operations failed",
+	"tx_too_early":              "The transaction was submitted before its time bounds",
+	"tx_too_late":               "The transaction was submitted after its time bounds",
+	"tx_missing_operation":      "The transaction has no operations",
+	"tx_bad_seq":                "The sequence number does not match the source account",
+	"tx_bad_auth":               "The transaction is missing signatures or has invalid ones",
+	"tx_insufficient_balance":   "The fee would bring the source account below its minimum balance",
+	"tx_no_account":             "The source account does not exist",
+	"tx_insufficient_fee":       "The fee is too small",
+	"tx_bad_auth_extra":         "The transaction has unused signatures",
+	"tx_internal_error":         "An unknown error occurred in the network",
+	"tx_not_supported":          "The transaction type is not supported",
+	"tx_bad_sponsorship":        "A sponsorship was not confirmed",
+	"tx_bad_min_seq_age_or_gap": "The minimum sequence age or gap preconditions were not met",
+	"tx_malformed":              "The transaction is malformed",
+	"tx_soroban_invalid":        "The Soroban transaction is invalid",
+	"another_operation_failed":  "The payment succeeded but another operation of the transaction failed",
+	// operation result codes shared by all operations
+	"op_bad_auth":            "The operation is missing signatures or has invalid ones",
+	"op_no_account":          "The source account of the operation does not exist",
+	"op_not_supported":       "The operation is not supported",
+	"op_too_many_subentries": "The account has too many subentries",
+	"op_exceeded_work_limit": "The operation exceeded the work limit",
+	"op_too_many_sponsoring": "The account is sponsoring too many entries",
+	// payment, path payment and create account result codes
+	"op_malformed":          "The operation is malformed",
+	"op_underfunded":        "The source account does not have enough funds",
+	"op_src_no_trust":       "The source account does not trust the asset",
+	"op_src_not_authorized": "The source account is not authorized to hold the asset",
+	"op_no_destination":     "The destination account does not exist",
+	"op_no_trust":           "The destination account does not trust the asset",
+	"op_not_authorized":     "The destination account is not authorized to hold the asset",
+	"op_line_full":          "The destination trustline would exceed its limit",
+	"op_no_issuer":          "The issuer of the asset does not exist",
+	"op_too_few_offers":     "There is not enough liquidity along the path",
+	"op_offer_cross_self":   "The path would cross an offer of the source account",
+	"op_over_sendmax":       "The path would send more than the maximum amount",
+	"op_under_destmin":      "The path would deliver less than the minimum amount",
+	"op_low_reserve":        "The starting balance is below the minimum reserve",
+	"op_already_exist":      "The destination account already exists",
+	// account merge result codes
+	"op_immutable_set":   "The source account has the immutable flag set",
+	"op_has_sub_entries": "The source account still has subentries",
+	"op_seqnum_too_far":  "The sequence number of the source account is too high to merge",
+	"op_dest_full":       "The destination balance would exceed its limit",
+	"op_is_sponsor":      "The source account is still sponsoring entries",
+	// claim claimable balance result codes
+	"op_does_not_exist": "The claimable balance does not exist",
+	"op_cannot_claim":   "The claim predicate is not satisfied",
+}
+
+// failureReason describes why a payment failed, it's nil for successful payments. Operations that succeeded within a
+// failed transaction are reported as such, and the transaction code is used when no operation was applied.
+func (p Payment) failureReason() *string {
+	if p.Successful {
+		return nil
+	}
+
+	var code string
+	switch {
+	case p.OperationResultCode != nil && *p.OperationResultCode == "op_success":
+		code = "another_operation_failed"
+	case p.OperationResultCode != nil:
+		code = *p.OperationResultCode
+	case p.TransactionResultCode != nil:
+		code = *p.TransactionResultCode
+	default:
+		return nil
+	}
+
+	reason, ok := failureReasons[code]
+	if !ok {
+		reason = describeResultCode(code)
+	}
+	return &reason
+}
+
+// describeResultCode turns an unknown result code like op_bad_thing into "Bad thing".
+func describeResultCode(code string) string {
+	description := strings.TrimPrefix(strings.TrimPrefix(code, "op_"), "tx_")
+	description = strings.ReplaceAll(description, "_", " ")
+	if description == "" {
+		return code
+	}
+	return strings.ToUpper(description[:1]) + description[1:]
+}
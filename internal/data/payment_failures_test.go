@@ -0,0 +1,54 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+func TestPaymentFailureReason(t *testing.T) {
+	testCases := []struct {
+		name       string
+		payment    Payment
+		wantReason *string
+	}{
+		{
+			name:       "successful_payment",
+			payment:    Payment{Successful: true},
+			wantReason: nil,
+		},
+		{
+			name:       "failed_operation",
+			payment:    Payment{TransactionResultCode: utils.PointOf("tx_failed"), OperationResultCode: utils.PointOf("op_underfunded")},
+			wantReason: utils.PointOf("The source account does not have enough funds"),
+		},
+		{
+			name:       "another_operation_failed",
+			payment:    Payment{TransactionResultCode: utils.PointOf("tx_failed"), OperationResultCode: utils.PointOf("op_success")},
+			wantReason: utils.PointOf("The payment succeeded but another operation of the transaction failed"),
+		},
+		{
+			name:       "failed_transaction",
+			payment:    Payment{TransactionResultCode: utils.PointOf("tx_bad_seq")},
+			wantReason: utils.PointOf("The sequence number does not match the source account"),
+		},
+		{
+			name:       "unknown_code",
+			payment:    Payment{TransactionResultCode: utils.PointOf("tx_failed"), OperationResultCode: utils.PointOf("op_trust_line_missing")},
+			wantReason: utils.PointOf("Trust line missing"),
+		},
+		{
+			name:       "missing_codes",
+			payment:    Payment{},
+			wantReason: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantReason, tc.payment.failureReason())
+		})
+	}
+}
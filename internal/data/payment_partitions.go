@@ -0,0 +1,152 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/wallet-backend/internal/db"
+)
+
+// PaymentsPartitionLedgers is the number of ledgers in each ingest_payments partition, about a month of pubnet
+// ledgers. It must match the partitioning migration.
+const PaymentsPartitionLedgers = 500_000
+
+const paymentsPartitionPrefix = "ingest_payments_ledger_"
+
+// PaymentPartition is an ingest_payments partition, it holds the payments of [StartLedger, EndLedger).
+type PaymentPartition struct {
+	Name        string
+	StartLedger uint32
+	EndLedger   uint32
+}
+
+// PaymentPartitionFor returns the partition holding the payments of the ledger.
+func PaymentPartitionFor(ledger uint32) PaymentPartition {
+	startLedger := ledger / PaymentsPartitionLedgers * PaymentsPartitionLedgers
+	return PaymentPartition{
+		Name:        paymentsPartitionPrefix + strconv.FormatUint(uint64(startLedger), 10),
+		StartLedger: startLedger,
+		EndLedger:   startLedger + PaymentsPartitionLedgers,
+	}
+}
+
+// operationIDRange returns the bounds of the operation IDs of the partition ledgers, operation IDs start with their
+// ledger in the 32 most significant bits.
+func (p PaymentPartition) operationIDRange() (int64, int64) {
+	return int64(p.StartLedger) << 32, int64(p.EndLedger) << 32
+}
+
+// GetPaymentPartitions returns the partitions attached to ingest_payments ordered by ledger, leaving the default one
+// out.
+func (m *PaymentModel) GetPaymentPartitions(ctx context.Context) ([]PaymentPartition, error) {
+	const query = `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'ingest_payments'::regclass
+	`
+	var names []string
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &names, query)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "pg_inherits", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting payment partitions: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "pg_inherits")
+
+	partitions := make([]PaymentPartition, 0, len(names))
+	for _, name := range names {
+		suffix, ok := strings.CutPrefix(name, paymentsPartitionPrefix)
+		if !ok {
+			continue
+		}
+		startLedger, err := strconv.ParseUint(suffix, 10, 32)
+		if err != nil {
+			continue
+		}
+		partitions = append(partitions, PaymentPartitionFor(uint32(startLedger)))
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].StartLedger < partitions[j].StartLedger })
+	return partitions, nil
+}
+
+// CreatePaymentPartition creates the partition unless it's already attached, and reports whether it was created. The
+// payments of its ledgers are moved out of the default partition, so it can be attached.
+func (m *PaymentModel) CreatePaymentPartition(ctx context.Context, partition PaymentPartition) (bool, error) {
+	from, to := partition.operationIDRange()
+	start := time.Now()
+	created, err := db.RunInTransactionWithResult(ctx, m.DB, nil, func(dbTx db.Transaction) (bool, error) {
+		// serializes concurrent backfill workers creating the same partition
+		if _, err := dbTx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('ingest_payments_partitions'))`); err != nil {
+			return false, fmt.Errorf("locking payment partitions: %w", err)
+		}
+		var exists bool
+		err := dbTx.GetContext(ctx, &exists, `
+			SELECT EXISTS (
+				SELECT 1 FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+				WHERE i.inhparent = 'ingest_payments'::regclass AND c.relname = $1
+			)
+		`, partition.Name)
+		if err != nil {
+			return false, fmt.Errorf("checking whether the partition exists: %w", err)
+		}
+		if exists {
+			return false, nil
+		}
+
+		statements := []string{
+			fmt.Sprintf(`CREATE TABLE %s (LIKE ingest_payments INCLUDING DEFAULTS)`, partition.Name),
+			fmt.Sprintf(`
+				WITH moved AS (
+					DELETE FROM ingest_payments_default WHERE operation_id >= %d AND operation_id < %d RETURNING *
+				)
+				INSERT INTO %s SELECT * FROM moved
+			`, from, to, partition.Name),
+			fmt.Sprintf(`ALTER TABLE ingest_payments ATTACH PARTITION %s FOR VALUES FROM (%d) TO (%d)`, partition.Name, from, to),
+		}
+		for _, statement := range statements {
+			if _, err := dbTx.ExecContext(ctx, statement); err != nil {
+				return false, fmt.Errorf("creating partition %s: %w", partition.Name, err)
+			}
+		}
+		return true, nil
+	})
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("CREATE", "ingest_payments", duration)
+	if err != nil {
+		return false, fmt.Errorf("creating payment partition %s: %w", partition.Name, err)
+	}
+	m.MetricsService.IncDBQuery("CREATE", "ingest_payments")
+	return created, nil
+}
+
+// DetachPaymentPartition detaches the partition, so its payments are no longer returned, and renames it with a
+// _detached_<unix time> suffix so the partition can be created again if its ledgers are backfilled. The detached table
+// is kept to be archived or dropped.
+func (m *PaymentModel) DetachPaymentPartition(ctx context.Context, partition PaymentPartition) error {
+	start := time.Now()
+	err := db.RunInTransaction(ctx, m.DB, nil, func(dbTx db.Transaction) error {
+		if _, err := dbTx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('ingest_payments_partitions'))`); err != nil {
+			return fmt.Errorf("locking payment partitions: %w", err)
+		}
+		if _, err := dbTx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE ingest_payments DETACH PARTITION %s`, partition.Name)); err != nil {
+			return fmt.Errorf("detaching partition: %w", err)
+		}
+		if _, err := dbTx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s RENAME TO %s_detached_%d`, partition.Name, partition.Name, time.Now().Unix())); err != nil {
+			return fmt.Errorf("renaming detached partition: %w", err)
+		}
+		return nil
+	})
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("DETACH", "ingest_payments", duration)
+	if err != nil {
+		return fmt.Errorf("detaching payment partition %s: %w", partition.Name, err)
+	}
+	m.MetricsService.IncDBQuery("DETACH", "ingest_payments")
+	return nil
+}
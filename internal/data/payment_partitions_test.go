@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestPaymentPartitionFor(t *testing.T) {
+	testCases := []struct {
+		ledger uint32
+		want   PaymentPartition
+	}{
+		{ledger: 0, want: PaymentPartition{Name: "ingest_payments_ledger_0", StartLedger: 0, EndLedger: 500_000}},
+		{ledger: 499_999, want: PaymentPartition{Name: "ingest_payments_ledger_0", StartLedger: 0, EndLedger: 500_000}},
+		{ledger: 500_000, want: PaymentPartition{Name: "ingest_payments_ledger_500000", StartLedger: 500_000, EndLedger: 1_000_000}},
+		{ledger: 52_345_678, want: PaymentPartition{Name: "ingest_payments_ledger_52000000", StartLedger: 52_000_000, EndLedger: 52_500_000}},
+	}
+	for _, tc := range testCases {
+		t.Run(strconv.FormatUint(uint64(tc.ledger), 10), func(t *testing.T) {
+			assert.Equal(t, tc.want, PaymentPartitionFor(tc.ledger))
+		})
+	}
+}
+
+func TestPaymentModelPaymentPartitions(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything).Return()
+
+	ctx := context.Background()
+	m := &PaymentModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	// a payment of ledger 1,000,001, ingested before its partition exists
+	operationID := strconv.FormatInt(int64(1_000_001)<<32|1, 10)
+	InsertTestPayments(t, ctx, []Payment{{
+		OperationID:   operationID,
+		OperationType: xdr.OperationTypePayment.String(),
+		TransactionID: operationID,
+		FromAddress:   keypair.MustRandom().Address(),
+		ToAddress:     keypair.MustRandom().Address(),
+		SrcAssetType:  xdr.AssetTypeAssetTypeNative.String(),
+		DestAssetType: xdr.AssetTypeAssetTypeNative.String(),
+		CreatedAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		MemoType:      xdr.MemoTypeMemoNone.String(),
+		Successful:    true,
+	}}, dbConnectionPool)
+
+	countIn := func(table string) int {
+		var count int
+		err := dbConnectionPool.GetContext(ctx, &count, "SELECT COUNT(*) FROM "+table)
+		require.NoError(t, err)
+		return count
+	}
+	require.Equal(t, 1, countIn("ingest_payments_default"))
+
+	partitions, err := m.GetPaymentPartitions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, partitions)
+
+	partition := PaymentPartitionFor(1_000_001)
+	created, err := m.CreatePaymentPartition(ctx, partition)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, 0, countIn("ingest_payments_default"))
+	assert.Equal(t, 1, countIn(partition.Name))
+	assert.Equal(t, 1, countIn("ingest_payments"))
+
+	created, err = m.CreatePaymentPartition(ctx, partition)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	_, err = m.CreatePaymentPartition(ctx, PaymentPartitionFor(0))
+	require.NoError(t, err)
+	partitions, err = m.GetPaymentPartitions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []PaymentPartition{PaymentPartitionFor(0), partition}, partitions)
+
+	err = m.DetachPaymentPartition(ctx, partition)
+	require.NoError(t, err)
+	assert.Equal(t, 0, countIn("ingest_payments"))
+	partitions, err = m.GetPaymentPartitions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []PaymentPartition{PaymentPartitionFor(0)}, partitions)
+
+	// the detached partition was renamed, so it can be created again
+	created, err = m.CreatePaymentPartition(ctx, partition)
+	require.NoError(t, err)
+	assert.True(t, created)
+}
@@ -0,0 +1,186 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+// PaymentVolumeModel maintains the payment_volumes rollup of the successful payments per account, UTC day and asset,
+// so the payment aggregates don't scan ingest_payments. The rollup outlives the payments pruned by the retention.
+type PaymentVolumeModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+// PaymentAggregateInterval is the length of the time buckets payments are aggregated in.
+type PaymentAggregateInterval string
+
+const (
+	PaymentAggregateDay   PaymentAggregateInterval = "day"
+	PaymentAggregateWeek  PaymentAggregateInterval = "week"
+	PaymentAggregateMonth PaymentAggregateInterval = "month"
+)
+
+func (i PaymentAggregateInterval) IsValid() bool {
+	return i == PaymentAggregateDay || i == PaymentAggregateWeek || i == PaymentAggregateMonth
+}
+
+// PaymentAggregatesFilter restricts the payments aggregated by GetAggregates.
+type PaymentAggregatesFilter struct {
+	// Address aggregates the payments of the account, or of every registered account when it's empty.
+	Address  string
+	Interval PaymentAggregateInterval
+	// AssetCode aggregates the payments of the asset code only, or of the asset when AssetIssuer is set too.
+	AssetCode   string
+	AssetIssuer string
+	// From and To bound the UTC days aggregated to [From, To), they're truncated to their day.
+	From *time.Time
+	To   *time.Time
+}
+
+// PaymentAggregate is the volume of an asset sent and received within a time bucket. Amounts are in stroops, as
+// strings since their sums may not fit in an int64.
+type PaymentAggregate struct {
+	Bucket         time.Time `db:"bucket" json:"bucket"`
+	AssetCode      string    `db:"asset_code" json:"assetCode"`
+	AssetIssuer    string    `db:"asset_issuer" json:"assetIssuer"`
+	SentAmount     string    `db:"sent_amount" json:"sentAmount"`
+	SentCount      int64     `db:"sent_count" json:"sentCount"`
+	ReceivedAmount string    `db:"received_amount" json:"receivedAmount"`
+	ReceivedCount  int64     `db:"received_count" json:"receivedCount"`
+}
+
+// paymentVolumesPrunedBeforeKey is the ingest_store key of the creation time the rolled up payment keys were pruned
+// before, the payments created before it may have been rolled up already.
+const paymentVolumesPrunedBeforeKey = "payment_volume_payments_pruned_before"
+
+// AddLedger rolls the successful payments stored for the ledger up into the volumes. Only the payments that were never
+// rolled up are counted, so reingesting a ledger, even after its payments were pruned, doesn't count them twice. The
+// payments created before the keys pruned by PruneRolledUpPayments are never rolled up again.
+func (m *PaymentVolumeModel) AddLedger(ctx context.Context, tx db.Transaction, ledger uint32) error {
+	// concurrent ingestions of the same ledger are serialized, the second one finding its payments rolled up already
+	const lockQuery = `SELECT pg_advisory_xact_lock(hashtext('payment_volumes'), $1)`
+	// self payments have both sides in the same volume, so the sides are grouped before they're upserted
+	const query = `
+		WITH rolled_up AS (
+			INSERT INTO payment_volume_payments (operation_id, event_index, created_at)
+			SELECT operation_id, event_index, created_at FROM ingest_payments
+			WHERE operation_id >= $1 AND operation_id < $2 AND successful
+				AND created_at >= COALESCE((SELECT value::timestamptz FROM ingest_store WHERE key = $3), '-infinity')
+			ON CONFLICT (operation_id, event_index) DO NOTHING
+			RETURNING operation_id, event_index
+		), ledger_payments AS (
+			SELECT p.* FROM ingest_payments p JOIN rolled_up USING (operation_id, event_index)
+			WHERE p.operation_id >= $1 AND p.operation_id < $2
+		), sides AS (
+			SELECT from_address AS account_address, (created_at AT TIME ZONE 'UTC')::date AS day, src_asset_code AS asset_code,
+				src_asset_issuer AS asset_issuer, src_amount AS sent_amount, 1 AS sent_count, 0 AS received_amount, 0 AS received_count
+			FROM ledger_payments
+			UNION ALL
+			SELECT to_address, (created_at AT TIME ZONE 'UTC')::date, dest_asset_code, dest_asset_issuer, 0, 0, dest_amount, 1
+			FROM ledger_payments
+		)
+		INSERT INTO payment_volumes (account_address, day, asset_code, asset_issuer, sent_amount, sent_count, received_amount, received_count)
+		SELECT account_address, day, asset_code, asset_issuer, SUM(sent_amount), SUM(sent_count), SUM(received_amount), SUM(received_count)
+		FROM sides
+		GROUP BY account_address, day, asset_code, asset_issuer
+		ON CONFLICT (account_address, day, asset_code, asset_issuer) DO UPDATE SET
+			sent_amount = payment_volumes.sent_amount + EXCLUDED.sent_amount,
+			sent_count = payment_volumes.sent_count + EXCLUDED.sent_count,
+			received_amount = payment_volumes.received_amount + EXCLUDED.received_amount,
+			received_count = payment_volumes.received_count + EXCLUDED.received_count
+	`
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, lockQuery, int32(ledger))
+	if err == nil {
+		_, err = tx.ExecContext(ctx, query, int64(ledger)<<32, (int64(ledger)+1)<<32, paymentVolumesPrunedBeforeKey)
+	}
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "payment_volumes", duration)
+	if err != nil {
+		return fmt.Errorf("rolling up the payment volumes of ledger %d: %w", ledger, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "payment_volumes")
+	return nil
+}
+
+// PruneRolledUpPayments deletes up to limit keys of the rolled up payments created before the given time, and moves the
+// time the payments are rolled up from past it.
+func (m *PaymentVolumeModel) PruneRolledUpPayments(ctx context.Context, before time.Time, limit int) (int64, error) {
+	const query = `
+		WITH pruned AS (
+			DELETE FROM payment_volume_payments
+			WHERE (operation_id, event_index) IN (
+				SELECT operation_id, event_index FROM payment_volume_payments WHERE created_at < $1 LIMIT $2
+			)
+			RETURNING 1
+		), pruned_before AS (
+			INSERT INTO ingest_store (key, value) VALUES ($3, $1::timestamptz::text)
+			ON CONFLICT (key) DO UPDATE SET value = GREATEST(ingest_store.value::timestamptz, EXCLUDED.value::timestamptz)::text
+		)
+		SELECT COUNT(*) FROM pruned
+	`
+	var deleted int64
+	start := time.Now()
+	err := m.DB.GetContext(ctx, &deleted, query, before, limit, paymentVolumesPrunedBeforeKey)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("DELETE", "payment_volume_payments", duration)
+	if err != nil {
+		return 0, fmt.Errorf("pruning rolled up payments created before %s: %w", before, err)
+	}
+	m.MetricsService.IncDBQuery("DELETE", "payment_volume_payments")
+	return deleted, nil
+}
+
+// GetAggregates returns the volumes matching the filter per time bucket and asset, the latest buckets first.
+func (m *PaymentVolumeModel) GetAggregates(ctx context.Context, filter PaymentAggregatesFilter) ([]PaymentAggregate, error) {
+	if !filter.Interval.IsValid() {
+		return nil, fmt.Errorf("invalid interval %q", filter.Interval)
+	}
+
+	const query = `
+		SELECT
+			date_trunc($1, day::timestamp) AS bucket,
+			asset_code,
+			asset_issuer,
+			SUM(sent_amount)::text AS sent_amount,
+			SUM(sent_count)::bigint AS sent_count,
+			SUM(received_amount)::text AS received_amount,
+			SUM(received_count)::bigint AS received_count
+		FROM payment_volumes
+		WHERE (account_address = $2::text OR ($2::text = '' AND account_address IN (SELECT stellar_address FROM accounts)))
+			AND ($3::text = '' OR asset_code = $3::text)
+			AND ($4::text = '' OR asset_issuer = $4::text)
+			AND ($5::date IS NULL OR day >= $5::date)
+			AND ($6::date IS NULL OR day < $6::date)
+		GROUP BY bucket, asset_code, asset_issuer
+		ORDER BY bucket DESC, asset_code, asset_issuer
+	`
+	aggregates := make([]PaymentAggregate, 0)
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &aggregates, query, filter.Interval, filter.Address, filter.AssetCode, filter.AssetIssuer,
+		utcDay(filter.From), utcDay(filter.To))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "payment_volumes", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting payment aggregates: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "payment_volumes")
+	for i := range aggregates {
+		aggregates[i].Bucket = aggregates[i].Bucket.UTC()
+	}
+	return aggregates, nil
+}
+
+// utcDay formats the UTC day of t as a date, or returns nil when t is nil.
+func utcDay(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	day := t.UTC().Format(time.DateOnly)
+	return &day
+}
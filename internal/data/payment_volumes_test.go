@@ -0,0 +1,147 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+func TestPaymentVolumeModel(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64")).Return()
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything).Return()
+	m := &PaymentVolumeModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	account := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	unregistered := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1), ($2)`, account, otherAccount)
+	require.NoError(t, err)
+
+	newPayment := func(ledger, op int32, from, to string, assetCode string, amount int64, createdAt time.Time, successful bool) Payment {
+		return Payment{
+			OperationID:   utils.OperationID(ledger, 1, op),
+			OperationType: xdr.OperationTypePayment.String(),
+			TransactionID: utils.TransactionID(ledger, 1),
+			FromAddress:   from,
+			ToAddress:     to,
+			SrcAssetCode:  assetCode,
+			SrcAmount:     amount,
+			DestAssetCode: assetCode,
+			DestAmount:    amount,
+			CreatedAt:     createdAt,
+			MemoType:      xdr.MemoTypeMemoNone.String(),
+			Successful:    successful,
+		}
+	}
+	// 2024-06-03 is a Monday
+	june := func(day, hour int) time.Time { return time.Date(2024, 6, day, hour, 0, 0, 0, time.UTC) }
+	ledger10Payments := []Payment{
+		newPayment(10, 1, account, unregistered, "XLM", 100, june(3, 10), true),
+		newPayment(10, 2, unregistered, account, "XLM", 40, june(3, 11), true),
+		newPayment(10, 3, account, unregistered, "XLM", 1000, june(3, 12), false),
+	}
+	InsertTestPayments(t, ctx, append([]Payment{
+		newPayment(20, 1, account, otherAccount, "USDC", 7, june(4, 23), true),
+		newPayment(30, 1, otherAccount, account, "XLM", 5, june(10, 0), true),
+	}, ledger10Payments...), dbConnectionPool)
+
+	applyLedgers := func(apply func(ctx context.Context, tx db.Transaction, ledger uint32) error, ledgers ...uint32) {
+		err := db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+			for _, ledger := range ledgers {
+				if err := apply(ctx, dbTx, ledger); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	applyLedgers(m.AddLedger, 10, 20, 30)
+	// reingesting a ledger doesn't count its payments twice
+	applyLedgers(m.AddLedger, 10)
+
+	accountDailyVolumes := []PaymentAggregate{
+		{Bucket: june(10, 0), AssetCode: "XLM", SentAmount: "0", ReceivedAmount: "5", ReceivedCount: 1},
+		{Bucket: june(4, 0), AssetCode: "USDC", SentAmount: "7", SentCount: 1, ReceivedAmount: "0"},
+		{Bucket: june(3, 0), AssetCode: "XLM", SentAmount: "100", SentCount: 1, ReceivedAmount: "40", ReceivedCount: 1},
+	}
+
+	t.Run("🟢daily_volumes_of_an_account", func(t *testing.T) {
+		aggregates, err := m.GetAggregates(ctx, PaymentAggregatesFilter{Address: account, Interval: PaymentAggregateDay})
+		require.NoError(t, err)
+		assert.Equal(t, accountDailyVolumes, aggregates)
+	})
+
+	t.Run("🟢weekly_volumes_of_the_registered_accounts", func(t *testing.T) {
+		aggregates, err := m.GetAggregates(ctx, PaymentAggregatesFilter{Interval: PaymentAggregateWeek, AssetCode: "XLM"})
+		require.NoError(t, err)
+		assert.Equal(t, []PaymentAggregate{
+			{Bucket: june(10, 0), AssetCode: "XLM", SentAmount: "5", SentCount: 1, ReceivedAmount: "5", ReceivedCount: 1},
+			{Bucket: june(3, 0), AssetCode: "XLM", SentAmount: "100", SentCount: 1, ReceivedAmount: "40", ReceivedCount: 1},
+		}, aggregates)
+	})
+
+	t.Run("🟢monthly_volumes_within_a_range", func(t *testing.T) {
+		from, to := june(4, 12), june(10, 0)
+		aggregates, err := m.GetAggregates(ctx, PaymentAggregatesFilter{Interval: PaymentAggregateMonth, From: &from, To: &to})
+		require.NoError(t, err)
+		assert.Equal(t, []PaymentAggregate{
+			{Bucket: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), AssetCode: "USDC", SentAmount: "7", SentCount: 1, ReceivedAmount: "7", ReceivedCount: 1},
+		}, aggregates)
+	})
+
+	t.Run("🟢reingesting_a_pruned_ledger_keeps_the_volumes", func(t *testing.T) {
+		_, err := dbConnectionPool.ExecContext(ctx, `DELETE FROM ingest_payments WHERE operation_id >= $1 AND operation_id < $2`, int64(10)<<32, int64(11)<<32)
+		require.NoError(t, err)
+		InsertTestPayments(t, ctx, ledger10Payments, dbConnectionPool)
+		applyLedgers(m.AddLedger, 10)
+
+		aggregates, err := m.GetAggregates(ctx, PaymentAggregatesFilter{Address: account, Interval: PaymentAggregateDay})
+		require.NoError(t, err)
+		assert.Equal(t, accountDailyVolumes, aggregates)
+	})
+
+	t.Run("🟢payments_created_before_the_pruned_keys_are_not_rolled_up_again", func(t *testing.T) {
+		deleted, err := m.PruneRolledUpPayments(ctx, june(4, 0), 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		deleted, err = m.PruneRolledUpPayments(ctx, june(1, 0), 10)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+
+		// an earlier prune doesn't move the time back, so ledger 10 is still not rolled up again
+		applyLedgers(m.AddLedger, 10)
+
+		aggregates, err := m.GetAggregates(ctx, PaymentAggregatesFilter{Address: account, Interval: PaymentAggregateDay})
+		require.NoError(t, err)
+		assert.Equal(t, accountDailyVolumes, aggregates)
+	})
+
+	t.Run("🔴invalid_interval", func(t *testing.T) {
+		_, err := m.GetAggregates(ctx, PaymentAggregatesFilter{Interval: "year"})
+		assert.ErrorContains(t, err, `invalid interval "year"`)
+	})
+}
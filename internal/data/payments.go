@@ -5,24 +5,42 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/stellar/wallet-backend/internal/db"
 	"github.com/stellar/wallet-backend/internal/metrics"
 )
 
+// ErrNotLockHolder is returned when the cursor is updated by a session that doesn't hold the ingestion lock.
+var ErrNotLockHolder = errors.New("not the ingestion lock holder")
+
 type PaymentModel struct {
 	DB             db.ConnectionPool
 	MetricsService metrics.MetricsService
 }
 
 type Payment struct {
-	OperationID     string    `db:"operation_id" json:"operationId"`
-	OperationType   string    `db:"operation_type" json:"operationType"`
-	TransactionID   string    `db:"transaction_id" json:"transactionId"`
-	TransactionHash string    `db:"transaction_hash" json:"transactionHash"`
-	FromAddress     string    `db:"from_address" json:"fromAddress"`
-	ToAddress       string    `db:"to_address" json:"toAddress"`
+	OperationID string `db:"operation_id" json:"operationId"`
+	// EventIndex tells apart the token transfers emitted by the same operation, it's 0 for the other payments.
+	EventIndex      int32  `db:"event_index" json:"eventIndex"`
+	OperationType   string `db:"operation_type" json:"operationType"`
+	TransactionID   string `db:"transaction_id" json:"transactionId"`
+	TransactionHash string `db:"transaction_hash" json:"transactionHash"`
+	FromAddress     string `db:"from_address" json:"fromAddress"`
+	ToAddress       string `db:"to_address" json:"toAddress"`
+	// FromMuxedID and FromMuxedAddress are set when the payment is sent from a muxed account, FromAddress is its
+	// G-address. IDs are strings since they may not fit in a JSON number.
+	FromMuxedID      *string `db:"from_muxed_id" json:"fromMuxedId,omitempty"`
+	FromMuxedAddress *string `db:"from_muxed_address" json:"fromMuxedAddress,omitempty"`
+	// ToMuxedID and ToMuxedAddress are set when the payment is sent to a muxed account, ToAddress is its G-address.
+	ToMuxedID       *string   `db:"to_muxed_id" json:"toMuxedId,omitempty"`
+	ToMuxedAddress  *string   `db:"to_muxed_address" json:"toMuxedAddress,omitempty"`
 	SrcAssetCode    string    `db:"src_asset_code" json:"srcAssetCode"`
 	SrcAssetIssuer  string    `db:"src_asset_issuer" json:"srcAssetIssuer"`
 	SrcAssetType    string    `db:"src_asset_type" json:"srcAssetType"`
@@ -34,6 +52,17 @@ type Payment struct {
 	CreatedAt       time.Time `db:"created_at" json:"createdAt"`
 	Memo            *string   `db:"memo" json:"memo"`
 	MemoType        string    `db:"memo_type" json:"memoType"`
+	// ContractEventType is the SEP-41 event (transfer, mint, burn or clawback) a Soroban token payment was built from.
+	ContractEventType *string `db:"contract_event_type" json:"contractEventType"`
+	// Successful is false for the payments of failed transactions, which keep the codes the transaction and operation
+	// failed with. OperationResultCode is nil when the transaction failed before its operations were applied.
+	Successful            bool    `db:"successful" json:"successful"`
+	TransactionResultCode *string `db:"transaction_result_code" json:"transactionResultCode,omitempty"`
+	OperationResultCode   *string `db:"operation_result_code" json:"operationResultCode,omitempty"`
+	// FailureReason describes the result codes of a failed payment, it's not stored.
+	FailureReason *string `db:"-" json:"failureReason,omitempty"`
+	// Internal is set on the payments between two of the addresses queried together, it's not stored.
+	Internal bool `db:"-" json:"internal,omitempty"`
 }
 
 func (m *PaymentModel) GetLatestLedgerSynced(ctx context.Context, cursorName string) (uint32, error) {
@@ -54,13 +83,13 @@ func (m *PaymentModel) GetLatestLedgerSynced(ctx context.Context, cursorName str
 	return lastSyncedLedger, nil
 }
 
-func (m *PaymentModel) UpdateLatestLedgerSynced(ctx context.Context, cursorName string, ledger uint32) error {
+func (m *PaymentModel) UpdateLatestLedgerSynced(ctx context.Context, sqlExec db.SQLExecuter, cursorName string, ledger uint32) error {
 	const query = `
 		INSERT INTO ingest_store (key, value) VALUES ($1, $2)
 		ON CONFLICT (key) DO UPDATE SET value = excluded.value
 	`
 	start := time.Now()
-	_, err := m.DB.ExecContext(ctx, query, cursorName, ledger)
+	_, err := sqlExec.ExecContext(ctx, query, cursorName, ledger)
 	duration := time.Since(start).Seconds()
 	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_store", duration)
 	if err != nil {
@@ -71,17 +100,67 @@ func (m *PaymentModel) UpdateLatestLedgerSynced(ctx context.Context, cursorName
 	return nil
 }
 
+// DeleteLatestLedgersSynced deletes the given cursors, the ones that don't exist are ignored.
+func (m *PaymentModel) DeleteLatestLedgersSynced(ctx context.Context, cursorNames []string) error {
+	start := time.Now()
+	_, err := m.DB.ExecContext(ctx, `DELETE FROM ingest_store WHERE key = ANY($1)`, pq.Array(cursorNames))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("DELETE", "ingest_store", duration)
+	if err != nil {
+		return fmt.Errorf("deleting cursors %v: %w", cursorNames, err)
+	}
+	m.MetricsService.IncDBQuery("DELETE", "ingest_store")
+
+	return nil
+}
+
+// UpdateLatestLedgerSyncedAsLockHolder updates the cursor only while the database session with the given PID holds the
+// advisory lock, so a leader that lost its lock can never move the cursor of the replica that took over. It returns
+// ErrNotLockHolder otherwise.
+func (m *PaymentModel) UpdateLatestLedgerSyncedAsLockHolder(ctx context.Context, sqlExec db.SQLExecuter, cursorName string, ledger uint32, lockKey int64, lockHolderPID int) error {
+	// bigint advisory locks are split in two 32 bits halves in pg_locks
+	const query = `
+		INSERT INTO ingest_store (key, value)
+		SELECT $1, $2
+		WHERE EXISTS (
+			SELECT 1 FROM pg_locks
+			WHERE locktype = 'advisory' AND granted AND objsubid = 1 AND pid = $4
+				AND classid::bigint = ($3::bigint >> 32) & 4294967295
+				AND objid::bigint = $3::bigint & 4294967295
+		)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value
+	`
+	start := time.Now()
+	result, err := sqlExec.ExecContext(ctx, query, cursorName, ledger, lockKey, lockHolderPID)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_store", duration)
+	if err != nil {
+		return fmt.Errorf("updating last synced ledger to %d: %w", ledger, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "ingest_store")
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("updating last synced ledger to %d: %w", ledger, ErrNotLockHolder)
+	}
+	return nil
+}
+
 func (m *PaymentModel) AddPayment(ctx context.Context, tx db.Transaction, payment Payment) error {
 	const query = `
 		INSERT INTO ingest_payments (
 			operation_id, operation_type, transaction_id, transaction_hash, from_address, to_address, src_asset_code, src_asset_issuer, src_asset_type, src_amount, 
-			dest_asset_code, dest_asset_issuer, dest_asset_type, dest_amount, created_at, memo, memo_type
+			dest_asset_code, dest_asset_issuer, dest_asset_type, dest_amount, created_at, memo, memo_type, contract_event_type,
+			from_muxed_id, from_muxed_address, to_muxed_id, to_muxed_address, successful, transaction_result_code, operation_result_code, event_index
 		)
-		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19::numeric, $20, $21::numeric, $22, $23, $24, $25, $26
 		WHERE EXISTS (
 			SELECT 1 FROM accounts WHERE stellar_address IN ($5, $6)
 		)
-		ON CONFLICT (operation_id) DO UPDATE SET
+		ON CONFLICT (operation_id, event_index) DO UPDATE SET
 			operation_type = EXCLUDED.operation_type,
 			transaction_id = EXCLUDED.transaction_id,
 			transaction_hash = EXCLUDED.transaction_hash,
@@ -97,12 +176,21 @@ func (m *PaymentModel) AddPayment(ctx context.Context, tx db.Transaction, paymen
 			dest_amount = EXCLUDED.dest_amount,
 			created_at = EXCLUDED.created_at,
 			memo = EXCLUDED.memo,
-			memo_type = EXCLUDED.memo_type
+			memo_type = EXCLUDED.memo_type,
+			contract_event_type = EXCLUDED.contract_event_type,
+			from_muxed_id = EXCLUDED.from_muxed_id,
+			from_muxed_address = EXCLUDED.from_muxed_address,
+			to_muxed_id = EXCLUDED.to_muxed_id,
+			to_muxed_address = EXCLUDED.to_muxed_address,
+			successful = EXCLUDED.successful,
+			transaction_result_code = EXCLUDED.transaction_result_code,
+			operation_result_code = EXCLUDED.operation_result_code
 		;
 	`
 	start := time.Now()
 	_, err := tx.ExecContext(ctx, query, payment.OperationID, payment.OperationType, payment.TransactionID, payment.TransactionHash, payment.FromAddress, payment.ToAddress, payment.SrcAssetCode, payment.SrcAssetIssuer, payment.SrcAssetType, payment.SrcAmount,
-		payment.DestAssetCode, payment.DestAssetIssuer, payment.DestAssetType, payment.DestAmount, payment.CreatedAt, payment.Memo, payment.MemoType)
+		payment.DestAssetCode, payment.DestAssetIssuer, payment.DestAssetType, payment.DestAmount, payment.CreatedAt, payment.Memo, payment.MemoType, payment.ContractEventType,
+		payment.FromMuxedID, payment.FromMuxedAddress, payment.ToMuxedID, payment.ToMuxedAddress, payment.Successful, payment.TransactionResultCode, payment.OperationResultCode, payment.EventIndex)
 	duration := time.Since(start).Seconds()
 	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_payments", duration)
 	if err != nil {
@@ -112,7 +200,345 @@ func (m *PaymentModel) AddPayment(ctx context.Context, tx db.Transaction, paymen
 	return nil
 }
 
-func (m *PaymentModel) GetPaymentsPaginated(ctx context.Context, address string, beforeID, afterID string, sort SortOrder, limit int) ([]Payment, bool, bool, error) {
+// BatchAddPayments upserts all the given payments in a single round trip, which is considerably cheaper than calling
+// AddPayment once per operation on busy ledgers. Like AddPayment, only payments involving at least one registered
+// account are stored.
+func (m *PaymentModel) BatchAddPayments(ctx context.Context, tx db.Transaction, payments []Payment) error {
+	if len(payments) == 0 {
+		return nil
+	}
+
+	const query = `
+		INSERT INTO ingest_payments (
+			operation_id, operation_type, transaction_id, transaction_hash, from_address, to_address, src_asset_code, src_asset_issuer, src_asset_type, src_amount,
+			dest_asset_code, dest_asset_issuer, dest_asset_type, dest_amount, created_at, memo, memo_type, contract_event_type,
+			from_muxed_id, from_muxed_address, to_muxed_id, to_muxed_address, successful, transaction_result_code, operation_result_code, event_index
+		)
+		SELECT
+			p.operation_id, p.operation_type, p.transaction_id, p.transaction_hash, p.from_address, p.to_address, p.src_asset_code, p.src_asset_issuer, p.src_asset_type, p.src_amount,
+			p.dest_asset_code, p.dest_asset_issuer, p.dest_asset_type, p.dest_amount, p.created_at, p.memo, p.memo_type, p.contract_event_type,
+			p.from_muxed_id, p.from_muxed_address, p.to_muxed_id, p.to_muxed_address, p.successful, p.transaction_result_code, p.operation_result_code, p.event_index
+		FROM UNNEST(
+			$1::bigint[], $2::text[], $3::bigint[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[], $9::text[], $10::bigint[],
+			$11::text[], $12::text[], $13::text[], $14::bigint[], $15::timestamptz[], $16::text[], $17::text[], $18::text[],
+			$19::numeric[], $20::text[], $21::numeric[], $22::text[], $23::boolean[], $24::text[], $25::text[], $26::integer[]
+		) AS p(
+			operation_id, operation_type, transaction_id, transaction_hash, from_address, to_address, src_asset_code, src_asset_issuer, src_asset_type, src_amount,
+			dest_asset_code, dest_asset_issuer, dest_asset_type, dest_amount, created_at, memo, memo_type, contract_event_type,
+			from_muxed_id, from_muxed_address, to_muxed_id, to_muxed_address, successful, transaction_result_code, operation_result_code, event_index
+		)
+		WHERE EXISTS (
+			SELECT 1 FROM accounts WHERE stellar_address IN (p.from_address, p.to_address)
+		)
+		ON CONFLICT (operation_id, event_index) DO UPDATE SET
+			operation_type = EXCLUDED.operation_type,
+			transaction_id = EXCLUDED.transaction_id,
+			transaction_hash = EXCLUDED.transaction_hash,
+			from_address = EXCLUDED.from_address,
+			to_address = EXCLUDED.to_address,
+			src_asset_code = EXCLUDED.src_asset_code,
+			src_asset_issuer = EXCLUDED.src_asset_issuer,
+			src_asset_type = EXCLUDED.src_asset_type,
+			src_amount = EXCLUDED.src_amount,
+			dest_asset_code = EXCLUDED.dest_asset_code,
+			dest_asset_issuer = EXCLUDED.dest_asset_issuer,
+			dest_asset_type = EXCLUDED.dest_asset_type,
+			dest_amount = EXCLUDED.dest_amount,
+			created_at = EXCLUDED.created_at,
+			memo = EXCLUDED.memo,
+			memo_type = EXCLUDED.memo_type,
+			contract_event_type = EXCLUDED.contract_event_type,
+			from_muxed_id = EXCLUDED.from_muxed_id,
+			from_muxed_address = EXCLUDED.from_muxed_address,
+			to_muxed_id = EXCLUDED.to_muxed_id,
+			to_muxed_address = EXCLUDED.to_muxed_address,
+			successful = EXCLUDED.successful,
+			transaction_result_code = EXCLUDED.transaction_result_code,
+			operation_result_code = EXCLUDED.operation_result_code
+		;
+	`
+
+	n := len(payments)
+	var (
+		operationIDs      = make([]string, n)
+		operationTypes    = make([]string, n)
+		transactionIDs    = make([]string, n)
+		transactionHashes = make([]string, n)
+		fromAddresses     = make([]string, n)
+		toAddresses       = make([]string, n)
+		srcAssetCodes     = make([]string, n)
+		srcAssetIssuers   = make([]string, n)
+		srcAssetTypes     = make([]string, n)
+		srcAmounts        = make([]int64, n)
+		destAssetCodes    = make([]string, n)
+		destAssetIssuers  = make([]string, n)
+		destAssetTypes    = make([]string, n)
+		destAmounts       = make([]int64, n)
+		createdAts        = make([]string, n)
+		memos             = make([]*string, n)
+		memoTypes         = make([]string, n)
+		eventTypes        = make([]*string, n)
+		fromMuxedIDs      = make([]*string, n)
+		fromMuxedAddrs    = make([]*string, n)
+		toMuxedIDs        = make([]*string, n)
+		toMuxedAddrs      = make([]*string, n)
+		successfuls       = make([]bool, n)
+		txResultCodes     = make([]*string, n)
+		opResultCodes     = make([]*string, n)
+		eventIndexes      = make([]int64, n)
+	)
+	for i, payment := range payments {
+		operationIDs[i] = payment.OperationID
+		operationTypes[i] = payment.OperationType
+		transactionIDs[i] = payment.TransactionID
+		transactionHashes[i] = payment.TransactionHash
+		fromAddresses[i] = payment.FromAddress
+		toAddresses[i] = payment.ToAddress
+		srcAssetCodes[i] = payment.SrcAssetCode
+		srcAssetIssuers[i] = payment.SrcAssetIssuer
+		srcAssetTypes[i] = payment.SrcAssetType
+		srcAmounts[i] = payment.SrcAmount
+		destAssetCodes[i] = payment.DestAssetCode
+		destAssetIssuers[i] = payment.DestAssetIssuer
+		destAssetTypes[i] = payment.DestAssetType
+		destAmounts[i] = payment.DestAmount
+		// timestamps are sent as text so the array literal is unambiguous, Postgres casts them back with ::timestamptz[]
+		createdAts[i] = payment.CreatedAt.Format(time.RFC3339Nano)
+		memos[i] = payment.Memo
+		memoTypes[i] = payment.MemoType
+		eventTypes[i] = payment.ContractEventType
+		fromMuxedIDs[i] = payment.FromMuxedID
+		fromMuxedAddrs[i] = payment.FromMuxedAddress
+		toMuxedIDs[i] = payment.ToMuxedID
+		toMuxedAddrs[i] = payment.ToMuxedAddress
+		successfuls[i] = payment.Successful
+		txResultCodes[i] = payment.TransactionResultCode
+		opResultCodes[i] = payment.OperationResultCode
+		eventIndexes[i] = int64(payment.EventIndex)
+	}
+
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, query,
+		pq.Array(operationIDs), pq.Array(operationTypes), pq.Array(transactionIDs), pq.Array(transactionHashes), pq.Array(fromAddresses),
+		pq.Array(toAddresses), pq.Array(srcAssetCodes), pq.Array(srcAssetIssuers), pq.Array(srcAssetTypes), pq.Array(srcAmounts),
+		pq.Array(destAssetCodes), pq.Array(destAssetIssuers), pq.Array(destAssetTypes), pq.Array(destAmounts), pq.Array(createdAts),
+		pq.Array(memos), pq.Array(memoTypes), pq.Array(eventTypes), pq.Array(fromMuxedIDs), pq.Array(fromMuxedAddrs),
+		pq.Array(toMuxedIDs), pq.Array(toMuxedAddrs), pq.Array(successfuls), pq.Array(txResultCodes), pq.Array(opResultCodes),
+		pq.Array(eventIndexes))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_payments", duration)
+	if err != nil {
+		return fmt.Errorf("batch inserting %d payments: %w", n, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "ingest_payments")
+	return nil
+}
+
+// GetLedgerPayments returns the payments of the ledger ordered by ID.
+func (m *PaymentModel) GetLedgerPayments(ctx context.Context, sqlExec db.SQLExecuter, ledger uint32) ([]Payment, error) {
+	const query = `SELECT * FROM ingest_payments WHERE operation_id >= $1 AND operation_id < $2 ORDER BY operation_id, event_index`
+	var payments []Payment
+	start := time.Now()
+	err := sqlExec.SelectContext(ctx, &payments, query, int64(ledger)<<32, (int64(ledger)+1)<<32)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_payments", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting the payments of ledger %d: %w", ledger, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_payments")
+	for i := range payments {
+		payments[i].FailureReason = payments[i].failureReason()
+	}
+	return payments, nil
+}
+
+// GetTransactionPayments returns the payments of the transaction with the hash, ordered by ID.
+func (m *PaymentModel) GetTransactionPayments(ctx context.Context, hash string) ([]Payment, error) {
+	payments := make([]Payment, 0)
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &payments, `SELECT * FROM ingest_payments WHERE transaction_hash = $1 ORDER BY operation_id, event_index`, hash)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_payments", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting the payments of transaction %s: %w", hash, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_payments")
+	for i := range payments {
+		payments[i].FailureReason = payments[i].failureReason()
+	}
+	return payments, nil
+}
+
+// PaymentsNotificationChannel is the Postgres channel notified with the ledger of the payments ingested, so the
+// servers can push them to the payment streams.
+const PaymentsNotificationChannel = "ingest_payments"
+
+// NotifyPayments notifies the listeners of PaymentsNotificationChannel that the payments of the ledger were ingested.
+// Postgres only delivers the notification once the transaction commits.
+func (m *PaymentModel) NotifyPayments(ctx context.Context, tx db.Transaction, ledger uint32) error {
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, PaymentsNotificationChannel, strconv.FormatUint(uint64(ledger), 10))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("NOTIFY", "ingest_payments", duration)
+	if err != nil {
+		return fmt.Errorf("notifying the payments of ledger %d: %w", ledger, err)
+	}
+	m.MetricsService.IncDBQuery("NOTIFY", "ingest_payments")
+	return nil
+}
+
+// PaymentDirection narrows the payments of an address down to the ones it sent or received.
+type PaymentDirection string
+
+const (
+	PaymentDirectionSent     PaymentDirection = "sent"
+	PaymentDirectionReceived PaymentDirection = "received"
+)
+
+// PaymentsFilter restricts the payments returned by GetPaymentsPaginated, the zero value matches every payment.
+type PaymentsFilter struct {
+	// Address matches the payments from or to the account, including the ones from or to its muxed accounts.
+	Address string
+	// Addresses matches the payments from or to any of the accounts, so the payments of several accounts are returned
+	// as a single stream. The payments between two of them are marked as internal. It's not meant to be used along with
+	// Address.
+	Addresses []string
+	// ExternalUserID matches the payments from or to any of the accounts registered with this external user ID.
+	ExternalUserID string
+	// MuxedID narrows Address down to the payments from or to the muxed account with this ID.
+	MuxedID *uint64
+	// IncludeFailed also matches the payments of failed transactions, which are left out otherwise.
+	IncludeFailed bool
+	// Direction matches the payments sent from Address or received by it. The asset and amount filters apply to the
+	// side of the payment in that direction, the source asset and amount of sent payments and the destination ones of
+	// received payments, and to either side otherwise.
+	Direction PaymentDirection
+	// AssetCode matches the payments of the asset code, or of the asset when AssetIssuer is set too.
+	AssetCode   string
+	AssetIssuer string
+	// MinAmount matches the payments of at least this amount, in stroops.
+	MinAmount *int64
+	// OperationType matches the payments of the operation type, e.g. OperationTypePayment.
+	OperationType string
+	// Memo matches the payments of the transactions with this memo, as it's stored: ID memos in decimal and hash and
+	// return memos in lowercase hex. MemoType matches the payments of the memo type, e.g. MemoTypeMemoId.
+	Memo     string
+	MemoType string
+	// From and To bound the creation time of the payments to [From, To).
+	From *time.Time
+	To   *time.Time
+}
+
+// condition returns the SQL condition matching the filter along with its named arguments.
+func (f PaymentsFilter) condition() (string, map[string]interface{}) {
+	args := map[string]interface{}{}
+	conditions := []string{}
+	if !f.IncludeFailed {
+		conditions = append(conditions, "successful")
+	}
+	if f.Address != "" {
+		args["address"] = f.Address
+		fromCondition, toCondition := "from_address = :address", "to_address = :address"
+		if f.MuxedID != nil {
+			// uint64 args with the high bit set aren't supported by database/sql, so IDs are sent as text
+			args["muxed_id"] = strconv.FormatUint(*f.MuxedID, 10)
+			fromCondition, toCondition = "(from_address = :address AND from_muxed_id = :muxed_id)", "(to_address = :address AND to_muxed_id = :muxed_id)"
+		}
+		switch {
+		case f.Direction == PaymentDirectionSent:
+			conditions = append(conditions, fromCondition)
+		case f.Direction == PaymentDirectionReceived:
+			conditions = append(conditions, toCondition)
+		case f.MuxedID == nil:
+			conditions = append(conditions, ":address IN (from_address, to_address)")
+		default:
+			conditions = append(conditions, fmt.Sprintf("(%s OR %s)", fromCondition, toCondition))
+		}
+	}
+	if len(f.Addresses) > 0 {
+		args["addresses"] = f.Addresses
+		switch f.Direction {
+		case PaymentDirectionSent:
+			conditions = append(conditions, "from_address IN (:addresses)")
+		case PaymentDirectionReceived:
+			conditions = append(conditions, "to_address IN (:addresses)")
+		default:
+			conditions = append(conditions, "(from_address IN (:addresses) OR to_address IN (:addresses))")
+		}
+	}
+	if f.ExternalUserID != "" {
+		args["external_user_id"] = f.ExternalUserID
+		const userAddresses = "(SELECT stellar_address FROM accounts WHERE external_user_id = :external_user_id)"
+		switch f.Direction {
+		case PaymentDirectionSent:
+			conditions = append(conditions, "from_address IN "+userAddresses)
+		case PaymentDirectionReceived:
+			conditions = append(conditions, "to_address IN "+userAddresses)
+		default:
+			conditions = append(conditions, fmt.Sprintf("(from_address IN %s OR to_address IN %s)", userAddresses, userAddresses))
+		}
+	}
+
+	if f.AssetCode != "" || f.MinAmount != nil {
+		var srcCondition, destCondition []string
+		if f.AssetCode != "" {
+			args["asset_code"] = f.AssetCode
+			srcCondition = append(srcCondition, "src_asset_code = :asset_code")
+			destCondition = append(destCondition, "dest_asset_code = :asset_code")
+			if f.AssetIssuer != "" {
+				args["asset_issuer"] = f.AssetIssuer
+				srcCondition = append(srcCondition, "src_asset_issuer = :asset_issuer")
+				destCondition = append(destCondition, "dest_asset_issuer = :asset_issuer")
+			}
+		}
+		if f.MinAmount != nil {
+			args["min_amount"] = *f.MinAmount
+			srcCondition = append(srcCondition, "src_amount >= :min_amount")
+			destCondition = append(destCondition, "dest_amount >= :min_amount")
+		}
+		src, dest := strings.Join(srcCondition, " AND "), strings.Join(destCondition, " AND ")
+		switch f.Direction {
+		case PaymentDirectionSent:
+			conditions = append(conditions, src)
+		case PaymentDirectionReceived:
+			conditions = append(conditions, dest)
+		default:
+			conditions = append(conditions, fmt.Sprintf("((%s) OR (%s))", src, dest))
+		}
+	}
+
+	if f.OperationType != "" {
+		args["operation_type"] = f.OperationType
+		conditions = append(conditions, "operation_type = :operation_type")
+	}
+	if f.Memo != "" {
+		args["memo"] = f.Memo
+		conditions = append(conditions, "memo = :memo")
+	}
+	if f.MemoType != "" {
+		args["memo_type"] = f.MemoType
+		conditions = append(conditions, "memo_type = :memo_type")
+	}
+	if f.From != nil {
+		args["from"] = *f.From
+		conditions = append(conditions, "created_at >= :from")
+	}
+	if f.To != nil {
+		args["to"] = *f.To
+		conditions = append(conditions, "created_at < :to")
+	}
+	if len(conditions) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// isInternal tells whether the payment is between two of the filter Addresses.
+func (f PaymentsFilter) isInternal(payment Payment) bool {
+	return slices.Contains(f.Addresses, payment.FromAddress) && slices.Contains(f.Addresses, payment.ToAddress)
+}
+
+func (m *PaymentModel) GetPaymentsPaginated(ctx context.Context, filter PaymentsFilter, beforeID, afterID string, sort SortOrder, limit int) ([]Payment, bool, bool, error) {
 	if !sort.IsValid() {
 		return nil, false, false, fmt.Errorf("invalid sort value: %s", sort)
 	}
@@ -121,33 +547,47 @@ func (m *PaymentModel) GetPaymentsPaginated(ctx context.Context, address string,
 		return nil, false, false, errors.New("at most one cursor may be provided, got afterId and beforeId")
 	}
 
-	const filteredSetCTE = `
-		WITH filtered_set AS (
-			SELECT * FROM ingest_payments WHERE :address = '' OR :address IN (from_address, to_address)
+	before, err := parsePaymentID(beforeID)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("parsing beforeId: %w", err)
+	}
+	after, err := parsePaymentID(afterID)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("parsing afterId: %w", err)
+	}
+
+	// filtered_set isn't materialized so the cursor conditions are pushed down to the primary key index, pruning the
+	// ingest_payments partitions out of the page range
+	condition, filterArgs := filter.condition()
+	filteredSetCTE := fmt.Sprintf(`
+		WITH filtered_set AS NOT MATERIALIZED (
+			SELECT * FROM ingest_payments WHERE %s
 		)
-	`
+	`, condition)
 
 	var selectQ string
 	if beforeID != "" && sort == DESC {
-		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE operation_id > :before_id ORDER BY operation_id ASC LIMIT :limit) AS reverse_set ORDER BY operation_id DESC"
+		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE (operation_id, event_index) > (:before_id, :before_event_index) ORDER BY operation_id ASC, event_index ASC LIMIT :limit) AS reverse_set ORDER BY operation_id DESC, event_index DESC"
 	} else if beforeID != "" && sort == ASC {
-		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE operation_id < :before_id ORDER BY operation_id DESC LIMIT :limit) AS reverse_set ORDER BY operation_id ASC"
+		selectQ = "SELECT * FROM (SELECT * FROM filtered_set WHERE (operation_id, event_index) < (:before_id, :before_event_index) ORDER BY operation_id DESC, event_index DESC LIMIT :limit) AS reverse_set ORDER BY operation_id ASC, event_index ASC"
 	} else if afterID != "" && sort == DESC {
-		selectQ = "SELECT * FROM filtered_set WHERE operation_id < :after_id ORDER BY operation_id DESC LIMIT :limit"
+		selectQ = "SELECT * FROM filtered_set WHERE (operation_id, event_index) < (:after_id, :after_event_index) ORDER BY operation_id DESC, event_index DESC LIMIT :limit"
 	} else if afterID != "" && sort == ASC {
-		selectQ = "SELECT * FROM filtered_set WHERE operation_id > :after_id ORDER BY operation_id ASC LIMIT :limit"
+		selectQ = "SELECT * FROM filtered_set WHERE (operation_id, event_index) > (:after_id, :after_event_index) ORDER BY operation_id ASC, event_index ASC LIMIT :limit"
 	} else if sort == ASC {
-		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id ASC LIMIT :limit"
+		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id ASC, event_index ASC LIMIT :limit"
 	} else {
-		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id DESC LIMIT :limit"
+		selectQ = "SELECT * FROM filtered_set ORDER BY operation_id DESC, event_index DESC LIMIT :limit"
 	}
 
 	argumentsMap := map[string]interface{}{
-		"address":   address,
-		"limit":     limit,
-		"before_id": beforeID,
-		"after_id":  afterID,
+		"limit":              limit,
+		"before_id":          before.operationID,
+		"before_event_index": before.eventIndex,
+		"after_id":           after.operationID,
+		"after_event_index":  after.eventIndex,
 	}
+	maps.Copy(argumentsMap, filterArgs)
 
 	payments := make([]Payment, 0)
 	query := fmt.Sprintf("%s %s", filteredSetCTE, selectQ)
@@ -163,39 +603,107 @@ func (m *PaymentModel) GetPaymentsPaginated(ctx context.Context, address string,
 		return nil, false, false, fmt.Errorf("fetching payments: %w", err)
 	}
 	m.MetricsService.IncDBQuery("SELECT", "ingest_payments")
+	for i := range payments {
+		payments[i].FailureReason = payments[i].failureReason()
+		payments[i].Internal = filter.isInternal(payments[i])
+	}
 
-	prevExists, nextExists, err := m.existsPrevNext(ctx, filteredSetCTE, address, sort, payments)
+	prevExists, nextExists, err := m.existsPrevNext(ctx, filteredSetCTE, filterArgs, sort, payments)
 	if err != nil {
 		return nil, false, false, fmt.Errorf("checking prev and next pages: %w", err)
 	}
 	return payments, prevExists, nextExists, nil
 }
 
-func (m *PaymentModel) existsPrevNext(ctx context.Context, filteredSetCTE string, address string, sort SortOrder, payments []Payment) (bool, bool, error) {
+// GetPaymentsAfter returns up to limit payments matching the filter after the afterID cursor, or from the first one
+// when it's empty, in ascending order. Unlike GetPaymentsPaginated it doesn't look for the pages around the payments,
+// so walking a whole history with it is cheaper.
+func (m *PaymentModel) GetPaymentsAfter(ctx context.Context, filter PaymentsFilter, afterID string, limit int) ([]Payment, error) {
+	after, err := parsePaymentID(afterID)
+	if err != nil {
+		return nil, fmt.Errorf("parsing afterId: %w", err)
+	}
+	condition, argumentsMap := filter.condition()
+	if afterID != "" {
+		condition += " AND (operation_id, event_index) > (:after_id, :after_event_index)"
+		argumentsMap["after_id"] = after.operationID
+		argumentsMap["after_event_index"] = after.eventIndex
+	}
+	argumentsMap["limit"] = limit
+
+	query := fmt.Sprintf("SELECT * FROM ingest_payments WHERE %s ORDER BY operation_id ASC, event_index ASC LIMIT :limit", condition)
+	query, args, err := PrepareNamedQuery(ctx, m.DB, query, argumentsMap)
+	if err != nil {
+		return nil, fmt.Errorf("preparing named query: %w", err)
+	}
+	payments := make([]Payment, 0)
+	start := time.Now()
+	err = m.DB.SelectContext(ctx, &payments, query, args...)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_payments", duration)
+	if err != nil {
+		return nil, fmt.Errorf("fetching payments after %q: %w", afterID, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_payments")
+	for i := range payments {
+		payments[i].FailureReason = payments[i].failureReason()
+		payments[i].Internal = filter.isInternal(payments[i])
+	}
+	return payments, nil
+}
+
+// GetLatestPaymentID returns the ID of the latest payment matching the filter, or an empty ID when there's none.
+func (m *PaymentModel) GetLatestPaymentID(ctx context.Context, filter PaymentsFilter) (string, error) {
+	condition, argumentsMap := filter.condition()
+	query := fmt.Sprintf("SELECT * FROM ingest_payments WHERE %s ORDER BY operation_id DESC, event_index DESC LIMIT 1", condition)
+	query, args, err := PrepareNamedQuery(ctx, m.DB, query, argumentsMap)
+	if err != nil {
+		return "", fmt.Errorf("preparing named query: %w", err)
+	}
+	payments := make([]Payment, 0, 1)
+	start := time.Now()
+	err = m.DB.SelectContext(ctx, &payments, query, args...)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_payments", duration)
+	if err != nil {
+		return "", fmt.Errorf("fetching the latest payment: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_payments")
+	return FirstPaymentID(payments), nil
+}
+
+func (m *PaymentModel) existsPrevNext(ctx context.Context, filteredSetCTE string, filterArgs map[string]interface{}, sort SortOrder, payments []Payment) (bool, bool, error) {
 	if len(payments) == 0 {
 		return false, false, nil
 	}
 
-	firstElementID := FirstPaymentOperationID(payments)
-	lastElementID := LastPaymentOperationID(payments)
+	first, last := payments[0], payments[len(payments)-1]
 
 	query := fmt.Sprintf(`
 		%s
 		SELECT
 			EXISTS(
-				SELECT 1 FROM filtered_set WHERE CASE WHEN :sort = 'ASC' THEN operation_id < :first_element_id WHEN :sort = 'DESC' THEN operation_id > :first_element_id END LIMIT 1
+				SELECT 1 FROM filtered_set WHERE CASE
+					WHEN :sort = 'ASC' THEN (operation_id, event_index) < (:first_element_id, :first_element_event_index)
+					WHEN :sort = 'DESC' THEN (operation_id, event_index) > (:first_element_id, :first_element_event_index)
+				END LIMIT 1
 			) AS prev_exists,
 			EXISTS(
-				SELECT 1 FROM filtered_set WHERE CASE WHEN :sort = 'ASC' THEN operation_id > :last_element_id WHEN :sort = 'DESC' THEN operation_id < :last_element_id END LIMIT 1
+				SELECT 1 FROM filtered_set WHERE CASE
+					WHEN :sort = 'ASC' THEN (operation_id, event_index) > (:last_element_id, :last_element_event_index)
+					WHEN :sort = 'DESC' THEN (operation_id, event_index) < (:last_element_id, :last_element_event_index)
+				END LIMIT 1
 			) AS next_exists
 	`, filteredSetCTE)
 
 	argumentsMap := map[string]interface{}{
-		"address":          address,
-		"first_element_id": firstElementID,
-		"last_element_id":  lastElementID,
-		"sort":             sort,
+		"first_element_id":          first.OperationID,
+		"first_element_event_index": first.EventIndex,
+		"last_element_id":           last.OperationID,
+		"last_element_event_index":  last.EventIndex,
+		"sort":                      sort,
 	}
+	maps.Copy(argumentsMap, filterArgs)
 
 	query, args, err := PrepareNamedQuery(ctx, m.DB, query, argumentsMap)
 	if err != nil {
@@ -214,17 +722,95 @@ func (m *PaymentModel) existsPrevNext(ctx context.Context, filteredSetCTE string
 	return prevExists, nextExists, nil
 }
 
-func FirstPaymentOperationID(payments []Payment) string {
+// ID returns the ID payments are paginated and streamed by. It's the operation ID, followed by the event index for the
+// token transfers emitted by an operation after its first one.
+func (p Payment) ID() string {
+	if p.EventIndex == 0 {
+		return p.OperationID
+	}
+	return fmt.Sprintf("%s-%d", p.OperationID, p.EventIndex)
+}
+
+// paymentID is a parsed Payment ID.
+type paymentID struct {
+	operationID int64
+	eventIndex  int32
+}
+
+// parsePaymentID parses an ID returned by Payment.ID, the empty ID is the zero paymentID.
+func parsePaymentID(id string) (paymentID, error) {
+	if id == "" {
+		return paymentID{}, nil
+	}
+	operationID, eventIndex, hasEventIndex := strings.Cut(id, "-")
+	var parsed paymentID
+	var err error
+	if parsed.operationID, err = strconv.ParseInt(operationID, 10, 64); err != nil {
+		return paymentID{}, fmt.Errorf("invalid payment ID %q", id)
+	}
+	if hasEventIndex {
+		index, err := strconv.ParseInt(eventIndex, 10, 32)
+		if err != nil || index <= 0 {
+			return paymentID{}, fmt.Errorf("invalid payment ID %q", id)
+		}
+		parsed.eventIndex = int32(index)
+	}
+	return parsed, nil
+}
+
+// ValidatePaymentID returns an error when the ID wasn't returned by Payment.ID.
+func ValidatePaymentID(id string) error {
+	_, err := parsePaymentID(id)
+	return err
+}
+
+func FirstPaymentID(payments []Payment) string {
 	if len(payments) > 0 {
-		return payments[0].OperationID
+		return payments[0].ID()
 	}
 	return ""
 }
 
-func LastPaymentOperationID(payments []Payment) string {
+func LastPaymentID(payments []Payment) string {
 	len := len(payments)
 	if len > 0 {
-		return payments[len-1].OperationID
+		return payments[len-1].ID()
 	}
 	return ""
 }
+
+// PrunePayments deletes up to limit payments created before the given time and returns how many were deleted. With
+// unregisteredOnly, only the payments whose accounts are no longer registered and were deregistered before the given
+// time too are deleted. Accounts deregistered before their deregistration time was recorded count as deregistered long
+// ago.
+func (m *PaymentModel) PrunePayments(ctx context.Context, before time.Time, unregisteredOnly bool, limit int) (int64, error) {
+	const query = `
+		DELETE FROM ingest_payments
+		WHERE (operation_id, event_index) IN (
+			SELECT p.operation_id, p.event_index FROM ingest_payments p
+			WHERE p.created_at < $1
+				AND (NOT $2 OR (
+					NOT EXISTS (SELECT 1 FROM accounts WHERE stellar_address IN (p.from_address, p.to_address))
+					AND NOT EXISTS (
+						SELECT 1 FROM deregistered_accounts
+						WHERE stellar_address IN (p.from_address, p.to_address) AND deregistered_at >= $1
+					)
+				))
+			LIMIT $3
+		)
+	`
+	start := time.Now()
+	result, err := m.DB.ExecContext(ctx, query, before, unregisteredOnly, limit)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("DELETE", "ingest_payments", duration)
+	if err != nil {
+		return 0, fmt.Errorf("pruning payments created before %s: %w", before, err)
+	}
+	m.MetricsService.IncDBQuery("DELETE", "ingest_payments")
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("getting the number of pruned payments: %w", err)
+	}
+	return deleted, nil
+}
@@ -3,9 +3,12 @@ package data
 import (
 	"context"
 	"database/sql"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
+	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/xdr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -58,6 +61,7 @@ func TestPaymentModelAddPayment(t *testing.T) {
 		CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
 		Memo:            nil,
 		MemoType:        xdr.MemoTypeMemoNone.String(),
+		Successful:      true,
 	}
 
 	addPayment := func(p Payment) {
@@ -132,6 +136,7 @@ func TestPaymentModelAddPayment(t *testing.T) {
 			CreatedAt:       time.Date(2023, 12, 16, 1, 0, 0, 0, time.UTC),
 			Memo:            utils.PointOf("diff"),
 			MemoType:        xdr.MemoTypeMemoText.String(),
+			Successful:      true,
 		}
 
 		_, err := dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, toAddress)
@@ -148,6 +153,165 @@ func TestPaymentModelAddPayment(t *testing.T) {
 	})
 }
 
+func TestPaymentModelBatchAddPayments(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Return()
+	defer mockMetricsService.AssertExpectations(t)
+
+	m := &PaymentModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	const (
+		knownAddress   = "GCYQBVCREYSLKHHOWLT27VNZNGVIXXAPYVNNOWMQV67WVDD4PP2VZAX7"
+		unknownAddress = "GDDEAH46MNFO6JD7NTQ5FWJBC4ZSA47YEK3RKFHQWADYTS6NDVD5CORW"
+	)
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, knownAddress)
+	require.NoError(t, err)
+
+	newPayment := func(operationID, from, to string, memo *string) Payment {
+		return Payment{
+			OperationID:     operationID,
+			OperationType:   xdr.OperationTypePayment.String(),
+			TransactionID:   "2120562792996864",
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			FromAddress:     from,
+			ToAddress:       to,
+			SrcAssetCode:    "USDC",
+			SrcAssetIssuer:  "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
+			SrcAssetType:    xdr.AssetTypeAssetTypeCreditAlphanum4.String(),
+			SrcAmount:       500000000,
+			DestAssetCode:   "USDC",
+			DestAssetIssuer: "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
+			DestAssetType:   xdr.AssetTypeAssetTypeCreditAlphanum4.String(),
+			DestAmount:      500000000,
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+			Memo:            memo,
+			MemoType:        xdr.MemoTypeMemoText.String(),
+			Successful:      true,
+		}
+	}
+
+	batchAddPayments := func(payments []Payment) {
+		err := db.RunInTransaction(ctx, m.DB, nil, func(dbTx db.Transaction) error {
+			return m.BatchAddPayments(ctx, dbTx, payments)
+		})
+		require.NoError(t, err)
+	}
+
+	fromKnown := newPayment("2120562792996865", knownAddress, unknownAddress, utils.PointOf("with \"quotes\", commas and {braces}"))
+	toKnown := newPayment("2120562792996866", unknownAddress, knownAddress, nil)
+	unrelated := newPayment("2120562792996867", unknownAddress, unknownAddress, nil)
+	batchAddPayments([]Payment{fromKnown, toKnown, unrelated})
+
+	var dbPayments []Payment
+	err = dbConnectionPool.SelectContext(ctx, &dbPayments, "SELECT * FROM ingest_payments ORDER BY operation_id")
+	require.NoError(t, err)
+	assert.Equal(t, []Payment{fromKnown, toKnown}, dbPayments)
+
+	t.Run("update_on_reingestion", func(t *testing.T) {
+		updated := fromKnown
+		updated.SrcAmount = 1
+		updated.DestAmount = 1
+		batchAddPayments([]Payment{updated})
+
+		var dbPayment Payment
+		err := dbConnectionPool.GetContext(ctx, &dbPayment, "SELECT * FROM ingest_payments WHERE operation_id = $1", updated.OperationID)
+		require.NoError(t, err)
+		assert.Equal(t, updated, dbPayment)
+	})
+
+	t.Run("empty_batch_is_a_noop", func(t *testing.T) {
+		err := m.BatchAddPayments(ctx, nil, nil)
+		require.NoError(t, err)
+	})
+}
+
+// BenchmarkPaymentModelAddPayments compares inserting a busy ledger worth of payments one by one against inserting
+// them in a single batch.
+func BenchmarkPaymentModelAddPayments(b *testing.B) {
+	dbt := dbtest.Open(b)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(b, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Return()
+
+	m := &PaymentModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	const numPayments = 1000
+	registeredAccount := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, registeredAccount)
+	require.NoError(b, err)
+
+	payments := make([]Payment, numPayments)
+	for i := range payments {
+		// only one in ten payments involves a registered account, which is closer to what we see in pubnet ledgers
+		toAddress := keypair.MustRandom().Address()
+		if i%10 == 0 {
+			toAddress = registeredAccount
+		}
+		payments[i] = Payment{
+			OperationID:     utils.OperationID(1000, int32(i/10+1), int32(i%10+1)),
+			OperationType:   xdr.OperationTypePayment.String(),
+			TransactionID:   utils.TransactionID(1000, int32(i/10+1)),
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			FromAddress:     keypair.MustRandom().Address(),
+			ToAddress:       toAddress,
+			SrcAssetCode:    "XLM",
+			SrcAssetType:    xdr.AssetTypeAssetTypeNative.String(),
+			SrcAmount:       int64(i),
+			DestAssetCode:   "XLM",
+			DestAssetType:   xdr.AssetTypeAssetTypeNative.String(),
+			DestAmount:      int64(i),
+			CreatedAt:       time.Now(),
+			MemoType:        xdr.MemoTypeMemoNone.String(),
+			Successful:      true,
+		}
+	}
+
+	b.Run("one_by_one", func(b *testing.B) {
+		for range b.N {
+			err := db.RunInTransaction(ctx, m.DB, nil, func(dbTx db.Transaction) error {
+				for _, payment := range payments {
+					if err := m.AddPayment(ctx, dbTx, payment); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for range b.N {
+			err := db.RunInTransaction(ctx, m.DB, nil, func(dbTx db.Transaction) error {
+				return m.BatchAddPayments(ctx, dbTx, payments)
+			})
+			require.NoError(b, err)
+		}
+	})
+}
+
 func TestPaymentModelGetLatestLedgerSynced(t *testing.T) {
 	dbt := dbtest.Open(t)
 	defer dbt.Close()
@@ -198,7 +362,7 @@ func TestPaymentModelUpdateLatestLedgerSynced(t *testing.T) {
 	}
 
 	const key = "ingest_store_key"
-	err = m.UpdateLatestLedgerSynced(ctx, key, 123)
+	err = m.UpdateLatestLedgerSynced(ctx, dbConnectionPool, key, 123)
 	require.NoError(t, err)
 
 	var lastSyncedLedger uint32
@@ -207,6 +371,39 @@ func TestPaymentModelUpdateLatestLedgerSynced(t *testing.T) {
 	assert.Equal(t, uint32(123), lastSyncedLedger)
 }
 
+func TestPaymentModelDeleteLatestLedgersSynced(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_store", mock.Anything).Return().Times(3)
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_store").Return().Times(3)
+	mockMetricsService.On("ObserveDBQueryDuration", "DELETE", "ingest_store", mock.Anything).Return().Times(1)
+	mockMetricsService.On("IncDBQuery", "DELETE", "ingest_store").Return().Times(1)
+	defer mockMetricsService.AssertExpectations(t)
+
+	ctx := context.Background()
+	m := &PaymentModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	for _, key := range []string{"backfill_1_10", "backfill_11_20", "ingestionLedger"} {
+		require.NoError(t, m.UpdateLatestLedgerSynced(ctx, dbConnectionPool, key, 10))
+	}
+
+	err = m.DeleteLatestLedgersSynced(ctx, []string{"backfill_1_10", "backfill_11_20", "backfill_21_30"})
+	require.NoError(t, err)
+
+	var keys []string
+	err = m.DB.SelectContext(ctx, &keys, `SELECT key FROM ingest_store`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ingestionLedger"}, keys)
+}
+
 func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 	dbt := dbtest.Open(t)
 	defer dbt.Close()
@@ -217,11 +414,11 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 	ctx := context.Background()
 
 	dbPayments := []Payment{
-		{OperationID: "1", OperationType: xdr.OperationTypePayment.String(), TransactionID: "11", TransactionHash: "c370ff20144e4c96b17432b8d14664c1", FromAddress: "GAZ37ZO4TU3H", ToAddress: "GDD2HQO6IOFT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 10, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 10, CreatedAt: time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "2", OperationType: xdr.OperationTypePayment.String(), TransactionID: "22", TransactionHash: "30850d8fc7d1439782885103390cd975", FromAddress: "GBZ5Q56JKHJQ", ToAddress: "GASV72SENBSY", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 20, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 20, CreatedAt: time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "3", OperationType: xdr.OperationTypePayment.String(), TransactionID: "33", TransactionHash: "d9521ed7057d4d1e9b9dd22ab515cbf1", FromAddress: "GAYFAYPOECBT", ToAddress: "GDWDPNMALNIT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 30, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 30, CreatedAt: time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "4", OperationType: xdr.OperationTypePayment.String(), TransactionID: "44", TransactionHash: "2af98496a86741c6a6814200e06027fd", FromAddress: "GACKTNR2QQXU", ToAddress: "GBZ5KUZHAAVI", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 40, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 40, CreatedAt: time.Date(2024, 6, 24, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "5", OperationType: xdr.OperationTypePayment.String(), TransactionID: "55", TransactionHash: "edfab36f9f104c4fb74b549de44cfbcc", FromAddress: "GA4CMYJEC5W5", ToAddress: "GAZ37ZO4TU3H", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 50, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 50, CreatedAt: time.Date(2024, 6, 25, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
+		{OperationID: "1", OperationType: xdr.OperationTypePayment.String(), TransactionID: "11", TransactionHash: "c370ff20144e4c96b17432b8d14664c1", FromAddress: "GAZ37ZO4TU3H", ToAddress: "GDD2HQO6IOFT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 10, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 10, CreatedAt: time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "2", OperationType: xdr.OperationTypePayment.String(), TransactionID: "22", TransactionHash: "30850d8fc7d1439782885103390cd975", FromAddress: "GBZ5Q56JKHJQ", ToAddress: "GASV72SENBSY", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 20, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 20, CreatedAt: time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "3", OperationType: xdr.OperationTypePayment.String(), TransactionID: "33", TransactionHash: "d9521ed7057d4d1e9b9dd22ab515cbf1", FromAddress: "GAYFAYPOECBT", ToAddress: "GDWDPNMALNIT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 30, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 30, CreatedAt: time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "4", OperationType: xdr.OperationTypePayment.String(), TransactionID: "44", TransactionHash: "2af98496a86741c6a6814200e06027fd", FromAddress: "GACKTNR2QQXU", ToAddress: "GBZ5KUZHAAVI", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 40, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 40, CreatedAt: time.Date(2024, 6, 24, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "5", OperationType: xdr.OperationTypePayment.String(), TransactionID: "55", TransactionHash: "edfab36f9f104c4fb74b549de44cfbcc", FromAddress: "GA4CMYJEC5W5", ToAddress: "GAZ37ZO4TU3H", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 50, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 50, CreatedAt: time.Date(2024, 6, 25, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
 	}
 	InsertTestPayments(t, ctx, dbPayments, dbConnectionPool)
 
@@ -236,7 +433,7 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, "", "", "", DESC, 2)
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", "", DESC, 2)
 		require.NoError(t, err)
 
 		assert.False(t, prevExists)
@@ -259,7 +456,7 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, "", "", "", ASC, 2)
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", "", ASC, 2)
 		require.NoError(t, err)
 
 		assert.False(t, prevExists)
@@ -282,7 +479,7 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, dbPayments[1].FromAddress, "", "", DESC, 2)
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{Address: dbPayments[1].FromAddress}, "", "", DESC, 2)
 		require.NoError(t, err)
 
 		assert.False(t, prevExists)
@@ -304,7 +501,7 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, "", "", dbPayments[3].OperationID, DESC, 2)
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", dbPayments[3].OperationID, DESC, 2)
 		require.NoError(t, err)
 
 		assert.True(t, prevExists)
@@ -327,7 +524,7 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, "", "", dbPayments[3].OperationID, ASC, 2)
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", dbPayments[3].OperationID, ASC, 2)
 		require.NoError(t, err)
 
 		assert.True(t, prevExists)
@@ -349,7 +546,7 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, "", dbPayments[2].OperationID, "", DESC, 2)
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, dbPayments[2].OperationID, "", DESC, 2)
 		require.NoError(t, err)
 
 		assert.False(t, prevExists)
@@ -372,7 +569,7 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, "", dbPayments[2].OperationID, "", ASC, 2)
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, dbPayments[2].OperationID, "", ASC, 2)
 		require.NoError(t, err)
 
 		assert.False(t, prevExists)
@@ -392,7 +589,268 @@ func TestPaymentModelGetPaymentsPaginated(t *testing.T) {
 			MetricsService: mockMetricsService,
 		}
 
-		_, _, _, err := m.GetPaymentsPaginated(ctx, "", dbPayments[4].OperationID, dbPayments[2].OperationID, ASC, 2)
+		_, _, _, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, dbPayments[4].OperationID, dbPayments[2].OperationID, ASC, 2)
 		assert.ErrorContains(t, err, "at most one cursor may be provided, got afterId and beforeId")
 	})
+
+	t.Run("filters", func(t *testing.T) {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return()
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return()
+
+		m := &PaymentModel{
+			DB:             dbConnectionPool,
+			MetricsService: mockMetricsService,
+		}
+
+		june := func(day int) *time.Time {
+			return utils.PointOf(time.Date(2024, 6, day, 0, 0, 0, 0, time.UTC))
+		}
+		internalPayment := dbPayments[4]
+		internalPayment.Internal = true
+		_, err := dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address, external_user_id) VALUES ('GAZ37ZO4TU3H', 'user-1'), ('GDD2HQO6IOFT', 'user-1')`)
+		require.NoError(t, err)
+		testCases := []struct {
+			name   string
+			filter PaymentsFilter
+			want   []Payment
+		}{
+			{name: "asset_code", filter: PaymentsFilter{AssetCode: "USDC"}, want: []Payment{dbPayments[4], dbPayments[3]}},
+			{name: "asset_code_and_issuer", filter: PaymentsFilter{AssetCode: "USDC", AssetIssuer: "GOTHERISSUER"}, want: []Payment{}},
+			{name: "operation_type", filter: PaymentsFilter{OperationType: xdr.OperationTypePathPaymentStrictSend.String()}, want: []Payment{}},
+			{name: "created_at_range", filter: PaymentsFilter{From: june(22), To: june(24)}, want: []Payment{dbPayments[2], dbPayments[1]}},
+			{name: "min_amount", filter: PaymentsFilter{MinAmount: utils.PointOf(int64(40))}, want: []Payment{dbPayments[4], dbPayments[3]}},
+			{name: "direction_sent", filter: PaymentsFilter{Address: "GAZ37ZO4TU3H", Direction: PaymentDirectionSent}, want: []Payment{dbPayments[0]}},
+			{name: "direction_received", filter: PaymentsFilter{Address: "GAZ37ZO4TU3H", Direction: PaymentDirectionReceived}, want: []Payment{dbPayments[4]}},
+			{name: "addresses", filter: PaymentsFilter{Addresses: []string{"GAZ37ZO4TU3H", "GA4CMYJEC5W5"}}, want: []Payment{internalPayment, dbPayments[0]}},
+			{name: "addresses_sent", filter: PaymentsFilter{Addresses: []string{"GAZ37ZO4TU3H", "GDD2HQO6IOFT"}, Direction: PaymentDirectionSent}, want: []Payment{dbPayments[0]}},
+			{name: "external_user_id", filter: PaymentsFilter{ExternalUserID: "user-1"}, want: []Payment{dbPayments[4], dbPayments[0]}},
+			{name: "external_user_id_sent", filter: PaymentsFilter{ExternalUserID: "user-1", Direction: PaymentDirectionSent}, want: []Payment{dbPayments[0]}},
+			{name: "unknown_external_user_id", filter: PaymentsFilter{ExternalUserID: "user-2"}, want: []Payment{}},
+			{name: "received_asset", filter: PaymentsFilter{Address: "GAZ37ZO4TU3H", Direction: PaymentDirectionReceived, AssetCode: "XLM"}, want: []Payment{}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				payments, _, _, err := m.GetPaymentsPaginated(ctx, tc.filter, "", "", DESC, 10)
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, payments)
+			})
+		}
+	})
+
+	t.Run("after", func(t *testing.T) {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return().Times(2)
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return().Times(2)
+		defer mockMetricsService.AssertExpectations(t)
+
+		m := &PaymentModel{
+			DB:             dbConnectionPool,
+			MetricsService: mockMetricsService,
+		}
+
+		payments, err := m.GetPaymentsAfter(ctx, PaymentsFilter{}, "", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []Payment{dbPayments[0], dbPayments[1]}, payments)
+
+		payments, err = m.GetPaymentsAfter(ctx, PaymentsFilter{AssetCode: "USDC"}, "4", 2)
+		require.NoError(t, err)
+		assert.Equal(t, []Payment{dbPayments[4]}, payments)
+	})
+
+	t.Run("latest_id", func(t *testing.T) {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return().Times(2)
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return().Times(2)
+		defer mockMetricsService.AssertExpectations(t)
+
+		m := &PaymentModel{
+			DB:             dbConnectionPool,
+			MetricsService: mockMetricsService,
+		}
+
+		latestID, err := m.GetLatestPaymentID(ctx, PaymentsFilter{AssetCode: "USDC"})
+		require.NoError(t, err)
+		assert.Equal(t, dbPayments[4].ID(), latestID)
+
+		latestID, err = m.GetLatestPaymentID(ctx, PaymentsFilter{ExternalUserID: "user-2"})
+		require.NoError(t, err)
+		assert.Empty(t, latestID)
+	})
+}
+
+func TestPaymentModelGetPaymentsPaginatedMuxedAccounts(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return()
+	m := &PaymentModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	const exchange = "GAZ37ZO4TU3H"
+	newPayment := func(operationID, from, to string) Payment {
+		return Payment{OperationID: operationID, OperationType: xdr.OperationTypePayment.String(), TransactionID: operationID, TransactionHash: "hash" + operationID, FromAddress: from, ToAddress: to, SrcAssetCode: "XLM", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 10, DestAssetCode: "XLM", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 10, CreatedAt: time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), MemoType: xdr.MemoTypeMemoNone.String(), Successful: true}
+	}
+	withdrawal := newPayment("1", exchange, "GDD2HQO6IOFT")
+	withdrawal.FromMuxedID, withdrawal.FromMuxedAddress = utils.PointOf("1"), utils.PointOf("MAZ37ZO4TU3H1")
+	deposit := newPayment("2", "GBZ5Q56JKHJQ", exchange)
+	deposit.ToMuxedID, deposit.ToMuxedAddress = utils.PointOf("18446744073709551615"), utils.PointOf("MAZ37ZO4TU3H2")
+	unmuxed := newPayment("3", exchange, "GBZ5Q56JKHJQ")
+	dbPayments := []Payment{withdrawal, deposit, unmuxed}
+	InsertTestPayments(t, ctx, dbPayments, dbConnectionPool)
+
+	testCases := []struct {
+		name         string
+		filter       PaymentsFilter
+		wantPayments []Payment
+	}{
+		{
+			name:         "base_address_matches_its_muxed_accounts",
+			filter:       PaymentsFilter{Address: exchange},
+			wantPayments: []Payment{withdrawal, deposit, unmuxed},
+		},
+		{
+			name:         "muxed_source",
+			filter:       PaymentsFilter{Address: exchange, MuxedID: utils.PointOf(uint64(1))},
+			wantPayments: []Payment{withdrawal},
+		},
+		{
+			name:         "muxed_destination_with_the_largest_id",
+			filter:       PaymentsFilter{Address: exchange, MuxedID: utils.PointOf(uint64(math.MaxUint64))},
+			wantPayments: []Payment{deposit},
+		},
+		{
+			name:         "muxed_id_of_another_account",
+			filter:       PaymentsFilter{Address: "GBZ5Q56JKHJQ", MuxedID: utils.PointOf(uint64(1))},
+			wantPayments: []Payment{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, tc.filter, "", "", ASC, 10)
+			require.NoError(t, err)
+			assert.False(t, prevExists)
+			assert.False(t, nextExists)
+			assert.Equal(t, tc.wantPayments, payments)
+		})
+	}
+}
+
+func TestPaymentModelGetPaymentsPaginatedEventIndexes(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return()
+	m := &PaymentModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	const holder = "GAZ37ZO4TU3H"
+	newPayment := func(operationID string, eventIndex int32) Payment {
+		return Payment{OperationID: operationID, EventIndex: eventIndex, OperationType: xdr.OperationTypeInvokeHostFunction.String(), TransactionID: operationID, TransactionHash: "hash" + operationID, FromAddress: holder, ToAddress: "GDD2HQO6IOFT", SrcAssetCode: "CCONTRACT", SrcAssetType: "contract", SrcAmount: 10, DestAssetCode: "CCONTRACT", DestAssetType: "contract", DestAmount: 10, CreatedAt: time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), MemoType: xdr.MemoTypeMemoNone.String(), ContractEventType: utils.PointOf("transfer"), Successful: true}
+	}
+	// the token transfers of a single operation, followed by another operation
+	dbPayments := []Payment{newPayment("1", 0), newPayment("1", 1), newPayment("1", 2), newPayment("2", 0)}
+	InsertTestPayments(t, ctx, dbPayments, dbConnectionPool)
+
+	t.Run("pages_through_the_events_of_an_operation", func(t *testing.T) {
+		payments, prevExists, nextExists, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", "", ASC, 2)
+		require.NoError(t, err)
+		assert.False(t, prevExists)
+		assert.True(t, nextExists)
+		assert.Equal(t, dbPayments[:2], payments)
+		assert.Equal(t, "1-1", LastPaymentID(payments))
+
+		payments, prevExists, nextExists, err = m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", LastPaymentID(payments), ASC, 2)
+		require.NoError(t, err)
+		assert.True(t, prevExists)
+		assert.False(t, nextExists)
+		assert.Equal(t, dbPayments[2:], payments)
+
+		payments, _, _, err = m.GetPaymentsPaginated(ctx, PaymentsFilter{}, FirstPaymentID(payments), "", ASC, 2)
+		require.NoError(t, err)
+		assert.Equal(t, dbPayments[:2], payments)
+	})
+
+	t.Run("after_id_desc", func(t *testing.T) {
+		payments, _, _, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", "1-2", DESC, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []Payment{dbPayments[1], dbPayments[0]}, payments)
+	})
+
+	t.Run("get_payments_after", func(t *testing.T) {
+		payments, err := m.GetPaymentsAfter(ctx, PaymentsFilter{}, "1", 10)
+		require.NoError(t, err)
+		assert.Equal(t, dbPayments[1:], payments)
+	})
+
+	t.Run("invalid_id", func(t *testing.T) {
+		_, _, _, err := m.GetPaymentsPaginated(ctx, PaymentsFilter{}, "", "1-a", ASC, 10)
+		assert.EqualError(t, err, `parsing afterId: invalid payment ID "1-a"`)
+	})
+}
+
+func TestPaymentID(t *testing.T) {
+	assert.Equal(t, "4294967297", Payment{OperationID: "4294967297"}.ID())
+	assert.Equal(t, "4294967297-3", Payment{OperationID: "4294967297", EventIndex: 3}.ID())
+
+	for _, id := range []string{"", "4294967297", "4294967297-3"} {
+		assert.NoError(t, ValidatePaymentID(id), id)
+	}
+	for _, id := range []string{"abc", "4294967297-", "4294967297-0", "4294967297--1", "-1"} {
+		assert.Error(t, ValidatePaymentID(id), id)
+	}
+}
+
+func TestPaymentModelNotifyPayments(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.Anything).Return().Once()
+	mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Return().Once()
+	defer mockMetricsService.AssertExpectations(t)
+
+	ctx := context.Background()
+	m := &PaymentModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+
+	listener := pq.NewListener(dbt.DSN, time.Second, time.Second, nil)
+	defer listener.Close()
+	require.NoError(t, listener.Listen(PaymentsNotificationChannel))
+
+	err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+		return m.NotifyPayments(ctx, dbTx, 123)
+	})
+	require.NoError(t, err)
+
+	select {
+	case notification := <-listener.Notify:
+		require.NotNil(t, notification)
+		assert.Equal(t, PaymentsNotificationChannel, notification.Channel)
+		assert.Equal(t, "123", notification.Extra)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the payments weren't notified")
+	}
 }
@@ -0,0 +1,148 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+type TransactionFeeModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+// TransactionFee is the fee charged for a transaction. FeeAccount paid it, which is the fee bump account when the fee
+// was bumped and SourceAccount otherwise.
+type TransactionFee struct {
+	TransactionID   string    `db:"transaction_id" json:"transactionId"`
+	TransactionHash string    `db:"transaction_hash" json:"transactionHash"`
+	LedgerNumber    uint32    `db:"ledger_number" json:"ledgerNumber"`
+	SourceAccount   string    `db:"source_account" json:"sourceAccount"`
+	FeeAccount      string    `db:"fee_account" json:"feeAccount"`
+	FeeBumped       bool      `db:"fee_bumped" json:"feeBumped"`
+	FeeCharged      int64     `db:"fee_charged" json:"feeCharged"`
+	MaxFee          int64     `db:"max_fee" json:"maxFee"`
+	Successful      bool      `db:"successful" json:"successful"`
+	CreatedAt       time.Time `db:"created_at" json:"createdAt"`
+}
+
+// FeeSummary aggregates the fees paid by an account.
+type FeeSummary struct {
+	Account          string `db:"account" json:"account,omitempty"`
+	TransactionCount int64  `db:"transaction_count" json:"transactionCount"`
+	FeeBumpedCount   int64  `db:"fee_bumped_count" json:"feeBumpedCount"`
+	FailedCount      int64  `db:"failed_count" json:"failedCount"`
+	FeeCharged       int64  `db:"fee_charged" json:"feeCharged"`
+}
+
+// BatchAddTransactionFees upserts the given fees in a single round trip. Only the fees of transactions whose source or
+// fee account is registered, a channel account or one of trackedAccounts are stored.
+func (m *TransactionFeeModel) BatchAddTransactionFees(ctx context.Context, tx db.Transaction, fees []TransactionFee, trackedAccounts []string) error {
+	if len(fees) == 0 {
+		return nil
+	}
+
+	const query = `
+		INSERT INTO ingest_transaction_fees (
+			transaction_id, transaction_hash, ledger_number, source_account, fee_account, fee_bumped, fee_charged, max_fee,
+			successful, created_at
+		)
+		SELECT
+			f.transaction_id, f.transaction_hash, f.ledger_number, f.source_account, f.fee_account, f.fee_bumped, f.fee_charged,
+			f.max_fee, f.successful, f.created_at
+		FROM UNNEST(
+			$1::bigint[], $2::text[], $3::integer[], $4::text[], $5::text[], $6::boolean[], $7::bigint[], $8::bigint[],
+			$9::boolean[], $10::timestamptz[]
+		) AS f(
+			transaction_id, transaction_hash, ledger_number, source_account, fee_account, fee_bumped, fee_charged, max_fee,
+			successful, created_at
+		)
+		WHERE f.source_account = ANY($11::text[]) OR f.fee_account = ANY($11::text[])
+			OR EXISTS (SELECT 1 FROM accounts WHERE stellar_address IN (f.source_account, f.fee_account))
+			OR EXISTS (SELECT 1 FROM channel_accounts WHERE public_key IN (f.source_account, f.fee_account))
+		ON CONFLICT (transaction_id) DO UPDATE SET
+			transaction_hash = EXCLUDED.transaction_hash,
+			ledger_number = EXCLUDED.ledger_number,
+			source_account = EXCLUDED.source_account,
+			fee_account = EXCLUDED.fee_account,
+			fee_bumped = EXCLUDED.fee_bumped,
+			fee_charged = EXCLUDED.fee_charged,
+			max_fee = EXCLUDED.max_fee,
+			successful = EXCLUDED.successful,
+			created_at = EXCLUDED.created_at
+		;
+	`
+
+	n := len(fees)
+	var (
+		transactionIDs    = make([]string, n)
+		transactionHashes = make([]string, n)
+		ledgerNumbers     = make([]int64, n)
+		sourceAccounts    = make([]string, n)
+		feeAccounts       = make([]string, n)
+		feeBumpeds        = make([]bool, n)
+		feesCharged       = make([]int64, n)
+		maxFees           = make([]int64, n)
+		successfuls       = make([]bool, n)
+		createdAts        = make([]string, n)
+	)
+	for i, fee := range fees {
+		transactionIDs[i] = fee.TransactionID
+		transactionHashes[i] = fee.TransactionHash
+		ledgerNumbers[i] = int64(fee.LedgerNumber)
+		sourceAccounts[i] = fee.SourceAccount
+		feeAccounts[i] = fee.FeeAccount
+		feeBumpeds[i] = fee.FeeBumped
+		feesCharged[i] = fee.FeeCharged
+		maxFees[i] = fee.MaxFee
+		successfuls[i] = fee.Successful
+		createdAts[i] = fee.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, query,
+		pq.Array(transactionIDs), pq.Array(transactionHashes), pq.Array(ledgerNumbers), pq.Array(sourceAccounts), pq.Array(feeAccounts),
+		pq.Array(feeBumpeds), pq.Array(feesCharged), pq.Array(maxFees), pq.Array(successfuls), pq.Array(createdAts),
+		pq.Array(trackedAccounts))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_transaction_fees", duration)
+	if err != nil {
+		return fmt.Errorf("batch inserting %d transaction fees: %w", n, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "ingest_transaction_fees")
+	return nil
+}
+
+// GetFeeSummaries returns the fees paid by each account within [from, to), ordered by the fees paid. An empty account
+// returns every account, and nil bounds leave the range open.
+func (m *TransactionFeeModel) GetFeeSummaries(ctx context.Context, account string, from, to *time.Time) ([]FeeSummary, error) {
+	const query = `
+		SELECT
+			fee_account AS account,
+			COUNT(*) AS transaction_count,
+			COUNT(*) FILTER (WHERE fee_bumped) AS fee_bumped_count,
+			COUNT(*) FILTER (WHERE NOT successful) AS failed_count,
+			SUM(fee_charged)::bigint AS fee_charged
+		FROM ingest_transaction_fees
+		WHERE ($1::text = '' OR fee_account = $1::text)
+			AND ($2::timestamptz IS NULL OR created_at >= $2::timestamptz)
+			AND ($3::timestamptz IS NULL OR created_at < $3::timestamptz)
+		GROUP BY fee_account
+		ORDER BY fee_charged DESC, fee_account
+	`
+	summaries := make([]FeeSummary, 0)
+	start := time.Now()
+	err := m.DB.SelectContext(ctx, &summaries, query, account, from, to)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_transaction_fees", duration)
+	if err != nil {
+		return nil, fmt.Errorf("getting fee summaries: %w", err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_transaction_fees")
+	return summaries, nil
+}
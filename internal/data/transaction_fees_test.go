@@ -0,0 +1,163 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestTransactionFeeModelBatchAddTransactionFees(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_transaction_fees", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_transaction_fees").Return()
+	defer mockMetricsService.AssertExpectations(t)
+
+	m := &TransactionFeeModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	registeredAccount := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, registeredAccount)
+	require.NoError(t, err)
+	channelAccount := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO channel_accounts (public_key, encrypted_private_key) VALUES ($1, 'encrypted')`, channelAccount)
+	require.NoError(t, err)
+	distributionAccount := keypair.MustRandom().Address()
+	unrelatedAccount := keypair.MustRandom().Address()
+
+	createdAt := time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC)
+	fees := []TransactionFee{
+		{
+			TransactionID:   "1",
+			TransactionHash: "hash1",
+			LedgerNumber:    10,
+			SourceAccount:   registeredAccount,
+			FeeAccount:      registeredAccount,
+			FeeCharged:      100,
+			MaxFee:          200,
+			Successful:      true,
+			CreatedAt:       createdAt,
+		},
+		{
+			TransactionID:   "2",
+			TransactionHash: "hash2",
+			LedgerNumber:    10,
+			SourceAccount:   channelAccount,
+			FeeAccount:      distributionAccount,
+			FeeBumped:       true,
+			FeeCharged:      300,
+			MaxFee:          1000,
+			Successful:      false,
+			CreatedAt:       createdAt,
+		},
+		{
+			TransactionID:   "3",
+			TransactionHash: "hash3",
+			LedgerNumber:    10,
+			SourceAccount:   unrelatedAccount,
+			FeeAccount:      unrelatedAccount,
+			FeeCharged:      100,
+			MaxFee:          100,
+			Successful:      true,
+			CreatedAt:       createdAt,
+		},
+	}
+
+	// ingesting the same ledger twice must not fail
+	for range 2 {
+		err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+			return m.BatchAddTransactionFees(ctx, dbTx, fees, []string{distributionAccount})
+		})
+		require.NoError(t, err)
+	}
+
+	var dbFees []TransactionFee
+	err = dbConnectionPool.SelectContext(ctx, &dbFees, `SELECT * FROM ingest_transaction_fees ORDER BY transaction_id`)
+	require.NoError(t, err)
+	require.Len(t, dbFees, 2)
+	assert.Equal(t, fees[0], dbFees[0])
+	assert.Equal(t, fees[1], dbFees[1])
+}
+
+func TestTransactionFeeModelGetFeeSummaries(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+
+	account := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	for i, row := range []struct {
+		feeAccount string
+		feeBumped  bool
+		feeCharged int64
+		successful bool
+		createdAt  time.Time
+	}{
+		{account, false, 100, true, day(1)},
+		{account, true, 200, false, day(2)},
+		{account, false, 400, true, day(3)},
+		{otherAccount, false, 50, true, day(2)},
+	} {
+		_, err = dbConnectionPool.ExecContext(ctx, `
+			INSERT INTO ingest_transaction_fees (transaction_id, transaction_hash, ledger_number, source_account, fee_account, fee_bumped, fee_charged, max_fee, successful, created_at)
+			VALUES ($1, 'hash', 1, $2, $2, $3, $4, $4, $5, $6)
+		`, i+1, row.feeAccount, row.feeBumped, row.feeCharged, row.successful, row.createdAt)
+		require.NoError(t, err)
+	}
+
+	newModel := func(t *testing.T) *TransactionFeeModel {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_transaction_fees", mock.Anything).Return().Once()
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_transaction_fees").Return().Once()
+		t.Cleanup(func() { mockMetricsService.AssertExpectations(t) })
+		return &TransactionFeeModel{DB: dbConnectionPool, MetricsService: mockMetricsService}
+	}
+
+	t.Run("all_accounts", func(t *testing.T) {
+		summaries, err := newModel(t).GetFeeSummaries(ctx, "", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []FeeSummary{
+			{Account: account, TransactionCount: 3, FeeBumpedCount: 1, FailedCount: 1, FeeCharged: 700},
+			{Account: otherAccount, TransactionCount: 1, FeeCharged: 50},
+		}, summaries)
+	})
+
+	t.Run("account_and_range", func(t *testing.T) {
+		from, to := day(2), day(3)
+		summaries, err := newModel(t).GetFeeSummaries(ctx, account, &from, &to)
+		require.NoError(t, err)
+		assert.Equal(t, []FeeSummary{
+			{Account: account, TransactionCount: 1, FeeBumpedCount: 1, FailedCount: 1, FeeCharged: 200},
+		}, summaries)
+	})
+
+	t.Run("no_fees", func(t *testing.T) {
+		summaries, err := newModel(t).GetFeeSummaries(ctx, keypair.MustRandom().Address(), nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, summaries)
+	})
+}
@@ -0,0 +1,124 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+type TransactionModel struct {
+	DB             db.ConnectionPool
+	MetricsService metrics.MetricsService
+}
+
+type Transaction struct {
+	TransactionID   string          `db:"transaction_id" json:"transactionId"`
+	TransactionHash string          `db:"transaction_hash" json:"transactionHash"`
+	LedgerNumber    uint32          `db:"ledger_number" json:"ledgerNumber"`
+	SourceAccount   string          `db:"source_account" json:"sourceAccount"`
+	Memo            *string         `db:"memo" json:"memo"`
+	MemoType        string          `db:"memo_type" json:"memoType"`
+	Details         json.RawMessage `db:"details" json:"details"`
+	Participants    pq.StringArray  `db:"participants" json:"-"`
+	CreatedAt       time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// BatchAddTransactions upserts the given transactions in a single round trip. Only transactions with at least one
+// registered account among their participants are stored.
+func (m *TransactionModel) BatchAddTransactions(ctx context.Context, tx db.Transaction, transactions []Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	const query = `
+		INSERT INTO ingest_transactions (
+			transaction_id, transaction_hash, ledger_number, source_account, memo, memo_type, details, participants, created_at
+		)
+		SELECT
+			t.transaction_id, t.transaction_hash, t.ledger_number, t.source_account, t.memo, t.memo_type, t.details,
+			string_to_array(t.participants, ','), t.created_at
+		FROM UNNEST(
+			$1::bigint[], $2::text[], $3::integer[], $4::text[], $5::text[], $6::text[], $7::jsonb[], $8::text[], $9::timestamptz[]
+		) AS t(
+			transaction_id, transaction_hash, ledger_number, source_account, memo, memo_type, details, participants, created_at
+		)
+		WHERE EXISTS (
+			SELECT 1 FROM accounts WHERE stellar_address = ANY(string_to_array(t.participants, ','))
+		)
+		ON CONFLICT (transaction_id) DO UPDATE SET
+			transaction_hash = EXCLUDED.transaction_hash,
+			ledger_number = EXCLUDED.ledger_number,
+			source_account = EXCLUDED.source_account,
+			memo = EXCLUDED.memo,
+			memo_type = EXCLUDED.memo_type,
+			details = EXCLUDED.details,
+			participants = EXCLUDED.participants,
+			created_at = EXCLUDED.created_at
+		;
+	`
+
+	n := len(transactions)
+	var (
+		transactionIDs    = make([]string, n)
+		transactionHashes = make([]string, n)
+		ledgerNumbers     = make([]int64, n)
+		sourceAccounts    = make([]string, n)
+		memos             = make([]*string, n)
+		memoTypes         = make([]string, n)
+		details           = make([]string, n)
+		participants      = make([]string, n)
+		createdAts        = make([]string, n)
+	)
+	for i, transaction := range transactions {
+		transactionIDs[i] = transaction.TransactionID
+		transactionHashes[i] = transaction.TransactionHash
+		ledgerNumbers[i] = int64(transaction.LedgerNumber)
+		sourceAccounts[i] = transaction.SourceAccount
+		memos[i] = transaction.Memo
+		memoTypes[i] = transaction.MemoType
+		details[i] = string(transaction.Details)
+		// participants are flattened since UNNEST can't return a different array per row
+		participants[i] = strings.Join(transaction.Participants, ",")
+		createdAts[i] = transaction.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	start := time.Now()
+	_, err := tx.ExecContext(ctx, query,
+		pq.Array(transactionIDs), pq.Array(transactionHashes), pq.Array(ledgerNumbers), pq.Array(sourceAccounts), pq.Array(memos),
+		pq.Array(memoTypes), pq.Array(details), pq.Array(participants), pq.Array(createdAts))
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("INSERT", "ingest_transactions", duration)
+	if err != nil {
+		return fmt.Errorf("batch inserting %d transactions: %w", n, err)
+	}
+	m.MetricsService.IncDBQuery("INSERT", "ingest_transactions")
+	return nil
+}
+
+// GetByHash returns the ingested transaction with the hash, or ErrTransactionNotFound.
+func (m *TransactionModel) GetByHash(ctx context.Context, hash string) (*Transaction, error) {
+	var transaction Transaction
+	start := time.Now()
+	err := m.DB.GetContext(ctx, &transaction, `SELECT * FROM ingest_transactions WHERE transaction_hash = $1 LIMIT 1`, hash)
+	duration := time.Since(start).Seconds()
+	m.MetricsService.ObserveDBQueryDuration("SELECT", "ingest_transactions", duration)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("getting transaction %s: %w", hash, err)
+	}
+	m.MetricsService.IncDBQuery("SELECT", "ingest_transactions")
+	return &transaction, nil
+}
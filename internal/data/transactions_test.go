@@ -0,0 +1,83 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+func TestTransactionModelBatchAddTransactions(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_transactions", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_transactions").Return()
+	defer mockMetricsService.AssertExpectations(t)
+
+	m := &TransactionModel{
+		DB:             dbConnectionPool,
+		MetricsService: mockMetricsService,
+	}
+	ctx := context.Background()
+
+	registeredAccount := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `INSERT INTO accounts (stellar_address) VALUES ($1)`, registeredAccount)
+	require.NoError(t, err)
+
+	sourceAccount := keypair.MustRandom().Address()
+	transactions := []Transaction{
+		{
+			TransactionID:   "2120562792996864",
+			TransactionHash: "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			LedgerNumber:    493733,
+			SourceAccount:   sourceAccount,
+			Memo:            utils.PointOf("memo"),
+			MemoType:        xdr.MemoTypeMemoText.String(),
+			Details:         json.RawMessage(`{"feeBump": false, "feeCharged": 100}`),
+			Participants:    []string{sourceAccount, registeredAccount},
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			TransactionID:   "2120562792996865",
+			TransactionHash: "b3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887",
+			LedgerNumber:    493733,
+			SourceAccount:   sourceAccount,
+			MemoType:        xdr.MemoTypeMemoNone.String(),
+			Details:         json.RawMessage(`{}`),
+			Participants:    []string{sourceAccount},
+			CreatedAt:       time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+		return m.BatchAddTransactions(ctx, dbTx, transactions)
+	})
+	require.NoError(t, err)
+
+	var dbTransactions []Transaction
+	err = dbConnectionPool.SelectContext(ctx, &dbTransactions, `SELECT * FROM ingest_transactions ORDER BY transaction_id`)
+	require.NoError(t, err)
+	require.Len(t, dbTransactions, 1)
+
+	expected := transactions[0]
+	assert.JSONEq(t, string(expected.Details), string(dbTransactions[0].Details))
+	expected.Details = dbTransactions[0].Details
+	assert.Equal(t, expected, dbTransactions[0])
+}
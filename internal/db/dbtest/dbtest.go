@@ -11,7 +11,7 @@ import (
 	"github.com/stellar/wallet-backend/internal/db/migrations"
 )
 
-func Open(t *testing.T) *dbtest.DB {
+func Open(t testing.TB) *dbtest.DB {
 	db := dbtest.Postgres(t)
 	conn := db.Open()
 	defer conn.Close()
@@ -26,7 +26,7 @@ func Open(t *testing.T) *dbtest.DB {
 	return db
 }
 
-func OpenWithoutMigrations(t *testing.T) *dbtest.DB {
+func OpenWithoutMigrations(t testing.TB) *dbtest.DB {
 	db := dbtest.Postgres(t)
 	return db
 }
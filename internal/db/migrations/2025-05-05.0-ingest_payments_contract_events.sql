@@ -0,0 +1,22 @@
+-- +migrate Up
+
+-- The token transfer events of an operation are stored under the operation's ID, event_index tells them apart. It's
+-- the index of the event among the token transfers emitted by the operation, and 0 for the other payments.
+ALTER TABLE ingest_payments
+  ADD COLUMN contract_event_type text NULL,
+  ADD COLUMN event_index integer NOT NULL DEFAULT 0;
+
+ALTER TABLE ingest_payments DROP CONSTRAINT ingest_payments_pkey;
+ALTER TABLE ingest_payments ADD PRIMARY KEY (operation_id, event_index);
+
+-- +migrate Down
+
+-- the token transfers after the first one of each operation can't be keyed by the operation ID alone
+DELETE FROM ingest_payments WHERE event_index > 0;
+
+ALTER TABLE ingest_payments DROP CONSTRAINT ingest_payments_pkey;
+ALTER TABLE ingest_payments ADD PRIMARY KEY (operation_id);
+
+ALTER TABLE ingest_payments
+  DROP COLUMN contract_event_type,
+  DROP COLUMN event_index;
@@ -0,0 +1,38 @@
+-- +migrate Up
+
+CREATE TABLE ingest_transactions (
+  transaction_id bigint NOT NULL,
+  transaction_hash text NOT NULL,
+  ledger_number integer NOT NULL,
+  source_account text NOT NULL,
+  memo text NULL,
+  memo_type text NULL,
+  details jsonb NOT NULL,
+  participants text[] NOT NULL,
+  created_at timestamp with time zone NOT NULL,
+  PRIMARY KEY (transaction_id)
+);
+
+CREATE INDEX ingest_transactions_transaction_hash_idx ON ingest_transactions (transaction_hash);
+CREATE INDEX ingest_transactions_participants_idx ON ingest_transactions USING GIN (participants);
+
+CREATE TABLE ingest_operations (
+  operation_id bigint NOT NULL,
+  operation_type text NOT NULL,
+  transaction_id bigint NOT NULL,
+  transaction_hash text NOT NULL,
+  source_account text NOT NULL,
+  details jsonb NOT NULL,
+  participants text[] NOT NULL,
+  created_at timestamp with time zone NOT NULL,
+  PRIMARY KEY (operation_id)
+);
+
+CREATE INDEX ingest_operations_transaction_id_idx ON ingest_operations (transaction_id);
+CREATE INDEX ingest_operations_participants_idx ON ingest_operations USING GIN (participants);
+
+-- +migrate Down
+
+DROP TABLE ingest_operations;
+
+DROP TABLE ingest_transactions;
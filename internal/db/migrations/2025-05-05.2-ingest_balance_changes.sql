@@ -0,0 +1,19 @@
+-- +migrate Up
+
+CREATE TABLE ingest_balance_changes (
+  account text NOT NULL,
+  operation_id bigint NOT NULL,
+  asset text NOT NULL,
+  transaction_id bigint NOT NULL,
+  transaction_hash text NOT NULL,
+  ledger_number integer NOT NULL,
+  reason text NOT NULL,
+  amount bigint NOT NULL,
+  balance bigint NULL,
+  created_at timestamp with time zone NOT NULL,
+  PRIMARY KEY (account, operation_id, asset)
+);
+
+-- +migrate Down
+
+DROP TABLE ingest_balance_changes;
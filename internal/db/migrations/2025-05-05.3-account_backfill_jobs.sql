@@ -0,0 +1,20 @@
+-- +migrate Up
+
+CREATE TABLE account_backfill_jobs (
+  id bigserial PRIMARY KEY,
+  stellar_address text NOT NULL,
+  status text NOT NULL DEFAULT 'pending',
+  start_ledger integer NULL,
+  end_ledger integer NULL,
+  last_ledger integer NULL,
+  error text NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX account_backfill_jobs_stellar_address_idx ON account_backfill_jobs (stellar_address, id);
+CREATE INDEX account_backfill_jobs_unfinished_idx ON account_backfill_jobs (id) WHERE status IN ('pending', 'running');
+
+-- +migrate Down
+
+DROP TABLE account_backfill_jobs;
@@ -0,0 +1,17 @@
+-- +migrate Up
+
+CREATE TABLE ingested_ledger_ranges (
+  id bigserial PRIMARY KEY,
+  source text NOT NULL,
+  start_ledger integer NOT NULL,
+  end_ledger integer NOT NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ingested_ledger_ranges_source_end_ledger_idx ON ingested_ledger_ranges (source, end_ledger);
+CREATE INDEX ingested_ledger_ranges_start_ledger_idx ON ingested_ledger_ranges (start_ledger, end_ledger);
+
+-- +migrate Down
+
+DROP TABLE ingested_ledger_ranges;
@@ -0,0 +1,23 @@
+-- +migrate Up
+
+-- from_address and to_address always hold the G-address, muxed accounts also keep their ID and M-address. IDs are
+-- unsigned 64 bits integers, which don't fit in a bigint.
+ALTER TABLE ingest_payments
+  ADD COLUMN from_muxed_id numeric(20, 0) NULL,
+  ADD COLUMN from_muxed_address text NULL,
+  ADD COLUMN to_muxed_id numeric(20, 0) NULL,
+  ADD COLUMN to_muxed_address text NULL;
+
+CREATE INDEX ingest_payments_from_muxed_idx ON ingest_payments (from_address, from_muxed_id) WHERE from_muxed_id IS NOT NULL;
+CREATE INDEX ingest_payments_to_muxed_idx ON ingest_payments (to_address, to_muxed_id) WHERE to_muxed_id IS NOT NULL;
+
+-- +migrate Down
+
+DROP INDEX ingest_payments_to_muxed_idx;
+DROP INDEX ingest_payments_from_muxed_idx;
+
+ALTER TABLE ingest_payments
+  DROP COLUMN to_muxed_address,
+  DROP COLUMN to_muxed_id,
+  DROP COLUMN from_muxed_address,
+  DROP COLUMN from_muxed_id;
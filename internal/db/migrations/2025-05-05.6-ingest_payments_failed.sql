@@ -0,0 +1,17 @@
+-- +migrate Up
+
+-- payments of failed transactions are stored along with the result codes that made them fail, the operation code is
+-- NULL when the transaction failed before its operations were applied.
+ALTER TABLE ingest_payments
+  ADD COLUMN successful boolean NOT NULL DEFAULT TRUE,
+  ADD COLUMN transaction_result_code text NULL,
+  ADD COLUMN operation_result_code text NULL;
+
+-- +migrate Down
+
+DELETE FROM ingest_payments WHERE NOT successful;
+
+ALTER TABLE ingest_payments
+  DROP COLUMN operation_result_code,
+  DROP COLUMN transaction_result_code,
+  DROP COLUMN successful;
@@ -0,0 +1,24 @@
+-- +migrate Up
+
+-- fee_account is the account charged for the transaction, the fee bump account of fee bumped transactions and the
+-- source account otherwise.
+CREATE TABLE ingest_transaction_fees (
+  transaction_id bigint NOT NULL,
+  transaction_hash text NOT NULL,
+  ledger_number integer NOT NULL,
+  source_account text NOT NULL,
+  fee_account text NOT NULL,
+  fee_bumped boolean NOT NULL,
+  fee_charged bigint NOT NULL,
+  max_fee bigint NOT NULL,
+  successful boolean NOT NULL,
+  created_at timestamp with time zone NOT NULL,
+  PRIMARY KEY (transaction_id)
+);
+
+CREATE INDEX ingest_transaction_fees_fee_account_created_at_idx ON ingest_transaction_fees (fee_account, created_at);
+CREATE INDEX ingest_transaction_fees_created_at_idx ON ingest_transaction_fees (created_at);
+
+-- +migrate Down
+
+DROP TABLE ingest_transaction_fees;
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- Used to prune the payments older than the retention.
+CREATE INDEX ingest_payments_created_at_idx ON ingest_payments (created_at);
+
+-- +migrate Down
+
+DROP INDEX ingest_payments_created_at_idx;
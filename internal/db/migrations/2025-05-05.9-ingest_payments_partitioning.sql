@@ -0,0 +1,50 @@
+-- +migrate Up
+
+-- ingest_payments is range partitioned on operation_id, each partition holding the payments of 500,000 ledgers (see
+-- data.PaymentsPartitionLedgers). The ledger of a payment is operation_id >> 32. Payments without a partition land in
+-- ingest_payments_default, and are moved out of it when their partition is created.
+ALTER TABLE ingest_payments RENAME TO ingest_payments_unpartitioned;
+
+CREATE TABLE ingest_payments (LIKE ingest_payments_unpartitioned INCLUDING DEFAULTS) PARTITION BY RANGE (operation_id);
+CREATE TABLE ingest_payments_default PARTITION OF ingest_payments DEFAULT;
+
+-- +migrate StatementBegin
+DO $$
+DECLARE
+  start_ledger bigint;
+BEGIN
+  FOR start_ledger IN
+    SELECT DISTINCT (operation_id >> 32) / 500000 * 500000 FROM ingest_payments_unpartitioned
+  LOOP
+    EXECUTE format(
+      'CREATE TABLE %I PARTITION OF ingest_payments FOR VALUES FROM (%s) TO (%s)',
+      'ingest_payments_ledger_' || start_ledger, start_ledger << 32, (start_ledger + 500000) << 32
+    );
+  END LOOP;
+END $$;
+-- +migrate StatementEnd
+
+INSERT INTO ingest_payments SELECT * FROM ingest_payments_unpartitioned;
+DROP TABLE ingest_payments_unpartitioned;
+
+ALTER TABLE ingest_payments ADD PRIMARY KEY (operation_id, event_index);
+CREATE INDEX from_address_idx ON ingest_payments (from_address);
+CREATE INDEX to_address_idx ON ingest_payments (to_address);
+CREATE INDEX ingest_payments_from_muxed_idx ON ingest_payments (from_address, from_muxed_id) WHERE from_muxed_id IS NOT NULL;
+CREATE INDEX ingest_payments_to_muxed_idx ON ingest_payments (to_address, to_muxed_id) WHERE to_muxed_id IS NOT NULL;
+CREATE INDEX ingest_payments_created_at_idx ON ingest_payments (created_at);
+
+-- +migrate Down
+
+ALTER TABLE ingest_payments RENAME TO ingest_payments_partitioned;
+
+CREATE TABLE ingest_payments (LIKE ingest_payments_partitioned INCLUDING DEFAULTS);
+INSERT INTO ingest_payments SELECT * FROM ingest_payments_partitioned;
+DROP TABLE ingest_payments_partitioned;
+
+ALTER TABLE ingest_payments ADD PRIMARY KEY (operation_id, event_index);
+CREATE INDEX from_address_idx ON ingest_payments (from_address);
+CREATE INDEX to_address_idx ON ingest_payments (to_address);
+CREATE INDEX ingest_payments_from_muxed_idx ON ingest_payments (from_address, from_muxed_id) WHERE from_muxed_id IS NOT NULL;
+CREATE INDEX ingest_payments_to_muxed_idx ON ingest_payments (to_address, to_muxed_id) WHERE to_muxed_id IS NOT NULL;
+CREATE INDEX ingest_payments_created_at_idx ON ingest_payments (created_at);
@@ -0,0 +1,11 @@
+-- +migrate Up
+
+CREATE INDEX ingest_payments_src_asset_idx ON ingest_payments (src_asset_code, src_asset_issuer);
+CREATE INDEX ingest_payments_dest_asset_idx ON ingest_payments (dest_asset_code, dest_asset_issuer);
+CREATE INDEX ingest_payments_operation_type_idx ON ingest_payments (operation_type);
+
+-- +migrate Down
+
+DROP INDEX ingest_payments_operation_type_idx;
+DROP INDEX ingest_payments_dest_asset_idx;
+DROP INDEX ingest_payments_src_asset_idx;
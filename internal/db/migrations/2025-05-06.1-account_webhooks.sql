@@ -0,0 +1,41 @@
+-- +migrate Up
+
+CREATE TABLE account_webhooks (
+  id bigserial PRIMARY KEY,
+  url text NOT NULL,
+  created_at timestamp with time zone NOT NULL DEFAULT NOW()
+);
+
+-- a webhook is dropped from the addresses that are deregistered
+CREATE TABLE account_webhook_addresses (
+  webhook_id bigint NOT NULL REFERENCES account_webhooks (id) ON DELETE CASCADE,
+  stellar_address text NOT NULL REFERENCES accounts (stellar_address) ON DELETE CASCADE,
+  PRIMARY KEY (webhook_id, stellar_address)
+);
+
+CREATE INDEX account_webhook_addresses_stellar_address_idx ON account_webhook_addresses (stellar_address);
+
+-- each event is delivered once per webhook, even when it involves several of its addresses
+CREATE TABLE account_webhook_deliveries (
+  id bigserial PRIMARY KEY,
+  webhook_id bigint NOT NULL REFERENCES account_webhooks (id) ON DELETE CASCADE,
+  event_type text NOT NULL,
+  event_id text NOT NULL,
+  payload jsonb NOT NULL,
+  status text NOT NULL DEFAULT 'pending',
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text NULL,
+  next_attempt_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  created_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp with time zone NOT NULL DEFAULT NOW(),
+  UNIQUE (webhook_id, event_type, event_id)
+);
+
+CREATE INDEX account_webhook_deliveries_due_idx ON account_webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX account_webhook_deliveries_webhook_id_idx ON account_webhook_deliveries (webhook_id, id);
+
+-- +migrate Down
+
+DROP TABLE account_webhook_deliveries;
+DROP TABLE account_webhook_addresses;
+DROP TABLE account_webhooks;
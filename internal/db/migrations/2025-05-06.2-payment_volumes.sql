@@ -0,0 +1,34 @@
+-- +migrate Up
+
+-- payment_volumes rolls the successful payments up per account, UTC day and asset, the source side of the payments
+-- counting as sent by from_address and the destination side as received by to_address. Amounts are numeric since
+-- they may not fit in a bigint once summed.
+CREATE TABLE payment_volumes (
+  account_address text NOT NULL,
+  day date NOT NULL,
+  asset_code text NOT NULL,
+  asset_issuer text NOT NULL,
+  sent_amount numeric NOT NULL DEFAULT 0,
+  sent_count bigint NOT NULL DEFAULT 0,
+  received_amount numeric NOT NULL DEFAULT 0,
+  received_count bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (account_address, day, asset_code, asset_issuer)
+);
+
+CREATE INDEX payment_volumes_day_idx ON payment_volumes (day);
+
+INSERT INTO payment_volumes (account_address, day, asset_code, asset_issuer, sent_amount, sent_count, received_amount, received_count)
+SELECT account_address, day, asset_code, asset_issuer, SUM(sent_amount), SUM(sent_count), SUM(received_amount), SUM(received_count)
+FROM (
+  SELECT from_address AS account_address, (created_at AT TIME ZONE 'UTC')::date AS day, src_asset_code AS asset_code,
+    src_asset_issuer AS asset_issuer, src_amount AS sent_amount, 1 AS sent_count, 0 AS received_amount, 0 AS received_count
+  FROM ingest_payments WHERE successful
+  UNION ALL
+  SELECT to_address, (created_at AT TIME ZONE 'UTC')::date, dest_asset_code, dest_asset_issuer, 0, 0, dest_amount, 1
+  FROM ingest_payments WHERE successful
+) AS sides
+GROUP BY account_address, day, asset_code, asset_issuer;
+
+-- +migrate Down
+
+DROP TABLE payment_volumes;
@@ -0,0 +1,11 @@
+-- +migrate Up
+
+CREATE INDEX ingest_payments_transaction_hash_idx ON ingest_payments (transaction_hash);
+CREATE INDEX ingest_payments_memo_idx ON ingest_payments (memo, memo_type) WHERE memo IS NOT NULL;
+CREATE INDEX ingest_operations_transaction_hash_idx ON ingest_operations (transaction_hash);
+
+-- +migrate Down
+
+DROP INDEX ingest_operations_transaction_hash_idx;
+DROP INDEX ingest_payments_memo_idx;
+DROP INDEX ingest_payments_transaction_hash_idx;
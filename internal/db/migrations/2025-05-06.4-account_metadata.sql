@@ -0,0 +1,19 @@
+-- +migrate Up
+
+ALTER TABLE accounts
+  ADD COLUMN external_user_id text,
+  ADD COLUMN labels text[] NOT NULL DEFAULT '{}',
+  ADD COLUMN metadata jsonb NOT NULL DEFAULT '{}';
+
+CREATE INDEX accounts_external_user_id_idx ON accounts (external_user_id) WHERE external_user_id IS NOT NULL;
+CREATE INDEX accounts_labels_idx ON accounts USING GIN (labels);
+
+-- +migrate Down
+
+DROP INDEX accounts_labels_idx;
+DROP INDEX accounts_external_user_id_idx;
+
+ALTER TABLE accounts
+  DROP COLUMN metadata,
+  DROP COLUMN labels,
+  DROP COLUMN external_user_id;
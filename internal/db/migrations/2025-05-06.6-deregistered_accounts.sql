@@ -0,0 +1,13 @@
+-- +migrate Up
+
+-- deregistered_accounts keeps when each account was last deregistered, the retention of the payments of unregistered
+-- accounts is measured from it.
+CREATE TABLE deregistered_accounts (
+  stellar_address text NOT NULL,
+  deregistered_at timestamptz NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (stellar_address)
+);
+
+-- +migrate Down
+
+DROP TABLE deregistered_accounts;
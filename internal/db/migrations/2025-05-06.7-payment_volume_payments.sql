@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- payment_volume_payments keeps the keys of the payments rolled up into payment_volumes, so a ledger reingested after
+-- its payments were pruned doesn't count them twice. The keys are pruned with the payments retention, and the payments
+-- created before the pruned ones, kept in ingest_store, are never rolled up again.
+CREATE TABLE payment_volume_payments (
+  operation_id bigint NOT NULL,
+  event_index integer NOT NULL,
+  created_at timestamptz NOT NULL,
+  PRIMARY KEY (operation_id, event_index)
+);
+
+CREATE INDEX payment_volume_payments_created_at_idx ON payment_volume_payments (created_at);
+
+INSERT INTO payment_volume_payments (operation_id, event_index, created_at)
+SELECT operation_id, event_index, created_at FROM ingest_payments WHERE successful;
+
+-- +migrate Down
+
+DELETE FROM ingest_store WHERE key = 'payment_volume_payments_pruned_before';
+DROP TABLE payment_volume_payments;
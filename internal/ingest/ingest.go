@@ -33,16 +33,52 @@ type Configs struct {
 	WebhookChannelMaxRetries      int
 	WebhookChannelWaitBtwnTriesMS int
 	WebhookChannel                tss.Channel
+	BackfillWorkers               int
+	BackfillChunkSize             int
+	// LedgerBackend is where ledgers are read from, either "rpc" or "files".
+	LedgerBackend     string
+	LedgerFilesDir    string
+	LedgersPerFile    int
+	FilesPerPartition int
+	NetworkPassphrase string
+	// LedgerProcessors are the names of the processors run on every ingested ledger, in order.
+	LedgerProcessors []string
+	// DistributionAccountPublicKey is optional, the fees it pays are ingested when it's set.
+	DistributionAccountPublicKey string
+	// Retention is how long the rows of each table are kept, the ingestion prunes older rows every PruneInterval in
+	// batches of PruneBatchSize.
+	Retention      services.RetentionPolicy
+	PruneInterval  time.Duration
+	PruneBatchSize int
+	// PaymentPartitionsAhead is the number of ingest_payments partitions created ahead of the live ledger, and
+	// PaymentPartitionsRetained the number of recent partitions kept attached, or all of them when it's zero.
+	PaymentPartitionsAhead    int
+	PaymentPartitionsRetained int
 }
 
+const (
+	RPCLedgerBackend   = "rpc"
+	FilesLedgerBackend = "files"
+	// leaderElectionPollInterval is how often standbys try to take the lock over and the leader checks it still holds it.
+	leaderElectionPollInterval = 5 * time.Second
+	// paymentPartitionsInterval is how often ingest_payments partitions are created ahead and detached.
+	paymentPartitionsInterval = time.Hour
+)
+
 func Ingest(cfg Configs) error {
 	ctx := context.Background()
 
-	ingestService, err := setupDeps(cfg)
+	ingestService, maintenance, err := setupDeps(cfg, true)
 	if err != nil {
 		log.Ctx(ctx).Fatalf("Error setting up dependencies for ingest: %v", err)
 	}
 
+	// Account backfills and webhook deliveries run on every replica, which spreads them out. It's safe since both are
+	// claimed with FOR UPDATE SKIP LOCKED under a lease, so a job or delivery is only picked up by another replica once
+	// its lease expires. The other maintenance jobs only run on the leader.
+	go ingestService.RunAccountBackfills(ctx)
+	go maintenance.accountWebhookDispatcher.Run(ctx)
+
 	if err = ingestService.Run(ctx, uint32(cfg.StartLedger), uint32(cfg.EndLedger)); err != nil {
 		log.Ctx(ctx).Fatalf("Running ingest from %d to %d: %v", cfg.StartLedger, cfg.EndLedger, err)
 	}
@@ -50,28 +86,148 @@ func Ingest(cfg Configs) error {
 	return nil
 }
 
-func setupDeps(cfg Configs) (services.IngestService, error) {
+// Backfill ingests the [cfg.StartLedger, cfg.EndLedger] range in parallel. It can run alongside Ingest since it keeps
+// its own per-chunk cursors and never updates cfg.LedgerCursorName.
+func Backfill(cfg Configs) error {
+	ctx := context.Background()
+
+	ingestService, _, err := setupDeps(cfg, false)
+	if err != nil {
+		log.Ctx(ctx).Fatalf("Error setting up dependencies for backfill: %v", err)
+	}
+
+	if err = ingestService.Backfill(ctx, uint32(cfg.StartLedger), uint32(cfg.EndLedger), cfg.BackfillWorkers, cfg.BackfillChunkSize); err != nil {
+		log.Ctx(ctx).Fatalf("Running backfill from %d to %d: %v", cfg.StartLedger, cfg.EndLedger, err)
+	}
+
+	return nil
+}
+
+// Verify logs the gaps and overlaps between the ledger ranges ingested within [cfg.StartLedger, cfg.EndLedger] and
+// returns an error when there are gaps, so it can be used to alert on them. Like Backfill, it can run alongside Ingest.
+func Verify(cfg Configs) error {
+	ctx := context.Background()
+
+	ingestService, _, err := setupDeps(cfg, false)
+	if err != nil {
+		log.Ctx(ctx).Fatalf("Error setting up dependencies for verify: %v", err)
+	}
+
+	report, err := ingestService.VerifyIngestedRanges(ctx, uint32(cfg.StartLedger), uint32(cfg.EndLedger))
+	if err != nil {
+		return fmt.Errorf("verifying ingested ranges: %w", err)
+	}
+	logIngestedRangesReport(ctx, report)
+	if len(report.Gaps) > 0 {
+		return fmt.Errorf("found %d gaps between ledgers %d and %d, run ingest repair to fill them", len(report.Gaps), report.StartLedger, report.EndLedger)
+	}
+	return nil
+}
+
+// Repair backfills the gaps between the ledger ranges ingested within [cfg.StartLedger, cfg.EndLedger]. Like Backfill,
+// it can run alongside Ingest.
+func Repair(cfg Configs) error {
+	ctx := context.Background()
+
+	ingestService, _, err := setupDeps(cfg, false)
+	if err != nil {
+		log.Ctx(ctx).Fatalf("Error setting up dependencies for repair: %v", err)
+	}
+
+	report, err := ingestService.RepairIngestedRanges(ctx, uint32(cfg.StartLedger), uint32(cfg.EndLedger), cfg.BackfillWorkers, cfg.BackfillChunkSize)
+	if err != nil {
+		return fmt.Errorf("repairing ingested ranges: %w", err)
+	}
+	log.Ctx(ctx).Infof("repaired %d gaps between ledgers %d and %d", len(report.Gaps), report.StartLedger, report.EndLedger)
+	return nil
+}
+
+// Prune deletes the rows older than cfg.Retention once.
+func Prune(cfg Configs) error {
+	ctx := context.Background()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("connecting to the database: %w", err)
+	}
+	defer dbConnectionPool.Close()
+	sqlxDB, err := dbConnectionPool.SqlxDB(ctx)
+	if err != nil {
+		return fmt.Errorf("getting sqlx db: %w", err)
+	}
+	metricsService := metrics.NewMetricsService(sqlxDB)
+	models, err := data.NewModels(dbConnectionPool, metricsService)
+	if err != nil {
+		return fmt.Errorf("creating models: %w", err)
+	}
+	tssStore, err := tssstore.NewStore(dbConnectionPool, metricsService)
+	if err != nil {
+		return fmt.Errorf("instantiating tss store: %w", err)
+	}
+	pruner, err := services.NewPruner(models, tssStore, cfg.Retention, cfg.PruneBatchSize, metricsService)
+	if err != nil {
+		return fmt.Errorf("instantiating pruner: %w", err)
+	}
+	if !pruner.Enabled() {
+		log.Ctx(ctx).Warn("no retention was configured, nothing to prune")
+		return nil
+	}
+
+	results, err := pruner.Prune(ctx)
+	for _, result := range results {
+		log.Ctx(ctx).Infof("pruned %d rows from %s", result.Deleted, result.Table)
+	}
+	if err != nil {
+		return fmt.Errorf("pruning: %w", err)
+	}
+	return nil
+}
+
+func logIngestedRangesReport(ctx context.Context, report *services.IngestedRangesReport) {
+	log.Ctx(ctx).Infof("verified ingested ledgers %d to %d: %d gaps, %d overlaps", report.StartLedger, report.EndLedger, len(report.Gaps), len(report.Overlaps))
+	for _, gap := range report.Gaps {
+		log.Ctx(ctx).Warnf("gap: ledgers %s were never ingested", gap)
+	}
+	for _, overlap := range report.Overlaps {
+		log.Ctx(ctx).Infof("overlap: ledgers %s were ingested more than once", overlap)
+	}
+}
+
+// maintenance are the background jobs of the live ingestion run on every replica.
+type maintenance struct {
+	accountWebhookDispatcher *services.AccountWebhookDispatcher
+}
+
+// setupDeps wires the ingest service. The live ingestion elects a leader, so replicas sharing cfg.LedgerCursorName only
+// ingest while holding its lock, gets its maintenance jobs and serves the metrics. The pruner and the payment
+// partitions only run on the leader, their DDL and deletes would otherwise race between replicas. The maintenance jobs
+// are nil when the ingestion isn't live.
+func setupDeps(cfg Configs, live bool) (services.IngestService, *maintenance, error) {
 	dbConnectionPool, err := db.OpenDBConnectionPool(cfg.DatabaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("connecting to the database: %w", err)
+		return nil, nil, fmt.Errorf("connecting to the database: %w", err)
 	}
 	db, err := dbConnectionPool.SqlxDB(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("getting sqlx db: %w", err)
+		return nil, nil, fmt.Errorf("getting sqlx db: %w", err)
 	}
 	metricsService := metrics.NewMetricsService(db)
 	models, err := data.NewModels(dbConnectionPool, metricsService)
 	if err != nil {
-		return nil, fmt.Errorf("creating models: %w", err)
+		return nil, nil, fmt.Errorf("creating models: %w", err)
 	}
 	httpClient := &http.Client{Timeout: 30 * time.Second}
 	rpcService, err := services.NewRPCService(cfg.RPCURL, httpClient, metricsService)
 	if err != nil {
-		return nil, fmt.Errorf("instantiating rpc service: %w", err)
+		return nil, nil, fmt.Errorf("instantiating rpc service: %w", err)
+	}
+	ledgerBackend, err := newLedgerBackend(cfg, rpcService)
+	if err != nil {
+		return nil, nil, fmt.Errorf("instantiating ledger backend: %w", err)
 	}
 	tssStore, err := tssstore.NewStore(dbConnectionPool, metricsService)
 	if err != nil {
-		return nil, fmt.Errorf("instantiating tss store: %w", err)
+		return nil, nil, fmt.Errorf("instantiating tss store: %w", err)
 	}
 	tssRouterConfig := tssrouter.RouterConfigs{
 		WebhookChannel: cfg.WebhookChannel,
@@ -79,19 +235,86 @@ func setupDeps(cfg Configs) (services.IngestService, error) {
 
 	router := tssrouter.NewRouter(tssRouterConfig)
 
+	var leaderElection *services.LeaderElection
+	if live {
+		leaderElection, err = services.NewLeaderElection(dbConnectionPool, cfg.LedgerCursorName, leaderElectionPollInterval, metricsService)
+		if err != nil {
+			return nil, nil, fmt.Errorf("instantiating leader election: %w", err)
+		}
+	}
+
+	// the payments processor and the job maintaining the partitions share the partitions known to be attached
+	paymentPartitions, err := services.NewPaymentPartitions(models)
+	if err != nil {
+		return nil, nil, fmt.Errorf("instantiating payment partitions: %w", err)
+	}
 	ingestService, err := services.NewIngestService(
-		models, cfg.LedgerCursorName, cfg.AppTracker, rpcService, router, tssStore, metricsService)
+		models, cfg.LedgerCursorName, cfg.AppTracker, rpcService, ledgerBackend, router, tssStore, leaderElection, paymentPartitions, cfg.LedgerProcessors, cfg.DistributionAccountPublicKey, metricsService)
 	if err != nil {
-		return nil, fmt.Errorf("instantiating ingest service: %w", err)
+		return nil, nil, fmt.Errorf("instantiating ingest service: %w", err)
 	}
 
-	http.Handle("/ingest-metrics", promhttp.HandlerFor(metricsService.GetRegistry(), promhttp.HandlerOpts{}))
-	go func() {
-		err := http.ListenAndServe(":8002", nil)
+	var jobs *maintenance
+	if live {
+		pruner, err := services.NewPruner(models, tssStore, cfg.Retention, cfg.PruneBatchSize, metricsService)
 		if err != nil {
-			log.Ctx(context.Background()).Fatalf("starting ingest metrics server: %v", err)
+			return nil, nil, fmt.Errorf("instantiating pruner: %w", err)
 		}
-	}()
+		if pruner.Enabled() && cfg.PruneInterval <= 0 {
+			return nil, nil, fmt.Errorf("prune interval must be greater than zero")
+		}
+		accountWebhookDispatcher, err := services.NewAccountWebhookDispatcher(services.AccountWebhookDispatcherConfigs{
+			Models:               models,
+			HTTPClient:           &http.Client{Timeout: 30 * time.Second},
+			RequestTimeout:       30 * time.Second,
+			MaxRetries:           cfg.WebhookChannelMaxRetries,
+			MinWaitBtwnRetriesMS: cfg.WebhookChannelWaitBtwnTriesMS,
+			MaxBufferSize:        cfg.WebhookChannelMaxBufferSize,
+			MaxWorkers:           cfg.WebhookChannelMaxWorkers,
+			MetricsService:       metricsService,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("instantiating account webhook dispatcher: %w", err)
+		}
+		jobs = &maintenance{accountWebhookDispatcher: accountWebhookDispatcher}
 
-	return ingestService, nil
+		leaderElection.RunWhileLeading(func(ctx context.Context) {
+			paymentPartitions.Run(ctx, cfg.LedgerCursorName, paymentPartitionsInterval, cfg.PaymentPartitionsAhead, cfg.PaymentPartitionsRetained)
+		})
+		if pruner.Enabled() {
+			leaderElection.RunWhileLeading(func(ctx context.Context) {
+				pruner.Run(ctx, cfg.PruneInterval)
+			})
+		}
+	}
+
+	// Only the live ingestion serves metrics, so the one-off commands can run next to it without fighting over the port.
+	if live {
+		go serveMetrics(metricsService)
+	}
+
+	return ingestService, jobs, nil
+}
+
+func serveMetrics(metricsService metrics.MetricsService) {
+	mux := http.NewServeMux()
+	mux.Handle("/ingest-metrics", promhttp.HandlerFor(metricsService.GetRegistry(), promhttp.HandlerOpts{}))
+	if err := http.ListenAndServe(":8002", mux); err != nil {
+		log.Ctx(context.Background()).Fatalf("starting ingest metrics server: %v", err)
+	}
+}
+
+func newLedgerBackend(cfg Configs, rpcService services.RPCService) (services.LedgerBackend, error) {
+	switch cfg.LedgerBackend {
+	case RPCLedgerBackend:
+		return services.NewRPCLedgerBackend(rpcService)
+	case FilesLedgerBackend:
+		schema := services.LedgerFilesSchema{
+			LedgersPerFile:    uint32(cfg.LedgersPerFile),
+			FilesPerPartition: uint32(cfg.FilesPerPartition),
+		}
+		return services.NewFileLedgerBackend(cfg.LedgerFilesDir, schema, cfg.NetworkPassphrase)
+	default:
+		return nil, fmt.Errorf("unknown ledger backend %q", cfg.LedgerBackend)
+	}
 }
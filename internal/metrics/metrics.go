@@ -16,6 +16,7 @@ type MetricsService interface {
 	SetNumPaymentOpsIngestedPerLedger(operationType string, value int)
 	SetNumTssTransactionsIngestedPerLedger(status string, value float64)
 	SetLatestLedgerIngested(value float64)
+	SetIngestLeader(leader bool)
 	ObserveIngestionDuration(ingestionType string, duration float64)
 	IncNumTSSTransactionsSubmitted()
 	ObserveTSSTransactionInclusionTime(status string, durationSeconds float64)
@@ -33,6 +34,8 @@ type MetricsService interface {
 	IncDBQuery(queryType, table string)
 	RecordTSSTransactionStatusTransition(oldStatus, newStatus string)
 	IncSignatureVerificationExpired(expiredSeconds float64)
+	AddPrunedRows(table string, count int64)
+	ObservePruneDuration(table string, duration float64)
 }
 
 // MetricsService handles all metrics for the wallet-backend
@@ -44,6 +47,7 @@ type metricsService struct {
 	numPaymentOpsIngestedPerLedger      *prometheus.GaugeVec
 	numTssTransactionsIngestedPerLedger *prometheus.GaugeVec
 	latestLedgerIngested                prometheus.Gauge
+	ingestLeader                        prometheus.Gauge
 	ingestionDuration                   *prometheus.SummaryVec
 
 	// TSS Service Metrics
@@ -74,6 +78,10 @@ type metricsService struct {
 
 	// Signature Verification Metrics
 	signatureVerificationExpired *prometheus.CounterVec
+
+	// Pruning Metrics
+	prunedRowsTotal *prometheus.CounterVec
+	pruneDuration   *prometheus.SummaryVec
 }
 
 // NewMetricsService creates a new metrics service with all metrics registered
@@ -104,6 +112,12 @@ func NewMetricsService(db *sqlx.DB) MetricsService {
 			Help: "Latest ledger ingested",
 		},
 	)
+	m.ingestLeader = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ingest_leader",
+			Help: "Ingestion leadership status (1 when this replica holds the ingestion lock, 0 when it's a standby)",
+		},
+	)
 	m.ingestionDuration = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Name:       "ingestion_duration_seconds",
@@ -231,6 +245,23 @@ func NewMetricsService(db *sqlx.DB) MetricsService {
 		[]string{"expired_seconds"},
 	)
 
+	// Pruning Metrics
+	m.prunedRowsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pruned_rows_total",
+			Help: "Total number of rows deleted by the pruner",
+		},
+		[]string{"table"},
+	)
+	m.pruneDuration = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:       "prune_duration_seconds",
+			Help:       "Duration of pruning a table",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+		[]string{"table"},
+	)
+
 	m.registerMetrics()
 	return m
 }
@@ -242,6 +273,7 @@ func (m *metricsService) registerMetrics() {
 		m.numPaymentOpsIngestedPerLedger,
 		m.numTssTransactionsIngestedPerLedger,
 		m.latestLedgerIngested,
+		m.ingestLeader,
 		m.ingestionDuration,
 		m.numTSSTransactionsSubmitted,
 		m.timeUntilTSSTransactionInclusion,
@@ -258,6 +290,8 @@ func (m *metricsService) registerMetrics() {
 		m.dbQueryDuration,
 		m.dbQueriesTotal,
 		m.signatureVerificationExpired,
+		m.prunedRowsTotal,
+		m.pruneDuration,
 	)
 }
 
@@ -359,6 +393,14 @@ func (m *metricsService) SetLatestLedgerIngested(value float64) {
 	m.latestLedgerIngested.Set(value)
 }
 
+func (m *metricsService) SetIngestLeader(leader bool) {
+	if leader {
+		m.ingestLeader.Set(1)
+	} else {
+		m.ingestLeader.Set(0)
+	}
+}
+
 func (m *metricsService) ObserveIngestionDuration(ingestionType string, duration float64) {
 	m.ingestionDuration.WithLabelValues(ingestionType).Observe(duration)
 }
@@ -444,3 +486,12 @@ func (m *metricsService) RecordTSSTransactionStatusTransition(oldStatus, newStat
 func (m *metricsService) IncSignatureVerificationExpired(expiredSeconds float64) {
 	m.signatureVerificationExpired.WithLabelValues(fmt.Sprintf("%fs", expiredSeconds)).Inc()
 }
+
+// Pruning Metrics
+func (m *metricsService) AddPrunedRows(table string, count int64) {
+	m.prunedRowsTotal.WithLabelValues(table).Add(float64(count))
+}
+
+func (m *metricsService) ObservePruneDuration(table string, duration float64) {
+	m.pruneDuration.WithLabelValues(table).Observe(duration)
+}
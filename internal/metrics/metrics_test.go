@@ -67,6 +67,34 @@ func TestIngestMetrics(t *testing.T) {
 		assert.True(t, found)
 	})
 
+	t.Run("ingest leader metrics", func(t *testing.T) {
+		ms.SetIngestLeader(true)
+
+		metricFamilies, err := ms.GetRegistry().Gather()
+		require.NoError(t, err)
+
+		found := false
+		for _, mf := range metricFamilies {
+			if mf.GetName() == "ingest_leader" {
+				found = true
+				metric := mf.GetMetric()[0]
+				assert.Equal(t, float64(1), metric.GetGauge().GetValue())
+			}
+		}
+		assert.True(t, found)
+
+		ms.SetIngestLeader(false)
+		metricFamilies, err = ms.GetRegistry().Gather()
+		require.NoError(t, err)
+
+		for _, mf := range metricFamilies {
+			if mf.GetName() == "ingest_leader" {
+				metric := mf.GetMetric()[0]
+				assert.Equal(t, float64(0), metric.GetGauge().GetValue())
+			}
+		}
+	})
+
 	t.Run("ingestion duration metrics", func(t *testing.T) {
 		ms.ObserveIngestionDuration("payment", 0.5)
 		ms.ObserveIngestionDuration("transaction", 1.0)
@@ -352,6 +380,43 @@ func TestDBMetrics(t *testing.T) {
 	assert.True(t, foundDuration, "Query duration metric not found")
 }
 
+func TestPruneMetrics(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ms := NewMetricsService(db)
+	table := "ingest_payments"
+
+	ms.AddPrunedRows(table, 500)
+	ms.AddPrunedRows(table, 20)
+	ms.ObservePruneDuration(table, 0.5)
+
+	metricFamilies, err := ms.GetRegistry().Gather()
+	require.NoError(t, err)
+
+	foundRows := false
+	foundDuration := false
+
+	for _, mf := range metricFamilies {
+		switch mf.GetName() {
+		case "pruned_rows_total":
+			foundRows = true
+			metric := mf.GetMetric()[0]
+			assert.Equal(t, float64(520), metric.GetCounter().GetValue())
+			assert.Equal(t, table, metric.GetLabel()[0].GetValue())
+		case "prune_duration_seconds":
+			foundDuration = true
+			metric := mf.GetMetric()[0]
+			assert.Equal(t, uint64(1), metric.GetSummary().GetSampleCount())
+			assert.Equal(t, 0.5, metric.GetSummary().GetSampleSum())
+			assert.Equal(t, table, metric.GetLabel()[0].GetValue())
+		}
+	}
+
+	assert.True(t, foundRows, "Pruned rows metric not found")
+	assert.True(t, foundDuration, "Prune duration metric not found")
+}
+
 func TestPoolMetrics(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
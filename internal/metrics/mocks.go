@@ -37,6 +37,10 @@ func (m *MockMetricsService) SetLatestLedgerIngested(value float64) {
 	m.Called(value)
 }
 
+func (m *MockMetricsService) SetIngestLeader(leader bool) {
+	m.Called(leader)
+}
+
 func (m *MockMetricsService) ObserveIngestionDuration(ingestionType string, duration float64) {
 	m.Called(ingestionType, duration)
 }
@@ -104,3 +108,11 @@ func (m *MockMetricsService) RecordTSSTransactionStatusTransition(oldStatus, new
 func (m *MockMetricsService) IncSignatureVerificationExpired(expiredSeconds float64) {
 	m.Called(expiredSeconds)
 }
+
+func (m *MockMetricsService) AddPrunedRows(table string, count int64) {
+	m.Called(table, count)
+}
+
+func (m *MockMetricsService) ObservePruneDuration(table string, duration float64) {
+	m.Called(table, duration)
+}
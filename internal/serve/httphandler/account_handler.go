@@ -1,13 +1,16 @@
 package httphandler
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 
+	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/render/httpjson"
 	"github.com/stellar/go/txnbuild"
 
 	"github.com/stellar/wallet-backend/internal/apptracker"
+	"github.com/stellar/wallet-backend/internal/data"
 	"github.com/stellar/wallet-backend/internal/entities"
 	"github.com/stellar/wallet-backend/internal/serve/httperror"
 	"github.com/stellar/wallet-backend/internal/services"
@@ -17,6 +20,7 @@ import (
 type AccountHandler struct {
 	AccountService            services.AccountService
 	AccountSponsorshipService services.AccountSponsorshipService
+	AccountBalanceService     services.AccountBalanceService
 	SupportedAssets           []entities.Asset
 	AppTracker                apptracker.AppTracker
 }
@@ -25,6 +29,23 @@ type AccountRegistrationRequest struct {
 	Address string `json:"address" validate:"required,public_key"`
 }
 
+type AccountRegistrationQuery struct {
+	// Backfill enqueues a job that ingests the account history still retained by the RPC.
+	Backfill bool `query:"backfill"`
+}
+
+// AccountRegistrationBody is the optional body of the registration, which replaces the metadata of the account.
+type AccountRegistrationBody struct {
+	ExternalUserID *string  `json:"externalUserId" validate:"omitempty,max=255"`
+	Labels         []string `json:"labels" validate:"lte=20,dive,required,max=64"`
+	// Metadata is a free-form JSON object.
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+type AccountRegistrationResponse struct {
+	BackfillJob *data.AccountBackfillJob `json:"backfillJob"`
+}
+
 func (h AccountHandler) RegisterAccount(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -35,13 +56,122 @@ func (h AccountHandler) RegisterAccount(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := h.AccountService.RegisterAccount(ctx, reqParams.Address)
+	var reqQuery AccountRegistrationQuery
+	httpErr = DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	var metadata *data.AccountMetadata
+	var reqBody AccountRegistrationBody
+	hasBody, httpErr := DecodeOptionalJSONAndValidate(ctx, r, &reqBody, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+	if hasBody {
+		metadata, httpErr = accountMetadata(reqBody)
+		if httpErr != nil {
+			httpErr.Render(w)
+			return
+		}
+	}
+
+	err := h.AccountService.RegisterAccount(ctx, reqParams.Address, metadata)
 	if err != nil {
 		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if !reqQuery.Backfill {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	job, err := h.AccountService.EnqueueBackfill(ctx, reqParams.Address)
+	if err != nil {
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+	httpjson.Render(w, AccountRegistrationResponse{BackfillJob: job}, httpjson.JSON)
+}
+
+// accountMetadata validates that the metadata of the registration body is a JSON object.
+func accountMetadata(reqBody AccountRegistrationBody) (*data.AccountMetadata, *httperror.ErrorResponse) {
+	metadata := &data.AccountMetadata{
+		ExternalUserID: reqBody.ExternalUserID,
+		Labels:         reqBody.Labels,
+	}
+	if len(reqBody.Metadata) == 0 {
+		return metadata, nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(reqBody.Metadata, &object); err != nil {
+		return nil, httperror.BadRequest("Validation error.", map[string]interface{}{"metadata": "Should be a JSON object"})
+	}
+	if object != nil {
+		metadata.Metadata = reqBody.Metadata
+	}
+	return metadata, nil
+}
+
+type AccountsRequest struct {
+	ExternalUserID string `query:"externalUserId"`
+	// Labels returns the accounts with all of the labels.
+	Labels []string `query:"labels[]" validate:"lte=20,dive,required"`
+	// AfterAddress returns the accounts after this address, to get the page following the one it's the last of.
+	AfterAddress string `query:"afterAddress" validate:"public_key"`
+	Limit        int    `query:"limit" validate:"gt=0,lte=200"`
+}
+
+type AccountsResponse struct {
+	Accounts []data.Account `json:"accounts"`
+}
+
+// ListAccounts returns the registered accounts, along with their metadata, ordered by address.
+func (h AccountHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reqQuery := AccountsRequest{Limit: 50}
+	httpErr := DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	filter := data.AccountsFilter{
+		ExternalUserID: reqQuery.ExternalUserID,
+		Labels:         reqQuery.Labels,
+	}
+	accounts, err := h.AccountService.ListAccounts(ctx, filter, reqQuery.AfterAddress, reqQuery.Limit)
+	if err != nil {
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+	httpjson.Render(w, AccountsResponse{Accounts: accounts}, httpjson.JSON)
+}
+
+func (h AccountHandler) GetBackfillJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqParams AccountRegistrationRequest
+	httpErr := DecodePathAndValidate(ctx, r, &reqParams, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	job, err := h.AccountService.GetLatestBackfillJob(ctx, reqParams.Address)
+	if err != nil {
+		if errors.Is(err, data.ErrAccountBackfillJobNotFound) {
+			httperror.NotFound.Render(w)
+			return
+		}
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+	httpjson.Render(w, job, httpjson.JSON)
 }
 
 func (h AccountHandler) DeregisterAccount(w http.ResponseWriter, r *http.Request) {
@@ -63,6 +193,39 @@ func (h AccountHandler) DeregisterAccount(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 }
 
+type AccountBalancesRequest struct {
+	Address string `path:"address" validate:"required"`
+}
+
+// GetAccountBalances returns the live native and supported assets balances of a Stellar account or contract, read from
+// the RPC ledger entries.
+func (h AccountHandler) GetAccountBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqParams AccountBalancesRequest
+	httpErr := DecodePathAndValidate(ctx, r, &reqParams, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+	if !strkey.IsValidEd25519PublicKey(reqParams.Address) && !strkey.IsValidMuxedAccountEd25519PublicKey(reqParams.Address) &&
+		!strkey.IsValidContractAddress(reqParams.Address) {
+		httperror.BadRequest("Validation error.", map[string]interface{}{"address": "Invalid public key or contract address provided"}).Render(w)
+		return
+	}
+
+	balances, err := h.AccountBalanceService.GetAccountBalances(ctx, reqParams.Address)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountNotFound) {
+			httperror.NotFound.Render(w)
+			return
+		}
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+	httpjson.Render(w, balances, httpjson.JSON)
+}
+
 type SponsorAccountCreationRequest struct {
 	Address string            `json:"address" validate:"required,public_key"`
 	Signers []entities.Signer `json:"signers" validate:"required,gt=0,dive"`
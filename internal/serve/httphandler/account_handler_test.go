@@ -3,6 +3,7 @@ package httphandler
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -27,6 +28,7 @@ import (
 	"github.com/stellar/wallet-backend/internal/entities"
 	"github.com/stellar/wallet-backend/internal/metrics"
 	"github.com/stellar/wallet-backend/internal/services"
+	"github.com/stellar/wallet-backend/internal/utils"
 )
 
 func TestAccountHandlerRegisterAccount(t *testing.T) {
@@ -121,6 +123,116 @@ func TestAccountHandlerRegisterAccount(t *testing.T) {
 		clearAccounts(ctx)
 	})
 
+	t.Run("success_with_backfill", func(t *testing.T) {
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "accounts", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Once()
+		mockMetricsService.On("IncActiveAccount").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "account_backfill_jobs", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "account_backfill_jobs").Once()
+		defer mockMetricsService.AssertExpectations(t)
+
+		address := keypair.MustRandom().Address()
+		req, err := http.NewRequest(http.MethodPost, path.Join("/accounts", address)+"?backfill=true", nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp AccountRegistrationResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.NotNil(t, resp.BackfillJob)
+		assert.Equal(t, address, resp.BackfillJob.Address)
+		assert.Equal(t, data.AccountBackfillJobPending, resp.BackfillJob.Status)
+
+		clearAccounts(context.Background())
+	})
+
+	t.Run("success_with_metadata", func(t *testing.T) {
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "accounts", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Once()
+		mockMetricsService.On("IncActiveAccount").Once()
+		defer mockMetricsService.AssertExpectations(t)
+
+		address := keypair.MustRandom().Address()
+		reqBody := `{"externalUserId": "user-1", "labels": ["savings"], "metadata": {"tier": 2}}`
+		req, err := http.NewRequest(http.MethodPost, path.Join("/accounts", address), strings.NewReader(reqBody))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		ctx := context.Background()
+		var account struct {
+			ExternalUserID string `db:"external_user_id"`
+			Labels         string `db:"labels"`
+			Metadata       string `db:"metadata"`
+		}
+		err = dbConnectionPool.GetContext(ctx, &account, "SELECT external_user_id, labels, metadata FROM accounts WHERE stellar_address = $1", address)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", account.ExternalUserID)
+		assert.Equal(t, "{savings}", account.Labels)
+		assert.JSONEq(t, `{"tier": 2}`, account.Metadata)
+
+		clearAccounts(ctx)
+	})
+
+	t.Run("success_with_empty_chunked_body", func(t *testing.T) {
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "accounts", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Once()
+		mockMetricsService.On("IncActiveAccount").Once()
+		defer mockMetricsService.AssertExpectations(t)
+
+		address := keypair.MustRandom().Address()
+		req, err := http.NewRequest(http.MethodPost, path.Join("/accounts", address), strings.NewReader(""))
+		require.NoError(t, err)
+		// chunked bodies have an unknown length
+		req.ContentLength = -1
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var externalUserID sql.NullString
+		err = dbConnectionPool.GetContext(context.Background(), &externalUserID, "SELECT external_user_id FROM accounts WHERE stellar_address = $1", address)
+		require.NoError(t, err)
+		assert.False(t, externalUserID.Valid)
+
+		clearAccounts(context.Background())
+	})
+
+	t.Run("invalid_metadata", func(t *testing.T) {
+		address := keypair.MustRandom().Address()
+		testCases := []struct {
+			reqBody      string
+			wantRespBody string
+		}{
+			{
+				reqBody:      `{"metadata": ["tier"]}`,
+				wantRespBody: `{"error": "Validation error.", "extras": {"metadata": "Should be a JSON object"}}`,
+			},
+			{
+				reqBody:      `{"labels": [""]}`,
+				wantRespBody: `{"error": "Validation error.", "extras": {"labels[0]": "This field is required"}}`,
+			},
+			{
+				reqBody:      `{"externalUserId": 1}`,
+				wantRespBody: `{"error": "Invalid request body."}`,
+			},
+		}
+		for _, tc := range testCases {
+			req, err := http.NewRequest(http.MethodPost, path.Join("/accounts", address), strings.NewReader(tc.reqBody))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.JSONEq(t, tc.wantRespBody, rr.Body.String())
+		}
+	})
+
 	t.Run("invalid_address", func(t *testing.T) {
 		// Prepare request
 		randomString := uuid.NewString()
@@ -140,6 +252,192 @@ func TestAccountHandlerRegisterAccount(t *testing.T) {
 	})
 }
 
+func TestAccountHandlerListAccounts(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "accounts", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "SELECT", "accounts").Return()
+
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	accountService, err := services.NewAccountService(models, mockMetricsService)
+	require.NoError(t, err)
+	handler := &AccountHandler{
+		AccountService: accountService,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/accounts", handler.ListAccounts)
+
+	ctx := context.Background()
+	_, err = dbConnectionPool.ExecContext(ctx, `
+		INSERT INTO accounts (stellar_address, external_user_id, labels, metadata) VALUES
+			('GA1', 'user-1', '{savings,vip}', '{"tier": 2}'),
+			('GA2', 'user-1', '{savings}', '{}'),
+			('GA3', 'user-2', '{vip}', '{}')
+	`)
+	require.NoError(t, err)
+
+	t.Run("filters", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/accounts?externalUserId=user-1&labels[]=vip", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp AccountsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Accounts, 1)
+		assert.Equal(t, "GA1", resp.Accounts[0].StellarAddress)
+		assert.Equal(t, utils.PointOf("user-1"), resp.Accounts[0].ExternalUserID)
+		assert.Equal(t, []string{"savings", "vip"}, []string(resp.Accounts[0].Labels))
+		assert.JSONEq(t, `{"tier": 2}`, string(resp.Accounts[0].Metadata))
+	})
+
+	t.Run("page", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/accounts?limit=1", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp AccountsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Accounts, 1)
+		assert.Equal(t, "GA1", resp.Accounts[0].StellarAddress)
+	})
+
+	t.Run("invalid_limit", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/accounts?limit=0", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error": "Validation error.", "extras": {"limit": "Should be greater than 0"}}`, rr.Body.String())
+	})
+}
+
+func TestAccountHandlerGetBackfillJob(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "account_backfill_jobs", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "SELECT", "account_backfill_jobs").Return()
+
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	accountService, err := services.NewAccountService(models, mockMetricsService)
+	require.NoError(t, err)
+	handler := &AccountHandler{
+		AccountService: accountService,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/accounts/{address}/backfill", handler.GetBackfillJob)
+
+	ctx := context.Background()
+	address := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `
+		INSERT INTO account_backfill_jobs (stellar_address, status, start_ledger, end_ledger, last_ledger)
+		VALUES ($1, 'failed', 1, 10, 5), ($1, 'running', 1, 20, 15)
+	`, address)
+	require.NoError(t, err)
+
+	t.Run("latest_job", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, path.Join("/accounts", address, "backfill"), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var job data.AccountBackfillJob
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+		assert.Equal(t, data.AccountBackfillJobRunning, job.Status)
+		assert.Equal(t, utils.PointOf(uint32(20)), job.EndLedger)
+		assert.Equal(t, utils.PointOf(uint32(15)), job.LastLedger)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, path.Join("/accounts", keypair.MustRandom().Address(), "backfill"), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestAccountHandlerGetAccountBalances(t *testing.T) {
+	rpcService := services.NewRPCServiceMock(t)
+	accountBalanceService, err := services.NewAccountBalanceService(rpcService, nil, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	handler := &AccountHandler{
+		AccountBalanceService: accountBalanceService,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/accounts/{address}/balances", handler.GetAccountBalances)
+
+	address := keypair.MustRandom().Address()
+	accountKey, err := utils.GetAccountLedgerKey(address)
+	require.NoError(t, err)
+
+	t.Run("balances", func(t *testing.T) {
+		accountEntry := xdr.AccountEntry{AccountId: xdr.MustAddress(address), Balance: xdr.Int64(10_0000000)}
+		dataXDR, err := xdr.MarshalBase64(xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeAccount, Account: &accountEntry})
+		require.NoError(t, err)
+		rpcService.On("GetLedgerEntries", []string{accountKey}).Return(entities.RPCGetLedgerEntriesResult{
+			LatestLedger: 100,
+			Entries:      []entities.LedgerEntryResult{{DataXDR: dataXDR, LastModifiedLedger: 90}},
+		}, nil).Once()
+
+		req, err := http.NewRequest(http.MethodGet, path.Join("/accounts", address, "balances"), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var balances services.AccountBalances
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &balances))
+		assert.Equal(t, uint32(100), balances.LatestLedger)
+		require.Len(t, balances.Balances, 1)
+		assert.Equal(t, "native", balances.Balances[0].AssetType)
+		assert.Equal(t, "10.0000000", balances.Balances[0].Balance)
+		assert.Equal(t, "1.0000000", balances.Reserves.MinimumBalance)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		rpcService.On("GetLedgerEntries", []string{accountKey}).Return(entities.RPCGetLedgerEntriesResult{LatestLedger: 100}, nil).Once()
+
+		req, err := http.NewRequest(http.MethodGet, path.Join("/accounts", address, "balances"), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("invalid_address", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/accounts/invalid/balances", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error": "Validation error.", "extras": {"address": "Invalid public key or contract address provided"}}`, rr.Body.String())
+	})
+}
+
 func TestAccountHandlerDeregisterAccount(t *testing.T) {
 	dbt := dbtest.Open(t)
 	defer dbt.Close()
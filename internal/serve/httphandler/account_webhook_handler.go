@@ -0,0 +1,163 @@
+package httphandler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/stellar/go/support/render/httpjson"
+
+	"github.com/stellar/wallet-backend/internal/apptracker"
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/serve/httperror"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+type AccountWebhookHandler struct {
+	AccountWebhookService services.AccountWebhookService
+	AppTracker            apptracker.AppTracker
+}
+
+type CreateAccountWebhookRequest struct {
+	URL       string   `json:"url" validate:"required,http_url"`
+	Addresses []string `json:"addresses" validate:"required,gt=0,lte=200,dive,public_key"`
+}
+
+type AccountWebhookPathParams struct {
+	ID int64 `path:"id" validate:"required,gt=0"`
+}
+
+type AccountWebhookDeliveriesRequest struct {
+	Status data.AccountWebhookDeliveryStatus `query:"status" validate:"omitempty,oneof=pending sent failed"`
+	Limit  int                               `query:"limit" validate:"gt=0,lte=200"`
+}
+
+type AccountWebhookDeliveriesResponse struct {
+	Deliveries []data.AccountWebhookDelivery `json:"deliveries"`
+}
+
+type ReplayAccountWebhookRequest struct {
+	// From is the RFC 3339 timestamp from which the deliveries created are replayed.
+	From       time.Time `json:"from" validate:"required"`
+	FailedOnly bool      `json:"failedOnly"`
+}
+
+type ReplayAccountWebhookResponse struct {
+	Replayed int64 `json:"replayed"`
+}
+
+func (h AccountWebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqBody CreateAccountWebhookRequest
+	httpErr := DecodeJSONAndValidate(ctx, r, &reqBody, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	webhook, err := h.AccountWebhookService.CreateWebhook(ctx, reqBody.URL, reqBody.Addresses)
+	if err != nil {
+		if errors.Is(err, data.ErrAccountWebhookAddressNotRegistered) {
+			httperror.BadRequest("Validation error.", map[string]interface{}{"addresses": "All the addresses should be registered"}).Render(w)
+			return
+		}
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+
+	httpjson.RenderStatus(w, http.StatusCreated, webhook, httpjson.JSON)
+}
+
+func (h AccountWebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqPath AccountWebhookPathParams
+	httpErr := DecodePathAndValidate(ctx, r, &reqPath, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	webhook, err := h.AccountWebhookService.GetWebhook(ctx, reqPath.ID)
+	if err != nil {
+		h.renderError(w, r, err)
+		return
+	}
+	httpjson.Render(w, webhook, httpjson.JSON)
+}
+
+func (h AccountWebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqPath AccountWebhookPathParams
+	httpErr := DecodePathAndValidate(ctx, r, &reqPath, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	if err := h.AccountWebhookService.DeleteWebhook(ctx, reqPath.ID); err != nil {
+		h.renderError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h AccountWebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqPath AccountWebhookPathParams
+	httpErr := DecodePathAndValidate(ctx, r, &reqPath, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	reqQuery := AccountWebhookDeliveriesRequest{Limit: 50}
+	httpErr = DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	deliveries, err := h.AccountWebhookService.GetDeliveries(ctx, reqPath.ID, reqQuery.Status, reqQuery.Limit)
+	if err != nil {
+		h.renderError(w, r, err)
+		return
+	}
+	httpjson.Render(w, AccountWebhookDeliveriesResponse{Deliveries: deliveries}, httpjson.JSON)
+}
+
+func (h AccountWebhookHandler) ReplayDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqPath AccountWebhookPathParams
+	httpErr := DecodePathAndValidate(ctx, r, &reqPath, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	var reqBody ReplayAccountWebhookRequest
+	httpErr = DecodeJSONAndValidate(ctx, r, &reqBody, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	replayed, err := h.AccountWebhookService.ReplayDeliveries(ctx, reqPath.ID, reqBody.From, reqBody.FailedOnly)
+	if err != nil {
+		h.renderError(w, r, err)
+		return
+	}
+	httpjson.Render(w, ReplayAccountWebhookResponse{Replayed: replayed}, httpjson.JSON)
+}
+
+func (h AccountWebhookHandler) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, data.ErrAccountWebhookNotFound) {
+		httperror.NotFound.Render(w)
+		return
+	}
+	httperror.InternalServerError(r.Context(), "", err, nil, h.AppTracker).Render(w)
+}
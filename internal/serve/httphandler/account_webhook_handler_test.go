@@ -0,0 +1,135 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+func TestAccountWebhookHandler(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	accountWebhookService, err := services.NewAccountWebhookService(models)
+	require.NoError(t, err)
+	handler := &AccountWebhookHandler{
+		AccountWebhookService: accountWebhookService,
+	}
+
+	r := chi.NewRouter()
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Post("/", handler.CreateWebhook)
+		r.Get("/{id}", handler.GetWebhook)
+		r.Delete("/{id}", handler.DeleteWebhook)
+		r.Get("/{id}/deliveries", handler.GetDeliveries)
+		r.Post("/{id}/replay", handler.ReplayDeliveries)
+	})
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	ctx := context.Background()
+	address := keypair.MustRandom().Address()
+	require.NoError(t, models.Account.Insert(ctx, address))
+
+	t.Run("🔴invalid_webhook", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/webhooks", `{"url": "not a url", "addresses": []}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = serve(http.MethodPost, "/webhooks", fmt.Sprintf(`{"url": "https://example.com/hook", "addresses": [%q]}`, keypair.MustRandom().Address()))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error": "Validation error.", "extras": {"addresses": "All the addresses should be registered"}}`, rr.Body.String())
+	})
+
+	rr := serve(http.MethodPost, "/webhooks", fmt.Sprintf(`{"url": "https://example.com/hook", "addresses": [%q]}`, address))
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var webhook data.AccountWebhook
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &webhook))
+	assert.Equal(t, "https://example.com/hook", webhook.URL)
+	webhookPath := fmt.Sprintf("/webhooks/%d", webhook.ID)
+
+	t.Run("🟢get", func(t *testing.T) {
+		rr := serve(http.MethodGet, webhookPath, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got data.AccountWebhook
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, []string{address}, []string(got.Addresses))
+
+		rr = serve(http.MethodGet, fmt.Sprintf("/webhooks/%d", webhook.ID+1), "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		rr = serve(http.MethodGet, "/webhooks/abc", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+		return models.AccountWebhooks.BatchInsertDeliveries(ctx, dbTx, []data.AccountWebhookDelivery{
+			{WebhookID: webhook.ID, EventType: data.AccountWebhookPaymentEvent, EventID: "1", Payload: json.RawMessage(`{}`)},
+		})
+	})
+	require.NoError(t, err)
+
+	t.Run("🟢deliveries", func(t *testing.T) {
+		rr := serve(http.MethodGet, webhookPath+"/deliveries?status=pending", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp AccountWebhookDeliveriesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Deliveries, 1)
+		assert.Equal(t, "1", resp.Deliveries[0].EventID)
+
+		rr = serve(http.MethodGet, webhookPath+"/deliveries?status=sent", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"deliveries": []}`, rr.Body.String())
+
+		rr = serve(http.MethodGet, webhookPath+"/deliveries?status=lost", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("🟢replay", func(t *testing.T) {
+		rr := serve(http.MethodPost, webhookPath+"/replay", `{"from": "2024-01-01T00:00:00Z"}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"replayed": 1}`, rr.Body.String())
+
+		rr = serve(http.MethodPost, webhookPath+"/replay", `{"from": "2024-01-01T00:00:00Z", "failedOnly": true}`)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"replayed": 0}`, rr.Body.String())
+
+		rr = serve(http.MethodPost, webhookPath+"/replay", `{}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("🟢delete", func(t *testing.T) {
+		rr := serve(http.MethodDelete, webhookPath, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = serve(http.MethodDelete, webhookPath, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,68 @@
+package httphandler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/stellar/go/support/render/httpjson"
+
+	"github.com/stellar/wallet-backend/internal/apptracker"
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/serve/httperror"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+type BalanceChangeHandler struct {
+	BalanceChangeService services.BalanceChangeService
+	AppTracker           apptracker.AppTracker
+}
+
+type AccountBalanceHistoryPathParams struct {
+	Address string `path:"address" validate:"required,public_key"`
+}
+
+type AccountBalanceHistoryRequest struct {
+	AfterCursor  string         `query:"afterCursor"`
+	BeforeCursor string         `query:"beforeCursor"`
+	Sort         data.SortOrder `query:"sort" validate:"oneof=ASC DESC"`
+	Limit        int            `query:"limit" validate:"gt=0,lte=200"`
+}
+
+type AccountBalanceHistoryResponse struct {
+	BalanceChanges []data.BalanceChange `json:"balanceChanges"`
+	entities.Pagination
+}
+
+func (h BalanceChangeHandler) GetAccountBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqPath AccountBalanceHistoryPathParams
+	httpErr := DecodePathAndValidate(ctx, r, &reqPath, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	reqQuery := AccountBalanceHistoryRequest{Sort: data.DESC, Limit: 50}
+	httpErr = DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	balanceChanges, pagination, err := h.BalanceChangeService.GetAccountBalanceChangesPaginated(ctx, reqPath.Address, reqQuery.BeforeCursor, reqQuery.AfterCursor, reqQuery.Sort, reqQuery.Limit)
+	if err != nil {
+		if errors.Is(err, data.ErrInvalidBalanceChangeCursor) {
+			httperror.BadRequest("Invalid cursor.", nil).Render(w)
+			return
+		}
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+
+	httpjson.Render(w, AccountBalanceHistoryResponse{
+		BalanceChanges: balanceChanges,
+		Pagination:     pagination,
+	}, httpjson.JSON)
+}
@@ -0,0 +1,88 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+func TestBalanceChangeHandlerGetAccountBalanceHistory(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_balance_changes", mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_balance_changes")
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	balanceChangeService, err := services.NewBalanceChangeService(models, "http://testing.com")
+	require.NoError(t, err)
+	handler := &BalanceChangeHandler{
+		BalanceChangeService: balanceChangeService,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/accounts/{address}/balance-history", handler.GetAccountBalanceHistory)
+
+	ctx := context.Background()
+	address := keypair.MustRandom().Address()
+	for i := 1; i <= 2; i++ {
+		_, err = dbConnectionPool.ExecContext(ctx, `
+			INSERT INTO ingest_balance_changes (account, operation_id, asset, transaction_id, transaction_hash, ledger_number, reason, amount, balance, created_at)
+			VALUES ($1, $2, 'native', $2, 'hash', 1, 'OperationTypePayment', -100, 900, NOW())
+		`, address, i)
+		require.NoError(t, err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%s/balance-history?limit=1", address), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp AccountBalanceHistoryResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.BalanceChanges, 1)
+		assert.Equal(t, "2", resp.BalanceChanges[0].OperationID)
+		assert.Equal(t, int64(-100), resp.BalanceChanges[0].Amount)
+		assert.Equal(t, fmt.Sprintf("http://testing.com/accounts/%s/balance-history?afterCursor=2-native&limit=1&sort=DESC", address), resp.Links.Next)
+	})
+
+	t.Run("invalid_cursor", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%s/balance-history?afterCursor=invalid", address), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid_address", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/accounts/invalid/balance-history", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
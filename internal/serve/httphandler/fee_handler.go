@@ -0,0 +1,48 @@
+package httphandler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/stellar/go/support/render/httpjson"
+
+	"github.com/stellar/wallet-backend/internal/apptracker"
+	"github.com/stellar/wallet-backend/internal/serve/httperror"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+type FeeHandler struct {
+	FeeService services.FeeService
+	AppTracker apptracker.AppTracker
+}
+
+type FeeSummaryRequest struct {
+	// Account restricts the summary to the fees paid by this account.
+	Account string `query:"account" validate:"public_key"`
+	// From and To are RFC 3339 timestamps bounding the summary to [From, To).
+	From *time.Time `query:"from"`
+	To   *time.Time `query:"to"`
+}
+
+func (h FeeHandler) GetFeeSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqQuery FeeSummaryRequest
+	httpErr := DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+	if reqQuery.From != nil && reqQuery.To != nil && !reqQuery.From.Before(*reqQuery.To) {
+		httperror.BadRequest("Validation error.", map[string]interface{}{"to": "Should be after from"}).Render(w)
+		return
+	}
+
+	report, err := h.FeeService.GetFeeSummary(ctx, reqQuery.Account, reqQuery.From, reqQuery.To)
+	if err != nil {
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+
+	httpjson.Render(w, report, httpjson.JSON)
+}
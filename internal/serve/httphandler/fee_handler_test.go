@@ -0,0 +1,112 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+func TestFeeHandlerGetFeeSummary(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_transaction_fees", mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_transaction_fees")
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	feeService, err := services.NewFeeService(models)
+	require.NoError(t, err)
+	handler := &FeeHandler{
+		FeeService: feeService,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/fees/summary", handler.GetFeeSummary)
+
+	ctx := context.Background()
+	account := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	for i, row := range []struct {
+		feeAccount string
+		feeCharged int64
+		createdAt  string
+	}{
+		{account, 100, "2024-01-01T00:00:00Z"},
+		{account, 200, "2024-01-02T00:00:00Z"},
+		{otherAccount, 50, "2024-01-02T00:00:00Z"},
+	} {
+		_, err = dbConnectionPool.ExecContext(ctx, `
+			INSERT INTO ingest_transaction_fees (transaction_id, transaction_hash, ledger_number, source_account, fee_account, fee_bumped, fee_charged, max_fee, successful, created_at)
+			VALUES ($1, 'hash', 1, $2, $2, false, $3, $3, true, $4)
+		`, i+1, row.feeAccount, row.feeCharged, row.createdAt)
+		require.NoError(t, err)
+	}
+
+	t.Run("all_accounts", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/fees/summary", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp services.FeeSummaryReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, data.FeeSummary{TransactionCount: 3, FeeCharged: 350}, resp.Total)
+		assert.Equal(t, []data.FeeSummary{
+			{Account: account, TransactionCount: 2, FeeCharged: 300},
+			{Account: otherAccount, TransactionCount: 1, FeeCharged: 50},
+		}, resp.Accounts)
+	})
+
+	t.Run("account_and_range", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/fees/summary?account="+account+"&from=2024-01-02T00:00:00Z&to=2024-01-03T00:00:00Z", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp services.FeeSummaryReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, data.FeeSummary{Account: account, TransactionCount: 1, FeeCharged: 200}, resp.Total)
+		require.NotNil(t, resp.From)
+		assert.Equal(t, "2024-01-02T00:00:00Z", resp.From.UTC().Format("2006-01-02T15:04:05Z07:00"))
+	})
+
+	t.Run("invalid_account", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/fees/summary?account=invalid", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("to_before_from", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/fees/summary?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.JSONEq(t, `{"error": "Validation error.", "extras": {"to": "Should be after from"}}`, rr.Body.String())
+	})
+}
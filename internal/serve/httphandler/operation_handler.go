@@ -0,0 +1,63 @@
+package httphandler
+
+import (
+	"net/http"
+
+	"github.com/stellar/go/support/render/httpjson"
+
+	"github.com/stellar/wallet-backend/internal/apptracker"
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/serve/httperror"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+type OperationHandler struct {
+	OperationService services.OperationService
+	AppTracker       apptracker.AppTracker
+}
+
+type AccountOperationsPathParams struct {
+	Address string `path:"address" validate:"required,public_key"`
+}
+
+type AccountOperationsRequest struct {
+	AfterID  string         `query:"afterId"`
+	BeforeID string         `query:"beforeId"`
+	Sort     data.SortOrder `query:"sort" validate:"oneof=ASC DESC"`
+	Limit    int            `query:"limit" validate:"gt=0,lte=200"`
+}
+
+type AccountOperationsResponse struct {
+	Operations []data.Operation `json:"operations"`
+	entities.Pagination
+}
+
+func (h OperationHandler) GetAccountOperations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqPath AccountOperationsPathParams
+	httpErr := DecodePathAndValidate(ctx, r, &reqPath, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	reqQuery := AccountOperationsRequest{Sort: data.DESC, Limit: 50}
+	httpErr = DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	operations, pagination, err := h.OperationService.GetAccountOperationsPaginated(ctx, reqPath.Address, reqQuery.BeforeID, reqQuery.AfterID, reqQuery.Sort, reqQuery.Limit)
+	if err != nil {
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+
+	httpjson.Render(w, AccountOperationsResponse{
+		Operations: operations,
+		Pagination: pagination,
+	}, httpjson.JSON)
+}
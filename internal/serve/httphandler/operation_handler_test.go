@@ -0,0 +1,85 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+func TestOperationHandlerGetAccountOperations(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_operations", mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_operations")
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	operationService, err := services.NewOperationService(models, "http://testing.com")
+	require.NoError(t, err)
+	handler := &OperationHandler{
+		OperationService: operationService,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/accounts/{address}/operations", handler.GetAccountOperations)
+
+	ctx := context.Background()
+	address := keypair.MustRandom().Address()
+	_, err = dbConnectionPool.ExecContext(ctx, `
+		INSERT INTO ingest_operations (operation_id, operation_type, transaction_id, transaction_hash, source_account, details, participants, created_at)
+		VALUES (1, 'OperationTypeManageData', 1, 'hash', $1, '{"name": "key"}', ARRAY[$1], NOW())
+	`, address)
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%s/operations?limit=10", address), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp AccountOperationsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Operations, 1)
+		assert.Equal(t, "1", resp.Operations[0].OperationID)
+		assert.JSONEq(t, `{"name": "key"}`, string(resp.Operations[0].Details))
+	})
+
+	t.Run("invalid_address", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/accounts/invalid/operations", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid_limit", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%s/operations?limit=0", address), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -1,9 +1,19 @@
 package httphandler
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/render/httpjson"
+	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/wallet-backend/internal/apptracker"
 	"github.com/stellar/wallet-backend/internal/data"
@@ -13,16 +23,81 @@ import (
 )
 
 type PaymentHandler struct {
-	PaymentService services.PaymentService
-	AppTracker     apptracker.AppTracker
+	PaymentService       services.PaymentService
+	PaymentSubscriber    services.PaymentSubscriber
+	PaymentExportService services.PaymentExportService
+	AppTracker           apptracker.AppTracker
 }
 
+const (
+	// paymentStreamKeepAliveInterval is how often a comment is sent on idle payment streams so proxies keep them open.
+	// The payments are read again on keep-alives too, in case a notification was missed.
+	paymentStreamKeepAliveInterval = 15 * time.Second
+	paymentStreamBatchSize         = 100
+)
+
 type PaymentsRequest struct {
-	Address  string         `query:"address" validate:"public_key"`
-	AfterID  string         `query:"afterId"`
-	BeforeID string         `query:"beforeId"`
-	Sort     data.SortOrder `query:"sort" validate:"oneof=ASC DESC"`
-	Limit    int            `query:"limit" validate:"gt=0,lte=200"`
+	// Address is either a G-address, which also matches its muxed accounts, or an M-address.
+	Address string `query:"address" validate:"public_key"`
+	// Addresses are the G-addresses of several accounts whose payments are returned as a single stream, the payments
+	// between two of them are marked as internal.
+	Addresses []string `query:"addresses[]" validate:"lte=20,dive,required,public_key"`
+	// ExternalUserID returns the payments of the accounts registered with this external user ID as a single stream.
+	ExternalUserID string `query:"externalUserId"`
+	// MuxedID narrows a G-address down to one of its muxed accounts.
+	MuxedID *uint64 `query:"muxedId"`
+	// IncludeFailed also returns the payments of failed transactions, with the reason they failed.
+	IncludeFailed bool `query:"includeFailed"`
+	// Direction narrows the payments of the address down to the ones it sent or received.
+	Direction data.PaymentDirection `query:"direction" validate:"omitempty,oneof=sent received"`
+	// AssetCode and AssetIssuer match the payments of an asset, native payments have the XLM code and no issuer.
+	AssetCode   string `query:"assetCode"`
+	AssetIssuer string `query:"assetIssuer" validate:"public_key"`
+	// OperationType is the XDR name of the operation type, e.g. OperationTypePathPaymentStrictSend.
+	OperationType string `query:"operationType"`
+	// Memo matches the payments of the transactions with this memo, hash and return memos are hex or base64 encoded.
+	Memo string `query:"memo"`
+	// MemoType is the XDR name of the memo type, e.g. MemoTypeMemoId.
+	MemoType string `query:"memoType" validate:"omitempty,oneof=MemoTypeMemoNone MemoTypeMemoText MemoTypeMemoId MemoTypeMemoHash MemoTypeMemoReturn"`
+	// From and To are RFC 3339 timestamps bounding the creation time of the payments to [From, To).
+	From *time.Time `query:"from"`
+	To   *time.Time `query:"to"`
+	// MinAmount is the minimum amount of the payments in stroops.
+	MinAmount *int64         `query:"minAmount" validate:"omitempty,gte=0"`
+	AfterID   string         `query:"afterId"`
+	BeforeID  string         `query:"beforeId"`
+	Sort      data.SortOrder `query:"sort" validate:"oneof=ASC DESC"`
+	Limit     int            `query:"limit" validate:"gt=0,lte=200"`
+}
+
+type PaymentStreamRequest struct {
+	// Address is either a G-address, which also matches its muxed accounts, or an M-address.
+	Address string `query:"address" validate:"required,public_key"`
+}
+
+type PaymentExportRequest struct {
+	// Address is either a G-address, which also matches its muxed accounts, or an M-address.
+	Address string `query:"address" validate:"required,public_key"`
+	// From and To are RFC 3339 timestamps bounding the creation time of the payments to [From, To).
+	From   *time.Time                   `query:"from"`
+	To     *time.Time                   `query:"to"`
+	Format services.PaymentExportFormat `query:"format" validate:"oneof=csv jsonl"`
+}
+
+type PaymentAggregatesRequest struct {
+	// Address aggregates the payments of the account, or of every registered account when it's empty.
+	Address  string                        `query:"address" validate:"public_key"`
+	Interval data.PaymentAggregateInterval `query:"interval" validate:"oneof=day week month"`
+	// AssetCode and AssetIssuer aggregate the payments of an asset, native payments have the XLM code and no issuer.
+	AssetCode   string `query:"assetCode"`
+	AssetIssuer string `query:"assetIssuer" validate:"public_key"`
+	// From and To are RFC 3339 timestamps bounding the UTC days aggregated to [From, To), truncated to their day.
+	From *time.Time `query:"from"`
+	To   *time.Time `query:"to"`
+}
+
+type PaymentAggregatesResponse struct {
+	Aggregates []data.PaymentAggregate `json:"aggregates"`
 }
 
 type PaymentsResponse struct {
@@ -40,7 +115,13 @@ func (h PaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	payments, pagination, err := h.PaymentService.GetPaymentsPaginated(ctx, reqQuery.Address, reqQuery.BeforeID, reqQuery.AfterID, reqQuery.Sort, reqQuery.Limit)
+	filter, httpErr := paymentsFilter(reqQuery)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	payments, pagination, err := h.PaymentService.GetPaymentsPaginated(ctx, filter, reqQuery.BeforeID, reqQuery.AfterID, reqQuery.Sort, reqQuery.Limit)
 	if err != nil {
 		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
 		return
@@ -51,3 +132,270 @@ func (h PaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
 		Pagination: pagination,
 	}, httpjson.JSON)
 }
+
+// StreamPayments pushes the payments of the address as Server-Sent Events as soon as they're ingested. Each event ID is
+// the payment operation ID, so clients resume by sending the last one they've received in the Last-Event-ID header.
+// Streams without it start after the latest payment of the address.
+func (h PaymentHandler) StreamPayments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqQuery PaymentStreamRequest
+	httpErr := DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+	filter, httpErr := paymentsFilter(PaymentsRequest{Address: reqQuery.Address})
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+	cursor := r.Header.Get("Last-Event-ID")
+	if err := data.ValidatePaymentID(cursor); err != nil {
+		httperror.BadRequest("Validation error.", map[string]interface{}{"Last-Event-ID": "Should be a payment ID"}).Render(w)
+		return
+	}
+
+	// subscribing before reading the latest payment, so none is missed in between
+	wakeUps, unsubscribe := h.PaymentSubscriber.Subscribe()
+	defer unsubscribe()
+	if cursor == "" {
+		latestID, err := h.PaymentService.GetLatestPaymentID(ctx, filter)
+		if err != nil {
+			httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+			return
+		}
+		cursor = latestID
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	responseController := http.NewResponseController(w)
+
+	keepAlive := time.NewTicker(paymentStreamKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		cursor, err = h.writePaymentEvents(ctx, w, filter, cursor)
+		if err == nil {
+			err = responseController.Flush()
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				// the response is already started, so the error can only be reported
+				httperror.InternalServerError(ctx, "", fmt.Errorf("streaming payments: %w", err), nil, h.AppTracker)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wakeUps:
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ExportPayments streams the whole successful payment history of the address within the time range, oldest first, as
+// CSV or JSON Lines.
+func (h PaymentHandler) ExportPayments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reqQuery := PaymentExportRequest{Format: services.PaymentExportCSV}
+	httpErr := DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+	filter, httpErr := paymentsFilter(PaymentsRequest{Address: reqQuery.Address, From: reqQuery.From, To: reqQuery.To})
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", reqQuery.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payments-%s.%s"`, reqQuery.Address, reqQuery.Format))
+	exported, err := h.PaymentExportService.ExportPayments(ctx, w, filter, reqQuery.Format)
+	if err != nil {
+		if exported == 0 {
+			httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+			return
+		}
+		if ctx.Err() == nil {
+			// the response is already started, so the error can only be reported
+			httperror.InternalServerError(ctx, "", fmt.Errorf("exporting payments: %w", err), nil, h.AppTracker)
+		}
+	}
+}
+
+// GetPaymentAggregates returns the volumes sent and received per time bucket and asset, the latest buckets first.
+func (h PaymentHandler) GetPaymentAggregates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reqQuery := PaymentAggregatesRequest{Interval: data.PaymentAggregateDay}
+	httpErr := DecodeQueryAndValidate(ctx, r, &reqQuery, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+	if strkey.IsValidMuxedAccountEd25519PublicKey(reqQuery.Address) {
+		httperror.BadRequest("Validation error.", map[string]interface{}{"address": "Muxed addresses aren't supported"}).Render(w)
+		return
+	}
+	if reqQuery.AssetIssuer != "" && reqQuery.AssetCode == "" {
+		httperror.BadRequest("Validation error.", map[string]interface{}{"assetCode": "The asset code is required to filter by asset issuer"}).Render(w)
+		return
+	}
+	if reqQuery.From != nil && reqQuery.To != nil && !reqQuery.From.Before(*reqQuery.To) {
+		httperror.BadRequest("Validation error.", map[string]interface{}{"to": "Should be after from"}).Render(w)
+		return
+	}
+
+	aggregates, err := h.PaymentService.GetPaymentAggregates(ctx, data.PaymentAggregatesFilter{
+		Address:     reqQuery.Address,
+		Interval:    reqQuery.Interval,
+		AssetCode:   reqQuery.AssetCode,
+		AssetIssuer: reqQuery.AssetIssuer,
+		From:        reqQuery.From,
+		To:          reqQuery.To,
+	})
+	if err != nil {
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+
+	httpjson.Render(w, PaymentAggregatesResponse{Aggregates: aggregates}, httpjson.JSON)
+}
+
+// writePaymentEvents writes the payments after the cursor as events and returns the ID of the last one written.
+func (h PaymentHandler) writePaymentEvents(ctx context.Context, w io.Writer, filter data.PaymentsFilter, cursor string) (string, error) {
+	for {
+		payments, err := h.PaymentService.GetPaymentsAfter(ctx, filter, cursor, paymentStreamBatchSize)
+		if err != nil {
+			return cursor, fmt.Errorf("getting payments after %q: %w", cursor, err)
+		}
+		for _, payment := range payments {
+			paymentJSON, err := json.Marshal(payment)
+			if err != nil {
+				return cursor, fmt.Errorf("marshalling payment %s: %w", payment.ID(), err)
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: payment\ndata: %s\n\n", payment.ID(), paymentJSON); err != nil {
+				return cursor, fmt.Errorf("writing payment %s: %w", payment.ID(), err)
+			}
+			cursor = payment.ID()
+		}
+		if len(payments) < paymentStreamBatchSize {
+			return cursor, nil
+		}
+	}
+}
+
+// paymentsFilter validates the filters depending on each other and resolves an M-address to its G-address and mux ID,
+// since payments are stored by G-address.
+func paymentsFilter(reqQuery PaymentsRequest) (data.PaymentsFilter, *httperror.ErrorResponse) {
+	filter := data.PaymentsFilter{
+		Address:        reqQuery.Address,
+		Addresses:      reqQuery.Addresses,
+		ExternalUserID: reqQuery.ExternalUserID,
+		MuxedID:        reqQuery.MuxedID,
+		IncludeFailed:  reqQuery.IncludeFailed,
+		Direction:      reqQuery.Direction,
+		AssetCode:      reqQuery.AssetCode,
+		AssetIssuer:    reqQuery.AssetIssuer,
+		MinAmount:      reqQuery.MinAmount,
+		OperationType:  reqQuery.OperationType,
+		Memo:           reqQuery.Memo,
+		MemoType:       reqQuery.MemoType,
+		From:           reqQuery.From,
+		To:             reqQuery.To,
+	}
+	if filter.Address != "" && len(filter.Addresses) > 0 {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"addresses[]": "Either address or addresses[] should be provided, not both"})
+	}
+	if filter.ExternalUserID != "" && (filter.Address != "" || len(filter.Addresses) > 0) {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"externalUserId": "Either addresses or externalUserId should be provided, not both"})
+	}
+	for _, address := range filter.Addresses {
+		if !strkey.IsValidEd25519PublicKey(address) {
+			return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"addresses[]": "Muxed addresses aren't supported, use address instead"})
+		}
+	}
+	if filter.MuxedID != nil && filter.Address == "" {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"muxedId": "The address is required to filter by muxed ID"})
+	}
+	if filter.Direction != "" && filter.Address == "" && len(filter.Addresses) == 0 && filter.ExternalUserID == "" {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"direction": "The address is required to filter by direction"})
+	}
+	if filter.AssetIssuer != "" && filter.AssetCode == "" {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"assetCode": "The asset code is required to filter by asset issuer"})
+	}
+	if filter.OperationType != "" && !isOperationType(filter.OperationType) {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"operationType": "Unknown operation type"})
+	}
+	if filter.Memo != "" {
+		memo, ok := storedMemo(filter.Memo, filter.MemoType)
+		if !ok {
+			return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"memo": "Invalid memo for the memo type"})
+		}
+		filter.Memo = memo
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"to": "Should be after from"})
+	}
+	if !strkey.IsValidMuxedAccountEd25519PublicKey(filter.Address) {
+		return filter, nil
+	}
+
+	var muxedAccount xdr.MuxedAccount
+	if err := muxedAccount.SetAddress(filter.Address); err != nil {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"address": "Invalid public key provided"})
+	}
+	muxedID := uint64(muxedAccount.Med25519.Id)
+	if filter.MuxedID != nil && *filter.MuxedID != muxedID {
+		return filter, httperror.BadRequest("Validation error.", map[string]interface{}{"muxedId": "The muxed ID doesn't match the one in the muxed address"})
+	}
+	filter.Address = muxedAccount.ToAccountId().Address()
+	filter.MuxedID = &muxedID
+	return filter, nil
+}
+
+// storedMemo returns the memo as it's stored for the memo type: ID memos in decimal, and hash and return memos, which
+// may be given in hex or base64, in lowercase hex. Other memos are stored as they are.
+func storedMemo(memo, memoType string) (string, bool) {
+	switch memoType {
+	case xdr.MemoTypeMemoNone.String():
+		return "", false
+	case xdr.MemoTypeMemoId.String():
+		id, err := strconv.ParseUint(memo, 10, 64)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatUint(id, 10), true
+	case xdr.MemoTypeMemoHash.String(), xdr.MemoTypeMemoReturn.String():
+		if hash, err := hex.DecodeString(memo); err == nil && len(hash) == 32 {
+			return hex.EncodeToString(hash), true
+		}
+		if hash, err := base64.StdEncoding.DecodeString(memo); err == nil && len(hash) == 32 {
+			return hex.EncodeToString(hash), true
+		}
+		return "", false
+	default:
+		return memo, true
+	}
+}
+
+func isOperationType(name string) bool {
+	for _, operationType := range xdr.OperationTypeToStringMap {
+		if operationType == name {
+			return true
+		}
+	}
+	return false
+}
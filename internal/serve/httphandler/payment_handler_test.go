@@ -1,14 +1,19 @@
 package httphandler
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/xdr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -72,6 +77,7 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 			CreatedAt:       time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
 			Memo:            utils.PointOf("test"),
 			MemoType:        xdr.MemoTypeMemoText.String(),
+			Successful:      true,
 		},
 		{
 			OperationID:     "2",
@@ -91,6 +97,7 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 			CreatedAt:       time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC),
 			Memo:            utils.PointOf("123"),
 			MemoType:        xdr.MemoTypeMemoId.String(),
+			Successful:      true,
 		},
 		{
 			OperationID:     "3",
@@ -110,6 +117,7 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 			CreatedAt:       time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC),
 			Memo:            nil,
 			MemoType:        xdr.MemoTypeMemoNone.String(),
+			Successful:      true,
 		},
 	}
 	data.InsertTestPayments(t, ctx, dbPayments, dbConnectionPool)
@@ -144,6 +152,7 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 			"payments": [
 				{
 					"createdAt": "2024-06-23T00:00:00Z",
+					"contractEventType": null,
 					"destAmount": 30,
 					"destAssetCode": "USDC",
 					"destAssetIssuer": "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
@@ -152,7 +161,9 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 					"memo": null,
 					"memoType": "MemoTypeMemoNone",
 					"operationId": "3",
+					"eventIndex": 0,
 					"operationType": "OperationTypePathPaymentStrictSend",
+					"successful": true,
 					"srcAmount": 300,
 					"srcAssetCode": "XLM",
 					"srcAssetIssuer": "",
@@ -163,6 +174,7 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 				},
 				{
 					"createdAt": "2024-06-22T00:00:00Z",
+					"contractEventType": null,
 					"destAmount": 20,
 					"destAssetCode": "USDC",
 					"destAssetIssuer": "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
@@ -171,7 +183,9 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 					"memo": "123",
 					"memoType": "MemoTypeMemoId",
 					"operationId": "2",
+					"eventIndex": 0,
 					"operationType": "OperationTypePayment",
+					"successful": true,
 					"srcAmount": 20,
 					"srcAssetCode": "USDC",
 					"srcAssetIssuer": "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
@@ -182,6 +196,7 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 				},
 				{
 					"createdAt": "2024-06-21T00:00:00Z",
+					"contractEventType": null,
 					"destAmount": 10,
 					"destAssetCode": "XLM",
 					"destAssetIssuer": "",
@@ -190,7 +205,9 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 					"memo": "test",
 					"memoType": "MemoTypeMemoText",
 					"operationId": "1",
+					"eventIndex": 0,
 					"operationType": "OperationTypePayment",
+					"successful": true,
 					"srcAmount": 10,
 					"srcAssetCode": "XLM",
 					"srcAssetIssuer": "",
@@ -235,6 +252,7 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 			"payments": [
 				{
 					"createdAt": "2024-06-22T00:00:00Z",
+					"contractEventType": null,
 					"destAmount": 20,
 					"destAssetCode": "USDC",
 					"destAssetIssuer": "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
@@ -243,7 +261,9 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 					"memo": "123",
 					"memoType": "MemoTypeMemoId",
 					"operationId": "2",
+					"eventIndex": 0,
 					"operationType": "OperationTypePayment",
+					"successful": true,
 					"srcAmount": 20,
 					"srcAssetCode": "USDC",
 					"srcAssetIssuer": "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
@@ -258,6 +278,56 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 		mockMetricsService.AssertExpectations(t)
 	})
 
+	t.Run("filter_addresses", func(t *testing.T) {
+		handler, mockMetricsService := setupTest()
+		r := setupRouter(handler)
+
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return().Times(2)
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return().Times(2)
+
+		query := url.Values{"addresses[]": []string{
+			"GASP7HTICNNA2U5RKMPRQELEUJFO7PBB3AKKRGTAG23QVG255ESPZW2L",
+			"GDB4RW6QFWMGHGI6JTIKMGVUUQO7NNOLSFDMCOMUCCWHMAMFL3FH4Q2J",
+			"GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN",
+		}}
+		req, err := http.NewRequest(http.MethodGet, "/payments?"+query.Encode(), nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp PaymentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Payments, 2)
+		assert.Equal(t, "3", resp.Payments[0].OperationID)
+		assert.False(t, resp.Payments[0].Internal)
+		assert.Equal(t, "2", resp.Payments[1].OperationID)
+		assert.True(t, resp.Payments[1].Internal)
+		assert.Equal(t, "http://testing.com?"+query.Encode()+"&limit=50&sort=DESC", resp.Links.Self)
+		mockMetricsService.AssertExpectations(t)
+	})
+
+	t.Run("filter_memo", func(t *testing.T) {
+		handler, mockMetricsService := setupTest()
+		r := setupRouter(handler)
+
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return().Times(2)
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return().Times(2)
+
+		req, err := http.NewRequest(http.MethodGet, "/payments?memo=0123&memoType=MemoTypeMemoId", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp PaymentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Payments, 1)
+		assert.Equal(t, "2", resp.Payments[0].OperationID)
+		assert.Equal(t, "http://testing.com?limit=50&memo=123&memoType=MemoTypeMemoId&sort=DESC", resp.Links.Self)
+		mockMetricsService.AssertExpectations(t)
+	})
+
 	t.Run("invalid_params_1", func(t *testing.T) {
 		handler, mockMetricsService := setupTest()
 		r := setupRouter(handler)
@@ -315,4 +385,457 @@ func TestPaymentHandlerGetPayments(t *testing.T) {
 		assert.JSONEq(t, expectedRespBody, string(respBody))
 		mockMetricsService.AssertExpectations(t)
 	})
+	t.Run("filter_muxed_address", func(t *testing.T) {
+		handler, mockMetricsService := setupTest()
+		r := setupRouter(handler)
+
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return().Times(4)
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return().Times(4)
+
+		const exchange = "GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN"
+		muxedExchange, err := xdr.MuxedAccountFromAccountId(exchange, 42)
+		require.NoError(t, err)
+		muxedPayment := dbPayments[0]
+		muxedPayment.OperationID, muxedPayment.TransactionID, muxedPayment.TransactionHash = "4", "44", "f1c4a8a4c4c8431f9c2c0a3a4c1bfa7e"
+		muxedPayment.ToAddress = exchange
+		muxedPayment.ToMuxedID, muxedPayment.ToMuxedAddress = utils.PointOf("42"), utils.PointOf(muxedExchange.Address())
+		data.InsertTestPayments(t, ctx, []data.Payment{muxedPayment}, dbConnectionPool)
+
+		expectedRespBody := `{
+			"_links": {
+				"next": "",
+				"prev": "",
+				"self": "http://testing.com?address=GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN&limit=50&muxedId=42&sort=DESC"
+			},
+			"payments": [
+				{
+					"createdAt": "2024-06-21T00:00:00Z",
+					"contractEventType": null,
+					"destAmount": 10,
+					"destAssetCode": "XLM",
+					"destAssetIssuer": "",
+					"destAssetType": "AssetTypeAssetTypeNative",
+					"fromAddress": "GD73EG2IJJQQTCD33JKPKEGS76CJJ4TQ7NHDQYMS4D3Z5FBHPML6M66W",
+					"memo": "test",
+					"memoType": "MemoTypeMemoText",
+					"operationId": "4",
+					"eventIndex": 0,
+					"operationType": "OperationTypePayment",
+					"successful": true,
+					"srcAmount": 10,
+					"srcAssetCode": "XLM",
+					"srcAssetIssuer": "",
+					"srcAssetType": "AssetTypeAssetTypeNative",
+					"toAddress": "GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN",
+					"toMuxedId": "42",
+					"toMuxedAddress": "` + muxedExchange.Address() + `",
+					"transactionHash": "f1c4a8a4c4c8431f9c2c0a3a4c1bfa7e",
+					"transactionId": "44"
+				}
+			]
+		}`
+
+		// the M-address and the G-address with the mux ID are equivalent
+		for _, query := range []string{"address=" + muxedExchange.Address(), "address=" + exchange + "&muxedId=42"} {
+			req, err := http.NewRequest(http.MethodGet, "/payments?"+query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			respBody, err := io.ReadAll(rr.Result().Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, expectedRespBody, string(respBody))
+		}
+		mockMetricsService.AssertExpectations(t)
+	})
+
+	t.Run("invalid_muxed_id", func(t *testing.T) {
+		handler, mockMetricsService := setupTest()
+		r := setupRouter(handler)
+
+		muxedAccount, err := xdr.MuxedAccountFromAccountId("GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN", 42)
+		require.NoError(t, err)
+
+		testCases := []struct {
+			query        string
+			wantRespBody string
+		}{
+			{
+				query:        "muxedId=42",
+				wantRespBody: `{"error": "Validation error.", "extras": {"muxedId": "The address is required to filter by muxed ID"}}`,
+			},
+			{
+				query:        "address=" + muxedAccount.Address() + "&muxedId=43",
+				wantRespBody: `{"error": "Validation error.", "extras": {"muxedId": "The muxed ID doesn't match the one in the muxed address"}}`,
+			},
+		}
+		for _, tc := range testCases {
+			req, err := http.NewRequest(http.MethodGet, "/payments?"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			respBody, err := io.ReadAll(rr.Result().Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.wantRespBody, string(respBody))
+		}
+		mockMetricsService.AssertExpectations(t)
+	})
+
+	t.Run("invalid_filters", func(t *testing.T) {
+		handler, mockMetricsService := setupTest()
+		r := setupRouter(handler)
+
+		testCases := []struct {
+			query        string
+			wantRespBody string
+		}{
+			{
+				query:        "direction=received",
+				wantRespBody: `{"error": "Validation error.", "extras": {"direction": "The address is required to filter by direction"}}`,
+			},
+			{
+				query:        "assetIssuer=GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5",
+				wantRespBody: `{"error": "Validation error.", "extras": {"assetCode": "The asset code is required to filter by asset issuer"}}`,
+			},
+			{
+				query:        "operationType=OperationTypeUnknown",
+				wantRespBody: `{"error": "Validation error.", "extras": {"operationType": "Unknown operation type"}}`,
+			},
+			{
+				query:        "from=2024-06-23T00:00:00Z&to=2024-06-22T00:00:00Z",
+				wantRespBody: `{"error": "Validation error.", "extras": {"to": "Should be after from"}}`,
+			},
+			{
+				query:        "address=GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN&addresses[]=GASP7HTICNNA2U5RKMPRQELEUJFO7PBB3AKKRGTAG23QVG255ESPZW2L",
+				wantRespBody: `{"error": "Validation error.", "extras": {"addresses[]": "Either address or addresses[] should be provided, not both"}}`,
+			},
+			{
+				query:        "address=GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN&externalUserId=user-1",
+				wantRespBody: `{"error": "Validation error.", "extras": {"externalUserId": "Either addresses or externalUserId should be provided, not both"}}`,
+			},
+			{
+				query:        "addresses[]=MA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJUAAAAAAAAAAAACJUQ",
+				wantRespBody: `{"error": "Validation error.", "extras": {"addresses[]": "Muxed addresses aren't supported, use address instead"}}`,
+			},
+			{
+				query:        "memo=abc&memoType=MemoTypeMemoHash",
+				wantRespBody: `{"error": "Validation error.", "extras": {"memo": "Invalid memo for the memo type"}}`,
+			},
+			{
+				query:        "direction=both&address=GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN",
+				wantRespBody: `{"error": "Validation error.", "extras": {"direction": "Unexpected value \"both\". Expected one of the following values: sent, received"}}`,
+			},
+		}
+		for _, tc := range testCases {
+			req, err := http.NewRequest(http.MethodGet, "/payments?"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			respBody, err := io.ReadAll(rr.Result().Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.wantRespBody, string(respBody))
+		}
+		mockMetricsService.AssertExpectations(t)
+	})
+}
+
+type fakePaymentSubscriber struct {
+	wakeUps chan struct{}
+}
+
+func (s *fakePaymentSubscriber) Subscribe() (<-chan struct{}, func()) {
+	return s.wakeUps, func() {}
+}
+
+func TestPaymentHandlerStreamPayments(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.Anything).Return()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Return()
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	paymentService, err := services.NewPaymentService(models, "http://testing.com")
+	require.NoError(t, err)
+	subscriber := &fakePaymentSubscriber{wakeUps: make(chan struct{}, 1)}
+	handler := &PaymentHandler{
+		PaymentService:    paymentService,
+		PaymentSubscriber: subscriber,
+	}
+	r := chi.NewRouter()
+	r.Get("/payments/stream", handler.StreamPayments)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	ctx := context.Background()
+	const address = "GAX6VPTVC2YNJM52OYMJAZKTQMSLNQ6NKYYU77KSGRVHINZ2D3EUJWAN"
+	newPayment := func(operationID string) data.Payment {
+		return data.Payment{
+			OperationID:   operationID,
+			OperationType: xdr.OperationTypePayment.String(),
+			TransactionID: operationID,
+			FromAddress:   "GD73EG2IJJQQTCD33JKPKEGS76CJJ4TQ7NHDQYMS4D3Z5FBHPML6M66W",
+			ToAddress:     address,
+			SrcAssetCode:  "XLM",
+			SrcAssetType:  xdr.AssetTypeAssetTypeNative.String(),
+			SrcAmount:     10,
+			DestAssetCode: "XLM",
+			DestAssetType: xdr.AssetTypeAssetTypeNative.String(),
+			DestAmount:    10,
+			CreatedAt:     time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
+			MemoType:      xdr.MemoTypeMemoNone.String(),
+			Successful:    true,
+		}
+	}
+	data.InsertTestPayments(t, ctx, []data.Payment{newPayment("1"), newPayment("2")}, dbConnectionPool)
+
+	openStream := func(t *testing.T, lastEventID string) (*bufio.Reader, func()) {
+		reqCtx, cancel := context.WithCancel(ctx)
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, server.URL+"/payments/stream?address="+address, nil)
+		require.NoError(t, err)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		return bufio.NewReader(resp.Body), func() {
+			cancel()
+			resp.Body.Close()
+		}
+	}
+	// readEventID reads the next event and returns its ID
+	readEventID := func(t *testing.T, stream *bufio.Reader) string {
+		var id string
+		for {
+			line, err := stream.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" && id != "" {
+				return id
+			}
+			if value, ok := strings.CutPrefix(line, "id: "); ok {
+				id = value
+			}
+		}
+	}
+
+	t.Run("resumes_after_the_last_event_id", func(t *testing.T) {
+		stream, closeStream := openStream(t, "1")
+		defer closeStream()
+		assert.Equal(t, "2", readEventID(t, stream))
+
+		data.InsertTestPayments(t, ctx, []data.Payment{newPayment("3")}, dbConnectionPool)
+		subscriber.wakeUps <- struct{}{}
+		assert.Equal(t, "3", readEventID(t, stream))
+	})
+
+	t.Run("starts_after_the_latest_payment", func(t *testing.T) {
+		stream, closeStream := openStream(t, "")
+		defer closeStream()
+
+		data.InsertTestPayments(t, ctx, []data.Payment{newPayment("4")}, dbConnectionPool)
+		subscriber.wakeUps <- struct{}{}
+		assert.Equal(t, "4", readEventID(t, stream))
+	})
+
+	t.Run("invalid_last_event_id", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/payments/stream?address="+address, nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", "abc")
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		respBody, err := io.ReadAll(rr.Result().Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"error": "Validation error.", "extras": {"Last-Event-ID": "Should be a payment ID"}}`, string(respBody))
+	})
+}
+
+type fakePaymentExportService struct {
+	filter data.PaymentsFilter
+	format services.PaymentExportFormat
+	err    error
+}
+
+func (s *fakePaymentExportService) ExportPayments(_ context.Context, w io.Writer, filter data.PaymentsFilter, format services.PaymentExportFormat) (int, error) {
+	s.filter, s.format = filter, format
+	if s.err != nil {
+		return 0, s.err
+	}
+	_, err := io.WriteString(w, "exported\n")
+	return 1, err
+}
+
+func TestPaymentHandlerExportPayments(t *testing.T) {
+	address := keypair.MustRandom().Address()
+	exportService := &fakePaymentExportService{}
+	handler := &PaymentHandler{
+		PaymentExportService: exportService,
+	}
+	r := chi.NewRouter()
+	r.Get("/payments/export", handler.ExportPayments)
+	serve := func(target string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("🟢csv_by_default", func(t *testing.T) {
+		rr := serve("/payments/export?address=" + address + "&from=2024-01-01T00:00:00Z&to=2025-01-01T00:00:00Z")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="payments-`+address+`.csv"`, rr.Header().Get("Content-Disposition"))
+		assert.Equal(t, "exported\n", rr.Body.String())
+		assert.Equal(t, services.PaymentExportCSV, exportService.format)
+		assert.Equal(t, address, exportService.filter.Address)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *exportService.filter.From)
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), *exportService.filter.To)
+	})
+
+	t.Run("🟢jsonl", func(t *testing.T) {
+		rr := serve("/payments/export?address=" + address + "&format=jsonl")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+		assert.Equal(t, services.PaymentExportJSONL, exportService.format)
+	})
+
+	t.Run("🔴invalid_params", func(t *testing.T) {
+		for _, target := range []string{
+			"/payments/export",
+			"/payments/export?address=" + address + "&format=xlsx",
+			"/payments/export?address=" + address + "&from=2025-01-01T00:00:00Z&to=2024-01-01T00:00:00Z",
+		} {
+			rr := serve(target)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+		}
+	})
+
+	t.Run("🔴failed_before_writing", func(t *testing.T) {
+		exportService.err = assert.AnError
+		rr := serve("/payments/export?address=" + address)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.NotContains(t, rr.Header().Get("Content-Disposition"), "attachment")
+	})
+}
+
+type fakePaymentAggregatesService struct {
+	services.PaymentService
+	filter data.PaymentAggregatesFilter
+}
+
+func (s *fakePaymentAggregatesService) GetPaymentAggregates(_ context.Context, filter data.PaymentAggregatesFilter) ([]data.PaymentAggregate, error) {
+	s.filter = filter
+	return []data.PaymentAggregate{
+		{Bucket: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), AssetCode: "XLM", SentAmount: "100", SentCount: 1, ReceivedAmount: "0"},
+	}, nil
+}
+
+func TestPaymentHandlerGetPaymentAggregates(t *testing.T) {
+	address := keypair.MustRandom().Address()
+	paymentService := &fakePaymentAggregatesService{}
+	handler := &PaymentHandler{
+		PaymentService: paymentService,
+	}
+	r := chi.NewRouter()
+	r.Get("/payments/aggregates", handler.GetPaymentAggregates)
+	serve := func(target string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("🟢daily_by_default", func(t *testing.T) {
+		rr := serve("/payments/aggregates?address=" + address + "&assetCode=XLM")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"aggregates": [{
+			"bucket": "2024-06-03T00:00:00Z", "assetCode": "XLM", "assetIssuer": "",
+			"sentAmount": "100", "sentCount": 1, "receivedAmount": "0", "receivedCount": 0
+		}]}`, rr.Body.String())
+		assert.Equal(t, data.PaymentAggregatesFilter{Address: address, Interval: data.PaymentAggregateDay, AssetCode: "XLM"}, paymentService.filter)
+	})
+
+	t.Run("🟢monthly_of_the_registered_accounts", func(t *testing.T) {
+		rr := serve("/payments/aggregates?interval=month&from=2024-01-01T00:00:00Z")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, paymentService.filter.Address)
+		assert.Equal(t, data.PaymentAggregateMonth, paymentService.filter.Interval)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *paymentService.filter.From)
+	})
+
+	t.Run("🔴invalid_params", func(t *testing.T) {
+		testCases := []struct {
+			query        string
+			wantRespBody string
+		}{
+			{
+				query:        "interval=year",
+				wantRespBody: `{"error": "Validation error.", "extras": {"interval": "Unexpected value \"year\". Expected one of the following values: day, week, month"}}`,
+			},
+			{
+				query:        "address=MA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJUAAAAAAAAAAAACJUQ",
+				wantRespBody: `{"error": "Validation error.", "extras": {"address": "Muxed addresses aren't supported"}}`,
+			},
+			{
+				query:        "assetIssuer=" + address,
+				wantRespBody: `{"error": "Validation error.", "extras": {"assetCode": "The asset code is required to filter by asset issuer"}}`,
+			},
+			{
+				query:        "from=2024-06-02T00:00:00Z&to=2024-06-01T00:00:00Z",
+				wantRespBody: `{"error": "Validation error.", "extras": {"to": "Should be after from"}}`,
+			},
+		}
+		for _, tc := range testCases {
+			rr := serve("/payments/aggregates?" + tc.query)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, tc.query)
+			assert.JSONEq(t, tc.wantRespBody, rr.Body.String(), tc.query)
+		}
+	})
+}
+
+func TestStoredMemo(t *testing.T) {
+	const hexHash = "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887"
+	testCases := []struct {
+		name     string
+		memo     string
+		memoType string
+		want     string
+		wantOK   bool
+	}{
+		{name: "text", memo: "invoice 42", memoType: xdr.MemoTypeMemoText.String(), want: "invoice 42", wantOK: true},
+		{name: "any_type", memo: "12345", want: "12345", wantOK: true},
+		{name: "id", memo: "0012345", memoType: xdr.MemoTypeMemoId.String(), want: "12345", wantOK: true},
+		{name: "invalid_id", memo: "-1", memoType: xdr.MemoTypeMemoId.String()},
+		{name: "hex_hash", memo: strings.ToUpper(hexHash), memoType: xdr.MemoTypeMemoHash.String(), want: hexHash, wantOK: true},
+		{name: "base64_return", memo: "o9r/pk3EbbhIiLEgbcgBSkgAQuf+ixn9XQVGVwn06Ic=", memoType: xdr.MemoTypeMemoReturn.String(), want: hexHash, wantOK: true},
+		{name: "short_hash", memo: "a3daffa6", memoType: xdr.MemoTypeMemoHash.String()},
+		{name: "none", memo: "x", memoType: xdr.MemoTypeMemoNone.String()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			memo, ok := storedMemo(tc.memo, tc.memoType)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, memo)
+		})
+	}
 }
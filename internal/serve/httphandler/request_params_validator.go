@@ -3,6 +3,7 @@ package httphandler
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
@@ -22,6 +23,23 @@ func DecodeJSONAndValidate(ctx context.Context, req *http.Request, reqBody inter
 	return ValidateRequestParams(ctx, reqBody, appTracker)
 }
 
+// DecodeOptionalJSONAndValidate decodes and validates an optional body, returning false when it's empty. The length of
+// chunked bodies isn't known upfront, so an empty body is only noticed once it's read.
+func DecodeOptionalJSONAndValidate(ctx context.Context, req *http.Request, reqBody interface{}, appTracker apptracker.AppTracker) (bool, *httperror.ErrorResponse) {
+	if req.Body == nil {
+		return false, nil
+	}
+	err := httpdecode.DecodeJSON(req, reqBody)
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, httperror.BadRequest("Invalid request body.", nil)
+	}
+
+	return true, ValidateRequestParams(ctx, reqBody, appTracker)
+}
+
 func DecodeQueryAndValidate(ctx context.Context, req *http.Request, reqQuery interface{}, appTracker apptracker.AppTracker) *httperror.ErrorResponse {
 	err := httpdecode.DecodeQuery(req, reqQuery)
 	if err != nil {
@@ -0,0 +1,48 @@
+package httphandler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/stellar/go/support/render/httpjson"
+
+	"github.com/stellar/wallet-backend/internal/apptracker"
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/serve/httperror"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+type TransactionHandler struct {
+	TransactionService services.TransactionService
+	AppTracker         apptracker.AppTracker
+}
+
+type TransactionPathParams struct {
+	Hash string `path:"hash" validate:"required,len=64,hexadecimal"`
+}
+
+// GetTransaction returns the ingested transaction with the hash, along with its operations and payments.
+func (h TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var reqPath TransactionPathParams
+	httpErr := DecodePathAndValidate(ctx, r, &reqPath, h.AppTracker)
+	if httpErr != nil {
+		httpErr.Render(w)
+		return
+	}
+
+	// hashes are stored in lowercase hex
+	transaction, err := h.TransactionService.GetTransaction(ctx, strings.ToLower(reqPath.Hash))
+	if err != nil {
+		if errors.Is(err, data.ErrTransactionNotFound) {
+			httperror.NotFound.Render(w)
+			return
+		}
+		httperror.InternalServerError(ctx, "", err, nil, h.AppTracker).Render(w)
+		return
+	}
+
+	httpjson.Render(w, transaction, httpjson.JSON)
+}
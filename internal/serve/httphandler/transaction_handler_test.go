@@ -0,0 +1,72 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/services"
+)
+
+type fakeTransactionService struct {
+	hashes []string
+}
+
+func (s *fakeTransactionService) GetTransaction(_ context.Context, hash string) (*services.TransactionDetails, error) {
+	s.hashes = append(s.hashes, hash)
+	if !strings.HasPrefix(hash, "a3") {
+		return nil, data.ErrTransactionNotFound
+	}
+	return &services.TransactionDetails{
+		Operations: []data.Operation{},
+		Payments:   []data.Payment{{OperationID: "2120562792996865", TransactionHash: hash}},
+	}, nil
+}
+
+func TestTransactionHandlerGetTransaction(t *testing.T) {
+	transactionService := &fakeTransactionService{}
+	handler := &TransactionHandler{
+		TransactionService: transactionService,
+	}
+	r := chi.NewRouter()
+	r.Get("/transactions/{hash}", handler.GetTransaction)
+	serve := func(target string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("🟢found", func(t *testing.T) {
+		rr := serve("/transactions/A3DAFFA64DC46DB84888B1206DC8014A480042E7FE8B19FD5D05465709F4E887")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var details services.TransactionDetails
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &details))
+		assert.Nil(t, details.Transaction)
+		assert.Empty(t, details.Operations)
+		require.Len(t, details.Payments, 1)
+		assert.Equal(t, "2120562792996865", details.Payments[0].OperationID)
+		assert.Equal(t, "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887", transactionService.hashes[len(transactionService.hashes)-1])
+	})
+
+	t.Run("🔴not_found", func(t *testing.T) {
+		rr := serve("/transactions/b3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("🔴invalid_hash", func(t *testing.T) {
+		for _, hash := range []string{"a3daffa6", "z3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887"} {
+			rr := serve("/transactions/" + hash)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, hash)
+		}
+	})
+}
@@ -88,6 +88,14 @@ type handlerDeps struct {
 	AccountService            services.AccountService
 	AccountSponsorshipService services.AccountSponsorshipService
 	PaymentService            services.PaymentService
+	PaymentListener           *services.PaymentListener
+	PaymentExportService      services.PaymentExportService
+	OperationService          services.OperationService
+	BalanceChangeService      services.BalanceChangeService
+	FeeService                services.FeeService
+	AccountWebhookService     services.AccountWebhookService
+	TransactionService        services.TransactionService
+	AccountBalanceService     services.AccountBalanceService
 	MetricsService            metrics.MetricsService
 	// TSS
 	RPCCallerChannel      tss.Channel
@@ -116,6 +124,7 @@ func Serve(cfg Configs) error {
 		OnStarting: func() {
 			log.Infof("🌐 Starting Wallet Backend server on port %d", cfg.Port)
 			go populatePools(ctx, deps.PoolPopulator)
+			go deps.PaymentListener.Run(ctx)
 		},
 		OnStopping: func() {
 			log.Info("Stopping Wallet Backend server")
@@ -123,6 +132,9 @@ func Serve(cfg Configs) error {
 			deps.ErrorNonJitterChannel.Stop()
 			deps.RPCCallerChannel.Stop()
 			deps.WebhookChannel.Stop()
+			if err := deps.PaymentListener.Close(); err != nil {
+				log.Errorf("Error closing the payment listener: %v", err)
+			}
 		},
 	})
 
@@ -182,6 +194,46 @@ func initHandlerDeps(ctx context.Context, cfg Configs) (handlerDeps, error) {
 		return handlerDeps{}, fmt.Errorf("instantiating payment service: %w", err)
 	}
 
+	operationService, err := services.NewOperationService(models, cfg.ServerBaseURL)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating operation service: %w", err)
+	}
+
+	balanceChangeService, err := services.NewBalanceChangeService(models, cfg.ServerBaseURL)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating balance change service: %w", err)
+	}
+
+	paymentListener, err := services.NewPaymentListener(cfg.DatabaseURL)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating payment listener: %w", err)
+	}
+
+	paymentExportService, err := services.NewPaymentExportService(models)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating payment export service: %w", err)
+	}
+
+	feeService, err := services.NewFeeService(models)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating fee service: %w", err)
+	}
+
+	accountWebhookService, err := services.NewAccountWebhookService(models)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating account webhook service: %w", err)
+	}
+
+	transactionService, err := services.NewTransactionService(models)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating transaction service: %w", err)
+	}
+
+	accountBalanceService, err := services.NewAccountBalanceService(rpcService, cfg.SupportedAssets, cfg.NetworkPassphrase)
+	if err != nil {
+		return handlerDeps{}, fmt.Errorf("instantiating account balance service: %w", err)
+	}
+
 	// TSS setup
 	tssTxService, err := tssservices.NewTransactionService(tssservices.TransactionServiceOptions{
 		DB:                                 dbConnectionPool,
@@ -287,6 +339,14 @@ func initHandlerDeps(ctx context.Context, cfg Configs) (handlerDeps, error) {
 		AccountService:            accountService,
 		AccountSponsorshipService: accountSponsorshipService,
 		PaymentService:            paymentService,
+		PaymentListener:           paymentListener,
+		PaymentExportService:      paymentExportService,
+		OperationService:          operationService,
+		BalanceChangeService:      balanceChangeService,
+		FeeService:                feeService,
+		AccountWebhookService:     accountWebhookService,
+		TransactionService:        transactionService,
+		AccountBalanceService:     accountBalanceService,
 		MetricsService:            metricsService,
 		AppTracker:                cfg.AppTracker,
 		NetworkPassphrase:         cfg.NetworkPassphrase,
@@ -341,21 +401,73 @@ func handler(deps handlerDeps) http.Handler {
 			handler := &httphandler.AccountHandler{
 				AccountService:            deps.AccountService,
 				AccountSponsorshipService: deps.AccountSponsorshipService,
+				AccountBalanceService:     deps.AccountBalanceService,
 				SupportedAssets:           deps.SupportedAssets,
 				AppTracker:                deps.AppTracker,
 			}
 
+			r.Get("/", handler.ListAccounts)
 			r.Post("/{address}", handler.RegisterAccount)
 			r.Delete("/{address}", handler.DeregisterAccount)
+			r.Get("/{address}/backfill", handler.GetBackfillJob)
+			r.Get("/{address}/balances", handler.GetAccountBalances)
+
+			operationHandler := &httphandler.OperationHandler{
+				OperationService: deps.OperationService,
+				AppTracker:       deps.AppTracker,
+			}
+			r.Get("/{address}/operations", operationHandler.GetAccountOperations)
+
+			balanceChangeHandler := &httphandler.BalanceChangeHandler{
+				BalanceChangeService: deps.BalanceChangeService,
+				AppTracker:           deps.AppTracker,
+			}
+			r.Get("/{address}/balance-history", balanceChangeHandler.GetAccountBalanceHistory)
 		})
 
 		r.Route("/payments", func(r chi.Router) {
 			handler := &httphandler.PaymentHandler{
-				PaymentService: deps.PaymentService,
-				AppTracker:     deps.AppTracker,
+				PaymentService:       deps.PaymentService,
+				PaymentSubscriber:    deps.PaymentListener,
+				PaymentExportService: deps.PaymentExportService,
+				AppTracker:           deps.AppTracker,
 			}
 
 			r.Get("/", handler.GetPayments)
+			r.Get("/stream", handler.StreamPayments)
+			r.Get("/export", handler.ExportPayments)
+			r.Get("/aggregates", handler.GetPaymentAggregates)
+		})
+
+		r.Route("/transactions", func(r chi.Router) {
+			handler := &httphandler.TransactionHandler{
+				TransactionService: deps.TransactionService,
+				AppTracker:         deps.AppTracker,
+			}
+
+			r.Get("/{hash}", handler.GetTransaction)
+		})
+
+		r.Route("/fees", func(r chi.Router) {
+			handler := &httphandler.FeeHandler{
+				FeeService: deps.FeeService,
+				AppTracker: deps.AppTracker,
+			}
+
+			r.Get("/summary", handler.GetFeeSummary)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			handler := &httphandler.AccountWebhookHandler{
+				AccountWebhookService: deps.AccountWebhookService,
+				AppTracker:            deps.AppTracker,
+			}
+
+			r.Post("/", handler.CreateWebhook)
+			r.Get("/{id}", handler.GetWebhook)
+			r.Delete("/{id}", handler.DeleteWebhook)
+			r.Get("/{id}/deliveries", handler.GetDeliveries)
+			r.Post("/{id}/replay", handler.ReplayDeliveries)
 		})
 
 		r.Route("/tx", func(r chi.Router) {
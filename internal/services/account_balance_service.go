@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+// baseReserve is the network base reserve in stroops. It can only be changed by a validators vote and has been 0.5 XLM
+// on both pubnet and testnet since protocol 11.
+const baseReserve = 5_000_000
+
+// AccountBalances are the live balances of an account or contract, as of LatestLedger.
+type AccountBalances struct {
+	Address      string `json:"address"`
+	LatestLedger uint32 `json:"latestLedger"`
+	// Reserves is only set for Stellar accounts, contracts don't have a minimum balance.
+	Reserves *AccountReserves `json:"reserves,omitempty"`
+	Balances []AssetBalance   `json:"balances"`
+}
+
+type AccountReserves struct {
+	NumSubEntries  uint32 `json:"numSubEntries"`
+	NumSponsoring  uint32 `json:"numSponsoring"`
+	NumSponsored   uint32 `json:"numSponsored"`
+	BaseReserve    string `json:"baseReserve"`
+	MinimumBalance string `json:"minimumBalance"`
+	// Available is the native balance that can be spent: what's left above the minimum balance and the selling liabilities.
+	Available string `json:"available"`
+}
+
+type AssetBalance struct {
+	// AssetType is one of native, credit_alphanum4 or credit_alphanum12.
+	AssetType   string `json:"assetType"`
+	AssetCode   string `json:"assetCode,omitempty"`
+	AssetIssuer string `json:"assetIssuer,omitempty"`
+	// ContractID is the Stellar Asset Contract holding the balance, only set for the balances of contracts.
+	ContractID                      string `json:"contractId,omitempty"`
+	Balance                         string `json:"balance"`
+	Limit                           string `json:"limit,omitempty"`
+	BuyingLiabilities               string `json:"buyingLiabilities,omitempty"`
+	SellingLiabilities              string `json:"sellingLiabilities,omitempty"`
+	Authorized                      bool   `json:"authorized"`
+	AuthorizedToMaintainLiabilities bool   `json:"authorizedToMaintainLiabilities"`
+	ClawbackEnabled                 bool   `json:"clawbackEnabled"`
+	LastModifiedLedger              uint32 `json:"lastModifiedLedger"`
+}
+
+type AccountBalanceService interface {
+	// GetAccountBalances returns the native and supported assets balances of a Stellar account (G or M address) from its
+	// account and trustline entries, or of a contract (C address) from its Stellar Asset Contract balance entries.
+	GetAccountBalances(ctx context.Context, address string) (*AccountBalances, error)
+}
+
+var _ AccountBalanceService = (*accountBalanceService)(nil)
+
+type accountBalanceService struct {
+	rpcService        RPCService
+	supportedAssets   []entities.Asset
+	networkPassphrase string
+}
+
+func NewAccountBalanceService(rpcService RPCService, supportedAssets []entities.Asset, networkPassphrase string) (*accountBalanceService, error) {
+	if rpcService == nil {
+		return nil, errors.New("rpcService cannot be nil")
+	}
+	if networkPassphrase == "" {
+		return nil, errors.New("networkPassphrase cannot be empty")
+	}
+
+	return &accountBalanceService{
+		rpcService:        rpcService,
+		supportedAssets:   supportedAssets,
+		networkPassphrase: networkPassphrase,
+	}, nil
+}
+
+func (s *accountBalanceService) GetAccountBalances(_ context.Context, address string) (*AccountBalances, error) {
+	if strkey.IsValidContractAddress(address) {
+		return s.getContractBalances(address)
+	}
+	return s.getAccountBalances(address)
+}
+
+// creditAssets returns the supported assets as XDR, in the order they were configured.
+func (s *accountBalanceService) creditAssets() ([]xdr.Asset, error) {
+	assets := make([]xdr.Asset, 0, len(s.supportedAssets))
+	for _, supportedAsset := range s.supportedAssets {
+		asset, err := xdr.NewCreditAsset(supportedAsset.Code, supportedAsset.Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("building supported asset %s:%s: %w", supportedAsset.Code, supportedAsset.Issuer, err)
+		}
+		assets = append(assets, asset)
+	}
+	return assets, nil
+}
+
+// getAccountBalances fetches the account entry and the trustlines of the supported assets in a single getLedgerEntries
+// call. Supported assets the account doesn't trust are left out of the balances.
+func (s *accountBalanceService) getAccountBalances(address string) (*AccountBalances, error) {
+	muxedAccount, err := xdr.AddressToMuxedAccount(address)
+	if err != nil {
+		return nil, fmt.Errorf("decoding address %s: %w", address, err)
+	}
+	accountID := muxedAccount.ToAccountId()
+
+	accountKey, err := utils.GetAccountLedgerKey(accountID.Address())
+	if err != nil {
+		return nil, fmt.Errorf("getting account ledger key: %w", err)
+	}
+	assets, err := s.creditAssets()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{accountKey}
+	for _, asset := range assets {
+		var key xdr.LedgerKey
+		if err = key.SetTrustline(accountID, asset.ToTrustLineAsset()); err != nil {
+			return nil, fmt.Errorf("building trustline ledger key: %w", err)
+		}
+		keyXDR, err := key.MarshalBinaryBase64()
+		if err != nil {
+			return nil, fmt.Errorf("marshalling trustline ledger key: %w", err)
+		}
+		keys = append(keys, keyXDR)
+	}
+
+	result, err := s.rpcService.GetLedgerEntries(keys)
+	if err != nil {
+		return nil, fmt.Errorf("getting ledger entries: %w", err)
+	}
+
+	var nativeBalance *AssetBalance
+	var reserves *AccountReserves
+	trustlines := make(map[string]AssetBalance, len(assets))
+	for _, entry := range result.Entries {
+		var entryData xdr.LedgerEntryData
+		if err = xdr.SafeUnmarshalBase64(entry.DataXDR, &entryData); err != nil {
+			return nil, fmt.Errorf("decoding ledger entry: %w", err)
+		}
+
+		switch entryData.Type {
+		case xdr.LedgerEntryTypeAccount:
+			nativeBalance, reserves = accountEntryBalance(entryData.MustAccount(), entry.LastModifiedLedger)
+		case xdr.LedgerEntryTypeTrustline:
+			trustline := entryData.MustTrustLine()
+			balance, err := trustlineBalance(trustline, entry.LastModifiedLedger)
+			if err != nil {
+				return nil, err
+			}
+			trustlines[trustline.Asset.ToAsset().StringCanonical()] = balance
+		}
+	}
+	if nativeBalance == nil {
+		return nil, fmt.Errorf("%w: entry not found for account %s", ErrAccountNotFound, address)
+	}
+
+	balances := []AssetBalance{*nativeBalance}
+	for _, asset := range assets {
+		if balance, ok := trustlines[asset.StringCanonical()]; ok {
+			balances = append(balances, balance)
+		}
+	}
+	return &AccountBalances{
+		Address:      address,
+		LatestLedger: result.LatestLedger,
+		Reserves:     reserves,
+		Balances:     balances,
+	}, nil
+}
+
+func accountEntryBalance(account xdr.AccountEntry, lastModifiedLedger uint32) (*AssetBalance, *AccountReserves) {
+	liabilities := account.Liabilities()
+	numSubEntries, numSponsoring, numSponsored := uint32(account.NumSubEntries), uint32(account.NumSponsoring()), uint32(account.NumSponsored())
+
+	minimumBalance := (2 + int64(numSubEntries) + int64(numSponsoring) - int64(numSponsored)) * baseReserve
+	available := int64(account.Balance) - minimumBalance - int64(liabilities.Selling)
+	if available < 0 {
+		available = 0
+	}
+
+	nativeBalance := &AssetBalance{
+		AssetType:          xdr.AssetTypeToString[xdr.AssetTypeAssetTypeNative],
+		Balance:            amount.String(account.Balance),
+		BuyingLiabilities:  amount.String(liabilities.Buying),
+		SellingLiabilities: amount.String(liabilities.Selling),
+		Authorized:         true,
+		LastModifiedLedger: lastModifiedLedger,
+	}
+	reserves := &AccountReserves{
+		NumSubEntries:  numSubEntries,
+		NumSponsoring:  numSponsoring,
+		NumSponsored:   numSponsored,
+		BaseReserve:    amount.StringFromInt64(baseReserve),
+		MinimumBalance: amount.StringFromInt64(minimumBalance),
+		Available:      amount.StringFromInt64(available),
+	}
+	return nativeBalance, reserves
+}
+
+func trustlineBalance(trustline xdr.TrustLineEntry, lastModifiedLedger uint32) (AssetBalance, error) {
+	var assetType, assetCode, assetIssuer string
+	if err := trustline.Asset.Extract(&assetType, &assetCode, &assetIssuer); err != nil {
+		return AssetBalance{}, fmt.Errorf("extracting trustline asset: %w", err)
+	}
+	liabilities := trustline.Liabilities()
+	flags := xdr.TrustLineFlags(trustline.Flags)
+
+	return AssetBalance{
+		AssetType:                       assetType,
+		AssetCode:                       assetCode,
+		AssetIssuer:                     assetIssuer,
+		Balance:                         amount.String(trustline.Balance),
+		Limit:                           amount.String(trustline.Limit),
+		BuyingLiabilities:               amount.String(liabilities.Buying),
+		SellingLiabilities:              amount.String(liabilities.Selling),
+		Authorized:                      flags&xdr.TrustLineFlagsAuthorizedFlag != 0,
+		AuthorizedToMaintainLiabilities: flags&xdr.TrustLineFlagsAuthorizedToMaintainLiabilitiesFlag != 0,
+		ClawbackEnabled:                 flags&xdr.TrustLineFlagsTrustlineClawbackEnabledFlag != 0,
+		LastModifiedLedger:              lastModifiedLedger,
+	}, nil
+}
+
+// getContractBalances fetches the balance entries of the contract in the native and supported assets Stellar Asset
+// Contracts in a single getLedgerEntries call. Assets the contract never held are left out of the balances.
+func (s *accountBalanceService) getContractBalances(address string) (*AccountBalances, error) {
+	holderID, err := strkey.Decode(strkey.VersionByteContract, address)
+	if err != nil {
+		return nil, fmt.Errorf("decoding contract address %s: %w", address, err)
+	}
+	creditAssets, err := s.creditAssets()
+	if err != nil {
+		return nil, err
+	}
+	assets := append([]xdr.Asset{xdr.MustNewNativeAsset()}, creditAssets...)
+
+	keys := make([]string, 0, len(assets))
+	assetsByContractID := make(map[xdr.Hash]xdr.Asset, len(assets))
+	for _, asset := range assets {
+		contractID, err := asset.ContractID(s.networkPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("getting contract ID of asset %s: %w", asset.StringCanonical(), err)
+		}
+		keyXDR, err := sac.ContractBalanceLedgerKey(contractID, [32]byte(holderID)).MarshalBinaryBase64()
+		if err != nil {
+			return nil, fmt.Errorf("marshalling contract balance ledger key: %w", err)
+		}
+		keys = append(keys, keyXDR)
+		assetsByContractID[contractID] = asset
+	}
+
+	result, err := s.rpcService.GetLedgerEntries(keys)
+	if err != nil {
+		return nil, fmt.Errorf("getting ledger entries: %w", err)
+	}
+
+	contractBalances := make(map[xdr.Hash]AssetBalance, len(result.Entries))
+	for _, entry := range result.Entries {
+		var entryData xdr.LedgerEntryData
+		if err = xdr.SafeUnmarshalBase64(entry.DataXDR, &entryData); err != nil {
+			return nil, fmt.Errorf("decoding ledger entry: %w", err)
+		}
+		contractData, ok := entryData.GetContractData()
+		if !ok || contractData.Contract.ContractId == nil {
+			continue
+		}
+		contractID := *contractData.Contract.ContractId
+		asset, ok := assetsByContractID[contractID]
+		if !ok {
+			continue
+		}
+		balance, err := contractBalance(asset, contractID, contractData.Val, entry.LastModifiedLedger)
+		if err != nil {
+			return nil, err
+		}
+		contractBalances[contractID] = balance
+	}
+
+	balances := []AssetBalance{}
+	for _, asset := range assets {
+		contractID, err := asset.ContractID(s.networkPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("getting contract ID of asset %s: %w", asset.StringCanonical(), err)
+		}
+		if balance, ok := contractBalances[contractID]; ok {
+			balances = append(balances, balance)
+		}
+	}
+	return &AccountBalances{
+		Address:      address,
+		LatestLedger: result.LatestLedger,
+		Balances:     balances,
+	}, nil
+}
+
+// contractBalance decodes the {amount, authorized, clawback} map the Stellar Asset Contract stores as balance.
+func contractBalance(asset xdr.Asset, contractID xdr.Hash, val xdr.ScVal, lastModifiedLedger uint32) (AssetBalance, error) {
+	balanceMap, ok := val.GetMap()
+	if !ok || balanceMap == nil {
+		return AssetBalance{}, fmt.Errorf("unexpected contract balance value of type %s", val.Type)
+	}
+
+	var assetType, assetCode, assetIssuer string
+	if err := asset.Extract(&assetType, &assetCode, &assetIssuer); err != nil {
+		return AssetBalance{}, fmt.Errorf("extracting asset: %w", err)
+	}
+	balance := AssetBalance{
+		AssetType:          assetType,
+		AssetCode:          assetCode,
+		AssetIssuer:        assetIssuer,
+		ContractID:         strkey.MustEncode(strkey.VersionByteContract, contractID[:]),
+		LastModifiedLedger: lastModifiedLedger,
+	}
+	for _, mapEntry := range *balanceMap {
+		sym, ok := mapEntry.Key.GetSym()
+		if !ok {
+			continue
+		}
+		switch sym {
+		case "amount":
+			i128, ok := mapEntry.Val.GetI128()
+			if !ok {
+				return AssetBalance{}, errors.New("contract balance amount is not an i128")
+			}
+			balance.Balance = amount.String128(i128)
+		case "authorized":
+			balance.Authorized, _ = mapEntry.Val.GetB()
+		case "clawback":
+			balance.ClawbackEnabled, _ = mapEntry.Val.GetB()
+		}
+	}
+	return balance, nil
+}
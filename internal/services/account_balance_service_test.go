@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+func TestAccountBalanceServiceGetAccountBalances(t *testing.T) {
+	ctx := context.Background()
+	usdc := entities.Asset{Code: "USDC", Issuer: "GBBD47IF6LWK7P7MDEVSCWR7DPUWV3NY3DTQEVFL4NAT4AQH3ZLLFLA5"}
+	arst := entities.Asset{Code: "ARST", Issuer: "GB7TAYRUZGE6TVT7NHP5SMIZRNQA6PLM423EYISAOAP3MKYIQMVYP2JO"}
+	supportedAssets := []entities.Asset{usdc, arst}
+
+	accountKP := keypair.MustRandom()
+	accountID := xdr.MustAddress(accountKP.Address())
+	accountKey, err := utils.GetAccountLedgerKey(accountKP.Address())
+	require.NoError(t, err)
+	trustlineKey := func(asset entities.Asset) string {
+		var key xdr.LedgerKey
+		require.NoError(t, key.SetTrustline(accountID, xdr.MustNewCreditAsset(asset.Code, asset.Issuer).ToTrustLineAsset()))
+		keyXDR, err := key.MarshalBinaryBase64()
+		require.NoError(t, err)
+		return keyXDR
+	}
+	accountKeys := []string{accountKey, trustlineKey(usdc), trustlineKey(arst)}
+	entryResult := func(data xdr.LedgerEntryData, lastModifiedLedger uint32) entities.LedgerEntryResult {
+		dataXDR, err := xdr.MarshalBase64(data)
+		require.NoError(t, err)
+		return entities.LedgerEntryResult{DataXDR: dataXDR, LastModifiedLedger: lastModifiedLedger}
+	}
+
+	t.Run("🟢account_with_trustlines", func(t *testing.T) {
+		rpcService := NewRPCServiceMock(t)
+		service, err := NewAccountBalanceService(rpcService, supportedAssets, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+
+		accountEntry := xdr.AccountEntry{
+			AccountId:     accountID,
+			Balance:       xdr.Int64(100_0000000),
+			NumSubEntries: 1,
+			Ext: xdr.AccountEntryExt{V: 1, V1: &xdr.AccountEntryExtensionV1{
+				Liabilities: xdr.Liabilities{Buying: 0, Selling: 10_0000000},
+			}},
+		}
+		trustlineEntry := xdr.TrustLineEntry{
+			AccountId: accountID,
+			Asset:     xdr.MustNewCreditAsset(usdc.Code, usdc.Issuer).ToTrustLineAsset(),
+			Balance:   xdr.Int64(25_5000000),
+			Limit:     xdr.Int64(1000_0000000),
+			Flags:     xdr.Uint32(xdr.TrustLineFlagsAuthorizedFlag | xdr.TrustLineFlagsTrustlineClawbackEnabledFlag),
+		}
+		// entries aren't necessarily returned in the order of the keys
+		rpcService.On("GetLedgerEntries", accountKeys).Return(entities.RPCGetLedgerEntriesResult{
+			LatestLedger: 1000,
+			Entries: []entities.LedgerEntryResult{
+				entryResult(xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeTrustline, TrustLine: &trustlineEntry}, 900),
+				entryResult(xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeAccount, Account: &accountEntry}, 950),
+			},
+		}, nil).Once()
+
+		balances, err := service.GetAccountBalances(ctx, accountKP.Address())
+		require.NoError(t, err)
+		assert.Equal(t, &AccountBalances{
+			Address:      accountKP.Address(),
+			LatestLedger: 1000,
+			Reserves: &AccountReserves{
+				NumSubEntries:  1,
+				BaseReserve:    "0.5000000",
+				MinimumBalance: "1.5000000",
+				Available:      "88.5000000",
+			},
+			Balances: []AssetBalance{
+				{
+					AssetType:          "native",
+					Balance:            "100.0000000",
+					BuyingLiabilities:  "0.0000000",
+					SellingLiabilities: "10.0000000",
+					Authorized:         true,
+					LastModifiedLedger: 950,
+				},
+				{
+					AssetType:          "credit_alphanum4",
+					AssetCode:          usdc.Code,
+					AssetIssuer:        usdc.Issuer,
+					Balance:            "25.5000000",
+					Limit:              "1000.0000000",
+					BuyingLiabilities:  "0.0000000",
+					SellingLiabilities: "0.0000000",
+					Authorized:         true,
+					ClawbackEnabled:    true,
+					LastModifiedLedger: 900,
+				},
+			},
+		}, balances)
+	})
+
+	t.Run("🟢muxed_account_reads_the_underlying_account", func(t *testing.T) {
+		rpcService := NewRPCServiceMock(t)
+		service, err := NewAccountBalanceService(rpcService, supportedAssets, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+
+		muxedAccount, err := xdr.MuxedAccountFromAccountId(accountKP.Address(), 123)
+		require.NoError(t, err)
+		accountEntry := xdr.AccountEntry{AccountId: accountID, Balance: xdr.Int64(1_0000000)}
+		rpcService.On("GetLedgerEntries", accountKeys).Return(entities.RPCGetLedgerEntriesResult{
+			LatestLedger: 1000,
+			Entries: []entities.LedgerEntryResult{
+				entryResult(xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeAccount, Account: &accountEntry}, 950),
+			},
+		}, nil).Once()
+
+		balances, err := service.GetAccountBalances(ctx, muxedAccount.Address())
+		require.NoError(t, err)
+		assert.Equal(t, muxedAccount.Address(), balances.Address)
+		require.Len(t, balances.Balances, 1)
+		assert.Equal(t, "1.0000000", balances.Balances[0].Balance)
+		// the minimum balance is above the balance, so nothing can be spent
+		assert.Equal(t, "0.0000000", balances.Reserves.Available)
+	})
+
+	t.Run("🟢contract_sac_balances", func(t *testing.T) {
+		rpcService := NewRPCServiceMock(t)
+		service, err := NewAccountBalanceService(rpcService, []entities.Asset{usdc}, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+
+		var holderID [32]byte
+		copy(holderID[:], "a contract holding SAC balances")
+		contractAddress := strkey.MustEncode(strkey.VersionByteContract, holderID[:])
+		nativeContractID, err := xdr.MustNewNativeAsset().ContractID(network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		usdcContractID, err := xdr.MustNewCreditAsset(usdc.Code, usdc.Issuer).ContractID(network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		nativeKey, err := sac.ContractBalanceLedgerKey(nativeContractID, holderID).MarshalBinaryBase64()
+		require.NoError(t, err)
+		usdcKey, err := sac.ContractBalanceLedgerKey(usdcContractID, holderID).MarshalBinaryBase64()
+		require.NoError(t, err)
+
+		rpcService.On("GetLedgerEntries", []string{nativeKey, usdcKey}).Return(entities.RPCGetLedgerEntriesResult{
+			LatestLedger: 1000,
+			Entries: []entities.LedgerEntryResult{
+				entryResult(sac.BalanceToContractData(usdcContractID, holderID, 12_3456789), 800),
+			},
+		}, nil).Once()
+
+		balances, err := service.GetAccountBalances(ctx, contractAddress)
+		require.NoError(t, err)
+		assert.Equal(t, &AccountBalances{
+			Address:      contractAddress,
+			LatestLedger: 1000,
+			Balances: []AssetBalance{
+				{
+					AssetType:          "credit_alphanum4",
+					AssetCode:          usdc.Code,
+					AssetIssuer:        usdc.Issuer,
+					ContractID:         strkey.MustEncode(strkey.VersionByteContract, usdcContractID[:]),
+					Balance:            "12.3456789",
+					Authorized:         true,
+					ClawbackEnabled:    true,
+					LastModifiedLedger: 800,
+				},
+			},
+		}, balances)
+	})
+
+	t.Run("🔴account_not_found", func(t *testing.T) {
+		rpcService := NewRPCServiceMock(t)
+		service, err := NewAccountBalanceService(rpcService, supportedAssets, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+
+		rpcService.On("GetLedgerEntries", accountKeys).Return(entities.RPCGetLedgerEntriesResult{LatestLedger: 1000}, nil).Once()
+
+		_, err = service.GetAccountBalances(ctx, accountKP.Address())
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+
+	t.Run("🔴rpc_error", func(t *testing.T) {
+		rpcService := NewRPCServiceMock(t)
+		service, err := NewAccountBalanceService(rpcService, supportedAssets, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+
+		rpcService.On("GetLedgerEntries", accountKeys).Return(entities.RPCGetLedgerEntriesResult{}, errors.New("connection refused")).Once()
+
+		_, err = service.GetAccountBalances(ctx, accountKP.Address())
+		assert.EqualError(t, err, "getting ledger entries: connection refused")
+	})
+}
@@ -10,10 +10,17 @@ import (
 )
 
 type AccountService interface {
-	// RegisterAccount registers an externally created Stellar account to be sponsored, and tracked by ingestion
-	RegisterAccount(ctx context.Context, address string) error
+	// RegisterAccount registers an externally created Stellar account to be sponsored, and tracked by ingestion. When
+	// metadata is set it replaces the metadata of the account, otherwise the metadata of an account already registered is kept.
+	RegisterAccount(ctx context.Context, address string, metadata *data.AccountMetadata) error
 	// DeregisterAccount deregisters a Stellar account, no longer sponsoring its transactions, nor tracking it on ingestion
 	DeregisterAccount(ctx context.Context, address string) error
+	// EnqueueBackfill creates a job that ingests the history of the account still retained by the RPC
+	EnqueueBackfill(ctx context.Context, address string) (*data.AccountBackfillJob, error)
+	// GetLatestBackfillJob returns the most recent backfill job of the account
+	GetLatestBackfillJob(ctx context.Context, address string) (*data.AccountBackfillJob, error)
+	// ListAccounts returns the registered accounts matching the filter, ordered by address
+	ListAccounts(ctx context.Context, filter data.AccountsFilter, afterAddress string, limit int) ([]data.Account, error)
 }
 
 var _ AccountService = (*accountService)(nil)
@@ -34,8 +41,13 @@ func NewAccountService(models *data.Models, metricsService metrics.MetricsServic
 	}, nil
 }
 
-func (s *accountService) RegisterAccount(ctx context.Context, address string) error {
-	err := s.models.Account.Insert(ctx, address)
+func (s *accountService) RegisterAccount(ctx context.Context, address string, metadata *data.AccountMetadata) error {
+	var err error
+	if metadata == nil {
+		err = s.models.Account.Insert(ctx, address)
+	} else {
+		err = s.models.Account.Upsert(ctx, address, *metadata)
+	}
 	if err != nil {
 		return fmt.Errorf("registering account %s: %w", address, err)
 	}
@@ -51,3 +63,27 @@ func (s *accountService) DeregisterAccount(ctx context.Context, address string)
 	s.metricsService.DecActiveAccount()
 	return nil
 }
+
+func (s *accountService) EnqueueBackfill(ctx context.Context, address string) (*data.AccountBackfillJob, error) {
+	job, err := s.models.BackfillJobs.Insert(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("enqueueing backfill of account %s: %w", address, err)
+	}
+	return job, nil
+}
+
+func (s *accountService) GetLatestBackfillJob(ctx context.Context, address string) (*data.AccountBackfillJob, error) {
+	job, err := s.models.BackfillJobs.GetLatestByAddress(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("getting backfill job of account %s: %w", address, err)
+	}
+	return job, nil
+}
+
+func (s *accountService) ListAccounts(ctx context.Context, filter data.AccountsFilter, afterAddress string, limit int) ([]data.Account, error) {
+	accounts, err := s.models.Account.List(ctx, filter, afterAddress, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts: %w", err)
+	}
+	return accounts, nil
+}
@@ -36,7 +36,7 @@ func TestAccountRegister(t *testing.T) {
 
 	ctx := context.Background()
 	address := keypair.MustRandom().Address()
-	err = accountService.RegisterAccount(ctx, address)
+	err = accountService.RegisterAccount(ctx, address, nil)
 	require.NoError(t, err)
 
 	var dbAddress sql.NullString
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alitto/pond"
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+const (
+	AccountWebhookDispatcherName = "AccountWebhookDispatcher"
+	// accountWebhookDispatchInterval is how often due deliveries are looked for when the previous batch wasn't full.
+	accountWebhookDispatchInterval = time.Second
+	// accountWebhookClaimLeaseMargin is added on top of the time all the tries of a delivery can take, so a slow
+	// database write doesn't get a delivery claimed twice.
+	accountWebhookClaimLeaseMargin = time.Minute
+	// maxAccountWebhookRetries keeps the backoff between the tries, and so the claim lease, within reason.
+	maxAccountWebhookRetries = 20
+)
+
+type AccountWebhookDispatcherConfigs struct {
+	Models               *data.Models
+	HTTPClient           utils.HTTPClient
+	RequestTimeout       time.Duration
+	MaxRetries           int
+	MinWaitBtwnRetriesMS int
+	MaxBufferSize        int
+	MaxWorkers           int
+	MetricsService       metrics.MetricsService
+}
+
+// AccountWebhookDispatcher posts the pending account webhook deliveries. A delivery is tried up to MaxRetries times with
+// an exponential backoff, and is marked failed when none of the tries got a 2xx response.
+type AccountWebhookDispatcher struct {
+	models               *data.Models
+	pool                 *pond.WorkerPool
+	httpClient           utils.HTTPClient
+	requestTimeout       time.Duration
+	maxRetries           int
+	minWaitBtwnRetriesMS int
+	batchSize            int
+	// claimLease is how long a claimed delivery is left alone before it's claimed again.
+	claimLease time.Duration
+}
+
+func NewAccountWebhookDispatcher(cfg AccountWebhookDispatcherConfigs) (*AccountWebhookDispatcher, error) {
+	if cfg.Models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+	if cfg.HTTPClient == nil {
+		return nil, errors.New("httpClient cannot be nil")
+	}
+	if cfg.RequestTimeout <= 0 {
+		return nil, errors.New("requestTimeout must be greater than zero")
+	}
+	if cfg.MaxRetries <= 0 {
+		return nil, errors.New("maxRetries must be greater than zero")
+	}
+	if cfg.MaxRetries > maxAccountWebhookRetries {
+		return nil, fmt.Errorf("maxRetries must be at most %d", maxAccountWebhookRetries)
+	}
+	if cfg.MinWaitBtwnRetriesMS < 0 {
+		return nil, errors.New("minWaitBtwnRetriesMS cannot be negative")
+	}
+	if cfg.MaxWorkers <= 0 {
+		return nil, errors.New("maxWorkers must be greater than zero")
+	}
+
+	pool := pond.New(cfg.MaxWorkers, cfg.MaxBufferSize, pond.Strategy(pond.Balanced()))
+	if cfg.MetricsService != nil {
+		cfg.MetricsService.RegisterPoolMetrics(AccountWebhookDispatcherName, pool)
+	}
+	return &AccountWebhookDispatcher{
+		models:               cfg.Models,
+		pool:                 pool,
+		httpClient:           cfg.HTTPClient,
+		requestTimeout:       cfg.RequestTimeout,
+		maxRetries:           cfg.MaxRetries,
+		minWaitBtwnRetriesMS: cfg.MinWaitBtwnRetriesMS,
+		batchSize:            max(cfg.MaxBufferSize, cfg.MaxWorkers),
+		claimLease:           accountWebhookClaimLease(cfg.MaxRetries, cfg.MinWaitBtwnRetriesMS, cfg.RequestTimeout),
+	}, nil
+}
+
+// accountWebhookClaimLease returns a lease that outlasts all the tries of a delivery: each of them is bounded by the
+// request timeout, and the backoffs between them by their jitter.
+func accountWebhookClaimLease(maxRetries, minWaitBtwnRetriesMS int, requestTimeout time.Duration) time.Duration {
+	lease := time.Duration(maxRetries)*requestTimeout + accountWebhookClaimLeaseMargin
+	for i := 1; i < maxRetries; i++ {
+		backoff := accountWebhookBackoff(minWaitBtwnRetriesMS, i)
+		lease += backoff + backoff/4
+	}
+	return lease
+}
+
+// accountWebhookBackoff is the wait before the i-th try of a delivery, before its jitter.
+func accountWebhookBackoff(minWaitBtwnRetriesMS, i int) time.Duration {
+	return time.Duration(minWaitBtwnRetriesMS*(1<<(i-1))) * time.Millisecond
+}
+
+// Run dispatches the due deliveries until the context is done.
+func (d *AccountWebhookDispatcher) Run(ctx context.Context) {
+	defer d.pool.StopAndWait()
+	ticker := time.NewTicker(accountWebhookDispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		dispatched, err := d.DispatchDue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Ctx(ctx).Errorf("dispatching account webhook deliveries: %v", err)
+		}
+		// a full batch means more deliveries are likely due already
+		if dispatched == d.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue claims a batch of due deliveries and posts them, returning once all of them are resolved.
+func (d *AccountWebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := d.models.AccountWebhooks.ClaimDueDeliveries(ctx, d.batchSize, d.claimLease)
+	if err != nil {
+		return 0, fmt.Errorf("claiming due deliveries: %w", err)
+	}
+
+	group := d.pool.Group()
+	for _, delivery := range deliveries {
+		group.Submit(func() {
+			d.deliver(ctx, delivery)
+		})
+	}
+	group.Wait()
+	return len(deliveries), nil
+}
+
+func (d *AccountWebhookDispatcher) deliver(ctx context.Context, delivery data.AccountWebhookDelivery) {
+	var lastErr error
+	for i := range d.maxRetries {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				// left claimed, the delivery is tried again once its lease expires
+				return
+			case <-time.After(utils.Jitter(accountWebhookBackoff(d.minWaitBtwnRetriesMS, i))):
+			}
+		}
+		lastErr = d.post(ctx, delivery)
+		if lastErr == nil {
+			if err := d.models.AccountWebhooks.MarkDeliverySent(ctx, delivery.ID, i+1); err != nil {
+				log.Ctx(ctx).Errorf("[%s] %v", AccountWebhookDispatcherName, err)
+			}
+			return
+		}
+	}
+
+	log.Ctx(ctx).Warnf("[%s] delivery %d to webhook %d failed after %d tries: %v", AccountWebhookDispatcherName, delivery.ID, delivery.WebhookID, d.maxRetries, lastErr)
+	if err := d.models.AccountWebhooks.MarkDeliveryFailed(ctx, delivery.ID, d.maxRetries, lastErr.Error()); err != nil {
+		log.Ctx(ctx).Errorf("[%s] %v", AccountWebhookDispatcherName, err)
+	}
+}
+
+func (d *AccountWebhookDispatcher) post(ctx context.Context, delivery data.AccountWebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp, err := d.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("making POST request to webhook: %w", err)
+	}
+	defer utils.DeferredClose(ctx, httpResp.Body, "closing account webhook response body")
+	if httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %d", httpResp.StatusCode)
+	}
+	return nil
+}
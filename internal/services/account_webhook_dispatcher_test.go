@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+func TestAccountWebhookDispatcherDispatchDue(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var (
+		mu       sync.Mutex
+		received = map[string]int{}
+		bodies   []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path]++
+		bodies = append(bodies, string(body))
+		switch r.URL.Path {
+		case "/flaky":
+			// only the second try succeeds
+			if received[r.URL.Path] == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	address := keypair.MustRandom().Address()
+	require.NoError(t, models.Account.Insert(ctx, address))
+	flakyWebhook, err := models.AccountWebhooks.Insert(ctx, server.URL+"/flaky", []string{address})
+	require.NoError(t, err)
+	brokenWebhook, err := models.AccountWebhooks.Insert(ctx, server.URL+"/broken", []string{address})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(data.AccountWebhookEvent{Type: data.AccountWebhookPaymentEvent, Addresses: []string{address}})
+	require.NoError(t, err)
+	err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+		return models.AccountWebhooks.BatchInsertDeliveries(ctx, dbTx, []data.AccountWebhookDelivery{
+			{WebhookID: flakyWebhook.ID, EventType: data.AccountWebhookPaymentEvent, EventID: "1", Payload: payload},
+			{WebhookID: brokenWebhook.ID, EventType: data.AccountWebhookPaymentEvent, EventID: "1", Payload: payload},
+		})
+	})
+	require.NoError(t, err)
+
+	dispatcher, err := NewAccountWebhookDispatcher(AccountWebhookDispatcherConfigs{
+		Models:               models,
+		HTTPClient:           &http.Client{Timeout: 5 * time.Second},
+		RequestTimeout:       5 * time.Second,
+		MaxRetries:           3,
+		MinWaitBtwnRetriesMS: 1,
+		MaxBufferSize:        10,
+		MaxWorkers:           2,
+	})
+	require.NoError(t, err)
+
+	dispatched, err := dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+
+	flakyDeliveries, err := models.AccountWebhooks.GetDeliveries(ctx, flakyWebhook.ID, "", 10)
+	require.NoError(t, err)
+	require.Len(t, flakyDeliveries, 1)
+	assert.Equal(t, data.AccountWebhookDeliverySent, flakyDeliveries[0].Status)
+	assert.Equal(t, 2, flakyDeliveries[0].Attempts)
+
+	brokenDeliveries, err := models.AccountWebhooks.GetDeliveries(ctx, brokenWebhook.ID, "", 10)
+	require.NoError(t, err)
+	require.Len(t, brokenDeliveries, 1)
+	assert.Equal(t, data.AccountWebhookDeliveryFailed, brokenDeliveries[0].Status)
+	assert.Equal(t, 3, brokenDeliveries[0].Attempts)
+	require.NotNil(t, brokenDeliveries[0].LastError)
+	assert.Equal(t, "webhook responded with status 500", *brokenDeliveries[0].LastError)
+
+	// resolved deliveries aren't dispatched again
+	dispatched, err = dispatcher.DispatchDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, dispatched)
+	assert.Equal(t, map[string]int{"/flaky": 2, "/broken": 3}, received)
+	for _, body := range bodies {
+		assert.JSONEq(t, string(payload), body)
+	}
+}
+
+func TestNewAccountWebhookDispatcherClaimLease(t *testing.T) {
+	cfg := AccountWebhookDispatcherConfigs{
+		Models:               &data.Models{},
+		HTTPClient:           &utils.MockHTTPClient{},
+		RequestTimeout:       30 * time.Second,
+		MaxRetries:           3,
+		MinWaitBtwnRetriesMS: 1000,
+		MaxWorkers:           1,
+	}
+
+	dispatcher, err := NewAccountWebhookDispatcher(cfg)
+	require.NoError(t, err)
+	// 3 tries of 30s, plus the 1s and 2s backoffs with their jitter, plus the margin
+	assert.Equal(t, 90*time.Second+3750*time.Millisecond+accountWebhookClaimLeaseMargin, dispatcher.claimLease)
+
+	cfg.MaxRetries = maxAccountWebhookRetries + 1
+	_, err = NewAccountWebhookDispatcher(cfg)
+	assert.EqualError(t, err, "maxRetries must be at most 20")
+
+	cfg.MaxRetries = 3
+	cfg.RequestTimeout = 0
+	_, err = NewAccountWebhookDispatcher(cfg)
+	assert.EqualError(t, err, "requestTimeout must be greater than zero")
+}
+
+func TestAccountWebhookDispatcherDeliverStopsBackingOffWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mockHTTPClient := &utils.MockHTTPClient{}
+	mockHTTPClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(nil, assert.AnError).
+		Run(func(mock.Arguments) { cancel() }).
+		Once()
+	defer mockHTTPClient.AssertExpectations(t)
+
+	dispatcher, err := NewAccountWebhookDispatcher(AccountWebhookDispatcherConfigs{
+		Models:               &data.Models{},
+		HTTPClient:           mockHTTPClient,
+		RequestTimeout:       time.Second,
+		MaxRetries:           3,
+		MinWaitBtwnRetriesMS: int(time.Hour / time.Millisecond),
+		MaxWorkers:           1,
+	})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		// the delivery is left claimed, so no model is touched
+		dispatcher.deliver(ctx, data.AccountWebhookDelivery{ID: 1, URL: "http://localhost/webhook"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deliver kept backing off after the context was done")
+	}
+}
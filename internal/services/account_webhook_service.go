@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stellar/wallet-backend/internal/data"
+)
+
+type AccountWebhookService interface {
+	CreateWebhook(ctx context.Context, url string, addresses []string) (*data.AccountWebhook, error)
+	GetWebhook(ctx context.Context, id int64) (*data.AccountWebhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetDeliveries(ctx context.Context, webhookID int64, status data.AccountWebhookDeliveryStatus, limit int) ([]data.AccountWebhookDelivery, error)
+	ReplayDeliveries(ctx context.Context, webhookID int64, from time.Time, failedOnly bool) (int64, error)
+}
+
+var _ AccountWebhookService = (*accountWebhookService)(nil)
+
+type accountWebhookService struct {
+	models *data.Models
+}
+
+func NewAccountWebhookService(models *data.Models) (*accountWebhookService, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+
+	return &accountWebhookService{
+		models: models,
+	}, nil
+}
+
+// CreateWebhook subscribes the URL to the addresses, or returns data.ErrAccountWebhookAddressNotRegistered when any
+// of them isn't registered.
+func (s *accountWebhookService) CreateWebhook(ctx context.Context, url string, addresses []string) (*data.AccountWebhook, error) {
+	webhook, err := s.models.AccountWebhooks.Insert(ctx, url, addresses)
+	if err != nil {
+		if errors.Is(err, data.ErrAccountWebhookAddressNotRegistered) {
+			return nil, data.ErrAccountWebhookAddressNotRegistered
+		}
+		return nil, fmt.Errorf("creating webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s *accountWebhookService) GetWebhook(ctx context.Context, id int64) (*data.AccountWebhook, error) {
+	webhook, err := s.models.AccountWebhooks.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, data.ErrAccountWebhookNotFound) {
+			return nil, data.ErrAccountWebhookNotFound
+		}
+		return nil, fmt.Errorf("getting webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s *accountWebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	err := s.models.AccountWebhooks.Delete(ctx, id)
+	if err != nil {
+		if errors.Is(err, data.ErrAccountWebhookNotFound) {
+			return data.ErrAccountWebhookNotFound
+		}
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries returns the most recent deliveries of the webhook, or data.ErrAccountWebhookNotFound.
+func (s *accountWebhookService) GetDeliveries(ctx context.Context, webhookID int64, status data.AccountWebhookDeliveryStatus, limit int) ([]data.AccountWebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.models.AccountWebhooks.GetDeliveries(ctx, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("getting webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ReplayDeliveries posts the deliveries of the webhook created from the given time on again, or returns
+// data.ErrAccountWebhookNotFound.
+func (s *accountWebhookService) ReplayDeliveries(ctx context.Context, webhookID int64, from time.Time, failedOnly bool) (int64, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return 0, err
+	}
+
+	replayed, err := s.models.AccountWebhooks.ReplayDeliveries(ctx, webhookID, from, failedOnly)
+	if err != nil {
+		return 0, fmt.Errorf("replaying webhook deliveries: %w", err)
+	}
+	return replayed, nil
+}
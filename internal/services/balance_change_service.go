@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/entities"
+)
+
+type BalanceChangeService interface {
+	GetAccountBalanceChangesPaginated(ctx context.Context, address string, beforeCursor, afterCursor string, sort data.SortOrder, limit int) ([]data.BalanceChange, entities.Pagination, error)
+}
+
+var _ BalanceChangeService = (*balanceChangeService)(nil)
+
+type balanceChangeService struct {
+	models        *data.Models
+	serverBaseURL string
+}
+
+func NewBalanceChangeService(models *data.Models, serverBaseURL string) (*balanceChangeService, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+
+	if _, err := url.ParseRequestURI(serverBaseURL); err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", serverBaseURL, err)
+	}
+
+	return &balanceChangeService{
+		models:        models,
+		serverBaseURL: serverBaseURL,
+	}, nil
+}
+
+func (s *balanceChangeService) GetAccountBalanceChangesPaginated(ctx context.Context, address string, beforeCursor, afterCursor string, sort data.SortOrder, limit int) ([]data.BalanceChange, entities.Pagination, error) {
+	balanceChanges, prevExists, nextExists, err := s.models.BalanceChanges.GetBalanceChangesPaginated(ctx, address, beforeCursor, afterCursor, sort, limit)
+	if err != nil {
+		return nil, entities.Pagination{}, fmt.Errorf("getting balance changes: %w", err)
+	}
+
+	self, prev, next := "", "", ""
+	self, err = buildAccountBalanceHistoryURL(s.serverBaseURL, address, beforeCursor, afterCursor, sort, limit)
+	if err != nil {
+		return nil, entities.Pagination{}, fmt.Errorf("building self link: %w", err)
+	}
+
+	if prevExists {
+		prev, err = buildAccountBalanceHistoryURL(s.serverBaseURL, address, balanceChanges[0].Cursor(), "", sort, limit)
+		if err != nil {
+			return nil, entities.Pagination{}, fmt.Errorf("building prev link: %w", err)
+		}
+	}
+
+	if nextExists {
+		next, err = buildAccountBalanceHistoryURL(s.serverBaseURL, address, "", balanceChanges[len(balanceChanges)-1].Cursor(), sort, limit)
+		if err != nil {
+			return nil, entities.Pagination{}, fmt.Errorf("building next link: %w", err)
+		}
+	}
+
+	pagination := entities.Pagination{
+		Links: entities.PaginationLinks{
+			Self: self,
+			Prev: prev,
+			Next: next,
+		},
+	}
+	return balanceChanges, pagination, nil
+}
+
+func buildAccountBalanceHistoryURL(baseURL string, address string, beforeCursor, afterCursor string, sort data.SortOrder, limit int) (string, error) {
+	url, err := url.ParseRequestURI(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing base URL: %s: %w", baseURL, err)
+	}
+	url = url.JoinPath("accounts", address, "balance-history")
+
+	values := url.Query()
+	values.Add("sort", string(sort))
+	values.Add("limit", strconv.Itoa(limit))
+	if beforeCursor != "" {
+		values.Add("beforeCursor", beforeCursor)
+	}
+	if afterCursor != "" {
+		values.Add("afterCursor", afterCursor)
+	}
+	url.RawQuery = values.Encode()
+
+	return url.String(), nil
+}
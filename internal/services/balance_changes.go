@@ -0,0 +1,207 @@
+package services
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+const feeBalanceChangeReason = "fee"
+
+// balanceKey identifies the balance of an account in a given asset.
+type balanceKey struct {
+	account string
+	asset   string
+}
+
+// balanceChangeSet accumulates the balance deltas caused by a transaction or by one of its operations.
+type balanceChangeSet struct {
+	deltas   map[balanceKey]int64
+	balances map[balanceKey]int64
+}
+
+func newBalanceChangeSet() *balanceChangeSet {
+	return &balanceChangeSet{
+		deltas:   map[balanceKey]int64{},
+		balances: map[balanceKey]int64{},
+	}
+}
+
+// addLedgerEntryChanges adds the balance deltas of the account and trustline entries in the given changes. Offers,
+// liquidity pools and claimable balances don't belong to an account, but every time one of them is created, updated or
+// removed the balances of the accounts involved change accordingly, so looking at accounts and trustlines is enough.
+func (s *balanceChangeSet) addLedgerEntryChanges(changes xdr.LedgerEntryChanges) error {
+	var state *xdr.LedgerEntry
+	for _, change := range changes {
+		var pre, post *xdr.LedgerEntry
+		switch change.Type {
+		case xdr.LedgerEntryChangeTypeLedgerEntryState:
+			entry := change.MustState()
+			state = &entry
+			continue
+		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+			entry := change.MustCreated()
+			post = &entry
+		case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+			entry := change.MustUpdated()
+			pre, post = state, &entry
+		case xdr.LedgerEntryChangeTypeLedgerEntryRemoved:
+			pre = state
+		default:
+			continue
+		}
+		state = nil
+
+		preKey, preBalance, preOK, err := entryBalance(pre)
+		if err != nil {
+			return err
+		}
+		postKey, postBalance, postOK, err := entryBalance(post)
+		if err != nil {
+			return err
+		}
+		switch {
+		case preOK && postOK:
+			s.add(postKey, postBalance-preBalance, postBalance)
+		case postOK:
+			s.add(postKey, postBalance, postBalance)
+		case preOK:
+			s.add(preKey, -preBalance, 0)
+		}
+	}
+	return nil
+}
+
+func (s *balanceChangeSet) add(key balanceKey, delta, balance int64) {
+	s.deltas[key] += delta
+	s.balances[key] = balance
+}
+
+// entryBalance returns the owner, asset and balance of account and trustline entries. The last return value is false
+// for any other entry type.
+func entryBalance(entry *xdr.LedgerEntry) (balanceKey, int64, bool, error) {
+	if entry == nil {
+		return balanceKey{}, 0, false, nil
+	}
+
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		account := entry.Data.MustAccount()
+		return balanceKey{account: account.AccountId.Address(), asset: xdr.MustNewNativeAsset().StringCanonical()}, int64(account.Balance), true, nil
+	case xdr.LedgerEntryTypeTrustline:
+		trustLine := entry.Data.MustTrustLine()
+		var asset string
+		if trustLine.Asset.Type == xdr.AssetTypeAssetTypePoolShare {
+			poolID := trustLine.Asset.MustLiquidityPoolId()
+			asset = "liquidity_pool:" + hex.EncodeToString(poolID[:])
+		} else {
+			asset = trustLine.Asset.ToAsset().StringCanonical()
+		}
+		return balanceKey{account: trustLine.AccountId.Address(), asset: asset}, int64(trustLine.Balance), true, nil
+	default:
+		return balanceKey{}, 0, false, nil
+	}
+}
+
+// toBalanceChanges returns the non-zero deltas of the set sorted by account and asset, so ingesting the same ledger
+// always produces the same rows.
+func (s *balanceChangeSet) toBalanceChanges(template data.BalanceChange) []data.BalanceChange {
+	keys := make([]balanceKey, 0, len(s.deltas))
+	for key, delta := range s.deltas {
+		if delta != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].account != keys[j].account {
+			return keys[i].account < keys[j].account
+		}
+		return keys[i].asset < keys[j].asset
+	})
+
+	balanceChanges := make([]data.BalanceChange, 0, len(keys))
+	for _, key := range keys {
+		balanceChange := template
+		balanceChange.Account = key.account
+		balanceChange.Asset = key.asset
+		balanceChange.Amount = s.deltas[key]
+		if balance, ok := s.balances[key]; ok {
+			balanceChange.Balance = &balance
+		}
+		balanceChanges = append(balanceChanges, balanceChange)
+	}
+	return balanceChanges
+}
+
+// transactionBalanceChanges returns the balance deltas of every account touched by the transaction. The fee is
+// recorded at the transaction level, with the transaction ID as operation ID, and each operation gets its own deltas.
+//
+// The RPC doesn't return the fee processing meta, so the fee delta is taken from the fee charged in the transaction
+// result. For Soroban transactions that amount already accounts for the refund, which is why the refund changes in
+// the meta txChangesAfter are left out.
+func transactionBalanceChanges(tx entities.Transaction, txEnvelopeXDR xdr.TransactionEnvelope, txResultXDR xdr.TransactionResult) ([]data.BalanceChange, error) {
+	transactionID := utils.TransactionID(int32(tx.Ledger), int32(tx.ApplicationOrder))
+	template := data.BalanceChange{
+		TransactionID:   transactionID,
+		TransactionHash: tx.Hash,
+		LedgerNumber:    uint32(tx.Ledger),
+		CreatedAt:       time.Unix(int64(tx.CreatedAt), 0),
+	}
+
+	txLevelChanges := newBalanceChangeSet()
+	if txResultXDR.FeeCharged != 0 {
+		feeAccount := muxedAccountAddress(txEnvelopeXDR.FeeAccount())
+		txLevelChanges.deltas[balanceKey{account: feeAccount, asset: xdr.MustNewNativeAsset().StringCanonical()}] -= int64(txResultXDR.FeeCharged)
+	}
+
+	var txMeta xdr.TransactionMeta
+	if err := xdr.SafeUnmarshalBase64(tx.ResultMetaXDR, &txMeta); err != nil {
+		return nil, fmt.Errorf("unmarshalling transaction meta: %w", err)
+	}
+	var txChangesBefore xdr.LedgerEntryChanges
+	switch txMeta.V {
+	case 1:
+		txChangesBefore = txMeta.MustV1().TxChanges
+	case 2:
+		txChangesBefore = txMeta.MustV2().TxChangesBefore
+	case 3:
+		txChangesBefore = txMeta.MustV3().TxChangesBefore
+	}
+	if err := txLevelChanges.addLedgerEntryChanges(txChangesBefore); err != nil {
+		return nil, fmt.Errorf("processing transaction changes: %w", err)
+	}
+
+	feeTemplate := template
+	feeTemplate.OperationID = transactionID
+	feeTemplate.Reason = feeBalanceChangeReason
+	balanceChanges := txLevelChanges.toBalanceChanges(feeTemplate)
+
+	// failed transactions have no operation changes
+	if tx.Status != entities.SuccessStatus {
+		return balanceChanges, nil
+	}
+
+	operations := txEnvelopeXDR.Operations()
+	for idx, opMeta := range txMeta.OperationsMeta() {
+		if idx >= len(operations) {
+			break
+		}
+		opChanges := newBalanceChangeSet()
+		if err := opChanges.addLedgerEntryChanges(opMeta.Changes); err != nil {
+			return nil, fmt.Errorf("processing changes of operation %d: %w", idx+1, err)
+		}
+		opTemplate := template
+		opTemplate.OperationID = utils.OperationID(int32(tx.Ledger), int32(tx.ApplicationOrder), int32(idx+1))
+		opTemplate.Reason = operations[idx].Body.Type.String()
+		balanceChanges = append(balanceChanges, opChanges.toBalanceChanges(opTemplate)...)
+	}
+
+	return balanceChanges, nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/entities"
+)
+
+func makeAccountEntry(account string, balance int64) xdr.LedgerEntry {
+	return xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId: xdr.MustAddress(account),
+				Balance:   xdr.Int64(balance),
+			},
+		},
+	}
+}
+
+func makeTrustLineEntry(account string, asset xdr.Asset, balance int64) xdr.LedgerEntry {
+	return xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTrustline,
+			TrustLine: &xdr.TrustLineEntry{
+				AccountId: xdr.MustAddress(account),
+				Asset:     asset.ToTrustLineAsset(),
+				Balance:   xdr.Int64(balance),
+			},
+		},
+	}
+}
+
+func stateChange(entry xdr.LedgerEntry) xdr.LedgerEntryChange {
+	return xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &entry}
+}
+
+func updatedChange(entry xdr.LedgerEntry) xdr.LedgerEntryChange {
+	return xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &entry}
+}
+
+func createdChange(entry xdr.LedgerEntry) xdr.LedgerEntryChange {
+	return xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: &entry}
+}
+
+func removedChange(entry xdr.LedgerEntry) xdr.LedgerEntryChange {
+	key, err := entry.LedgerKey()
+	if err != nil {
+		panic(err)
+	}
+	return xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryRemoved, Removed: &key}
+}
+
+func TestBalanceChangeSetAddLedgerEntryChanges(t *testing.T) {
+	account := keypair.MustRandom().Address()
+	issuer := keypair.MustRandom().Address()
+	usdc := xdr.MustNewCreditAsset("USDC", issuer)
+	usdcTrustLine := makeTrustLineEntry(account, usdc, 300)
+
+	changes := newBalanceChangeSet()
+	err := changes.addLedgerEntryChanges(xdr.LedgerEntryChanges{
+		stateChange(makeAccountEntry(account, 1000)),
+		updatedChange(makeAccountEntry(account, 900)),
+		createdChange(makeAccountEntry(issuer, 50)),
+		stateChange(usdcTrustLine),
+		removedChange(usdcTrustLine),
+		// entries without a balance are ignored
+		createdChange(xdr.LedgerEntry{Data: xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeData, Data: &xdr.DataEntry{AccountId: xdr.MustAddress(account)}}}),
+	})
+	require.NoError(t, err)
+
+	balanceChanges := changes.toBalanceChanges(data.BalanceChange{OperationID: "1"})
+	expected := []data.BalanceChange{
+		{OperationID: "1", Account: account, Asset: "USDC:" + issuer, Amount: -300, Balance: int64Pointer(0)},
+		{OperationID: "1", Account: account, Asset: "native", Amount: -100, Balance: int64Pointer(900)},
+		{OperationID: "1", Account: issuer, Asset: "native", Amount: 50, Balance: int64Pointer(50)},
+	}
+	if account > issuer {
+		expected = []data.BalanceChange{expected[2], expected[0], expected[1]}
+	}
+	assert.Equal(t, expected, balanceChanges)
+}
+
+func TestTransactionBalanceChanges(t *testing.T) {
+	source := keypair.MustRandom()
+	destination := keypair.MustRandom().Address()
+	issuer := keypair.MustRandom().Address()
+	usdc := xdr.MustNewCreditAsset("USDC", issuer)
+
+	transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address()},
+		Operations: []txnbuild.Operation{
+			&txnbuild.Payment{Destination: destination, Amount: "10", Asset: txnbuild.NativeAsset{}},
+			&txnbuild.Payment{Destination: destination, Amount: "5", Asset: txnbuild.CreditAsset{Code: "USDC", Issuer: issuer}},
+			&txnbuild.BumpSequence{BumpTo: 100},
+		},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+	})
+	require.NoError(t, err)
+	transaction, err = transaction.Sign(network.TestNetworkPassphrase, source)
+	require.NoError(t, err)
+	txEnvXDR, err := transaction.Base64()
+	require.NoError(t, err)
+	txEnvelopeXDR := transaction.ToXDR()
+
+	txMeta := xdr.TransactionMeta{
+		V: 3,
+		V3: &xdr.TransactionMetaV3{
+			// the sequence number bump doesn't change the balance
+			TxChangesBefore: xdr.LedgerEntryChanges{
+				stateChange(makeAccountEntry(source.Address(), 1000_0000000)),
+				updatedChange(makeAccountEntry(source.Address(), 1000_0000000)),
+			},
+			Operations: []xdr.OperationMeta{
+				{Changes: xdr.LedgerEntryChanges{
+					stateChange(makeAccountEntry(source.Address(), 1000_0000000)),
+					updatedChange(makeAccountEntry(source.Address(), 990_0000000)),
+					stateChange(makeAccountEntry(destination, 20_0000000)),
+					updatedChange(makeAccountEntry(destination, 30_0000000)),
+				}},
+				{Changes: xdr.LedgerEntryChanges{
+					stateChange(makeTrustLineEntry(source.Address(), usdc, 5_0000000)),
+					updatedChange(makeTrustLineEntry(source.Address(), usdc, 0)),
+					stateChange(makeTrustLineEntry(destination, usdc, 0)),
+					updatedChange(makeTrustLineEntry(destination, usdc, 5_0000000)),
+				}},
+				{Changes: xdr.LedgerEntryChanges{}},
+			},
+		},
+	}
+	txMetaXDR, err := xdr.MarshalBase64(txMeta)
+	require.NoError(t, err)
+
+	tx := entities.Transaction{
+		Status:           entities.SuccessStatus,
+		Hash:             "hash",
+		ApplicationOrder: 1,
+		EnvelopeXDR:      txEnvXDR,
+		ResultMetaXDR:    txMetaXDR,
+		Ledger:           1,
+		CreatedAt:        1,
+	}
+	txResultXDR := xdr.TransactionResult{FeeCharged: 300}
+	createdAt := time.Unix(1, 0)
+
+	sortedBalanceChanges := func(first, second data.BalanceChange) []data.BalanceChange {
+		if first.Account > second.Account {
+			return []data.BalanceChange{second, first}
+		}
+		return []data.BalanceChange{first, second}
+	}
+	feeBalanceChange := data.BalanceChange{
+		Account: source.Address(), OperationID: "4294971392", Asset: "native", TransactionID: "4294971392", TransactionHash: "hash",
+		LedgerNumber: 1, Reason: "fee", Amount: -300, Balance: int64Pointer(1000_0000000), CreatedAt: createdAt,
+	}
+
+	t.Run("successful_transaction", func(t *testing.T) {
+		balanceChanges, err := transactionBalanceChanges(tx, txEnvelopeXDR, txResultXDR)
+		require.NoError(t, err)
+
+		expected := []data.BalanceChange{feeBalanceChange}
+		expected = append(expected, sortedBalanceChanges(
+			data.BalanceChange{
+				Account: source.Address(), OperationID: "4294971393", Asset: "native", TransactionID: "4294971392", TransactionHash: "hash",
+				LedgerNumber: 1, Reason: "OperationTypePayment", Amount: -10_0000000, Balance: int64Pointer(990_0000000), CreatedAt: createdAt,
+			},
+			data.BalanceChange{
+				Account: destination, OperationID: "4294971393", Asset: "native", TransactionID: "4294971392", TransactionHash: "hash",
+				LedgerNumber: 1, Reason: "OperationTypePayment", Amount: 10_0000000, Balance: int64Pointer(30_0000000), CreatedAt: createdAt,
+			},
+		)...)
+		expected = append(expected, sortedBalanceChanges(
+			data.BalanceChange{
+				Account: source.Address(), OperationID: "4294971394", Asset: "USDC:" + issuer, TransactionID: "4294971392", TransactionHash: "hash",
+				LedgerNumber: 1, Reason: "OperationTypePayment", Amount: -5_0000000, Balance: int64Pointer(0), CreatedAt: createdAt,
+			},
+			data.BalanceChange{
+				Account: destination, OperationID: "4294971394", Asset: "USDC:" + issuer, TransactionID: "4294971392", TransactionHash: "hash",
+				LedgerNumber: 1, Reason: "OperationTypePayment", Amount: 5_0000000, Balance: int64Pointer(5_0000000), CreatedAt: createdAt,
+			},
+		)...)
+		assert.Equal(t, expected, balanceChanges)
+	})
+
+	t.Run("failed_transaction_only_charges_the_fee", func(t *testing.T) {
+		failedTx := tx
+		failedTx.Status = entities.FailedStatus
+		balanceChanges, err := transactionBalanceChanges(failedTx, txEnvelopeXDR, txResultXDR)
+		require.NoError(t, err)
+		assert.Equal(t, []data.BalanceChange{feeBalanceChange}, balanceChanges)
+	})
+
+	t.Run("invalid_meta", func(t *testing.T) {
+		invalidTx := tx
+		invalidTx.ResultMetaXDR = "invalid"
+		_, err := transactionBalanceChanges(invalidTx, txEnvelopeXDR, txResultXDR)
+		assert.ErrorContains(t, err, "unmarshalling transaction meta")
+	})
+}
+
+func int64Pointer(i int64) *int64 {
+	return &i
+}
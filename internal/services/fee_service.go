@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stellar/wallet-backend/internal/data"
+)
+
+// FeeSummaryReport aggregates the fees paid within [From, To), per account and in total.
+type FeeSummaryReport struct {
+	From     *time.Time        `json:"from"`
+	To       *time.Time        `json:"to"`
+	Total    data.FeeSummary   `json:"total"`
+	Accounts []data.FeeSummary `json:"accounts"`
+}
+
+type FeeService interface {
+	GetFeeSummary(ctx context.Context, account string, from, to *time.Time) (*FeeSummaryReport, error)
+}
+
+var _ FeeService = (*feeService)(nil)
+
+type feeService struct {
+	models *data.Models
+}
+
+func NewFeeService(models *data.Models) (*feeService, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+
+	return &feeService{
+		models: models,
+	}, nil
+}
+
+// GetFeeSummary reports the fees paid by the account, or by every account whose fees are ingested when it's empty.
+func (s *feeService) GetFeeSummary(ctx context.Context, account string, from, to *time.Time) (*FeeSummaryReport, error) {
+	summaries, err := s.models.Fees.GetFeeSummaries(ctx, account, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("getting fee summaries: %w", err)
+	}
+
+	report := &FeeSummaryReport{
+		From:     from,
+		To:       to,
+		Total:    data.FeeSummary{Account: account},
+		Accounts: summaries,
+	}
+	for _, summary := range summaries {
+		report.Total.TransactionCount += summary.TransactionCount
+		report.Total.FeeBumpedCount += summary.FeeBumpedCount
+		report.Total.FailedCount += summary.FailedCount
+		report.Total.FeeCharged += summary.FeeCharged
+	}
+	return report, nil
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/alitto/pond"
+	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/log"
-	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/wallet-backend/internal/apptracker"
@@ -15,7 +17,6 @@ import (
 	"github.com/stellar/wallet-backend/internal/db"
 	"github.com/stellar/wallet-backend/internal/entities"
 	"github.com/stellar/wallet-backend/internal/metrics"
-	"github.com/stellar/wallet-backend/internal/tss"
 	tssrouter "github.com/stellar/wallet-backend/internal/tss/router"
 	tssstore "github.com/stellar/wallet-backend/internal/tss/store"
 	"github.com/stellar/wallet-backend/internal/utils"
@@ -27,11 +28,39 @@ const (
 	tssPrometheusLabel                      = "tss"
 	pathPaymentStrictSendPrometheusLabel    = "path_payment_strict_send"
 	pathPaymentStrictReceivePrometheusLabel = "path_payment_strict_receive"
+	tokenTransferPrometheusLabel            = "token_transfer"
+	createAccountPrometheusLabel            = "create_account"
+	accountMergePrometheusLabel             = "account_merge"
+	claimClaimableBalancePrometheusLabel    = "claim_claimable_balance"
+	operationsPrometheusLabel               = "operations"
+	balanceChangesPrometheusLabel           = "balance_changes"
+	feesPrometheusLabel                     = "fees"
+	accountWebhooksPrometheusLabel          = "account_webhooks"
 	totalIngestionPrometheusLabel           = "total"
+	backfillPrometheusLabel                 = "backfill"
+	accountBackfillPrometheusLabel          = "account_backfill"
+	accountBackfillPollInterval             = 10 * time.Second
+	// accountBackfillStaleAfter must be way longer than the time it takes to ingest a single ledger, since the job is
+	// only touched once per ledger.
+	accountBackfillStaleAfter = 5 * time.Minute
 )
 
 type IngestService interface {
 	Run(ctx context.Context, startLedger uint32, endLedger uint32) error
+	// Backfill ingests the payments of the [startLedger, endLedger] range by splitting it in chunks of chunkSize ledgers
+	// that are processed concurrently by numWorkers workers. Each chunk keeps its own cursor in the ingest store, so an
+	// interrupted backfill can be resumed by running it again with the same arguments. The chunk cursors are deleted once
+	// the whole range is backfilled, and the live ingestion cursor is never touched.
+	Backfill(ctx context.Context, startLedger, endLedger uint32, numWorkers, chunkSize int) error
+	// RunAccountBackfills processes the backfill jobs enqueued when accounts are registered until ctx is done. Each job
+	// ingests the history of a single account from the oldest ledger in the ledger backend up to the live cursor.
+	RunAccountBackfills(ctx context.Context)
+	// VerifyIngestedRanges reports the gaps and overlaps between the ledger ranges recorded by the live ingestion,
+	// backfills and repairs within [startLedger, endLedger]. Zero bounds default to the oldest and latest recorded ledgers.
+	VerifyIngestedRanges(ctx context.Context, startLedger, endLedger uint32) (*IngestedRangesReport, error)
+	// RepairIngestedRanges backfills the gaps reported by VerifyIngestedRanges. Like Backfill, it can be interrupted and
+	// run again with the same arguments.
+	RepairIngestedRanges(ctx context.Context, startLedger, endLedger uint32, numWorkers, chunkSize int) (*IngestedRangesReport, error)
 }
 
 var _ IngestService = (*ingestService)(nil)
@@ -41,9 +70,20 @@ type ingestService struct {
 	ledgerCursorName string
 	appTracker       apptracker.AppTracker
 	rpcService       RPCService
+	ledgerBackend    LedgerBackend
 	tssRouter        tssrouter.Router
 	tssStore         tssstore.Store
-	metricsService   metrics.MetricsService
+	// leaderElection is optional, when set Run only ingests while this replica is the leader
+	leaderElection *LeaderElection
+	// paymentPartitions is shared with the job maintaining the partitions, so the partitions it detaches are created
+	// again when their ledgers are reingested
+	paymentPartitions *PaymentPartitions
+	// distributionAccountPublicKey is optional, when set the fees it pays are ingested even if it isn't registered
+	distributionAccountPublicKey string
+	// liveProcessors are timed under their own name, backfillProcessors exclude the live only ones
+	liveProcessors     []LedgerProcessor
+	backfillProcessors []LedgerProcessor
+	metricsService     metrics.MetricsService
 }
 
 func NewIngestService(
@@ -51,8 +91,13 @@ func NewIngestService(
 	ledgerCursorName string,
 	appTracker apptracker.AppTracker,
 	rpcService RPCService,
+	ledgerBackend LedgerBackend,
 	tssRouter tssrouter.Router,
 	tssStore tssstore.Store,
+	leaderElection *LeaderElection,
+	paymentPartitions *PaymentPartitions,
+	ledgerProcessors []string,
+	distributionAccountPublicKey string,
 	metricsService metrics.MetricsService,
 ) (*ingestService, error) {
 	if models == nil {
@@ -67,30 +112,75 @@ func NewIngestService(
 	if rpcService == nil {
 		return nil, errors.New("rpcService cannot be nil")
 	}
+	if ledgerBackend == nil {
+		return nil, errors.New("ledgerBackend cannot be nil")
+	}
 	if tssRouter == nil {
 		return nil, errors.New("tssRouter cannot be nil")
 	}
 	if tssStore == nil {
 		return nil, errors.New("tssStore cannot be nil")
 	}
+	if distributionAccountPublicKey != "" && !strkey.IsValidEd25519PublicKey(distributionAccountPublicKey) {
+		return nil, fmt.Errorf("invalid distribution account public key %q", distributionAccountPublicKey)
+	}
 	if metricsService == nil {
 		return nil, errors.New("metricsService cannot be nil")
 	}
+	if paymentPartitions == nil {
+		var err error
+		paymentPartitions, err = NewPaymentPartitions(models)
+		if err != nil {
+			return nil, fmt.Errorf("instantiating payment partitions: %w", err)
+		}
+	}
 
-	return &ingestService{
-		models:           models,
-		ledgerCursorName: ledgerCursorName,
-		appTracker:       appTracker,
-		rpcService:       rpcService,
-		tssRouter:        tssRouter,
-		tssStore:         tssStore,
-		metricsService:   metricsService,
-	}, nil
+	m := &ingestService{
+		models:                       models,
+		ledgerCursorName:             ledgerCursorName,
+		appTracker:                   appTracker,
+		rpcService:                   rpcService,
+		ledgerBackend:                ledgerBackend,
+		tssRouter:                    tssRouter,
+		tssStore:                     tssStore,
+		leaderElection:               leaderElection,
+		paymentPartitions:            paymentPartitions,
+		metricsService:               metricsService,
+		distributionAccountPublicKey: distributionAccountPublicKey,
+	}
+	if err := m.registerLedgerProcessors(ledgerProcessors); err != nil {
+		return nil, fmt.Errorf("registering ledger processors: %w", err)
+	}
+	return m, nil
 }
 
+// Run ingests ledgers from startLedger, or from the live cursor when it's zero, until endLedger, or forever when it's
+// zero. With leader election, replicas wait as standbys until they hold the lock and go back to standby when they lose
+// it, resuming from the cursor left by the previous leader.
 func (m *ingestService) Run(ctx context.Context, startLedger uint32, endLedger uint32) error {
-	manualTriggerChannel := make(chan any, 1)
-	go m.rpcService.TrackRPCServiceHealth(ctx, manualTriggerChannel)
+	// the RPC health is tracked for the whole process, the leadership terms share its heartbeats
+	healthCheckTrigger := make(chan any, 1)
+	go m.rpcService.TrackRPCServiceHealth(ctx, healthCheckTrigger)
+
+	if m.leaderElection == nil {
+		return m.ingestLedgers(ctx, startLedger, endLedger, healthCheckTrigger)
+	}
+
+	for {
+		err := m.leaderElection.Lead(ctx, func(leaderCtx context.Context) error {
+			return m.ingestLedgers(leaderCtx, startLedger, endLedger, healthCheckTrigger)
+		})
+		if !errors.Is(err, ErrLeadershipLost) && !errors.Is(err, data.ErrNotLockHolder) {
+			return err
+		}
+		log.Ctx(ctx).Warnf("lost ingestion leadership, going back to standby: %v", err)
+		m.appTracker.CaptureException(err)
+		// the next term resumes from the cursor, which may have been moved by another leader since
+		startLedger = 0
+	}
+}
+
+func (m *ingestService) ingestLedgers(ctx context.Context, startLedger uint32, endLedger uint32, healthCheckTrigger chan any) error {
 	ingestHeartbeatChannel := make(chan any, 1)
 	rpcHeartbeatChannel := m.rpcService.GetHeartbeatChannel()
 	go trackIngestServiceHealth(ctx, ingestHeartbeatChannel, m.appTracker)
@@ -125,34 +215,36 @@ func (m *ingestService) Run(ctx context.Context, startLedger uint32, endLedger u
 			log.Ctx(ctx).Infof("ingesting ledger: %d, oldest: %d, latest: %d", ingestLedger, resp.OldestLedger, resp.LatestLedger)
 
 			start := time.Now()
-			ledgerTransactions, err := m.GetLedgerTransactions(int64(ingestLedger))
+			ledgerTransactions, err := m.ledgerBackend.GetLedgerTransactions(ctx, ingestLedger)
 			if err != nil {
 				log.Error("getTransactions: %w", err)
 				continue
 			}
-			ingestHeartbeatChannel <- true
-			startTime := time.Now()
-			err = m.ingestPayments(ctx, ledgerTransactions)
-			if err != nil {
-				return fmt.Errorf("error ingesting payments: %w", err)
+			// a pending heartbeat is as good as a new one, and nobody reads them once the term is over
+			select {
+			case ingestHeartbeatChannel <- true:
+			default:
 			}
-			m.metricsService.ObserveIngestionDuration(paymentPrometheusLabel, time.Since(startTime).Seconds())
-
-			startTime = time.Now()
-			err = m.processTSSTransactions(ctx, ledgerTransactions)
-			if err != nil {
-				return fmt.Errorf("error processing tss transactions: %w", err)
-			}
-			m.metricsService.ObserveIngestionDuration(tssPrometheusLabel, time.Since(startTime).Seconds())
-
-			err = m.models.Payments.UpdateLatestLedgerSynced(ctx, m.ledgerCursorName, ingestLedger)
+			ledger := LedgerTransactions{Sequence: ingestLedger, Transactions: ledgerTransactions}
+			err = m.processLedger(ctx, m.liveProcessors, ledger, func(dbTx db.Transaction) error {
+				if err := m.updateLiveCursor(ctx, dbTx, ingestLedger); err != nil {
+					return fmt.Errorf("updating latest synced ledger: %w", err)
+				}
+				if err := m.models.IngestedRanges.Record(ctx, dbTx, data.LiveLedgerRangeSource, ingestLedger); err != nil {
+					return fmt.Errorf("recording ingested ledger: %w", err)
+				}
+				return nil
+			})
 			if err != nil {
-				return fmt.Errorf("error updating latest synced ledger: %w", err)
+				return fmt.Errorf("error ingesting ledger %d: %w", ingestLedger, err)
 			}
 			m.metricsService.SetLatestLedgerIngested(float64(ingestLedger))
 			m.metricsService.ObserveIngestionDuration(totalIngestionPrometheusLabel, time.Since(start).Seconds())
 			if resp.LatestLedger-ingestLedger > 1 {
-				manualTriggerChannel <- true
+				select {
+				case healthCheckTrigger <- true:
+				default:
+				}
 			}
 
 			ingestLedger++
@@ -161,178 +253,254 @@ func (m *ingestService) Run(ctx context.Context, startLedger uint32, endLedger u
 	return nil
 }
 
-func (m *ingestService) GetLedgerTransactions(ledger int64) ([]entities.Transaction, error) {
-	var ledgerTransactions []entities.Transaction
-	var cursor string
-	lastLedgerSeen := ledger
-	for lastLedgerSeen == ledger {
-		getTxnsResp, err := m.rpcService.GetTransactions(ledger, cursor, 50)
+// updateLiveCursor advances the live cursor, which only the leader can do when leader election is enabled.
+func (m *ingestService) updateLiveCursor(ctx context.Context, sqlExec db.SQLExecuter, ledger uint32) error {
+	var err error
+	if m.leaderElection == nil {
+		err = m.models.Payments.UpdateLatestLedgerSynced(ctx, sqlExec, m.ledgerCursorName, ledger)
+	} else {
+		err = m.models.Payments.UpdateLatestLedgerSyncedAsLockHolder(ctx, sqlExec, m.ledgerCursorName, ledger,
+			m.leaderElection.LockKey(), m.leaderElection.HolderPID())
+	}
+	if err != nil {
+		return fmt.Errorf("updating cursor %s: %w", m.ledgerCursorName, err)
+	}
+	return nil
+}
+
+func (m *ingestService) Backfill(ctx context.Context, startLedger, endLedger uint32, numWorkers, chunkSize int) error {
+	return m.backfill(ctx, startLedger, endLedger, numWorkers, chunkSize, data.BackfillLedgerRangeSource)
+}
+
+// backfill splits the range in chunks ingested concurrently, the source names the chunk cursors and the ingested ranges
+// recorded, so repairs never resume from the cursor of a backfill.
+func (m *ingestService) backfill(ctx context.Context, startLedger, endLedger uint32, numWorkers, chunkSize int, source data.LedgerRangeSource) error {
+	if startLedger == 0 || endLedger < startLedger {
+		return fmt.Errorf("invalid backfill range [%d, %d]", startLedger, endLedger)
+	}
+	if numWorkers <= 0 {
+		return fmt.Errorf("invalid number of workers %d", numWorkers)
+	}
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+
+	oldestLedger, latestLedger, err := m.ledgerBackend.GetLedgerRange(ctx)
+	if err != nil {
+		return fmt.Errorf("getting ledger backend range: %w", err)
+	}
+	if startLedger < oldestLedger || endLedger > latestLedger {
+		return fmt.Errorf("backfill range [%d, %d] is outside of the ledger backend range [%d, %d]",
+			startLedger, endLedger, oldestLedger, latestLedger)
+	}
+
+	chunks := splitLedgerRange(startLedger, endLedger, uint32(chunkSize))
+	log.Ctx(ctx).Infof("backfilling ledgers [%d, %d] in %d chunks with %d workers", startLedger, endLedger, len(chunks), numWorkers)
+
+	pool := pond.New(numWorkers, len(chunks), pond.Strategy(pond.Balanced()))
+	defer pool.StopAndWait()
+	group, groupCtx := pool.GroupContext(ctx)
+	for _, chunk := range chunks {
+		group.Submit(func() error {
+			return m.backfillChunk(groupCtx, chunk, source)
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return fmt.Errorf("backfilling ledgers [%d, %d]: %w", startLedger, endLedger, err)
+	}
+
+	// the range is recorded as ingested by now, a later run over it must start over rather than skip the chunks
+	cursorNames := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		cursorNames = append(cursorNames, chunk.cursorName(source))
+	}
+	if err := m.models.Payments.DeleteLatestLedgersSynced(ctx, cursorNames); err != nil {
+		return fmt.Errorf("deleting the chunk cursors of ledgers [%d, %d]: %w", startLedger, endLedger, err)
+	}
+
+	log.Ctx(ctx).Infof("finished backfilling ledgers [%d, %d]", startLedger, endLedger)
+	return nil
+}
+
+// backfillChunk ingests a single chunk, resuming from the chunk cursor when a previous run was interrupted. The chunk
+// cursor is advanced in the same database transaction as the ledger writes, so a ledger is never ingested twice.
+func (m *ingestService) backfillChunk(ctx context.Context, chunk LedgerRange, source data.LedgerRangeSource) error {
+	cursorName := chunk.cursorName(source)
+	lastSynced, err := m.models.Payments.GetLatestLedgerSynced(ctx, cursorName)
+	if err != nil {
+		return fmt.Errorf("getting latest ledger synced for cursor %s: %w", cursorName, err)
+	}
+
+	ingestLedger := chunk.Start
+	if lastSynced >= chunk.Start {
+		ingestLedger = lastSynced + 1
+	}
+	if ingestLedger > chunk.End {
+		log.Ctx(ctx).Debugf("skipping already backfilled chunk [%d, %d]", chunk.Start, chunk.End)
+		return nil
+	}
+
+	for ; ingestLedger <= chunk.End; ingestLedger++ {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context cancelled: %w", ctx.Err())
+		default:
+		}
+
+		start := time.Now()
+		ledgerTransactions, err := m.ledgerBackend.GetLedgerTransactions(ctx, ingestLedger)
 		if err != nil {
-			return []entities.Transaction{}, fmt.Errorf("getTransactions: %w", err)
+			return fmt.Errorf("getting transactions for ledger %d: %w", ingestLedger, err)
 		}
-		cursor = getTxnsResp.Cursor
-		for _, tx := range getTxnsResp.Transactions {
-			if tx.Ledger == ledger {
-				ledgerTransactions = append(ledgerTransactions, tx)
-				lastLedgerSeen = tx.Ledger
-			} else {
-				lastLedgerSeen = tx.Ledger
-				break
+		ledger := LedgerTransactions{Sequence: ingestLedger, Transactions: ledgerTransactions}
+		err = m.processLedger(ctx, m.backfillProcessors, ledger, func(dbTx db.Transaction) error {
+			if err := m.models.Payments.UpdateLatestLedgerSynced(ctx, dbTx, cursorName, ingestLedger); err != nil {
+				return fmt.Errorf("updating latest synced ledger for cursor %s: %w", cursorName, err)
+			}
+			if err := m.models.IngestedRanges.Record(ctx, dbTx, source, ingestLedger); err != nil {
+				return fmt.Errorf("recording ingested ledger: %w", err)
 			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ingesting ledger %d: %w", ingestLedger, err)
 		}
+		m.metricsService.ObserveIngestionDuration(backfillPrometheusLabel, time.Since(start).Seconds())
 	}
-	return ledgerTransactions, nil
+
+	log.Ctx(ctx).Infof("backfilled chunk [%d, %d]", chunk.Start, chunk.End)
+	return nil
 }
 
-func (m *ingestService) ingestPayments(ctx context.Context, ledgerTransactions []entities.Transaction) error {
-	err := db.RunInTransaction(ctx, m.models.Payments.DB, nil, func(dbTx db.Transaction) error {
-		paymentOpsIngested := 0
-		pathPaymentStrictSendOpsIngested := 0
-		pathPaymentStrictReceiveOpsIngested := 0
-		for _, tx := range ledgerTransactions {
-			if tx.Status != entities.SuccessStatus {
-				continue
-			}
-			genericTx, err := txnbuild.TransactionFromXDR(tx.EnvelopeXDR)
-			if err != nil {
-				return fmt.Errorf("deserializing envelope xdr: %w", err)
-			}
-			txEnvelopeXDR, err := genericTx.ToXDR()
-			if err != nil {
-				return fmt.Errorf("generic transaction cannot be unpacked into a transaction")
-			}
-			txResultXDR, err := tss.UnmarshallTransactionResultXDR(tx.ResultXDR)
+func (m *ingestService) RunAccountBackfills(ctx context.Context) {
+	ticker := time.NewTicker(accountBackfillPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// drain every pending job before waiting for the next tick
+		for {
+			job, err := m.models.BackfillJobs.ClaimNext(ctx, accountBackfillStaleAfter)
 			if err != nil {
-				return fmt.Errorf("cannot unmarshal transacation result xdr: %s", err.Error())
+				log.Ctx(ctx).Errorf("claiming account backfill job: %v", err)
+				break
 			}
-
-			txMemo, txMemoType := utils.Memo(txEnvelopeXDR.Memo(), tx.Hash)
-			if txMemo != nil {
-				*txMemo = utils.SanitizeUTF8(*txMemo)
+			if job == nil {
+				break
 			}
-			for idx, op := range txEnvelopeXDR.Operations() {
-				opIdx := idx + 1
-				payment := data.Payment{
-					OperationID:     utils.OperationID(int32(tx.Ledger), int32(tx.ApplicationOrder), int32(opIdx)),
-					OperationType:   op.Body.Type.String(),
-					TransactionID:   utils.TransactionID(int32(tx.Ledger), int32(tx.ApplicationOrder)),
-					TransactionHash: tx.Hash,
-					FromAddress:     utils.SourceAccount(op, txEnvelopeXDR),
-					CreatedAt:       time.Unix(int64(tx.CreatedAt), 0),
-					Memo:            txMemo,
-					MemoType:        txMemoType,
-				}
 
-				switch op.Body.Type {
-				case xdr.OperationTypePayment:
-					paymentOpsIngested++
-					fillPayment(&payment, op.Body)
-				case xdr.OperationTypePathPaymentStrictSend:
-					pathPaymentStrictSendOpsIngested++
-					fillPathSend(&payment, op.Body, txResultXDR, opIdx)
-				case xdr.OperationTypePathPaymentStrictReceive:
-					pathPaymentStrictReceiveOpsIngested++
-					fillPathReceive(&payment, op.Body, txResultXDR, opIdx)
-				default:
-					continue
+			if err := m.backfillAccount(ctx, job); err != nil {
+				// the job is resumed by the next worker once it goes stale
+				if ctx.Err() != nil {
+					return
 				}
-
-				err = m.models.Payments.AddPayment(ctx, dbTx, payment)
-				if err != nil {
-					return fmt.Errorf("adding payment for ledger %d, tx %s (%d), operation %s (%d): %w", tx.Ledger, tx.Hash, tx.ApplicationOrder, payment.OperationID, opIdx, err)
+				log.Ctx(ctx).Errorf("backfilling account %s: %v", job.Address, err)
+				m.appTracker.CaptureException(err)
+				if err := m.models.BackfillJobs.Fail(ctx, job.ID, err.Error()); err != nil {
+					log.Ctx(ctx).Errorf("marking account backfill job %d as failed: %v", job.ID, err)
 				}
 			}
 		}
-		m.metricsService.SetNumPaymentOpsIngestedPerLedger(paymentPrometheusLabel, paymentOpsIngested)
-		m.metricsService.SetNumPaymentOpsIngestedPerLedger(pathPaymentStrictSendPrometheusLabel, pathPaymentStrictSendOpsIngested)
-		m.metricsService.SetNumPaymentOpsIngestedPerLedger(pathPaymentStrictReceivePrometheusLabel, pathPaymentStrictReceiveOpsIngested)
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("ingesting payments: %w", err)
-	}
 
-	return nil
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
-func (m *ingestService) processTSSTransactions(ctx context.Context, ledgerTransactions []entities.Transaction) error {
-	// Initialize a map to track counts by status
-	statusCounts := make(map[string]float64)
-
-	for _, tx := range ledgerTransactions {
-		if !tx.FeeBump {
-			// because all transactions submitted by TSS are fee bump transactions
-			continue
+// backfillAccount ingests the history of the job account. The range is fixed the first time the job runs, from the
+// oldest ledger in the ledger backend up to the live cursor, and an interrupted job resumes after its last ledger.
+func (m *ingestService) backfillAccount(ctx context.Context, job *data.AccountBackfillJob) error {
+	if job.StartLedger == nil || job.EndLedger == nil {
+		oldestLedger, _, err := m.ledgerBackend.GetLedgerRange(ctx)
+		if err != nil {
+			return fmt.Errorf("getting ledger backend range: %w", err)
 		}
-		tssTry, err := m.tssStore.GetTry(ctx, tx.Hash)
+		liveLedger, err := m.models.Payments.GetLatestLedgerSynced(ctx, m.ledgerCursorName)
 		if err != nil {
-			return fmt.Errorf("error when getting try: %w", err)
+			return fmt.Errorf("getting latest ledger synced for cursor %s: %w", m.ledgerCursorName, err)
 		}
-		if tssTry == (tssstore.Try{}) {
-			continue
+		startLedger, endLedger := oldestLedger, liveLedger
+		if err := m.models.BackfillJobs.SetRange(ctx, job.ID, startLedger, endLedger); err != nil {
+			return fmt.Errorf("setting job range: %w", err)
 		}
+		job.StartLedger, job.EndLedger = &startLedger, &endLedger
+	}
 
-		transaction, err := m.tssStore.GetTransaction(ctx, tssTry.OrigTxHash)
-		if err != nil {
-			return fmt.Errorf("error getting transaction: %w", err)
-		}
-		status := tss.RPCTXStatus{RPCStatus: tx.Status}
-		code, err := tss.TransactionResultXDRToCode(tx.ResultXDR)
-		if err != nil {
-			return fmt.Errorf("error unmarshaling resultxdr: %w", err)
+	ingestLedger := *job.StartLedger
+	if job.LastLedger != nil && *job.LastLedger >= ingestLedger {
+		ingestLedger = *job.LastLedger + 1
+	}
+	log.Ctx(ctx).Infof("backfilling account %s from ledger %d to %d", job.Address, ingestLedger, *job.EndLedger)
+
+	for ; ingestLedger <= *job.EndLedger; ingestLedger++ {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context cancelled: %w", ctx.Err())
+		default:
 		}
-		err = m.tssStore.UpsertTry(ctx, tssTry.OrigTxHash, tssTry.Hash, tssTry.XDR, status, code, tx.ResultXDR)
+
+		start := time.Now()
+		ledgerTransactions, err := m.ledgerBackend.GetLedgerTransactions(ctx, ingestLedger)
 		if err != nil {
-			return fmt.Errorf("error updating try: %w", err)
+			return fmt.Errorf("getting transactions for ledger %d: %w", ingestLedger, err)
 		}
-		err = m.tssStore.UpsertTransaction(ctx, transaction.WebhookURL, tssTry.OrigTxHash, transaction.XDR, status)
+		ledger := LedgerTransactions{Sequence: ingestLedger, Transactions: ledgerTransactions, AccountFilter: job.Address}
+		err = m.processLedger(ctx, m.backfillProcessors, ledger, func(dbTx db.Transaction) error {
+			if err := m.models.BackfillJobs.UpdateProgress(ctx, dbTx, job.ID, ingestLedger); err != nil {
+				return fmt.Errorf("updating job progress: %w", err)
+			}
+			return nil
+		})
 		if err != nil {
-			return fmt.Errorf("error updating transaction: %w", err)
+			return fmt.Errorf("ingesting ledger %d: %w", ingestLedger, err)
 		}
+		m.metricsService.ObserveIngestionDuration(accountBackfillPrometheusLabel, time.Since(start).Seconds())
+	}
 
-		txCode, err := tss.TransactionResultXDRToCode(tx.ResultXDR)
-		if err != nil {
-			return fmt.Errorf("unable to extract tx code from result xdr string: %w", err)
-		}
+	if err := m.models.BackfillJobs.Complete(ctx, job.ID); err != nil {
+		return fmt.Errorf("completing job: %w", err)
+	}
+	log.Ctx(ctx).Infof("backfilled account %s", job.Address)
+	return nil
+}
 
-		tssGetIngestResponse := tss.RPCGetIngestTxResponse{
-			Status:      tx.Status,
-			Code:        txCode,
-			EnvelopeXDR: tx.EnvelopeXDR,
-			ResultXDR:   tx.ResultXDR,
-			CreatedAt:   int64(tx.CreatedAt),
-		}
-		payload := tss.Payload{
-			RPCGetIngestTxResponse: tssGetIngestResponse,
-		}
-		err = m.tssRouter.Route(payload)
-		if err != nil {
-			return fmt.Errorf("unable to route payload: %w", err)
-		}
-		// Record the transaction status transition
-		m.metricsService.RecordTSSTransactionStatusTransition(transaction.Status, string(tx.Status))
+// LedgerRange is an inclusive range of ledgers.
+type LedgerRange struct {
+	Start uint32 `json:"start"`
+	End   uint32 `json:"end"`
+}
 
-		// Calculate and record the transaction inclusion time using the transaction's creation time in our system
-		inclusionTime := time.Since(transaction.CreatedAt).Seconds()
-		m.metricsService.ObserveTSSTransactionInclusionTime(string(tx.Status), inclusionTime)
+func (r LedgerRange) String() string {
+	return fmt.Sprintf("[%d, %d]", r.Start, r.End)
+}
 
-		// Increment the count for this status
-		statusCounts[string(tx.Status)]++
-	}
+// cursorName is the name of the ingest store cursor of a chunk ingested by the source.
+func (r LedgerRange) cursorName(source data.LedgerRangeSource) string {
+	return fmt.Sprintf("%s_%d_%d", source, r.Start, r.End)
+}
 
-	// Set the final counts for each status
-	for status, count := range statusCounts {
-		m.metricsService.SetNumTssTransactionsIngestedPerLedger(status, count)
+// splitLedgerRange splits the inclusive range [start, end] into consecutive chunks of at most chunkSize ledgers.
+func splitLedgerRange(start, end, chunkSize uint32) []LedgerRange {
+	var chunks []LedgerRange
+	for chunkStart := start; chunkStart <= end; chunkStart += chunkSize {
+		chunkEnd := chunkStart + chunkSize - 1
+		// guard against overflows when the range ends close to math.MaxUint32
+		if chunkEnd > end || chunkEnd < chunkStart {
+			chunkEnd = end
+		}
+		chunks = append(chunks, LedgerRange{Start: chunkStart, End: chunkEnd})
+		if chunkEnd == end {
+			break
+		}
 	}
-	return nil
+	return chunks
 }
 
 func trackIngestServiceHealth(ctx context.Context, heartbeat chan any, tracker apptracker.AppTracker) {
 	ticker := time.NewTicker(ingestHealthCheckMaxWaitTime)
-	defer func() {
-		ticker.Stop()
-		close(heartbeat)
-	}()
+	defer ticker.Stop()
 
 	for {
 		select {
@@ -353,9 +521,144 @@ func trackIngestServiceHealth(ctx context.Context, heartbeat chan any, tracker a
 	}
 }
 
+func fillCreateAccount(payment *data.Payment, operation xdr.OperationBody) {
+	createAccountOp := operation.MustCreateAccountOp()
+	payment.ToAddress = createAccountOp.Destination.Address()
+	fillNativeAmount(payment, int64(createAccountOp.StartingBalance))
+}
+
+// fillAccountMerge takes the amount from the operation result since it's the whole balance of the merged account,
+// failed merges don't move any funds.
+func fillAccountMerge(payment *data.Payment, operation xdr.OperationBody, txResult xdr.TransactionResult, operationIdx int) {
+	payment.ToAddress, payment.ToMuxedID, payment.ToMuxedAddress = muxedAccountDetails(operation.MustDestination())
+	var amount xdr.Int64
+	if result := utils.OperationResult(txResult, operationIdx); result != nil {
+		amount, _ = result.MustAccountMergeResult().GetSourceAccountBalance()
+	}
+	fillNativeAmount(payment, int64(amount))
+}
+
+// fillClaimClaimableBalance fills a claim as a payment to the claimant, the operation source, from the sponsor of the
+// claimable balance, which is the account that created it unless the sponsorship was transferred. The asset and amount
+// are taken from the claimable balance removed by the operation.
+func fillClaimClaimableBalance(payment *data.Payment, operation xdr.OperationBody, changes xdr.LedgerEntryChanges) error {
+	balanceID := operation.MustClaimClaimableBalanceOp().BalanceId
+	for _, change := range changes {
+		if change.Type != xdr.LedgerEntryChangeTypeLedgerEntryState {
+			continue
+		}
+		entry := change.MustState()
+		claimableBalance, ok := entry.Data.GetClaimableBalance()
+		if !ok || claimableBalance.BalanceId.MustV0() != balanceID.MustV0() {
+			continue
+		}
+
+		fillClaimant(payment)
+		if sponsor := entry.SponsoringID(); sponsor != nil {
+			payment.FromAddress = sponsor.Address()
+		}
+		payment.SrcAssetCode = utils.AssetCode(claimableBalance.Asset)
+		payment.SrcAssetIssuer = claimableBalance.Asset.GetIssuer()
+		payment.SrcAssetType = claimableBalance.Asset.Type.String()
+		payment.SrcAmount = int64(claimableBalance.Amount)
+		payment.DestAssetCode = payment.SrcAssetCode
+		payment.DestAssetIssuer = payment.SrcAssetIssuer
+		payment.DestAssetType = payment.SrcAssetType
+		payment.DestAmount = payment.SrcAmount
+		return nil
+	}
+
+	balanceIDHex, err := xdr.MarshalHex(balanceID)
+	if err != nil {
+		return fmt.Errorf("marshalling claimable balance id: %w", err)
+	}
+	return fmt.Errorf("claimable balance %s not found in the operation changes", balanceIDHex)
+}
+
+// fillClaimant moves the operation source, which claims the balance, to the payment destination. It's all that's known
+// about failed claims since they don't change any entry.
+func fillClaimant(payment *data.Payment) {
+	payment.ToAddress, payment.ToMuxedID, payment.ToMuxedAddress = payment.FromAddress, payment.FromMuxedID, payment.FromMuxedAddress
+	payment.FromAddress, payment.FromMuxedID, payment.FromMuxedAddress = "", nil, nil
+}
+
+func fillNativeAmount(payment *data.Payment, amount int64) {
+	native := xdr.MustNewNativeAsset()
+	payment.SrcAssetCode = utils.AssetCode(native)
+	payment.SrcAssetIssuer = ""
+	payment.SrcAssetType = native.Type.String()
+	payment.SrcAmount = amount
+	payment.DestAssetCode = payment.SrcAssetCode
+	payment.DestAssetIssuer = payment.SrcAssetIssuer
+	payment.DestAssetType = payment.SrcAssetType
+	payment.DestAmount = payment.SrcAmount
+}
+
+// tokenTransferPayments returns a payment for each SEP-41 token transfer, mint, burn or clawback event emitted by
+// the transaction's InvokeHostFunction operation. Soroban transactions have exactly one operation, so the payments share
+// its ID and are told apart by their event index.
+func tokenTransferPayments(tx entities.Transaction, txEnvelopeXDR xdr.TransactionEnvelope, txMemo *string, txMemoType string) ([]data.Payment, error) {
+	ops := txEnvelopeXDR.Operations()
+	if len(ops) != 1 || ops[0].Body.Type != xdr.OperationTypeInvokeHostFunction {
+		return nil, nil
+	}
+
+	var txMeta xdr.TransactionMeta
+	if err := xdr.SafeUnmarshalBase64(tx.ResultMetaXDR, &txMeta); err != nil {
+		return nil, fmt.Errorf("unmarshalling transaction meta: %w", err)
+	}
+	events, err := txMeta.GetContractEvents()
+	if err != nil {
+		return nil, fmt.Errorf("getting contract events: %w", err)
+	}
+
+	var payments []data.Payment
+	for _, event := range events {
+		transfer, ok := parseTokenTransferEvent(event)
+		if !ok {
+			continue
+		}
+		eventType := transfer.eventType
+		payments = append(payments, data.Payment{
+			OperationID:       utils.OperationID(int32(tx.Ledger), int32(tx.ApplicationOrder), 1),
+			EventIndex:        int32(len(payments)),
+			OperationType:     xdr.OperationTypeInvokeHostFunction.String(),
+			TransactionID:     utils.TransactionID(int32(tx.Ledger), int32(tx.ApplicationOrder)),
+			TransactionHash:   tx.Hash,
+			FromAddress:       transfer.from,
+			ToAddress:         transfer.to,
+			SrcAssetCode:      transfer.contractID,
+			SrcAssetType:      contractAssetType,
+			SrcAmount:         transfer.amount,
+			DestAssetCode:     transfer.contractID,
+			DestAssetType:     contractAssetType,
+			DestAmount:        transfer.amount,
+			CreatedAt:         time.Unix(int64(tx.CreatedAt), 0),
+			Memo:              txMemo,
+			MemoType:          txMemoType,
+			ContractEventType: &eventType,
+			Successful:        true,
+		})
+	}
+
+	return payments, nil
+}
+
+// muxedAccountDetails returns the G-address of the account, along with its mux ID and M-address when it's muxed.
+func muxedAccountDetails(account xdr.MuxedAccount) (address string, muxedID, muxedAddress *string) {
+	address = muxedAccountAddress(account)
+	med25519, ok := account.GetMed25519()
+	if !ok {
+		return address, nil, nil
+	}
+	id := strconv.FormatUint(uint64(med25519.Id), 10)
+	mAddress := account.Address()
+	return address, &id, &mAddress
+}
+
 func fillPayment(payment *data.Payment, operation xdr.OperationBody) {
 	paymentOp := operation.MustPaymentOp()
-	payment.ToAddress = paymentOp.Destination.Address()
+	payment.ToAddress, payment.ToMuxedID, payment.ToMuxedAddress = muxedAccountDetails(paymentOp.Destination)
 	payment.SrcAssetCode = utils.AssetCode(paymentOp.Asset)
 	payment.SrcAssetIssuer = paymentOp.Asset.GetIssuer()
 	payment.SrcAssetType = paymentOp.Asset.Type.String()
@@ -368,8 +671,7 @@ func fillPayment(payment *data.Payment, operation xdr.OperationBody) {
 
 func fillPathSend(payment *data.Payment, operation xdr.OperationBody, txResult xdr.TransactionResult, operationIdx int) {
 	pathOp := operation.MustPathPaymentStrictSendOp()
-	result := utils.OperationResult(txResult, operationIdx).MustPathPaymentStrictSendResult()
-	payment.ToAddress = pathOp.Destination.Address()
+	payment.ToAddress, payment.ToMuxedID, payment.ToMuxedAddress = muxedAccountDetails(pathOp.Destination)
 	payment.SrcAssetCode = utils.AssetCode(pathOp.SendAsset)
 	payment.SrcAssetIssuer = pathOp.SendAsset.GetIssuer()
 	payment.SrcAssetType = pathOp.SendAsset.Type.String()
@@ -377,19 +679,30 @@ func fillPathSend(payment *data.Payment, operation xdr.OperationBody, txResult x
 	payment.DestAssetCode = utils.AssetCode(pathOp.DestAsset)
 	payment.DestAssetIssuer = pathOp.DestAsset.GetIssuer()
 	payment.DestAssetType = pathOp.DestAsset.Type.String()
-	if result != (xdr.PathPaymentStrictSendResult{}) {
-		payment.DestAmount = int64(result.DestAmount())
+	// failed path payments only have the minimum amount that was requested
+	payment.DestAmount = int64(pathOp.DestMin)
+	if result := utils.OperationResult(txResult, operationIdx); result != nil {
+		sendResult := result.MustPathPaymentStrictSendResult()
+		if sendResult.Code == xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendSuccess {
+			payment.DestAmount = int64(sendResult.DestAmount())
+		}
 	}
 }
 
 func fillPathReceive(payment *data.Payment, operation xdr.OperationBody, txResult xdr.TransactionResult, operationIdx int) {
 	pathOp := operation.MustPathPaymentStrictReceiveOp()
-	result := utils.OperationResult(txResult, operationIdx).MustPathPaymentStrictReceiveResult()
-	payment.ToAddress = pathOp.Destination.Address()
+	payment.ToAddress, payment.ToMuxedID, payment.ToMuxedAddress = muxedAccountDetails(pathOp.Destination)
 	payment.SrcAssetCode = utils.AssetCode(pathOp.SendAsset)
 	payment.SrcAssetIssuer = pathOp.SendAsset.GetIssuer()
 	payment.SrcAssetType = pathOp.SendAsset.Type.String()
-	payment.SrcAmount = int64(result.SendAmount())
+	// failed path payments only have the maximum amount that was offered
+	payment.SrcAmount = int64(pathOp.SendMax)
+	if result := utils.OperationResult(txResult, operationIdx); result != nil {
+		receiveResult := result.MustPathPaymentStrictReceiveResult()
+		if receiveResult.Code == xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveSuccess {
+			payment.SrcAmount = int64(receiveResult.SendAmount())
+		}
+	}
 	payment.DestAssetCode = utils.AssetCode(pathOp.DestAsset)
 	payment.DestAssetIssuer = pathOp.DestAsset.GetIssuer()
 	payment.DestAssetType = pathOp.DestAsset.Type.String()
@@ -3,12 +3,17 @@ package services
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
@@ -25,107 +30,11 @@ import (
 	"github.com/stellar/wallet-backend/internal/tss"
 	tssrouter "github.com/stellar/wallet-backend/internal/tss/router"
 	tssstore "github.com/stellar/wallet-backend/internal/tss/store"
+	"github.com/stellar/wallet-backend/internal/utils"
 )
 
-func TestGetLedgerTransactions(t *testing.T) {
-	dbt := dbtest.Open(t)
-	defer dbt.Close()
-
-	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
-	require.NoError(t, err)
-	defer dbConnectionPool.Close()
-
-	mockMetricsService := metrics.NewMockMetricsService()
-	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
-	require.NoError(t, err)
-	mockAppTracker := apptracker.MockAppTracker{}
-	mockRPCService := RPCServiceMock{}
-	mockRouter := tssrouter.MockRouter{}
-	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
-	require.NoError(t, err)
-	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, &mockRouter, tssStore, mockMetricsService)
-	require.NoError(t, err)
-	t.Run("all_ledger_transactions_in_single_gettransactions_call", func(t *testing.T) {
-		defer mockMetricsService.AssertExpectations(t)
-
-		rpcGetTransactionsResult := entities.RPCGetTransactionsResult{
-			Cursor: "51",
-			Transactions: []entities.Transaction{
-				{
-					Status: entities.SuccessStatus,
-					Hash:   "hash1",
-					Ledger: 1,
-				},
-				{
-					Status: entities.FailedStatus,
-					Hash:   "hash2",
-					Ledger: 2,
-				},
-			},
-		}
-		mockRPCService.
-			On("GetTransactions", int64(1), "", 50).
-			Return(rpcGetTransactionsResult, nil).
-			Once()
-
-		txns, err := ingestService.GetLedgerTransactions(1)
-		assert.Equal(t, 1, len(txns))
-		assert.Equal(t, txns[0].Hash, "hash1")
-		assert.NoError(t, err)
-	})
-
-	t.Run("ledger_transactions_split_between_multiple_gettransactions_calls", func(t *testing.T) {
-		defer mockMetricsService.AssertExpectations(t)
-
-		rpcGetTransactionsResult1 := entities.RPCGetTransactionsResult{
-			Cursor: "51",
-			Transactions: []entities.Transaction{
-				{
-					Status: entities.SuccessStatus,
-					Hash:   "hash1",
-					Ledger: 1,
-				},
-				{
-					Status: entities.FailedStatus,
-					Hash:   "hash2",
-					Ledger: 1,
-				},
-			},
-		}
-		rpcGetTransactionsResult2 := entities.RPCGetTransactionsResult{
-			Cursor: "51",
-			Transactions: []entities.Transaction{
-				{
-					Status: entities.SuccessStatus,
-					Hash:   "hash3",
-					Ledger: 1,
-				},
-				{
-					Status: entities.FailedStatus,
-					Hash:   "hash4",
-					Ledger: 2,
-				},
-			},
-		}
-
-		mockRPCService.
-			On("GetTransactions", int64(1), "", 50).
-			Return(rpcGetTransactionsResult1, nil).
-			Once()
-
-		mockRPCService.
-			On("GetTransactions", int64(1), "51", 50).
-			Return(rpcGetTransactionsResult2, nil).
-			Once()
-
-		txns, err := ingestService.GetLedgerTransactions(1)
-		assert.Equal(t, 3, len(txns))
-		assert.Equal(t, txns[0].Hash, "hash1")
-		assert.Equal(t, txns[1].Hash, "hash2")
-		assert.Equal(t, txns[2].Hash, "hash3")
-		assert.NoError(t, err)
-	})
-}
+// emptyTxMetaXDR is a V3 transaction meta without any ledger entry changes.
+const emptyTxMetaXDR = "AAAAAwAAAAAAAAAAAAAAAAAAAAAAAAAA"
 
 func TestProcessTSSTransactions(t *testing.T) {
 	dbt := dbtest.Open(t)
@@ -143,7 +52,9 @@ func TestProcessTSSTransactions(t *testing.T) {
 	mockRouter := tssrouter.MockRouter{}
 	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
 	require.NoError(t, err)
-	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, &mockRouter, tssStore, mockMetricsService)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
 	require.NoError(t, err)
 
 	t.Run("routes_to_tss_router", func(t *testing.T) {
@@ -185,7 +96,7 @@ func TestProcessTSSTransactions(t *testing.T) {
 			Return(nil).
 			Once()
 
-		err := ingestService.processTSSTransactions(context.Background(), transactions)
+		err := runLedgerProcessor(context.Background(), ingestService, TSSLedgerProcessor, transactions)
 		assert.NoError(t, err)
 
 		updatedTX, err := tssStore.GetTransaction(context.Background(), "hash")
@@ -196,6 +107,48 @@ func TestProcessTSSTransactions(t *testing.T) {
 		assert.Equal(t, "AAAAAAAAAMj////9AAAAAA==", updatedTry.ResultXDR)
 		assert.Equal(t, int32(xdr.TransactionResultCodeTxTooLate), updatedTry.Code)
 	})
+
+	t.Run("rolled_back_ledger_is_not_routed", func(t *testing.T) {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+		mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+		mockMetricsService.On("RecordTSSTransactionStatusTransition", mock.Anything, mock.Anything)
+		mockMetricsService.On("ObserveTSSTransactionInclusionTime", mock.Anything, mock.AnythingOfType("float64"))
+		mockMetricsService.On("SetNumTssTransactionsIngestedPerLedger", mock.Anything, mock.Anything)
+		mockRouter := tssrouter.MockRouter{}
+		defer mockRouter.AssertExpectations(t)
+		tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+		require.NoError(t, err)
+		ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
+		require.NoError(t, err)
+		processor, err := ingestService.newLedgerProcessor(TSSLedgerProcessor)
+		require.NoError(t, err)
+
+		err = tssStore.UpsertTransaction(context.Background(), "localhost:8000/webhook", "rolledbackhash", "xdr", tss.RPCTXStatus{OtherStatus: tss.NewStatus})
+		require.NoError(t, err)
+		err = tssStore.UpsertTry(context.Background(), "rolledbackhash", "rolledbackfeebumphash", "feebumpxdr", tss.RPCTXStatus{OtherStatus: tss.NewStatus}, tss.RPCTXCode{OtherCodes: tss.NewCode}, "")
+		require.NoError(t, err)
+
+		transactions := []entities.Transaction{
+			{
+				Status:      entities.SuccessStatus,
+				Hash:        "rolledbackfeebumphash",
+				FeeBump:     true,
+				EnvelopeXDR: "feebumpxdr",
+				ResultXDR:   "AAAAAAAAAMj////9AAAAAA==",
+				Ledger:      123457,
+			},
+		}
+		err = ingestService.processLedger(context.Background(), []LedgerProcessor{processor}, LedgerTransactions{Sequence: 123457, Transactions: transactions}, func(db.Transaction) error {
+			return errors.New("cursor moved")
+		})
+		require.ErrorContains(t, err, "cursor moved")
+
+		// Route isn't expected and the status is still the one before the ledger
+		tssTransaction, err := tssStore.GetTransaction(context.Background(), "rolledbackhash")
+		require.NoError(t, err)
+		assert.Equal(t, string(tss.NewStatus), tssTransaction.Status)
+	})
 }
 
 func TestIngestPayments(t *testing.T) {
@@ -214,7 +167,9 @@ func TestIngestPayments(t *testing.T) {
 	mockRouter := tssrouter.MockRouter{}
 	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
 	require.NoError(t, err)
-	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, &mockRouter, tssStore, mockMetricsService)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
 	require.NoError(t, err)
 	srcAccount := keypair.MustRandom().Address()
 	destAccount := keypair.MustRandom().Address()
@@ -226,11 +181,21 @@ func TestIngestPayments(t *testing.T) {
 		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Once()
 		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
 		mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "payment_volumes", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "payment_volumes").Once()
 		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Times(2)
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 1).Once()
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
 		defer mockMetricsService.AssertExpectations(t)
 
 		err = models.Account.Insert(context.Background(), srcAccount)
@@ -266,25 +231,176 @@ func TestIngestPayments(t *testing.T) {
 
 		ledgerTransactions := []entities.Transaction{ledgerTransaction}
 
-		err = ingestService.ingestPayments(context.Background(), ledgerTransactions)
+		err = runLedgerProcessor(context.Background(), ingestService, PaymentsLedgerProcessor, ledgerTransactions)
 		require.NoError(t, err)
 
 		var payments []data.Payment
-		payments, _, _, err = models.Payments.GetPaymentsPaginated(context.Background(), srcAccount, "", "", data.ASC, 1)
+		payments, _, _, err = models.Payments.GetPaymentsPaginated(context.Background(), data.PaymentsFilter{Address: srcAccount}, "", "", data.ASC, 1)
 		assert.NoError(t, err)
 		assert.Equal(t, payments[0].TransactionHash, "abcd")
 	})
 
+	t.Run("test_op_payment_muxed_accounts", func(t *testing.T) {
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "accounts", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "payment_volumes", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "payment_volumes").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Times(2)
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 1).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
+		defer mockMetricsService.AssertExpectations(t)
+
+		// the exchange is registered by its G-address and receives the payment on one of its muxed accounts
+		exchange := keypair.MustRandom().Address()
+		err = models.Account.Insert(context.Background(), exchange)
+		require.NoError(t, err)
+		sender := keypair.MustRandom().Address()
+		muxedSender, err := xdr.MuxedAccountFromAccountId(sender, 7)
+		require.NoError(t, err)
+		muxedExchange, err := xdr.MuxedAccountFromAccountId(exchange, math.MaxUint64)
+		require.NoError(t, err)
+
+		paymentOp := txnbuild.Payment{
+			SourceAccount: muxedSender.Address(),
+			Destination:   muxedExchange.Address(),
+			Amount:        "10",
+			Asset:         txnbuild.NativeAsset{},
+		}
+		transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+			SourceAccount: &txnbuild.SimpleAccount{
+				AccountID: keypair.MustRandom().Address(),
+			},
+			Operations:    []txnbuild.Operation{&paymentOp},
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+		})
+		require.NoError(t, err)
+		txEnvXDR, err := transaction.Base64()
+		require.NoError(t, err)
+
+		ledgerTransaction := entities.Transaction{
+			Status:           entities.SuccessStatus,
+			Hash:             "muxed",
+			ApplicationOrder: 1,
+			FeeBump:          false,
+			EnvelopeXDR:      txEnvXDR,
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			Ledger:           2,
+		}
+		err = runLedgerProcessor(context.Background(), ingestService, PaymentsLedgerProcessor, []entities.Transaction{ledgerTransaction})
+		require.NoError(t, err)
+
+		payments, _, _, err := models.Payments.GetPaymentsPaginated(context.Background(), data.PaymentsFilter{Address: exchange, MuxedID: utils.PointOf(uint64(math.MaxUint64))}, "", "", data.ASC, 1)
+		require.NoError(t, err)
+		require.Len(t, payments, 1)
+		assert.Equal(t, sender, payments[0].FromAddress)
+		assert.Equal(t, utils.PointOf("7"), payments[0].FromMuxedID)
+		assert.Equal(t, utils.PointOf(muxedSender.Address()), payments[0].FromMuxedAddress)
+		assert.Equal(t, exchange, payments[0].ToAddress)
+		assert.Equal(t, utils.PointOf("18446744073709551615"), payments[0].ToMuxedID)
+		assert.Equal(t, utils.PointOf(muxedExchange.Address()), payments[0].ToMuxedAddress)
+	})
+
+	t.Run("test_op_payment_failed_transaction", func(t *testing.T) {
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "accounts", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "payment_volumes", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "payment_volumes").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Times(3)
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(3)
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 1).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
+		defer mockMetricsService.AssertExpectations(t)
+
+		sender := keypair.MustRandom().Address()
+		err = models.Account.Insert(context.Background(), sender)
+		require.NoError(t, err)
+		paymentOp := txnbuild.Payment{
+			SourceAccount: sender,
+			Destination:   destAccount,
+			Amount:        "10",
+			Asset:         txnbuild.NativeAsset{},
+		}
+		transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+			SourceAccount: &txnbuild.SimpleAccount{
+				AccountID: keypair.MustRandom().Address(),
+			},
+			Operations:    []txnbuild.Operation{&paymentOp},
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+		})
+		require.NoError(t, err)
+		txEnvXDR, err := transaction.Base64()
+		require.NoError(t, err)
+
+		// the transaction failed with tx_too_late, before its operations were applied
+		ledgerTransaction := entities.Transaction{
+			Status:           entities.FailedStatus,
+			Hash:             "failed",
+			ApplicationOrder: 1,
+			FeeBump:          false,
+			EnvelopeXDR:      txEnvXDR,
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			Ledger:           3,
+		}
+		err = runLedgerProcessor(context.Background(), ingestService, PaymentsLedgerProcessor, []entities.Transaction{ledgerTransaction})
+		require.NoError(t, err)
+
+		payments, _, _, err := models.Payments.GetPaymentsPaginated(context.Background(), data.PaymentsFilter{Address: sender}, "", "", data.ASC, 1)
+		require.NoError(t, err)
+		assert.Empty(t, payments)
+
+		payments, _, _, err = models.Payments.GetPaymentsPaginated(context.Background(), data.PaymentsFilter{Address: sender, IncludeFailed: true}, "", "", data.ASC, 1)
+		require.NoError(t, err)
+		require.Len(t, payments, 1)
+		assert.Equal(t, "failed", payments[0].TransactionHash)
+		assert.False(t, payments[0].Successful)
+		assert.Equal(t, utils.PointOf("tx_too_late"), payments[0].TransactionResultCode)
+		assert.Nil(t, payments[0].OperationResultCode)
+		assert.Equal(t, utils.PointOf("The transaction was submitted after its time bounds"), payments[0].FailureReason)
+	})
+
 	t.Run("test_op_path_payment_send", func(t *testing.T) {
 		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "accounts", mock.AnythingOfType("float64")).Times(2)
 		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Times(2)
 		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
 		mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "payment_volumes", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "payment_volumes").Once()
 		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Times(2)
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 0).Once()
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 1).Once()
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
 		defer mockMetricsService.AssertExpectations(t)
 
 		err = models.Account.Insert(context.Background(), srcAccount)
@@ -337,11 +453,11 @@ func TestIngestPayments(t *testing.T) {
 
 		ledgerTransactions := []entities.Transaction{ledgerTransaction}
 
-		err = ingestService.ingestPayments(context.Background(), ledgerTransactions)
+		err = runLedgerProcessor(context.Background(), ingestService, PaymentsLedgerProcessor, ledgerTransactions)
 		require.NoError(t, err)
 
 		var payments []data.Payment
-		payments, _, _, err = models.Payments.GetPaymentsPaginated(context.Background(), srcAccount, "", "", data.ASC, 1)
+		payments, _, _, err = models.Payments.GetPaymentsPaginated(context.Background(), data.PaymentsFilter{Address: srcAccount}, "", "", data.ASC, 1)
 		require.NoError(t, err)
 		require.NotEmpty(t, payments, "Expected at least one payment")
 		assert.Equal(t, payments[0].TransactionHash, ledgerTransaction.Hash)
@@ -358,11 +474,21 @@ func TestIngestPayments(t *testing.T) {
 		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Times(2)
 		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
 		mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "payment_volumes", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "payment_volumes").Once()
 		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Times(2)
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 0).Once()
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
 		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 1).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
 		defer mockMetricsService.AssertExpectations(t)
 
 		err = models.Account.Insert(context.Background(), srcAccount)
@@ -413,10 +539,10 @@ func TestIngestPayments(t *testing.T) {
 
 		ledgerTransactions := []entities.Transaction{ledgerTransaction}
 
-		err = ingestService.ingestPayments(context.Background(), ledgerTransactions)
+		err = runLedgerProcessor(context.Background(), ingestService, PaymentsLedgerProcessor, ledgerTransactions)
 		require.NoError(t, err)
 
-		payments, _, _, err := models.Payments.GetPaymentsPaginated(context.Background(), srcAccount, "", "", data.ASC, 1)
+		payments, _, _, err := models.Payments.GetPaymentsPaginated(context.Background(), data.PaymentsFilter{Address: srcAccount}, "", "", data.ASC, 1)
 		require.NoError(t, err)
 		require.NotEmpty(t, payments, "Expected at least one payment")
 		assert.Equal(t, payments[0].TransactionHash, ledgerTransaction.Hash)
@@ -426,88 +552,584 @@ func TestIngestPayments(t *testing.T) {
 		assert.Equal(t, payments[0].SrcAssetCode, "XLM")
 		assert.Equal(t, payments[0].DestAssetCode, "XLM")
 	})
+
+	t.Run("test_op_invoke_host_function_token_transfers", func(t *testing.T) {
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "accounts", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "accounts").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "payment_volumes", mock.AnythingOfType("float64")).Once()
+		mockMetricsService.On("IncDBQuery", "INSERT", "payment_volumes").Once()
+		mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Times(2)
+		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 2).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+		mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
+		defer mockMetricsService.AssertExpectations(t)
+
+		tokenHolder := keypair.MustRandom().Address()
+		err = models.Account.Insert(context.Background(), tokenHolder)
+		require.NoError(t, err)
+
+		contractID := strkey.MustEncode(strkey.VersionByteContract, make([]byte, 32))
+		var contractHash xdr.Hash
+		invokeHostFunctionOp := txnbuild.InvokeHostFunction{
+			SourceAccount: srcAccount,
+			HostFunction: xdr.HostFunction{
+				Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+				InvokeContract: &xdr.InvokeContractArgs{
+					ContractAddress: xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractHash},
+					FunctionName:    "transfer",
+				},
+			},
+		}
+		transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+			SourceAccount: &txnbuild.SimpleAccount{
+				AccountID: keypair.MustRandom().Address(),
+			},
+			Operations:    []txnbuild.Operation{&invokeHostFunctionOp},
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+		})
+		require.NoError(t, err)
+		txEnvXDR, err := transaction.Base64()
+		require.NoError(t, err)
+
+		txMeta := xdr.TransactionMeta{
+			V: 3,
+			V3: &xdr.TransactionMetaV3{
+				SorobanMeta: &xdr.SorobanTransactionMeta{
+					ReturnValue: xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+					Events: []xdr.ContractEvent{
+						makeContractEvent(t, contractID, makeI128(0, 100), makeSymbolTopic(t, "transfer"), makeAddressTopic(t, srcAccount), makeAddressTopic(t, tokenHolder)),
+						makeContractEvent(t, contractID, makeI128(0, 5), makeSymbolTopic(t, "approve"), makeAddressTopic(t, tokenHolder), makeAddressTopic(t, srcAccount)),
+						makeContractEvent(t, contractID, makeI128(0, 40), makeSymbolTopic(t, "burn"), makeAddressTopic(t, tokenHolder)),
+					},
+				},
+			},
+		}
+		txMetaXDR, err := xdr.MarshalBase64(txMeta)
+		require.NoError(t, err)
+
+		ledgerTransaction := entities.Transaction{
+			Status:           entities.SuccessStatus,
+			Hash:             "ijkl",
+			ApplicationOrder: 1,
+			FeeBump:          false,
+			EnvelopeXDR:      txEnvXDR,
+			ResultXDR:        "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAAYAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA==",
+			ResultMetaXDR:    txMetaXDR,
+			Ledger:           2,
+		}
+
+		err = runLedgerProcessor(context.Background(), ingestService, PaymentsLedgerProcessor, []entities.Transaction{ledgerTransaction})
+		require.NoError(t, err)
+
+		payments, _, _, err := models.Payments.GetPaymentsPaginated(context.Background(), data.PaymentsFilter{Address: tokenHolder}, "", "", data.ASC, 10)
+		require.NoError(t, err)
+		require.Len(t, payments, 2)
+
+		assert.Equal(t, "ijkl", payments[0].TransactionHash)
+		assert.Equal(t, xdr.OperationTypeInvokeHostFunction.String(), payments[0].OperationType)
+		assert.Equal(t, srcAccount, payments[0].FromAddress)
+		assert.Equal(t, tokenHolder, payments[0].ToAddress)
+		assert.Equal(t, contractID, payments[0].SrcAssetCode)
+		assert.Equal(t, contractAssetType, payments[0].SrcAssetType)
+		assert.Equal(t, int64(100), payments[0].SrcAmount)
+		assert.Equal(t, "transfer", *payments[0].ContractEventType)
+
+		assert.Equal(t, tokenHolder, payments[1].FromAddress)
+		assert.Equal(t, contractID, payments[1].ToAddress)
+		assert.Equal(t, int64(40), payments[1].DestAmount)
+		assert.Equal(t, "burn", *payments[1].ContractEventType)
+		// both events were emitted by the transaction's only operation
+		assert.Equal(t, utils.OperationID(2, 1, 1), payments[0].OperationID)
+		assert.Equal(t, payments[0].OperationID, payments[1].OperationID)
+		assert.Equal(t, int32(0), payments[0].EventIndex)
+		assert.Equal(t, int32(1), payments[1].EventIndex)
+	})
 }
 
-func TestIngest_LatestSyncedLedgerBehindRPC(t *testing.T) {
+func TestIngestOperations(t *testing.T) {
 	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
 	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
 	require.NoError(t, err)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer func() {
-		cancel()
-		require.NoError(t, dbConnectionPool.Close())
-		dbt.Close()
-	}()
+	defer dbConnectionPool.Close()
 
 	mockMetricsService := metrics.NewMockMetricsService()
-	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
-	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
-	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_store", mock.AnythingOfType("float64")).Once()
-	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_store").Once()
-	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_store", mock.AnythingOfType("float64")).Once()
-	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_store").Once()
-	mockMetricsService.On("SetLatestLedgerIngested", float64(50)).Once()
-	mockMetricsService.On("ObserveIngestionDuration", "payment", mock.AnythingOfType("float64")).Once()
-	mockMetricsService.On("ObserveIngestionDuration", "tss", mock.AnythingOfType("float64")).Once()
-	mockMetricsService.On("ObserveIngestionDuration", "total", mock.AnythingOfType("float64")).Once()
-	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 1).Once()
-	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
-	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
-	defer mockMetricsService.AssertExpectations(t)
-
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
 	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
 	require.NoError(t, err)
 	mockAppTracker := apptracker.MockAppTracker{}
 	mockRPCService := RPCServiceMock{}
-	mockRPCService.On("TrackRPCServiceHealth", ctx, mock.Anything).Once()
 	mockRouter := tssrouter.MockRouter{}
-
 	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
 	require.NoError(t, err)
-
-	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, &mockRouter, tssStore, mockMetricsService)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
 	require.NoError(t, err)
 
-	srcAccount := keypair.MustRandom().Address()
-	destAccount := keypair.MustRandom().Address()
+	ctx := context.Background()
+	registeredAccount := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	issuer := keypair.MustRandom().Address()
+	err = models.Account.Insert(ctx, registeredAccount)
+	require.NoError(t, err)
 
-	paymentOp := txnbuild.Payment{
-		SourceAccount: srcAccount,
-		Destination:   destAccount,
-		Amount:        "10",
-		Asset:         txnbuild.NativeAsset{},
+	buildTx := func(source string, ops ...txnbuild.Operation) string {
+		transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+			SourceAccount: &txnbuild.SimpleAccount{AccountID: source},
+			Operations:    ops,
+			BaseFee:       txnbuild.MinBaseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+		})
+		require.NoError(t, err)
+		txEnvXDR, err := transaction.Base64()
+		require.NoError(t, err)
+		return txEnvXDR
 	}
-	transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
-		SourceAccount: &txnbuild.SimpleAccount{
-			AccountID: keypair.MustRandom().Address(),
-		},
-		Operations:    []txnbuild.Operation{&paymentOp},
-		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
-	})
-	require.NoError(t, err)
-	txEnvXDR, err := transaction.Base64()
-	require.NoError(t, err)
-	mockResult := entities.RPCGetTransactionsResult{
-		Transactions: []entities.Transaction{{
+
+	ledgerTransactions := []entities.Transaction{
+		{
 			Status:           entities.SuccessStatus,
-			Hash:             "abcd",
+			Hash:             "registered",
 			ApplicationOrder: 1,
-			FeeBump:          false,
-			EnvelopeXDR:      txEnvXDR,
-			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
-			Ledger:           50,
-		}, {
+			EnvelopeXDR: buildTx(registeredAccount,
+				&txnbuild.ChangeTrust{Line: txnbuild.CreditAsset{Code: "USDC", Issuer: issuer}.MustToChangeTrustAsset(), Limit: "100"},
+				&txnbuild.SetOptions{SourceAccount: otherAccount, HomeDomain: utils.PointOf("example.com")},
+			),
+			ResultXDR: "AAAAAAAAAMj////9AAAAAA==",
+			Ledger:    10,
+		},
+		{
 			Status:           entities.SuccessStatus,
-			Hash:             "abcd",
-			ApplicationOrder: 1,
-			FeeBump:          false,
-			EnvelopeXDR:      txEnvXDR,
+			Hash:             "unrelated",
+			ApplicationOrder: 2,
+			EnvelopeXDR:      buildTx(otherAccount, &txnbuild.BumpSequence{BumpTo: 10}),
 			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
-			Ledger:           51,
-		}},
-		LatestLedger:          int64(100),
-		LatestLedgerCloseTime: int64(1),
+			Ledger:           10,
+		},
+		{
+			Status:           entities.FailedStatus,
+			Hash:             "failed",
+			ApplicationOrder: 3,
+			EnvelopeXDR:      buildTx(registeredAccount, &txnbuild.BumpSequence{BumpTo: 10}),
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			Ledger:           10,
+		},
+	}
+
+	err = runLedgerProcessor(ctx, ingestService, OperationsLedgerProcessor, ledgerTransactions)
+	require.NoError(t, err)
+
+	var transactions []data.Transaction
+	err = dbConnectionPool.SelectContext(ctx, &transactions, "SELECT * FROM ingest_transactions")
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, "registered", transactions[0].TransactionHash)
+	assert.Equal(t, registeredAccount, transactions[0].SourceAccount)
+	assert.ElementsMatch(t, []string{registeredAccount, otherAccount}, transactions[0].Participants)
+	assert.JSONEq(t, `{"feeBump": false, "feeCharged": 200, "maxFee": 200, "sequence": 1, "operationCount": 2}`, string(transactions[0].Details))
+
+	operations, _, _, err := models.Operations.GetOperationsPaginated(ctx, registeredAccount, "", "", data.ASC, 10)
+	require.NoError(t, err)
+	require.Len(t, operations, 2)
+	assert.Equal(t, utils.OperationID(10, 1, 1), operations[0].OperationID)
+	assert.Equal(t, xdr.OperationTypeChangeTrust.String(), operations[0].OperationType)
+	assert.JSONEq(t, fmt.Sprintf(`{"asset": "USDC:%s", "limit": "100.0000000"}`, issuer), string(operations[0].Details))
+	assert.Equal(t, xdr.OperationTypeSetOptions.String(), operations[1].OperationType)
+	assert.Equal(t, otherAccount, operations[1].SourceAccount)
+	assert.JSONEq(t, `{"homeDomain": "example.com"}`, string(operations[1].Details))
+
+	operations, _, _, err = models.Operations.GetOperationsPaginated(ctx, otherAccount, "", "", data.ASC, 10)
+	require.NoError(t, err)
+	require.Len(t, operations, 1, "only the operation of the transaction with a registered participant is stored")
+}
+
+func TestIngestBalanceChanges(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	mockAppTracker := apptracker.MockAppTracker{}
+	mockRPCService := RPCServiceMock{}
+	mockRouter := tssrouter.MockRouter{}
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	registeredAccount := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	err = models.Account.Insert(ctx, registeredAccount)
+	require.NoError(t, err)
+
+	transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: registeredAccount},
+		Operations:    []txnbuild.Operation{&txnbuild.Payment{Destination: otherAccount, Amount: "10", Asset: txnbuild.NativeAsset{}}},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+	})
+	require.NoError(t, err)
+	txEnvXDR, err := transaction.Base64()
+	require.NoError(t, err)
+	txMetaXDR, err := xdr.MarshalBase64(xdr.TransactionMeta{
+		V: 3,
+		V3: &xdr.TransactionMetaV3{
+			Operations: []xdr.OperationMeta{{Changes: xdr.LedgerEntryChanges{
+				stateChange(makeAccountEntry(registeredAccount, 100_0000000)),
+				updatedChange(makeAccountEntry(registeredAccount, 90_0000000)),
+				stateChange(makeAccountEntry(otherAccount, 0)),
+				updatedChange(makeAccountEntry(otherAccount, 10_0000000)),
+			}}},
+		},
+	})
+	require.NoError(t, err)
+
+	ledgerTransactions := []entities.Transaction{
+		{
+			Status:           entities.SuccessStatus,
+			Hash:             "success",
+			ApplicationOrder: 1,
+			EnvelopeXDR:      txEnvXDR,
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			ResultMetaXDR:    txMetaXDR,
+			Ledger:           10,
+		},
+		{
+			Status:           entities.FailedStatus,
+			Hash:             "failed",
+			ApplicationOrder: 2,
+			EnvelopeXDR:      txEnvXDR,
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			ResultMetaXDR:    txMetaXDR,
+			Ledger:           10,
+		},
+	}
+
+	err = runLedgerProcessor(ctx, ingestService, BalanceChangesLedgerProcessor, ledgerTransactions)
+	require.NoError(t, err)
+
+	balanceChanges, _, _, err := models.BalanceChanges.GetBalanceChangesPaginated(ctx, registeredAccount, "", "", data.ASC, 10)
+	require.NoError(t, err)
+	require.Len(t, balanceChanges, 3)
+	assert.Equal(t, "fee", balanceChanges[0].Reason)
+	assert.Equal(t, int64(-200), balanceChanges[0].Amount)
+	assert.Equal(t, utils.OperationID(10, 1, 1), balanceChanges[1].OperationID)
+	assert.Equal(t, xdr.OperationTypePayment.String(), balanceChanges[1].Reason)
+	assert.Equal(t, int64(-10_0000000), balanceChanges[1].Amount)
+	assert.Equal(t, "failed", balanceChanges[2].TransactionHash)
+	assert.Equal(t, "fee", balanceChanges[2].Reason)
+
+	balanceChanges, _, _, err = models.BalanceChanges.GetBalanceChangesPaginated(ctx, otherAccount, "", "", data.ASC, 10)
+	require.NoError(t, err)
+	assert.Empty(t, balanceChanges, "only the balance changes of registered accounts are stored")
+}
+
+func TestIngestFees(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	mockAppTracker := apptracker.MockAppTracker{}
+	mockRPCService := RPCServiceMock{}
+	mockRouter := tssrouter.MockRouter{}
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	distributionAccount := keypair.MustRandom().Address()
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, distributionAccount, mockMetricsService)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	registeredAccount := keypair.MustRandom().Address()
+	channelAccount := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	err = models.Account.Insert(ctx, registeredAccount)
+	require.NoError(t, err)
+
+	newTransaction := func(sourceAccount string) *txnbuild.Transaction {
+		transaction, txErr := txnbuild.NewTransaction(txnbuild.TransactionParams{
+			SourceAccount: &txnbuild.SimpleAccount{AccountID: sourceAccount},
+			Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{}},
+			BaseFee:       txnbuild.MinBaseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+		})
+		require.NoError(t, txErr)
+		return transaction
+	}
+	registeredTxXDR, err := newTransaction(registeredAccount).Base64()
+	require.NoError(t, err)
+	otherTxXDR, err := newTransaction(otherAccount).Base64()
+	require.NoError(t, err)
+	feeBumpTx, err := txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      newTransaction(channelAccount),
+		FeeAccount: distributionAccount,
+		BaseFee:    10 * txnbuild.MinBaseFee,
+	})
+	require.NoError(t, err)
+	feeBumpTxXDR, err := feeBumpTx.Base64()
+	require.NoError(t, err)
+
+	newTx := func(status entities.RPCStatus, hash, envelopeXDR string, applicationOrder int64) entities.Transaction {
+		return entities.Transaction{
+			Status:           status,
+			Hash:             hash,
+			ApplicationOrder: applicationOrder,
+			EnvelopeXDR:      envelopeXDR,
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			ResultMetaXDR:    emptyTxMetaXDR,
+			Ledger:           10,
+			CreatedAt:        1704067200,
+		}
+	}
+	ledgerTransactions := []entities.Transaction{
+		newTx(entities.SuccessStatus, "registered", registeredTxXDR, 1),
+		newTx(entities.FailedStatus, "feebump", feeBumpTxXDR, 2),
+		newTx(entities.SuccessStatus, "other", otherTxXDR, 3),
+	}
+
+	err = runLedgerProcessor(ctx, ingestService, FeesLedgerProcessor, ledgerTransactions)
+	require.NoError(t, err)
+
+	var fees []data.TransactionFee
+	err = dbConnectionPool.SelectContext(ctx, &fees, `SELECT * FROM ingest_transaction_fees ORDER BY transaction_id`)
+	require.NoError(t, err)
+	require.Len(t, fees, 2, "only the fees of registered accounts, channel accounts and the distribution account are stored")
+
+	assert.Equal(t, "registered", fees[0].TransactionHash)
+	assert.Equal(t, registeredAccount, fees[0].SourceAccount)
+	assert.Equal(t, registeredAccount, fees[0].FeeAccount)
+	assert.False(t, fees[0].FeeBumped)
+	assert.Equal(t, int64(200), fees[0].FeeCharged)
+	assert.Equal(t, int64(txnbuild.MinBaseFee), fees[0].MaxFee)
+	assert.True(t, fees[0].Successful)
+
+	assert.Equal(t, "feebump", fees[1].TransactionHash)
+	assert.Equal(t, channelAccount, fees[1].SourceAccount)
+	assert.Equal(t, distributionAccount, fees[1].FeeAccount)
+	assert.True(t, fees[1].FeeBumped)
+	assert.Equal(t, int64(20*txnbuild.MinBaseFee), fees[1].MaxFee)
+	assert.False(t, fees[1].Successful)
+}
+
+func TestIngestAccountWebhooks(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	mockAppTracker := apptracker.MockAppTracker{}
+	mockRPCService := RPCServiceMock{}
+	mockRouter := tssrouter.MockRouter{}
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	subscribedAccount := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	for _, account := range []string{subscribedAccount, otherAccount} {
+		err = models.Account.Insert(ctx, account)
+		require.NoError(t, err)
+	}
+	webhook, err := models.AccountWebhooks.Insert(ctx, "https://example.com/hook", []string{subscribedAccount})
+	require.NoError(t, err)
+
+	// the processor reads what the payments processor stored, runLedgerProcessor ingests ledger 0
+	newPayment := func(operationID, from, to string) data.Payment {
+		return data.Payment{
+			OperationID:   operationID,
+			OperationType: xdr.OperationTypePayment.String(),
+			TransactionID: operationID,
+			FromAddress:   from,
+			ToAddress:     to,
+			SrcAssetType:  xdr.AssetTypeAssetTypeNative.String(),
+			DestAssetType: xdr.AssetTypeAssetTypeNative.String(),
+			CreatedAt:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			MemoType:      xdr.MemoTypeMemoNone.String(),
+			Successful:    true,
+		}
+	}
+	data.InsertTestPayments(t, ctx, []data.Payment{
+		newPayment("1", subscribedAccount, otherAccount),
+		newPayment("2", otherAccount, otherAccount),
+	}, dbConnectionPool)
+
+	for range 2 {
+		err = runLedgerProcessor(ctx, ingestService, AccountWebhooksLedgerProcessor, nil)
+		require.NoError(t, err)
+	}
+
+	deliveries, err := models.AccountWebhooks.GetDeliveries(ctx, webhook.ID, "", 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1, "the payments of other accounts aren't delivered, nor are the reingested ones")
+	assert.Equal(t, data.AccountWebhookPaymentEvent, deliveries[0].EventType)
+	assert.Equal(t, "1", deliveries[0].EventID)
+	assert.Equal(t, data.AccountWebhookDeliveryPending, deliveries[0].Status)
+
+	var event data.AccountWebhookEvent
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &event))
+	assert.Equal(t, []string{subscribedAccount}, event.Addresses)
+	require.NotNil(t, event.Payment)
+	assert.Equal(t, otherAccount, event.Payment.ToAddress)
+}
+
+func TestIngest_LatestSyncedLedgerBehindRPC(t *testing.T) {
+	dbt := dbtest.Open(t)
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		require.NoError(t, dbConnectionPool.Close())
+		dbt.Close()
+	}()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_payments", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_payments").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "NOTIFY", "ingest_payments", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "NOTIFY", "ingest_payments").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "payment_volumes", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "payment_volumes").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_store", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_store").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_store", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_store").Once()
+	mockMetricsService.On("SetLatestLedgerIngested", float64(50)).Once()
+	mockMetricsService.On("ObserveIngestionDuration", "payment", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveIngestionDuration", "tss", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveIngestionDuration", "operations", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_transactions", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_transactions").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_operations", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_operations").Once()
+	mockMetricsService.On("ObserveIngestionDuration", "balance_changes", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_balance_changes", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_balance_changes").Once()
+	mockMetricsService.On("ObserveIngestionDuration", "fees", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_transaction_fees", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_transaction_fees").Once()
+	mockMetricsService.On("ObserveIngestionDuration", "account_webhooks", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_operations", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_operations").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "account_webhook_addresses", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "SELECT", "account_webhook_addresses").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingested_ledger_ranges", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingested_ledger_ranges").Once()
+	mockMetricsService.On("ObserveIngestionDuration", "total", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 1).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
+	defer mockMetricsService.AssertExpectations(t)
+
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	mockAppTracker := apptracker.MockAppTracker{}
+	mockRPCService := RPCServiceMock{}
+	mockRPCService.On("TrackRPCServiceHealth", ctx, mock.Anything).Once()
+	mockRouter := tssrouter.MockRouter{}
+
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
+	require.NoError(t, err)
+
+	srcAccount := keypair.MustRandom().Address()
+	destAccount := keypair.MustRandom().Address()
+
+	paymentOp := txnbuild.Payment{
+		SourceAccount: srcAccount,
+		Destination:   destAccount,
+		Amount:        "10",
+		Asset:         txnbuild.NativeAsset{},
+	}
+	transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{
+			AccountID: keypair.MustRandom().Address(),
+		},
+		Operations:    []txnbuild.Operation{&paymentOp},
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+	})
+	require.NoError(t, err)
+	txEnvXDR, err := transaction.Base64()
+	require.NoError(t, err)
+	mockResult := entities.RPCGetTransactionsResult{
+		Transactions: []entities.Transaction{{
+			Status:           entities.SuccessStatus,
+			Hash:             "abcd",
+			ApplicationOrder: 1,
+			FeeBump:          false,
+			EnvelopeXDR:      txEnvXDR,
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			ResultMetaXDR:    emptyTxMetaXDR,
+			Ledger:           50,
+		}, {
+			Status:           entities.SuccessStatus,
+			Hash:             "abcd",
+			ApplicationOrder: 1,
+			FeeBump:          false,
+			EnvelopeXDR:      txEnvXDR,
+			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			ResultMetaXDR:    emptyTxMetaXDR,
+			Ledger:           51,
+		}},
+		LatestLedger:          int64(100),
+		LatestLedgerCloseTime: int64(1),
 		OldestLedger:          int64(50),
 		OldestLedgerCloseTime: int64(1),
 	}
@@ -555,7 +1177,9 @@ func TestIngest_LatestSyncedLedgerAheadOfRPC(t *testing.T) {
 	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
 	require.NoError(t, err)
 
-	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, &mockRouter, tssStore, mockMetricsService)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
 	require.NoError(t, err)
 
 	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_store", mock.AnythingOfType("float64")).Once()
@@ -565,10 +1189,36 @@ func TestIngest_LatestSyncedLedgerAheadOfRPC(t *testing.T) {
 	mockMetricsService.On("SetLatestLedgerIngested", float64(100)).Once()
 	mockMetricsService.On("ObserveIngestionDuration", "payment", mock.AnythingOfType("float64")).Once()
 	mockMetricsService.On("ObserveIngestionDuration", "tss", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveIngestionDuration", "operations", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_transactions", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_transactions").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_operations", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_operations").Once()
+	mockMetricsService.On("ObserveIngestionDuration", "balance_changes", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_balance_changes", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_balance_changes").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "CREATE", "ingest_payments", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "CREATE", "ingest_payments").Once()
+	mockMetricsService.On("ObserveIngestionDuration", "fees", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingest_transaction_fees", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingest_transaction_fees").Once()
+	mockMetricsService.On("ObserveIngestionDuration", "account_webhooks", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_operations", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_operations").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "account_webhook_addresses", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "SELECT", "account_webhook_addresses").Once()
+	mockMetricsService.On("ObserveDBQueryDuration", "INSERT", "ingested_ledger_ranges", mock.AnythingOfType("float64")).Once()
+	mockMetricsService.On("IncDBQuery", "INSERT", "ingested_ledger_ranges").Once()
 	mockMetricsService.On("ObserveIngestionDuration", "total", mock.AnythingOfType("float64")).Once()
 	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "payment", 0).Once()
 	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_send", 0).Once()
 	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "path_payment_strict_receive", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "token_transfer", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "create_account", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "account_merge", 0).Once()
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", "claim_claimable_balance", 0).Once()
 	defer mockMetricsService.AssertExpectations(t)
 
 	heartbeatChan := make(chan entities.RPCGetHealthResult, 1)
@@ -605,6 +1255,7 @@ func TestIngest_LatestSyncedLedgerAheadOfRPC(t *testing.T) {
 			FeeBump:          false,
 			EnvelopeXDR:      txEnvXDR,
 			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			ResultMetaXDR:    emptyTxMetaXDR,
 			Ledger:           100,
 		}, {
 			Status:           entities.SuccessStatus,
@@ -613,6 +1264,7 @@ func TestIngest_LatestSyncedLedgerAheadOfRPC(t *testing.T) {
 			FeeBump:          false,
 			EnvelopeXDR:      txEnvXDR,
 			ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+			ResultMetaXDR:    emptyTxMetaXDR,
 			Ledger:           101,
 		}},
 		LatestLedger:          int64(100),
@@ -639,3 +1291,562 @@ func TestIngest_LatestSyncedLedgerAheadOfRPC(t *testing.T) {
 
 	mockRPCService.AssertExpectations(t)
 }
+
+func TestIngest_RunRegainsLeadership(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("SetIngestLeader", mock.Anything)
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	mockAppTracker := apptracker.MockAppTracker{}
+	mockAppTracker.On("CaptureException", ErrLeadershipLost).Once()
+	defer mockAppTracker.AssertExpectations(t)
+	mockRPCService := RPCServiceMock{}
+	// a single tracker serves every leadership term
+	mockRPCService.On("TrackRPCServiceHealth", ctx, mock.Anything).Once()
+	mockRPCService.On("GetHeartbeatChannel").Return(make(chan entities.RPCGetHealthResult, 1))
+	defer mockRPCService.AssertExpectations(t)
+	mockRouter := tssrouter.MockRouter{}
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	leaderElection, err := NewLeaderElection(dbConnectionPool, "ingestionLedger", 10*time.Millisecond, mockMetricsService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, leaderElection, nil, nil, "", mockMetricsService)
+	require.NoError(t, err)
+
+	runDone := make(chan error, 1)
+	go func() {
+		runDone <- ingestService.Run(ctx, 100, 0)
+	}()
+
+	var firstPID int
+	require.Eventually(t, func() bool {
+		firstPID = leaderElection.HolderPID()
+		return firstPID != 0
+	}, 5*time.Second, 10*time.Millisecond)
+	// killing the session holding the lock loses the leadership, which the same replica takes back
+	_, err = dbConnectionPool.ExecContext(context.Background(), "SELECT pg_terminate_backend($1)", firstPID)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		pid := leaderElection.HolderPID()
+		return pid != 0 && pid != firstPID
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-runDone:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run should return once the context is cancelled")
+	}
+}
+
+func TestSplitLedgerRange(t *testing.T) {
+	testCases := []struct {
+		name       string
+		start, end uint32
+		chunkSize  uint32
+		want       []LedgerRange
+	}{
+		{
+			name:      "single_ledger",
+			start:     10,
+			end:       10,
+			chunkSize: 5,
+			want:      []LedgerRange{{Start: 10, End: 10}},
+		},
+		{
+			name:      "exact_chunks",
+			start:     1,
+			end:       10,
+			chunkSize: 5,
+			want:      []LedgerRange{{Start: 1, End: 5}, {Start: 6, End: 10}},
+		},
+		{
+			name:      "last_chunk_is_smaller",
+			start:     1,
+			end:       12,
+			chunkSize: 5,
+			want:      []LedgerRange{{Start: 1, End: 5}, {Start: 6, End: 10}, {Start: 11, End: 12}},
+		},
+		{
+			name:      "range_ending_at_max_uint32",
+			start:     math.MaxUint32 - 3,
+			end:       math.MaxUint32,
+			chunkSize: 3,
+			want:      []LedgerRange{{Start: math.MaxUint32 - 3, End: math.MaxUint32 - 1}, {Start: math.MaxUint32, End: math.MaxUint32}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, splitLedgerRange(tc.start, tc.end, tc.chunkSize))
+		})
+	}
+}
+
+func TestFindLedgerRangeIssues(t *testing.T) {
+	ledgerRange := func(source data.LedgerRangeSource, start, end uint32) data.IngestedLedgerRange {
+		return data.IngestedLedgerRange{Source: source, StartLedger: start, EndLedger: end}
+	}
+
+	testCases := []struct {
+		name         string
+		ranges       []data.IngestedLedgerRange
+		start, end   uint32
+		wantGaps     []LedgerRange
+		wantOverlaps []LedgerRange
+	}{
+		{
+			name:         "no_ranges",
+			start:        10,
+			end:          20,
+			wantGaps:     []LedgerRange{{Start: 10, End: 20}},
+			wantOverlaps: []LedgerRange{},
+		},
+		{
+			name: "contiguous_ranges",
+			ranges: []data.IngestedLedgerRange{
+				ledgerRange(data.BackfillLedgerRangeSource, 1, 9),
+				ledgerRange(data.LiveLedgerRangeSource, 10, 20),
+			},
+			start:        1,
+			end:          20,
+			wantGaps:     []LedgerRange{},
+			wantOverlaps: []LedgerRange{},
+		},
+		{
+			name: "gaps_before_between_and_after_ranges",
+			ranges: []data.IngestedLedgerRange{
+				ledgerRange(data.LiveLedgerRangeSource, 5, 9),
+				ledgerRange(data.LiveLedgerRangeSource, 15, 20),
+			},
+			start:        1,
+			end:          25,
+			wantGaps:     []LedgerRange{{Start: 1, End: 4}, {Start: 10, End: 14}, {Start: 21, End: 25}},
+			wantOverlaps: []LedgerRange{},
+		},
+		{
+			name: "overlapping_and_nested_ranges",
+			ranges: []data.IngestedLedgerRange{
+				ledgerRange(data.LiveLedgerRangeSource, 1, 10),
+				ledgerRange(data.BackfillLedgerRangeSource, 3, 5),
+				ledgerRange(data.LiveLedgerRangeSource, 8, 15),
+			},
+			start:        1,
+			end:          15,
+			wantGaps:     []LedgerRange{},
+			wantOverlaps: []LedgerRange{{Start: 3, End: 5}, {Start: 8, End: 10}},
+		},
+		{
+			name: "ranges_clipped_to_bounds",
+			ranges: []data.IngestedLedgerRange{
+				ledgerRange(data.LiveLedgerRangeSource, 1, 10),
+				ledgerRange(data.LiveLedgerRangeSource, 12, 30),
+				ledgerRange(data.RepairLedgerRangeSource, 40, 50),
+			},
+			start:        8,
+			end:          20,
+			wantGaps:     []LedgerRange{{Start: 11, End: 11}},
+			wantOverlaps: []LedgerRange{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := findLedgerRangeIssues(tc.ranges, tc.start, tc.end)
+			assert.Equal(t, tc.start, report.StartLedger)
+			assert.Equal(t, tc.end, report.EndLedger)
+			assert.Equal(t, tc.wantGaps, report.Gaps)
+			assert.Equal(t, tc.wantOverlaps, report.Overlaps)
+		})
+	}
+}
+
+func TestIngest_Backfill(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", mock.Anything, mock.Anything)
+	mockMetricsService.On("ObserveIngestionDuration", "backfill", mock.AnythingOfType("float64")).Times(5)
+	defer mockMetricsService.AssertExpectations(t)
+
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	mockAppTracker := apptracker.MockAppTracker{}
+	mockRPCService := RPCServiceMock{}
+	mockRouter := tssrouter.MockRouter{}
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
+	require.NoError(t, err)
+
+	srcAccount := keypair.MustRandom().Address()
+	require.NoError(t, models.Account.Insert(ctx, srcAccount))
+
+	paymentOp := txnbuild.Payment{
+		SourceAccount: srcAccount,
+		Destination:   keypair.MustRandom().Address(),
+		Amount:        "10",
+		Asset:         txnbuild.NativeAsset{},
+	}
+	transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{
+			AccountID: keypair.MustRandom().Address(),
+		},
+		Operations:    []txnbuild.Operation{&paymentOp},
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+	})
+	require.NoError(t, err)
+	txEnvXDR, err := transaction.Base64()
+	require.NoError(t, err)
+
+	mockRPCService.On("GetHealth").Return(entities.RPCGetHealthResult{Status: "healthy", OldestLedger: 1, LatestLedger: 100}, nil).Once()
+	for ledger := int64(10); ledger <= 14; ledger++ {
+		mockRPCService.On("GetTransactions", ledger, "", 50).Return(entities.RPCGetTransactionsResult{
+			Transactions: []entities.Transaction{{
+				Status:           entities.SuccessStatus,
+				Hash:             fmt.Sprintf("hash%d", ledger),
+				ApplicationOrder: 1,
+				EnvelopeXDR:      txEnvXDR,
+				ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+				ResultMetaXDR:    emptyTxMetaXDR,
+				Ledger:           ledger,
+			}, {
+				Status: entities.SuccessStatus,
+				Hash:   "next",
+				Ledger: ledger + 1,
+			}},
+		}, nil).Maybe()
+	}
+
+	// simulate a previous run that was interrupted after the first ledger of the first chunk
+	require.NoError(t, models.Payments.UpdateLatestLedgerSynced(ctx, dbConnectionPool, "backfill_10_12", 10))
+
+	err = ingestService.Backfill(ctx, 10, 14, 2, 3)
+	require.NoError(t, err)
+
+	mockRPCService.AssertNotCalled(t, "GetTransactions", int64(10), "", 50)
+	mockRPCService.AssertExpectations(t)
+
+	payments, _, _, err := models.Payments.GetPaymentsPaginated(ctx, data.PaymentsFilter{Address: srcAccount}, "", "", data.ASC, 10)
+	require.NoError(t, err)
+	require.Len(t, payments, 4)
+	for i, payment := range payments {
+		assert.Equal(t, fmt.Sprintf("hash%d", 11+i), payment.TransactionHash)
+	}
+
+	// the chunk cursors are gone once the whole range is backfilled, and the live cursor was never touched
+	var cursorNames []string
+	err = dbConnectionPool.SelectContext(ctx, &cursorNames, `SELECT key FROM ingest_store WHERE key LIKE 'backfill_%' OR key = 'ingestionLedger'`)
+	require.NoError(t, err)
+	assert.Empty(t, cursorNames)
+
+	t.Run("verify_and_repair_ingested_ranges", func(t *testing.T) {
+		// the ledger ingested by the interrupted run was never recorded
+		report, err := ingestService.VerifyIngestedRanges(ctx, 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, &IngestedRangesReport{StartLedger: 11, EndLedger: 14, Gaps: []LedgerRange{}, Overlaps: []LedgerRange{}}, report)
+
+		report, err = ingestService.VerifyIngestedRanges(ctx, 10, 14)
+		require.NoError(t, err)
+		assert.Equal(t, []LedgerRange{{Start: 10, End: 10}}, report.Gaps)
+
+		mockRPCService.On("GetHealth").Return(entities.RPCGetHealthResult{Status: "healthy", OldestLedger: 1, LatestLedger: 100}, nil).Once()
+		report, err = ingestService.RepairIngestedRanges(ctx, 10, 14, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []LedgerRange{{Start: 10, End: 10}}, report.Gaps)
+
+		report, err = ingestService.VerifyIngestedRanges(ctx, 10, 14)
+		require.NoError(t, err)
+		assert.Empty(t, report.Gaps)
+		assert.Empty(t, report.Overlaps)
+
+		synced, err := models.Payments.GetLatestLedgerSynced(ctx, "repair_10_10")
+		require.NoError(t, err)
+		assert.Zero(t, synced)
+	})
+
+	t.Run("range_outside_of_rpc_retention", func(t *testing.T) {
+		mockRPCService.On("GetHealth").Return(entities.RPCGetHealthResult{Status: "healthy", OldestLedger: 50, LatestLedger: 100}, nil).Once()
+		err := ingestService.Backfill(ctx, 10, 60, 2, 10)
+		assert.ErrorContains(t, err, "backfill range [10, 60] is outside of the ledger backend range [50, 100]")
+	})
+}
+
+func TestIngest_BackfillAccount(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	mockMetricsService.On("SetNumPaymentOpsIngestedPerLedger", mock.Anything, mock.Anything)
+	mockMetricsService.On("ObserveIngestionDuration", "account_backfill", mock.AnythingOfType("float64")).Times(2)
+	defer mockMetricsService.AssertExpectations(t)
+
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	mockAppTracker := apptracker.MockAppTracker{}
+	mockRPCService := RPCServiceMock{}
+	mockRouter := tssrouter.MockRouter{}
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+	require.NoError(t, err)
+	ingestService, err := NewIngestService(models, "ingestionLedger", &mockAppTracker, &mockRPCService, ledgerBackend, &mockRouter, tssStore, nil, nil, nil, "", mockMetricsService)
+	require.NoError(t, err)
+
+	backfilledAccount := keypair.MustRandom().Address()
+	otherAccount := keypair.MustRandom().Address()
+	require.NoError(t, models.Account.Insert(ctx, backfilledAccount))
+	require.NoError(t, models.Account.Insert(ctx, otherAccount))
+	require.NoError(t, models.Payments.UpdateLatestLedgerSynced(ctx, dbConnectionPool, "ingestionLedger", 11))
+
+	paymentFrom := func(source string) string {
+		transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+			SourceAccount: &txnbuild.SimpleAccount{AccountID: source},
+			Operations: []txnbuild.Operation{&txnbuild.Payment{
+				Destination: keypair.MustRandom().Address(),
+				Amount:      "10",
+				Asset:       txnbuild.NativeAsset{},
+			}},
+			BaseFee:       txnbuild.MinBaseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+		})
+		require.NoError(t, err)
+		txEnvXDR, err := transaction.Base64()
+		require.NoError(t, err)
+		return txEnvXDR
+	}
+
+	mockRPCService.On("GetHealth").Return(entities.RPCGetHealthResult{Status: "healthy", OldestLedger: 10, LatestLedger: 100}, nil).Once()
+	for ledger, source := range map[int64]string{10: backfilledAccount, 11: otherAccount} {
+		mockRPCService.On("GetTransactions", ledger, "", 50).Return(entities.RPCGetTransactionsResult{
+			Transactions: []entities.Transaction{{
+				Status:           entities.SuccessStatus,
+				Hash:             fmt.Sprintf("hash%d", ledger),
+				ApplicationOrder: 1,
+				EnvelopeXDR:      paymentFrom(source),
+				ResultXDR:        "AAAAAAAAAMj////9AAAAAA==",
+				ResultMetaXDR:    emptyTxMetaXDR,
+				Ledger:           ledger,
+			}, {
+				Status: entities.SuccessStatus,
+				Hash:   "next",
+				Ledger: ledger + 1,
+			}},
+		}, nil).Once()
+	}
+
+	_, err = models.BackfillJobs.Insert(ctx, backfilledAccount)
+	require.NoError(t, err)
+	job, err := models.BackfillJobs.ClaimNext(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	err = ingestService.backfillAccount(ctx, job)
+	require.NoError(t, err)
+	mockRPCService.AssertExpectations(t)
+
+	job, err = models.BackfillJobs.GetLatestByAddress(ctx, backfilledAccount)
+	require.NoError(t, err)
+	assert.Equal(t, data.AccountBackfillJobCompleted, job.Status)
+	assert.Equal(t, utils.PointOf(uint32(10)), job.StartLedger)
+	assert.Equal(t, utils.PointOf(uint32(11)), job.EndLedger)
+	assert.Equal(t, utils.PointOf(uint32(11)), job.LastLedger)
+
+	payments, _, _, err := models.Payments.GetPaymentsPaginated(ctx, data.PaymentsFilter{Address: backfilledAccount}, "", "", data.ASC, 10)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, "hash10", payments[0].TransactionHash)
+
+	payments, _, _, err = models.Payments.GetPaymentsPaginated(ctx, data.PaymentsFilter{Address: otherAccount}, "", "", data.ASC, 10)
+	require.NoError(t, err)
+	assert.Empty(t, payments, "only the history of the backfilled account is ingested")
+
+	liveLedger, err := models.Payments.GetLatestLedgerSynced(ctx, "ingestionLedger")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(11), liveLedger, "the live cursor is never touched")
+}
+
+func TestMuxedAccountDetails(t *testing.T) {
+	address := keypair.MustRandom().Address()
+
+	gotAddress, gotMuxedID, gotMuxedAddress := muxedAccountDetails(xdr.MustMuxedAddress(address))
+	assert.Equal(t, address, gotAddress)
+	assert.Nil(t, gotMuxedID)
+	assert.Nil(t, gotMuxedAddress)
+
+	muxedAccount, err := xdr.MuxedAccountFromAccountId(address, math.MaxUint64)
+	require.NoError(t, err)
+	gotAddress, gotMuxedID, gotMuxedAddress = muxedAccountDetails(muxedAccount)
+	assert.Equal(t, address, gotAddress)
+	assert.Equal(t, utils.PointOf("18446744073709551615"), gotMuxedID)
+	assert.Equal(t, utils.PointOf(muxedAccount.Address()), gotMuxedAddress)
+}
+
+func TestFillValueTransferPayments(t *testing.T) {
+	source := keypair.MustRandom().Address()
+	destination := keypair.MustRandom().Address()
+	newPayment := func() data.Payment {
+		return data.Payment{FromAddress: source}
+	}
+
+	t.Run("create_account", func(t *testing.T) {
+		payment := newPayment()
+		fillCreateAccount(&payment, xdr.OperationBody{
+			Type:            xdr.OperationTypeCreateAccount,
+			CreateAccountOp: &xdr.CreateAccountOp{Destination: xdr.MustAddress(destination), StartingBalance: 50_0000000},
+		})
+
+		assert.Equal(t, data.Payment{
+			FromAddress: source, ToAddress: destination,
+			SrcAssetCode: "XLM", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 50_0000000,
+			DestAssetCode: "XLM", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 50_0000000,
+		}, payment)
+	})
+
+	t.Run("account_merge", func(t *testing.T) {
+		muxedDestination, err := xdr.MuxedAccountFromAccountId(destination, 3)
+		require.NoError(t, err)
+		mergedBalance := xdr.Int64(12_3456789)
+		txResult := xdr.TransactionResult{Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxSuccess,
+			Results: &[]xdr.OperationResult{{
+				Code: xdr.OperationResultCodeOpInner,
+				Tr: &xdr.OperationResultTr{
+					Type:               xdr.OperationTypeAccountMerge,
+					AccountMergeResult: &xdr.AccountMergeResult{Code: xdr.AccountMergeResultCodeAccountMergeSuccess, SourceAccountBalance: &mergedBalance},
+				},
+			}},
+		}}
+
+		payment := newPayment()
+		fillAccountMerge(&payment, xdr.OperationBody{Type: xdr.OperationTypeAccountMerge, Destination: &muxedDestination}, txResult, 1)
+
+		assert.Equal(t, data.Payment{
+			FromAddress: source, ToAddress: destination, ToMuxedID: utils.PointOf("3"), ToMuxedAddress: utils.PointOf(muxedDestination.Address()),
+			SrcAssetCode: "XLM", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 12_3456789,
+			DestAssetCode: "XLM", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 12_3456789,
+		}, payment)
+	})
+
+	t.Run("failed_account_merge", func(t *testing.T) {
+		muxedDestination := xdr.MustMuxedAddress(destination)
+		txResult := xdr.TransactionResult{Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxBadSeq}}
+
+		payment := newPayment()
+		fillAccountMerge(&payment, xdr.OperationBody{Type: xdr.OperationTypeAccountMerge, Destination: &muxedDestination}, txResult, 1)
+
+		assert.Equal(t, data.Payment{
+			FromAddress: source, ToAddress: destination,
+			SrcAssetCode: "XLM", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(),
+			DestAssetCode: "XLM", DestAssetType: xdr.AssetTypeAssetTypeNative.String(),
+		}, payment)
+	})
+
+	t.Run("failed_path_payments", func(t *testing.T) {
+		txResult := xdr.TransactionResult{Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFailed,
+			Results: &[]xdr.OperationResult{
+				{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+					Type:                        xdr.OperationTypePathPaymentStrictSend,
+					PathPaymentStrictSendResult: &xdr.PathPaymentStrictSendResult{Code: xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendUnderDestmin},
+				}},
+				{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+					Type:                           xdr.OperationTypePathPaymentStrictReceive,
+					PathPaymentStrictReceiveResult: &xdr.PathPaymentStrictReceiveResult{Code: xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveOverSendmax},
+				}},
+			},
+		}}
+
+		// failed path payments keep the amounts that were requested
+		payment := newPayment()
+		fillPathSend(&payment, xdr.OperationBody{
+			Type: xdr.OperationTypePathPaymentStrictSend,
+			PathPaymentStrictSendOp: &xdr.PathPaymentStrictSendOp{
+				SendAsset: xdr.MustNewNativeAsset(), SendAmount: 10, Destination: xdr.MustMuxedAddress(destination), DestAsset: xdr.MustNewNativeAsset(), DestMin: 9,
+			},
+		}, txResult, 1)
+		assert.Equal(t, int64(10), payment.SrcAmount)
+		assert.Equal(t, int64(9), payment.DestAmount)
+
+		payment = newPayment()
+		fillPathReceive(&payment, xdr.OperationBody{
+			Type: xdr.OperationTypePathPaymentStrictReceive,
+			PathPaymentStrictReceiveOp: &xdr.PathPaymentStrictReceiveOp{
+				SendAsset: xdr.MustNewNativeAsset(), SendMax: 11, Destination: xdr.MustMuxedAddress(destination), DestAsset: xdr.MustNewNativeAsset(), DestAmount: 10,
+			},
+		}, txResult, 2)
+		assert.Equal(t, int64(11), payment.SrcAmount)
+		assert.Equal(t, int64(10), payment.DestAmount)
+	})
+
+	t.Run("claim_claimable_balance", func(t *testing.T) {
+		issuer := keypair.MustRandom().Address()
+		usdc := xdr.MustNewCreditAsset("USDC", issuer)
+		balanceID := xdr.ClaimableBalanceId{Type: xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0, V0: &xdr.Hash{1}}
+		otherBalanceID := xdr.ClaimableBalanceId{Type: xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0, V0: &xdr.Hash{2}}
+		sponsor := xdr.MustAddress(destination)
+		claimableBalanceEntry := func(id xdr.ClaimableBalanceId, amount int64) xdr.LedgerEntry {
+			return xdr.LedgerEntry{
+				Data: xdr.LedgerEntryData{
+					Type:             xdr.LedgerEntryTypeClaimableBalance,
+					ClaimableBalance: &xdr.ClaimableBalanceEntry{BalanceId: id, Asset: usdc, Amount: xdr.Int64(amount)},
+				},
+				Ext: xdr.LedgerEntryExt{V: 1, V1: &xdr.LedgerEntryExtensionV1{SponsoringId: &sponsor}},
+			}
+		}
+		claimOp := xdr.OperationBody{
+			Type:                    xdr.OperationTypeClaimClaimableBalance,
+			ClaimClaimableBalanceOp: &xdr.ClaimClaimableBalanceOp{BalanceId: balanceID},
+		}
+
+		// the claimant is the operation source, so it becomes the payment destination
+		payment := newPayment()
+		err := fillClaimClaimableBalance(&payment, claimOp, xdr.LedgerEntryChanges{
+			stateChange(claimableBalanceEntry(otherBalanceID, 1)),
+			stateChange(claimableBalanceEntry(balanceID, 7_5000000)),
+			removedChange(claimableBalanceEntry(balanceID, 7_5000000)),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, data.Payment{
+			FromAddress: destination, ToAddress: source,
+			SrcAssetCode: "USDC", SrcAssetIssuer: issuer, SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 7_5000000,
+			DestAssetCode: "USDC", DestAssetIssuer: issuer, DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 7_5000000,
+		}, payment)
+
+		payment = newPayment()
+		err = fillClaimClaimableBalance(&payment, claimOp, xdr.LedgerEntryChanges{stateChange(claimableBalanceEntry(otherBalanceID, 1))})
+		assert.ErrorContains(t, err, "not found in the operation changes")
+	})
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/internal/data"
+)
+
+// IngestedRangesReport describes how well the recorded ingested ranges cover [StartLedger, EndLedger].
+type IngestedRangesReport struct {
+	StartLedger uint32        `json:"startLedger"`
+	EndLedger   uint32        `json:"endLedger"`
+	Gaps        []LedgerRange `json:"gaps"`
+	Overlaps    []LedgerRange `json:"overlaps"`
+}
+
+func (m *ingestService) VerifyIngestedRanges(ctx context.Context, startLedger, endLedger uint32) (*IngestedRangesReport, error) {
+	if endLedger != 0 && endLedger < startLedger {
+		return nil, fmt.Errorf("invalid range [%d, %d]", startLedger, endLedger)
+	}
+
+	ranges, err := m.models.IngestedRanges.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting ingested ranges: %w", err)
+	}
+	if len(ranges) > 0 {
+		if startLedger == 0 {
+			startLedger = ranges[0].StartLedger
+		}
+		if endLedger == 0 {
+			for _, r := range ranges {
+				endLedger = max(endLedger, r.EndLedger)
+			}
+		}
+	}
+
+	report := findLedgerRangeIssues(ranges, startLedger, endLedger)
+	return &report, nil
+}
+
+func (m *ingestService) RepairIngestedRanges(ctx context.Context, startLedger, endLedger uint32, numWorkers, chunkSize int) (*IngestedRangesReport, error) {
+	report, err := m.VerifyIngestedRanges(ctx, startLedger, endLedger)
+	if err != nil {
+		return nil, fmt.Errorf("verifying ingested ranges: %w", err)
+	}
+
+	for _, gap := range report.Gaps {
+		log.Ctx(ctx).Infof("repairing gap %s", gap)
+		if err := m.backfill(ctx, gap.Start, gap.End, numWorkers, chunkSize, data.RepairLedgerRangeSource); err != nil {
+			return report, fmt.Errorf("repairing gap %s: %w", gap, err)
+		}
+	}
+	return report, nil
+}
+
+// findLedgerRangeIssues returns the ledgers within [startLedger, endLedger] not covered by any range and the ledgers
+// covered by more than one. The ranges must be sorted by start ledger.
+func findLedgerRangeIssues(ranges []data.IngestedLedgerRange, startLedger, endLedger uint32) IngestedRangesReport {
+	report := IngestedRangesReport{
+		StartLedger: startLedger,
+		EndLedger:   endLedger,
+		Gaps:        []LedgerRange{},
+		Overlaps:    []LedgerRange{},
+	}
+	if startLedger == 0 && endLedger == 0 {
+		return report
+	}
+
+	// int64 so the ledger before startLedger can be represented when it's zero
+	coveredUntil := int64(startLedger) - 1
+	for _, r := range ranges {
+		if r.EndLedger < startLedger || r.StartLedger > endLedger {
+			continue
+		}
+		rangeStart, rangeEnd := int64(max(r.StartLedger, startLedger)), int64(min(r.EndLedger, endLedger))
+
+		if rangeStart > coveredUntil+1 {
+			report.Gaps = append(report.Gaps, LedgerRange{Start: uint32(coveredUntil + 1), End: uint32(rangeStart - 1)})
+		} else if rangeStart <= coveredUntil {
+			report.Overlaps = append(report.Overlaps, LedgerRange{Start: uint32(rangeStart), End: uint32(min(rangeEnd, coveredUntil))})
+		}
+		coveredUntil = max(coveredUntil, rangeEnd)
+	}
+	if coveredUntil < int64(endLedger) {
+		report.Gaps = append(report.Gaps, LedgerRange{Start: uint32(coveredUntil + 1), End: endLedger})
+	}
+	return report
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+var ErrLeadershipLost = errors.New("ingestion leadership lost")
+
+// LeaderElection elects a single leader among the replicas sharing a lock name using a session level Postgres advisory
+// lock. The lock is held by a dedicated connection, so it's released by Postgres as soon as the leader dies and a
+// standby takes over the next time it polls.
+type LeaderElection struct {
+	dbConnectionPool db.ConnectionPool
+	lockName         string
+	lockKey          int64
+	pollInterval     time.Duration
+	metricsService   metrics.MetricsService
+	// jobs are run in the background during every leadership term.
+	jobs []func(ctx context.Context)
+
+	mu  sync.Mutex
+	pid int
+}
+
+func NewLeaderElection(dbConnectionPool db.ConnectionPool, lockName string, pollInterval time.Duration, metricsService metrics.MetricsService) (*LeaderElection, error) {
+	if dbConnectionPool == nil {
+		return nil, errors.New("dbConnectionPool cannot be nil")
+	}
+	if lockName == "" {
+		return nil, errors.New("lockName cannot be empty")
+	}
+	if pollInterval <= 0 {
+		return nil, errors.New("pollInterval must be greater than zero")
+	}
+	if metricsService == nil {
+		return nil, errors.New("metricsService cannot be nil")
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(lockName))
+	return &LeaderElection{
+		dbConnectionPool: dbConnectionPool,
+		lockName:         lockName,
+		lockKey:          int64(hash.Sum64()),
+		pollInterval:     pollInterval,
+		metricsService:   metricsService,
+	}, nil
+}
+
+// LockKey is the advisory lock key, derived from the lock name.
+func (l *LeaderElection) LockKey() int64 {
+	return l.lockKey
+}
+
+// HolderPID is the PID of the database session holding the lock, or zero when this replica isn't the leader.
+func (l *LeaderElection) HolderPID() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pid
+}
+
+// RunWhileLeading registers a job started in the background every time this replica becomes the leader. It's given the
+// leader context, and the lock is only released once it returns, so it never runs on two replicas at once. It must be
+// called before Lead.
+func (l *LeaderElection) RunWhileLeading(job func(ctx context.Context)) {
+	l.jobs = append(l.jobs, job)
+}
+
+// Lead waits until this replica acquires the lock and runs fn while holding it, along with the RunWhileLeading jobs. The context passed to fn is cancelled
+// with ErrLeadershipLost when the lock connection stops responding, and Lead returns ErrLeadershipLost in that case so
+// the caller can go back to standby. The lock is released when fn returns.
+func (l *LeaderElection) Lead(ctx context.Context, fn func(ctx context.Context) error) error {
+	l.metricsService.SetIngestLeader(false)
+	conn, err := l.waitForLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer l.release(conn)
+
+	log.Ctx(ctx).Infof("acquired the %s lock, this replica is now the leader", l.lockName)
+	l.metricsService.SetIngestLeader(true)
+	defer l.metricsService.SetIngestLeader(false)
+
+	leaderCtx, cancel := context.WithCancelCause(ctx)
+	var jobs sync.WaitGroup
+	defer jobs.Wait()
+	defer cancel(nil)
+	go l.keepAlive(leaderCtx, conn, cancel)
+	for _, job := range l.jobs {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job(leaderCtx)
+		}()
+	}
+
+	err = fn(leaderCtx)
+	if errors.Is(context.Cause(leaderCtx), ErrLeadershipLost) && ctx.Err() == nil {
+		return ErrLeadershipLost
+	}
+	return err
+}
+
+// waitForLock polls the lock until it's acquired, returning the connection holding it.
+func (l *LeaderElection) waitForLock(ctx context.Context) (*sql.Conn, error) {
+	sqlDB, err := l.dbConnectionPool.SqlDB(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting sql db: %w", err)
+	}
+
+	ticker := time.NewTicker(l.pollInterval)
+	defer ticker.Stop()
+	for {
+		conn, acquired, err := l.tryLock(ctx, sqlDB)
+		if err != nil {
+			log.Ctx(ctx).Errorf("trying to acquire the %s lock: %v", l.lockName, err)
+		}
+		if acquired {
+			return conn, nil
+		}
+		log.Ctx(ctx).Debugf("the %s lock is held by another replica, waiting as a standby", l.lockName)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("context cancelled while waiting for the %s lock: %w", l.lockName, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *LeaderElection) tryLock(ctx context.Context, sqlDB *sql.DB) (*sql.Conn, bool, error) {
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("getting connection: %w", err)
+	}
+
+	var acquired bool
+	var pid int
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1), pg_backend_pid()", l.lockKey).Scan(&acquired, &pid)
+	if err != nil || !acquired {
+		conn.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("trying advisory lock: %w", err)
+		}
+		return nil, false, nil
+	}
+
+	l.mu.Lock()
+	l.pid = pid
+	l.mu.Unlock()
+	return conn, true, nil
+}
+
+// keepAlive checks the lock connection, the lock is gone as soon as its session is, so a failed query means another
+// replica may already be the leader.
+func (l *LeaderElection) keepAlive(ctx context.Context, conn *sql.Conn, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(l.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := conn.ExecContext(ctx, "SELECT 1"); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Ctx(ctx).Errorf("lost the %s lock connection: %v", l.lockName, err)
+				cancel(ErrLeadershipLost)
+				return
+			}
+		}
+	}
+}
+
+func (l *LeaderElection) release(conn *sql.Conn) {
+	l.mu.Lock()
+	l.pid = 0
+	l.mu.Unlock()
+
+	// the connection goes back to the pool, so the lock outlives it unless it's explicitly released
+	ctx, cancel := context.WithTimeout(context.Background(), l.pollInterval)
+	defer cancel()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.lockKey); err != nil {
+		log.Ctx(ctx).Errorf("releasing the %s lock: %v", l.lockName, err)
+		// a session still holding the lock must never go back to the pool, returning ErrBadConn makes database/sql
+		// discard it
+		//nolint:errcheck // the error is always driver.ErrBadConn
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	if err := conn.Close(); err != nil {
+		log.Ctx(ctx).Errorf("closing the %s lock connection: %v", l.lockName, err)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestLeaderElection(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx := context.Background()
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("SetIngestLeader", mock.Anything)
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+
+	leader, err := NewLeaderElection(dbConnectionPool, "ingestionLedger", 10*time.Millisecond, mockMetricsService)
+	require.NoError(t, err)
+	standby, err := NewLeaderElection(dbConnectionPool, "ingestionLedger", 10*time.Millisecond, mockMetricsService)
+	require.NoError(t, err)
+	assert.Equal(t, leader.LockKey(), standby.LockKey())
+
+	leading := make(chan struct{})
+	resign := make(chan struct{})
+	leaderDone := make(chan error, 1)
+	go func() {
+		leaderDone <- leader.Lead(ctx, func(ctx context.Context) error {
+			close(leading)
+			<-resign
+			return nil
+		})
+	}()
+	<-leading
+	assert.NotZero(t, leader.HolderPID())
+
+	t.Run("standby_waits_while_the_lock_is_held", func(t *testing.T) {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		err := standby.Lead(timeoutCtx, func(ctx context.Context) error {
+			t.Fatal("standby should not lead while the lock is held")
+			return nil
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Zero(t, standby.HolderPID())
+	})
+
+	t.Run("only_the_lock_holder_advances_the_cursor", func(t *testing.T) {
+		err := models.Payments.UpdateLatestLedgerSyncedAsLockHolder(ctx, dbConnectionPool, "ingestionLedger", 10, leader.LockKey(), leader.HolderPID())
+		require.NoError(t, err)
+
+		err = models.Payments.UpdateLatestLedgerSyncedAsLockHolder(ctx, dbConnectionPool, "ingestionLedger", 11, standby.LockKey(), leader.HolderPID()+1)
+		assert.ErrorIs(t, err, data.ErrNotLockHolder)
+
+		synced, err := models.Payments.GetLatestLedgerSynced(ctx, "ingestionLedger")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(10), synced)
+	})
+
+	t.Run("standby_takes_over_when_the_leader_resigns", func(t *testing.T) {
+		close(resign)
+		require.NoError(t, <-leaderDone)
+		assert.Zero(t, leader.HolderPID())
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		err := standby.Lead(timeoutCtx, func(ctx context.Context) error {
+			assert.NotZero(t, standby.HolderPID())
+			return models.Payments.UpdateLatestLedgerSyncedAsLockHolder(ctx, dbConnectionPool, "ingestionLedger", 11, standby.LockKey(), standby.HolderPID())
+		})
+		require.NoError(t, err)
+
+		synced, err := models.Payments.GetLatestLedgerSynced(ctx, "ingestionLedger")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(11), synced)
+	})
+
+	t.Run("jobs_run_while_leading", func(t *testing.T) {
+		jobs, err := NewLeaderElection(dbConnectionPool, "jobsLock", 10*time.Millisecond, mockMetricsService)
+		require.NoError(t, err)
+		jobStarted := make(chan struct{})
+		jobDone := make(chan struct{})
+		jobs.RunWhileLeading(func(ctx context.Context) {
+			close(jobStarted)
+			<-ctx.Done()
+			close(jobDone)
+		})
+
+		err = jobs.Lead(ctx, func(ctx context.Context) error {
+			<-jobStarted
+			return nil
+		})
+		require.NoError(t, err)
+		// the job is stopped before the lock is released
+		select {
+		case <-jobDone:
+		default:
+			t.Fatal("the job should be done once Lead returns")
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/wallet-backend/internal/entities"
+)
+
+var ErrLedgerNotFound = errors.New("ledger not found")
+
+// LedgerBackend is the source of the ledgers processed by the ingestion.
+type LedgerBackend interface {
+	// GetLedgerTransactions returns the transactions of the ledger in application order.
+	GetLedgerTransactions(ctx context.Context, ledger uint32) ([]entities.Transaction, error)
+	// GetLedgerRange returns the oldest and latest ledgers available in the backend.
+	GetLedgerRange(ctx context.Context) (uint32, uint32, error)
+}
+
+var _ LedgerBackend = (*rpcLedgerBackend)(nil)
+
+// rpcLedgerBackend reads the ledgers from the RPC, so only the ledgers within its retention window are available.
+type rpcLedgerBackend struct {
+	rpcService RPCService
+}
+
+func NewRPCLedgerBackend(rpcService RPCService) (*rpcLedgerBackend, error) {
+	if rpcService == nil {
+		return nil, errors.New("rpcService cannot be nil")
+	}
+
+	return &rpcLedgerBackend{rpcService: rpcService}, nil
+}
+
+func (b *rpcLedgerBackend) GetLedgerTransactions(_ context.Context, ledger uint32) ([]entities.Transaction, error) {
+	var ledgerTransactions []entities.Transaction
+	var cursor string
+	lastLedgerSeen := int64(ledger)
+	for lastLedgerSeen == int64(ledger) {
+		getTxnsResp, err := b.rpcService.GetTransactions(int64(ledger), cursor, 50)
+		if err != nil {
+			return []entities.Transaction{}, fmt.Errorf("getTransactions: %w", err)
+		}
+		cursor = getTxnsResp.Cursor
+		for _, tx := range getTxnsResp.Transactions {
+			if tx.Ledger == int64(ledger) {
+				ledgerTransactions = append(ledgerTransactions, tx)
+				lastLedgerSeen = tx.Ledger
+			} else {
+				lastLedgerSeen = tx.Ledger
+				break
+			}
+		}
+	}
+	return ledgerTransactions, nil
+}
+
+func (b *rpcLedgerBackend) GetLedgerRange(_ context.Context) (uint32, uint32, error) {
+	health, err := b.rpcService.GetHealth()
+	if err != nil {
+		return 0, 0, fmt.Errorf("getting rpc health: %w", err)
+	}
+	return health.OldestLedger, health.LatestLedger, nil
+}
+
+// LedgerFilesSchema describes how the ledgers are split in files, it must match the configuration of the exporter that
+// wrote them.
+type LedgerFilesSchema struct {
+	LedgersPerFile    uint32
+	FilesPerPartition uint32
+}
+
+var _ LedgerBackend = (*fileLedgerBackend)(nil)
+
+// fileLedgerBackend reads the zstd compressed LedgerCloseMetaBatch files exported by galexie to a local directory.
+// Each file holds LedgersPerFile ledgers and, when FilesPerPartition is greater than one, files are grouped in
+// partition directories. File and directory names start with the hex encoded MaxUint32 - start ledger, so the latest
+// ledgers sort first, followed by the range they hold, e.g. FFFFFFFF--0-63999/FFFFFFFE--1.xdr.zstd.
+type fileLedgerBackend struct {
+	dir               string
+	schema            LedgerFilesSchema
+	networkPassphrase string
+
+	// the last batch read is kept around since consecutive ledgers are usually in the same file
+	mu        sync.Mutex
+	lastBatch *xdr.LedgerCloseMetaBatch
+}
+
+func NewFileLedgerBackend(dir string, schema LedgerFilesSchema, networkPassphrase string) (*fileLedgerBackend, error) {
+	if dir == "" {
+		return nil, errors.New("dir cannot be empty")
+	}
+	if schema.LedgersPerFile == 0 {
+		return nil, errors.New("ledgers per file must be greater than zero")
+	}
+	if networkPassphrase == "" {
+		return nil, errors.New("networkPassphrase cannot be empty")
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("checking ledgers directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	return &fileLedgerBackend{
+		dir:               dir,
+		schema:            schema,
+		networkPassphrase: networkPassphrase,
+	}, nil
+}
+
+func (b *fileLedgerBackend) GetLedgerTransactions(_ context.Context, ledger uint32) ([]entities.Transaction, error) {
+	batch, err := b.getBatch(ledger)
+	if err != nil {
+		return nil, err
+	}
+	ledgerCloseMeta, err := batch.GetLedger(ledger)
+	if err != nil {
+		return nil, fmt.Errorf("getting ledger %d from batch: %w", ledger, err)
+	}
+
+	transactions, err := ledgerCloseMetaTransactions(ledgerCloseMeta, b.networkPassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("reading transactions of ledger %d: %w", ledger, err)
+	}
+	return transactions, nil
+}
+
+func (b *fileLedgerBackend) getBatch(ledger uint32) (*xdr.LedgerCloseMetaBatch, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.lastBatch != nil && uint32(b.lastBatch.StartSequence) <= ledger && ledger <= uint32(b.lastBatch.EndSequence) {
+		return b.lastBatch, nil
+	}
+
+	path := filepath.Join(b.dir, b.objectKey(ledger))
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %d is not in %s", ErrLedgerNotFound, ledger, b.dir)
+		}
+		return nil, fmt.Errorf("opening ledger file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var batch xdr.LedgerCloseMetaBatch
+	if _, err := compressxdr.NewXDRDecoder(compressxdr.DefaultCompressor, &batch).ReadFrom(file); err != nil {
+		return nil, fmt.Errorf("decoding ledger file %s: %w", path, err)
+	}
+	b.lastBatch = &batch
+	return &batch, nil
+}
+
+// objectKey returns the path of the file holding the ledger relative to the backend directory.
+func (b *fileLedgerBackend) objectKey(ledger uint32) string {
+	var key string
+	if b.schema.FilesPerPartition > 1 {
+		partitionSize := b.schema.LedgersPerFile * b.schema.FilesPerPartition
+		partitionStart := (ledger / partitionSize) * partitionSize
+		partitionEnd := partitionStart + partitionSize - 1
+		key = fmt.Sprintf("%08X--%d-%d/", math.MaxUint32-partitionStart, partitionStart, partitionEnd)
+	}
+
+	fileStart := (ledger / b.schema.LedgersPerFile) * b.schema.LedgersPerFile
+	fileEnd := fileStart + b.schema.LedgersPerFile - 1
+	key += fmt.Sprintf("%08X--%d", math.MaxUint32-fileStart, fileStart)
+	if fileStart != fileEnd {
+		key += fmt.Sprintf("-%d", fileEnd)
+	}
+	return key + ".xdr." + compressxdr.DefaultCompressor.Name()
+}
+
+var ledgerObjectNameRegex = regexp.MustCompile(`^[0-9A-F]{8}--(\d+)(?:-(\d+))?(?:\.xdr\.[a-z]+)?$`)
+
+// GetLedgerRange scans the directory names, so it doesn't check that every file between the oldest and latest ledgers
+// is present.
+func (b *fileLedgerBackend) GetLedgerRange(_ context.Context) (uint32, uint32, error) {
+	dir := b.dir
+	if b.schema.FilesPerPartition > 1 {
+		oldestPartition, latestPartition, err := objectNamesRange(dir)
+		if err != nil {
+			return 0, 0, err
+		}
+		oldest, _, err := objectNamesRange(filepath.Join(dir, oldestPartition.name))
+		if err != nil {
+			return 0, 0, err
+		}
+		_, latest, err := objectNamesRange(filepath.Join(dir, latestPartition.name))
+		if err != nil {
+			return 0, 0, err
+		}
+		return oldest.start, latest.end, nil
+	}
+
+	oldest, latest, err := objectNamesRange(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	return oldest.start, latest.end, nil
+}
+
+type ledgerObject struct {
+	name       string
+	start, end uint32
+}
+
+// objectNamesRange returns the entries of the directory holding the oldest and latest ledgers.
+func objectNamesRange(dir string) (ledgerObject, ledgerObject, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ledgerObject{}, ledgerObject{}, fmt.Errorf("reading directory %s: %w", dir, err)
+	}
+
+	var oldest, latest *ledgerObject
+	for _, entry := range entries {
+		matches := ledgerObjectNameRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+		start, err := strconv.ParseUint(matches[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		end := start
+		if matches[2] != "" {
+			if end, err = strconv.ParseUint(matches[2], 10, 32); err != nil {
+				continue
+			}
+		}
+
+		object := ledgerObject{name: entry.Name(), start: uint32(start), end: uint32(end)}
+		if oldest == nil || object.start < oldest.start {
+			oldest = &object
+		}
+		if latest == nil || object.end > latest.end {
+			latest = &object
+		}
+	}
+	if oldest == nil || latest == nil {
+		return ledgerObject{}, ledgerObject{}, fmt.Errorf("%w: no ledger files in %s", ErrLedgerNotFound, dir)
+	}
+	return *oldest, *latest, nil
+}
+
+// ledgerCloseMetaTransactions converts the transactions of the ledger to the format returned by the RPC, so the rest
+// of the ingestion doesn't depend on the backend.
+func ledgerCloseMetaTransactions(ledgerCloseMeta xdr.LedgerCloseMeta, networkPassphrase string) ([]entities.Transaction, error) {
+	txProcessing, err := ledgerCloseMeta.TxProcessing()
+	if err != nil {
+		return nil, fmt.Errorf("getting transactions processing: %w", err)
+	}
+
+	// the transaction set isn't in application order, so envelopes are matched to the results by hash
+	envelopes := map[xdr.Hash]xdr.TransactionEnvelope{}
+	for _, envelope := range ledgerCloseMeta.TransactionEnvelopes() {
+		hash, err := network.HashTransactionInEnvelope(envelope, networkPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("hashing transaction envelope: %w", err)
+		}
+		envelopes[hash] = envelope
+	}
+
+	ledger := ledgerCloseMeta.LedgerSequence()
+	closedAt := uint32(ledgerCloseMeta.LedgerCloseTime())
+	transactions := make([]entities.Transaction, 0, len(txProcessing))
+	for i, txResultMeta := range txProcessing {
+		hash := txResultMeta.Result.TransactionHash
+		envelope, ok := envelopes[hash]
+		if !ok {
+			return nil, fmt.Errorf("envelope of transaction %s not found, check the network passphrase", hex.EncodeToString(hash[:]))
+		}
+		result := txResultMeta.Result.Result
+
+		envelopeXDR, err := xdr.MarshalBase64(envelope)
+		if err != nil {
+			return nil, fmt.Errorf("encoding transaction envelope: %w", err)
+		}
+		resultXDR, err := xdr.MarshalBase64(result)
+		if err != nil {
+			return nil, fmt.Errorf("encoding transaction result: %w", err)
+		}
+		resultMetaXDR, err := xdr.MarshalBase64(txResultMeta.TxApplyProcessing)
+		if err != nil {
+			return nil, fmt.Errorf("encoding transaction meta: %w", err)
+		}
+
+		status := entities.FailedStatus
+		if result.Successful() {
+			status = entities.SuccessStatus
+		}
+		transactions = append(transactions, entities.Transaction{
+			Status:           status,
+			Hash:             hex.EncodeToString(hash[:]),
+			ApplicationOrder: int64(i + 1),
+			FeeBump:          envelope.IsFeeBump(),
+			EnvelopeXDR:      envelopeXDR,
+			ResultXDR:        resultXDR,
+			ResultMetaXDR:    resultMetaXDR,
+			Ledger:           int64(ledger),
+			CreatedAt:        closedAt,
+		})
+	}
+	return transactions, nil
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/entities"
+)
+
+func TestRPCLedgerBackendGetLedgerTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all_ledger_transactions_in_single_gettransactions_call", func(t *testing.T) {
+		mockRPCService := RPCServiceMock{}
+		defer mockRPCService.AssertExpectations(t)
+		ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+		require.NoError(t, err)
+
+		rpcGetTransactionsResult := entities.RPCGetTransactionsResult{
+			Cursor: "51",
+			Transactions: []entities.Transaction{
+				{
+					Status: entities.SuccessStatus,
+					Hash:   "hash1",
+					Ledger: 1,
+				},
+				{
+					Status: entities.FailedStatus,
+					Hash:   "hash2",
+					Ledger: 2,
+				},
+			},
+		}
+		mockRPCService.
+			On("GetTransactions", int64(1), "", 50).
+			Return(rpcGetTransactionsResult, nil).
+			Once()
+
+		txns, err := ledgerBackend.GetLedgerTransactions(ctx, 1)
+		assert.Equal(t, 1, len(txns))
+		assert.Equal(t, txns[0].Hash, "hash1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("ledger_transactions_split_between_multiple_gettransactions_calls", func(t *testing.T) {
+		mockRPCService := RPCServiceMock{}
+		defer mockRPCService.AssertExpectations(t)
+		ledgerBackend, err := NewRPCLedgerBackend(&mockRPCService)
+		require.NoError(t, err)
+
+		rpcGetTransactionsResult1 := entities.RPCGetTransactionsResult{
+			Cursor: "51",
+			Transactions: []entities.Transaction{
+				{
+					Status: entities.SuccessStatus,
+					Hash:   "hash1",
+					Ledger: 1,
+				},
+				{
+					Status: entities.FailedStatus,
+					Hash:   "hash2",
+					Ledger: 1,
+				},
+			},
+		}
+		rpcGetTransactionsResult2 := entities.RPCGetTransactionsResult{
+			Cursor: "51",
+			Transactions: []entities.Transaction{
+				{
+					Status: entities.SuccessStatus,
+					Hash:   "hash3",
+					Ledger: 1,
+				},
+				{
+					Status: entities.FailedStatus,
+					Hash:   "hash4",
+					Ledger: 2,
+				},
+			},
+		}
+
+		mockRPCService.
+			On("GetTransactions", int64(1), "", 50).
+			Return(rpcGetTransactionsResult1, nil).
+			Once()
+
+		mockRPCService.
+			On("GetTransactions", int64(1), "51", 50).
+			Return(rpcGetTransactionsResult2, nil).
+			Once()
+
+		txns, err := ledgerBackend.GetLedgerTransactions(ctx, 1)
+		assert.Equal(t, 3, len(txns))
+		assert.Equal(t, txns[0].Hash, "hash1")
+		assert.Equal(t, txns[1].Hash, "hash2")
+		assert.Equal(t, txns[2].Hash, "hash3")
+		assert.NoError(t, err)
+	})
+}
+
+func TestFileLedgerBackendObjectKey(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   LedgerFilesSchema
+		ledger   uint32
+		expected string
+	}{
+		{
+			name:     "one_ledger_per_file_with_partitions",
+			schema:   LedgerFilesSchema{LedgersPerFile: 1, FilesPerPartition: 64000},
+			ledger:   1,
+			expected: "FFFFFFFF--0-63999/FFFFFFFE--1.xdr.zstd",
+		},
+		{
+			name:     "many_ledgers_per_file_with_partitions",
+			schema:   LedgerFilesSchema{LedgersPerFile: 10, FilesPerPartition: 10},
+			ledger:   123,
+			expected: "FFFFFF9B--100-199/FFFFFF87--120-129.xdr.zstd",
+		},
+		{
+			name:     "without_partitions",
+			schema:   LedgerFilesSchema{LedgersPerFile: 1, FilesPerPartition: 1},
+			ledger:   10,
+			expected: "FFFFFFF5--10.xdr.zstd",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ledgerBackend := &fileLedgerBackend{schema: tc.schema}
+			assert.Equal(t, tc.expected, ledgerBackend.objectKey(tc.ledger))
+		})
+	}
+}
+
+// writeLedgerFile writes the ledgers in a single batch file, following the layout of the backend schema.
+func writeLedgerFile(t *testing.T, ledgerBackend *fileLedgerBackend, ledgerCloseMetas ...xdr.LedgerCloseMeta) {
+	t.Helper()
+
+	batch := xdr.LedgerCloseMetaBatch{
+		StartSequence:    xdr.Uint32(ledgerCloseMetas[0].LedgerSequence()),
+		EndSequence:      xdr.Uint32(ledgerCloseMetas[len(ledgerCloseMetas)-1].LedgerSequence()),
+		LedgerCloseMetas: ledgerCloseMetas,
+	}
+	path := filepath.Join(ledgerBackend.dir, ledgerBackend.objectKey(uint32(batch.StartSequence)))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, &batch).WriteTo(file)
+	require.NoError(t, err)
+}
+
+func makeLedgerCloseMeta(ledger uint32, closedAt int64, envelopes []xdr.TransactionEnvelope, txProcessing []xdr.TransactionResultMeta) xdr.LedgerCloseMeta {
+	return xdr.LedgerCloseMeta{
+		V: 0,
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq: xdr.Uint32(ledger),
+					ScpValue:  xdr.StellarValue{CloseTime: xdr.TimePoint(closedAt)},
+				},
+			},
+			TxSet:        xdr.TransactionSet{Txs: envelopes},
+			TxProcessing: txProcessing,
+		},
+	}
+}
+
+func TestFileLedgerBackend(t *testing.T) {
+	ctx := context.Background()
+	source := keypair.MustRandom()
+
+	transaction, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address()},
+		Operations: []txnbuild.Operation{
+			&txnbuild.BumpSequence{BumpTo: 100},
+		},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(10)},
+	})
+	require.NoError(t, err)
+	envelope := transaction.ToXDR()
+	hash, err := network.HashTransactionInEnvelope(envelope, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	result := xdr.TransactionResult{
+		FeeCharged: 100,
+		Result: xdr.TransactionResultResult{
+			Code:    xdr.TransactionResultCodeTxSuccess,
+			Results: &[]xdr.OperationResult{},
+		},
+	}
+	var txMeta xdr.TransactionMeta
+	require.NoError(t, xdr.SafeUnmarshalBase64(emptyTxMetaXDR, &txMeta))
+	txProcessing := []xdr.TransactionResultMeta{
+		{
+			Result:            xdr.TransactionResultPair{TransactionHash: hash, Result: result},
+			TxApplyProcessing: txMeta,
+		},
+	}
+
+	dir := t.TempDir()
+	ledgerBackend, err := NewFileLedgerBackend(dir, LedgerFilesSchema{LedgersPerFile: 2, FilesPerPartition: 4}, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	writeLedgerFile(t, ledgerBackend,
+		makeLedgerCloseMeta(10, 1000, []xdr.TransactionEnvelope{envelope}, txProcessing),
+		makeLedgerCloseMeta(11, 1005, nil, nil),
+	)
+	writeLedgerFile(t, ledgerBackend, makeLedgerCloseMeta(12, 1010, nil, nil), makeLedgerCloseMeta(13, 1015, nil, nil))
+
+	t.Run("get_ledger_transactions", func(t *testing.T) {
+		transactions, err := ledgerBackend.GetLedgerTransactions(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+
+		envelopeXDR, err := xdr.MarshalBase64(envelope)
+		require.NoError(t, err)
+		resultXDR, err := xdr.MarshalBase64(result)
+		require.NoError(t, err)
+		assert.Equal(t, entities.Transaction{
+			Status:           entities.SuccessStatus,
+			Hash:             hex.EncodeToString(hash[:]),
+			ApplicationOrder: 1,
+			FeeBump:          false,
+			EnvelopeXDR:      envelopeXDR,
+			ResultXDR:        resultXDR,
+			ResultMetaXDR:    emptyTxMetaXDR,
+			Ledger:           10,
+			CreatedAt:        1000,
+		}, transactions[0])
+
+		transactions, err = ledgerBackend.GetLedgerTransactions(ctx, 11)
+		require.NoError(t, err)
+		assert.Empty(t, transactions)
+	})
+
+	t.Run("get_ledger_transactions_wrong_network_passphrase", func(t *testing.T) {
+		otherNetworkBackend, err := NewFileLedgerBackend(dir, ledgerBackend.schema, network.PublicNetworkPassphrase)
+		require.NoError(t, err)
+
+		_, err = otherNetworkBackend.GetLedgerTransactions(ctx, 10)
+		assert.ErrorContains(t, err, "check the network passphrase")
+	})
+
+	t.Run("get_ledger_transactions_missing_file", func(t *testing.T) {
+		_, err := ledgerBackend.GetLedgerTransactions(ctx, 14)
+		assert.ErrorIs(t, err, ErrLedgerNotFound)
+	})
+
+	t.Run("get_ledger_range", func(t *testing.T) {
+		oldest, latest, err := ledgerBackend.GetLedgerRange(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(10), oldest)
+		assert.Equal(t, uint32(13), latest)
+	})
+
+	t.Run("get_ledger_range_empty_dir", func(t *testing.T) {
+		emptyBackend, err := NewFileLedgerBackend(t.TempDir(), ledgerBackend.schema, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+
+		_, _, err = emptyBackend.GetLedgerRange(ctx)
+		assert.ErrorIs(t, err, ErrLedgerNotFound)
+	})
+}
@@ -0,0 +1,736 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	"github.com/stellar/wallet-backend/internal/tss"
+	tssrouter "github.com/stellar/wallet-backend/internal/tss/router"
+	tssstore "github.com/stellar/wallet-backend/internal/tss/store"
+	"github.com/stellar/wallet-backend/internal/utils"
+)
+
+// LedgerTransactions are the transactions of a single ledger, in application order.
+type LedgerTransactions struct {
+	Sequence     uint32
+	Transactions []entities.Transaction
+	// AccountFilter restricts the data stored to the one involving this account when it's set, it's used to backfill
+	// the history of a single account.
+	AccountFilter string
+}
+
+// LedgerProcessor derives a dataset from the transactions of each ingested ledger. All the processors of a ledger
+// write in the same database transaction, which also advances the ingestion cursor, so a ledger is either fully
+// ingested or not at all.
+type LedgerProcessor interface {
+	// Name identifies the processor in the configuration and labels its ingestion duration metric.
+	Name() string
+	ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error
+}
+
+// preparedLedgerProcessor is implemented by processors that prepare the database for a ledger, like creating the
+// partitions it's stored in. DDL can't run inside the ledger transaction without blocking on it, so it's run before
+// the transaction opens.
+type preparedLedgerProcessor interface {
+	prepareLedger(ctx context.Context, ledger LedgerTransactions)
+}
+
+// committedLedgerProcessor is implemented by processors with side effects outside of the database, like routing TSS
+// payloads, which are only run once the ledger transaction committed.
+type committedLedgerProcessor interface {
+	afterCommit(ctx context.Context)
+}
+
+// liveOnlyLedgerProcessor is implemented by processors with side effects that only make sense for ledgers that just
+// closed, like notifying webhooks, so backfills skip them.
+type liveOnlyLedgerProcessor interface {
+	liveOnly()
+}
+
+const (
+	PaymentsLedgerProcessor        = paymentPrometheusLabel
+	OperationsLedgerProcessor      = operationsPrometheusLabel
+	BalanceChangesLedgerProcessor  = balanceChangesPrometheusLabel
+	FeesLedgerProcessor            = feesPrometheusLabel
+	AccountWebhooksLedgerProcessor = accountWebhooksPrometheusLabel
+	TSSLedgerProcessor             = tssPrometheusLabel
+)
+
+// DefaultLedgerProcessors are the processors enabled when none are configured, in the order they run.
+var DefaultLedgerProcessors = []string{
+	PaymentsLedgerProcessor,
+	OperationsLedgerProcessor,
+	BalanceChangesLedgerProcessor,
+	FeesLedgerProcessor,
+	AccountWebhooksLedgerProcessor,
+	TSSLedgerProcessor,
+}
+
+// newLedgerProcessor builds the processor registered under the name.
+func (m *ingestService) newLedgerProcessor(name string) (LedgerProcessor, error) {
+	switch name {
+	case PaymentsLedgerProcessor:
+		return &paymentsProcessor{models: m.models, partitions: m.paymentPartitions, metricsService: m.metricsService}, nil
+	case OperationsLedgerProcessor:
+		return &operationsProcessor{models: m.models}, nil
+	case BalanceChangesLedgerProcessor:
+		return &balanceChangesProcessor{models: m.models}, nil
+	case FeesLedgerProcessor:
+		return &feesProcessor{models: m.models, distributionAccountPublicKey: m.distributionAccountPublicKey}, nil
+	case AccountWebhooksLedgerProcessor:
+		return &accountWebhooksProcessor{models: m.models}, nil
+	case TSSLedgerProcessor:
+		return &tssProcessor{tssStore: m.tssStore, tssRouter: m.tssRouter, metricsService: m.metricsService}, nil
+	default:
+		return nil, fmt.Errorf("unknown ledger processor %q", name)
+	}
+}
+
+// registerLedgerProcessors enables the processors with the given names, in order, or the default ones when no names are
+// given.
+func (m *ingestService) registerLedgerProcessors(names []string) error {
+	if len(names) == 0 {
+		names = DefaultLedgerProcessors
+	}
+
+	m.liveProcessors = make([]LedgerProcessor, 0, len(names))
+	m.backfillProcessors = make([]LedgerProcessor, 0, len(names))
+	for i, name := range names {
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("ledger processor %q is enabled more than once", name)
+		}
+		processor, err := m.newLedgerProcessor(name)
+		if err != nil {
+			return err
+		}
+
+		m.liveProcessors = append(m.liveProcessors, &timedLedgerProcessor{LedgerProcessor: processor, metricsService: m.metricsService})
+		if _, ok := processor.(liveOnlyLedgerProcessor); !ok {
+			m.backfillProcessors = append(m.backfillProcessors, processor)
+		}
+	}
+	return nil
+}
+
+// processLedger runs the processors and then advanceCursor in a single database transaction. The processors prepare
+// the database before it opens, and their side effects outside of the database are run once it committed.
+func (m *ingestService) processLedger(ctx context.Context, processors []LedgerProcessor, ledger LedgerTransactions, advanceCursor func(dbTx db.Transaction) error) error {
+	for _, processor := range processors {
+		if prepared, ok := processor.(preparedLedgerProcessor); ok {
+			prepared.prepareLedger(ctx, ledger)
+		}
+	}
+
+	err := db.RunInTransaction(ctx, m.models.Payments.DB, nil, func(dbTx db.Transaction) error {
+		for _, processor := range processors {
+			if err := processor.ProcessLedger(ctx, dbTx, ledger); err != nil {
+				return fmt.Errorf("running %s ledger processor: %w", processor.Name(), err)
+			}
+		}
+		return advanceCursor(dbTx)
+	})
+	if err != nil {
+		return fmt.Errorf("processing ledger %d: %w", ledger.Sequence, err)
+	}
+
+	for _, processor := range processors {
+		if committed, ok := processor.(committedLedgerProcessor); ok {
+			committed.afterCommit(ctx)
+		}
+	}
+	return nil
+}
+
+// timedLedgerProcessor observes the duration of the wrapped processor under its name.
+type timedLedgerProcessor struct {
+	LedgerProcessor
+	metricsService metrics.MetricsService
+}
+
+func (p *timedLedgerProcessor) ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error {
+	start := time.Now()
+	if err := p.LedgerProcessor.ProcessLedger(ctx, dbTx, ledger); err != nil {
+		return err //nolint:wrapcheck // the processor error is wrapped by the caller
+	}
+	p.metricsService.ObserveIngestionDuration(p.Name(), time.Since(start).Seconds())
+	return nil
+}
+
+func (p *timedLedgerProcessor) prepareLedger(ctx context.Context, ledger LedgerTransactions) {
+	if prepared, ok := p.LedgerProcessor.(preparedLedgerProcessor); ok {
+		prepared.prepareLedger(ctx, ledger)
+	}
+}
+
+func (p *timedLedgerProcessor) afterCommit(ctx context.Context) {
+	if committed, ok := p.LedgerProcessor.(committedLedgerProcessor); ok {
+		committed.afterCommit(ctx)
+	}
+}
+
+type paymentsProcessor struct {
+	models         *data.Models
+	partitions     *PaymentPartitions
+	metricsService metrics.MetricsService
+}
+
+func (p *paymentsProcessor) Name() string {
+	return PaymentsLedgerProcessor
+}
+
+// prepareLedger creates the payment partition of the ledger. The payments land in the default partition when it can't
+// be created, so it's not worth failing the ledger for it.
+func (p *paymentsProcessor) prepareLedger(ctx context.Context, ledger LedgerTransactions) {
+	if len(ledger.Transactions) == 0 {
+		return
+	}
+	if err := p.partitions.Ensure(ctx, ledger.Sequence); err != nil {
+		log.Ctx(ctx).Warnf("ensuring the payment partition of ledger %d: %v", ledger.Sequence, err)
+	}
+}
+
+// ProcessLedger stores the payments of registered accounts. Payments of failed transactions are stored too, along with
+// the result codes they failed with.
+func (p *paymentsProcessor) ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error {
+	var payments []data.Payment
+	paymentOpsIngested := 0
+	pathPaymentStrictSendOpsIngested := 0
+	pathPaymentStrictReceiveOpsIngested := 0
+	tokenTransfersIngested := 0
+	createAccountOpsIngested := 0
+	accountMergeOpsIngested := 0
+	claimClaimableBalanceOpsIngested := 0
+	for _, tx := range ledger.Transactions {
+		if tx.Status != entities.SuccessStatus && tx.Status != entities.FailedStatus {
+			continue
+		}
+		successful := tx.Status == entities.SuccessStatus
+		genericTx, err := txnbuild.TransactionFromXDR(tx.EnvelopeXDR)
+		if err != nil {
+			return fmt.Errorf("deserializing envelope xdr: %w", err)
+		}
+		txEnvelopeXDR, err := genericTx.ToXDR()
+		if err != nil {
+			return fmt.Errorf("generic transaction cannot be unpacked into a transaction")
+		}
+		txResultXDR, err := tss.UnmarshallTransactionResultXDR(tx.ResultXDR)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal transacation result xdr: %s", err.Error())
+		}
+
+		txMemo, txMemoType := utils.Memo(txEnvelopeXDR.Memo(), tx.Hash)
+		if txMemo != nil {
+			*txMemo = utils.SanitizeUTF8(*txMemo)
+		}
+		// the meta is only needed by claims, so it's decoded the first time one shows up
+		var txMeta *xdr.TransactionMeta
+		for idx, op := range txEnvelopeXDR.Operations() {
+			opIdx := idx + 1
+			payment := data.Payment{
+				OperationID:     utils.OperationID(int32(tx.Ledger), int32(tx.ApplicationOrder), int32(opIdx)),
+				OperationType:   op.Body.Type.String(),
+				TransactionID:   utils.TransactionID(int32(tx.Ledger), int32(tx.ApplicationOrder)),
+				TransactionHash: tx.Hash,
+				CreatedAt:       time.Unix(int64(tx.CreatedAt), 0),
+				Memo:            txMemo,
+				MemoType:        txMemoType,
+				Successful:      successful,
+			}
+			if !successful {
+				txResultCode := utils.TransactionResultCode(txResultXDR)
+				payment.TransactionResultCode = &txResultCode
+				payment.OperationResultCode = utils.OperationResultCode(txResultXDR, opIdx)
+			}
+
+			payment.FromAddress, payment.FromMuxedID, payment.FromMuxedAddress = muxedAccountDetails(utils.SourceMuxedAccount(op, txEnvelopeXDR))
+
+			switch op.Body.Type {
+			case xdr.OperationTypePayment:
+				paymentOpsIngested++
+				fillPayment(&payment, op.Body)
+			case xdr.OperationTypePathPaymentStrictSend:
+				pathPaymentStrictSendOpsIngested++
+				fillPathSend(&payment, op.Body, txResultXDR, opIdx)
+			case xdr.OperationTypePathPaymentStrictReceive:
+				pathPaymentStrictReceiveOpsIngested++
+				fillPathReceive(&payment, op.Body, txResultXDR, opIdx)
+			case xdr.OperationTypeCreateAccount:
+				createAccountOpsIngested++
+				fillCreateAccount(&payment, op.Body)
+			case xdr.OperationTypeAccountMerge:
+				accountMergeOpsIngested++
+				fillAccountMerge(&payment, op.Body, txResultXDR, opIdx)
+			case xdr.OperationTypeClaimClaimableBalance:
+				claimClaimableBalanceOpsIngested++
+				if !successful {
+					fillClaimant(&payment)
+					break
+				}
+				if txMeta == nil {
+					txMeta = &xdr.TransactionMeta{}
+					if err := xdr.SafeUnmarshalBase64(tx.ResultMetaXDR, txMeta); err != nil {
+						return fmt.Errorf("unmarshalling meta of transaction %s: %w", tx.Hash, err)
+					}
+				}
+				opsMeta := txMeta.OperationsMeta()
+				if idx >= len(opsMeta) {
+					return fmt.Errorf("missing meta of operation %d of transaction %s", opIdx, tx.Hash)
+				}
+				if err := fillClaimClaimableBalance(&payment, op.Body, opsMeta[idx].Changes); err != nil {
+					return fmt.Errorf("filling claim of operation %d of transaction %s: %w", opIdx, tx.Hash, err)
+				}
+			default:
+				continue
+			}
+
+			payments = append(payments, payment)
+		}
+
+		// failed transactions don't emit events
+		if !successful {
+			continue
+		}
+		tokenTransferPayments, err := tokenTransferPayments(tx, txEnvelopeXDR, txMemo, txMemoType)
+		if err != nil {
+			return fmt.Errorf("getting token transfers of transaction %s: %w", tx.Hash, err)
+		}
+		tokenTransfersIngested += len(tokenTransferPayments)
+		payments = append(payments, tokenTransferPayments...)
+	}
+	if ledger.AccountFilter != "" {
+		payments = slices.DeleteFunc(payments, func(payment data.Payment) bool {
+			return payment.FromAddress != ledger.AccountFilter && payment.ToAddress != ledger.AccountFilter
+		})
+	}
+
+	err := p.models.Payments.BatchAddPayments(ctx, dbTx, payments)
+	if err != nil {
+		return fmt.Errorf("adding payments: %w", err)
+	}
+	if len(payments) > 0 {
+		if err := p.models.PaymentVolumes.AddLedger(ctx, dbTx, ledger.Sequence); err != nil {
+			return fmt.Errorf("adding payment volumes: %w", err)
+		}
+		if err := p.models.Payments.NotifyPayments(ctx, dbTx, ledger.Sequence); err != nil {
+			return fmt.Errorf("notifying payments: %w", err)
+		}
+	}
+	p.metricsService.SetNumPaymentOpsIngestedPerLedger(paymentPrometheusLabel, paymentOpsIngested)
+	p.metricsService.SetNumPaymentOpsIngestedPerLedger(pathPaymentStrictSendPrometheusLabel, pathPaymentStrictSendOpsIngested)
+	p.metricsService.SetNumPaymentOpsIngestedPerLedger(pathPaymentStrictReceivePrometheusLabel, pathPaymentStrictReceiveOpsIngested)
+	p.metricsService.SetNumPaymentOpsIngestedPerLedger(tokenTransferPrometheusLabel, tokenTransfersIngested)
+	p.metricsService.SetNumPaymentOpsIngestedPerLedger(createAccountPrometheusLabel, createAccountOpsIngested)
+	p.metricsService.SetNumPaymentOpsIngestedPerLedger(accountMergePrometheusLabel, accountMergeOpsIngested)
+	p.metricsService.SetNumPaymentOpsIngestedPerLedger(claimClaimableBalancePrometheusLabel, claimClaimableBalanceOpsIngested)
+	return nil
+}
+
+type operationsProcessor struct {
+	models *data.Models
+}
+
+func (p *operationsProcessor) Name() string {
+	return OperationsLedgerProcessor
+}
+
+// ProcessLedger stores every successful transaction and operation that has a registered account among its
+// participants, along with their decoded details.
+func (p *operationsProcessor) ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error {
+	var transactions []data.Transaction
+	var operations []data.Operation
+	for _, tx := range ledger.Transactions {
+		if tx.Status != entities.SuccessStatus {
+			continue
+		}
+		genericTx, err := txnbuild.TransactionFromXDR(tx.EnvelopeXDR)
+		if err != nil {
+			return fmt.Errorf("deserializing envelope xdr: %w", err)
+		}
+		txEnvelopeXDR, err := genericTx.ToXDR()
+		if err != nil {
+			return fmt.Errorf("generic transaction cannot be unpacked into a transaction")
+		}
+		txResultXDR, err := tss.UnmarshallTransactionResultXDR(tx.ResultXDR)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal transacation result xdr: %s", err.Error())
+		}
+
+		txMemo, txMemoType := utils.Memo(txEnvelopeXDR.Memo(), tx.Hash)
+		if txMemo != nil {
+			*txMemo = utils.SanitizeUTF8(*txMemo)
+		}
+		transactionID := utils.TransactionID(int32(tx.Ledger), int32(tx.ApplicationOrder))
+		createdAt := time.Unix(int64(tx.CreatedAt), 0)
+		txSourceAccount := muxedAccountAddress(txEnvelopeXDR.SourceAccount())
+		txParticipants := []string{txSourceAccount}
+		txDetails := map[string]any{
+			"feeBump":        txEnvelopeXDR.IsFeeBump(),
+			"feeCharged":     int64(txResultXDR.FeeCharged),
+			"maxFee":         int64(txEnvelopeXDR.Fee()),
+			"sequence":       txEnvelopeXDR.SeqNum(),
+			"operationCount": txEnvelopeXDR.OperationsCount(),
+		}
+		if txEnvelopeXDR.IsFeeBump() {
+			feeAccount := muxedAccountAddress(txEnvelopeXDR.FeeBumpAccount())
+			txDetails["feeAccount"] = feeAccount
+			txDetails["maxFee"] = txEnvelopeXDR.FeeBumpFee()
+			txParticipants = append(txParticipants, feeAccount)
+		}
+
+		for idx, op := range txEnvelopeXDR.Operations() {
+			opSourceAccount := utils.SourceAccount(op, txEnvelopeXDR)
+			opDetails, opParticipants, err := operationDetails(op, opSourceAccount)
+			if err != nil {
+				return fmt.Errorf("decoding operation %d of transaction %s: %w", idx+1, tx.Hash, err)
+			}
+			opDetailsJSON, err := json.Marshal(opDetails)
+			if err != nil {
+				return fmt.Errorf("marshalling operation details: %w", err)
+			}
+			operations = append(operations, data.Operation{
+				OperationID:     utils.OperationID(int32(tx.Ledger), int32(tx.ApplicationOrder), int32(idx+1)),
+				OperationType:   op.Body.Type.String(),
+				TransactionID:   transactionID,
+				TransactionHash: tx.Hash,
+				SourceAccount:   opSourceAccount,
+				Details:         opDetailsJSON,
+				Participants:    opParticipants,
+				CreatedAt:       createdAt,
+			})
+			txParticipants = append(txParticipants, opParticipants...)
+		}
+
+		txDetailsJSON, err := json.Marshal(txDetails)
+		if err != nil {
+			return fmt.Errorf("marshalling transaction details: %w", err)
+		}
+		transactions = append(transactions, data.Transaction{
+			TransactionID:   transactionID,
+			TransactionHash: tx.Hash,
+			LedgerNumber:    uint32(tx.Ledger),
+			SourceAccount:   txSourceAccount,
+			Memo:            txMemo,
+			MemoType:        txMemoType,
+			Details:         txDetailsJSON,
+			Participants:    uniqueAddresses(txParticipants),
+			CreatedAt:       createdAt,
+		})
+	}
+
+	if ledger.AccountFilter != "" {
+		transactions = slices.DeleteFunc(transactions, func(transaction data.Transaction) bool {
+			return !slices.Contains(transaction.Participants, ledger.AccountFilter)
+		})
+		operations = slices.DeleteFunc(operations, func(operation data.Operation) bool {
+			return !slices.Contains(operation.Participants, ledger.AccountFilter)
+		})
+	}
+
+	if err := p.models.Transactions.BatchAddTransactions(ctx, dbTx, transactions); err != nil {
+		return fmt.Errorf("adding transactions: %w", err)
+	}
+	if err := p.models.Operations.BatchAddOperations(ctx, dbTx, operations); err != nil {
+		return fmt.Errorf("adding operations: %w", err)
+	}
+	return nil
+}
+
+type balanceChangesProcessor struct {
+	models *data.Models
+}
+
+func (p *balanceChangesProcessor) Name() string {
+	return BalanceChangesLedgerProcessor
+}
+
+// ProcessLedger stores the balance deltas of registered accounts caused by the ledger transactions. Failed
+// transactions are included since they still charge a fee.
+func (p *balanceChangesProcessor) ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error {
+	var balanceChanges []data.BalanceChange
+	for _, tx := range ledger.Transactions {
+		genericTx, err := txnbuild.TransactionFromXDR(tx.EnvelopeXDR)
+		if err != nil {
+			return fmt.Errorf("deserializing envelope xdr: %w", err)
+		}
+		txEnvelopeXDR, err := genericTx.ToXDR()
+		if err != nil {
+			return fmt.Errorf("generic transaction cannot be unpacked into a transaction")
+		}
+		txResultXDR, err := tss.UnmarshallTransactionResultXDR(tx.ResultXDR)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal transacation result xdr: %s", err.Error())
+		}
+
+		txBalanceChanges, err := transactionBalanceChanges(tx, txEnvelopeXDR, txResultXDR)
+		if err != nil {
+			return fmt.Errorf("getting balance changes of transaction %s: %w", tx.Hash, err)
+		}
+		balanceChanges = append(balanceChanges, txBalanceChanges...)
+	}
+	if ledger.AccountFilter != "" {
+		balanceChanges = slices.DeleteFunc(balanceChanges, func(balanceChange data.BalanceChange) bool {
+			return balanceChange.Account != ledger.AccountFilter
+		})
+	}
+
+	if err := p.models.BalanceChanges.BatchAddBalanceChanges(ctx, dbTx, balanceChanges); err != nil {
+		return fmt.Errorf("adding balance changes: %w", err)
+	}
+	return nil
+}
+
+type feesProcessor struct {
+	models                       *data.Models
+	distributionAccountPublicKey string
+}
+
+func (p *feesProcessor) Name() string {
+	return FeesLedgerProcessor
+}
+
+// ProcessLedger stores the fees charged for the transactions sourced or paid by registered accounts, channel accounts or
+// the distribution account. Failed transactions are included since they're charged too.
+func (p *feesProcessor) ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error {
+	var fees []data.TransactionFee
+	for _, tx := range ledger.Transactions {
+		if tx.Status != entities.SuccessStatus && tx.Status != entities.FailedStatus {
+			continue
+		}
+		genericTx, err := txnbuild.TransactionFromXDR(tx.EnvelopeXDR)
+		if err != nil {
+			return fmt.Errorf("deserializing envelope xdr: %w", err)
+		}
+		txEnvelopeXDR, err := genericTx.ToXDR()
+		if err != nil {
+			return fmt.Errorf("generic transaction cannot be unpacked into a transaction")
+		}
+		txResultXDR, err := tss.UnmarshallTransactionResultXDR(tx.ResultXDR)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal transacation result xdr: %s", err.Error())
+		}
+
+		fee := data.TransactionFee{
+			TransactionID:   utils.TransactionID(int32(tx.Ledger), int32(tx.ApplicationOrder)),
+			TransactionHash: tx.Hash,
+			LedgerNumber:    uint32(tx.Ledger),
+			SourceAccount:   muxedAccountAddress(txEnvelopeXDR.SourceAccount()),
+			FeeBumped:       txEnvelopeXDR.IsFeeBump(),
+			FeeCharged:      int64(txResultXDR.FeeCharged),
+			MaxFee:          int64(txEnvelopeXDR.Fee()),
+			Successful:      tx.Status == entities.SuccessStatus,
+			CreatedAt:       time.Unix(int64(tx.CreatedAt), 0),
+		}
+		fee.FeeAccount = fee.SourceAccount
+		if fee.FeeBumped {
+			fee.FeeAccount = muxedAccountAddress(txEnvelopeXDR.FeeBumpAccount())
+			fee.MaxFee = txEnvelopeXDR.FeeBumpFee()
+		}
+		if ledger.AccountFilter != "" && ledger.AccountFilter != fee.SourceAccount && ledger.AccountFilter != fee.FeeAccount {
+			continue
+		}
+		fees = append(fees, fee)
+	}
+
+	var trackedAccounts []string
+	if p.distributionAccountPublicKey != "" {
+		trackedAccounts = append(trackedAccounts, p.distributionAccountPublicKey)
+	}
+	if err := p.models.Fees.BatchAddTransactionFees(ctx, dbTx, fees, trackedAccounts); err != nil {
+		return fmt.Errorf("adding transaction fees: %w", err)
+	}
+	return nil
+}
+
+type accountWebhooksProcessor struct {
+	models *data.Models
+}
+
+func (p *accountWebhooksProcessor) Name() string {
+	return AccountWebhooksLedgerProcessor
+}
+
+func (p *accountWebhooksProcessor) liveOnly() {}
+
+// ProcessLedger enqueues a delivery of each payment and operation of the ledger to the webhooks of the addresses
+// involved. It reads what the payments and operations processors stored for the ledger, so it must run after them.
+func (p *accountWebhooksProcessor) ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error {
+	payments, err := p.models.Payments.GetLedgerPayments(ctx, dbTx, ledger.Sequence)
+	if err != nil {
+		return fmt.Errorf("getting payments: %w", err)
+	}
+	operations, err := p.models.Operations.GetLedgerOperations(ctx, dbTx, ledger.Sequence)
+	if err != nil {
+		return fmt.Errorf("getting operations: %w", err)
+	}
+	if len(payments) == 0 && len(operations) == 0 {
+		return nil
+	}
+
+	var addresses []string
+	for _, payment := range payments {
+		addresses = append(addresses, payment.FromAddress, payment.ToAddress)
+	}
+	for _, operation := range operations {
+		addresses = append(addresses, operation.Participants...)
+	}
+	slices.Sort(addresses)
+	webhookIDs, err := p.models.AccountWebhooks.GetWebhookIDsByAddresses(ctx, dbTx, slices.Compact(addresses))
+	if err != nil {
+		return fmt.Errorf("getting webhooks: %w", err)
+	}
+	if len(webhookIDs) == 0 {
+		return nil
+	}
+
+	var deliveries []data.AccountWebhookDelivery
+	// enqueue delivers the event once to each webhook of its addresses, listing the addresses of that webhook involved
+	enqueue := func(event data.AccountWebhookEvent, eventID string, addresses []string) error {
+		webhookAddresses := map[int64][]string{}
+		var eventWebhookIDs []int64
+		for _, address := range addresses {
+			for _, webhookID := range webhookIDs[address] {
+				if _, ok := webhookAddresses[webhookID]; !ok {
+					eventWebhookIDs = append(eventWebhookIDs, webhookID)
+				}
+				if !slices.Contains(webhookAddresses[webhookID], address) {
+					webhookAddresses[webhookID] = append(webhookAddresses[webhookID], address)
+				}
+			}
+		}
+		for _, webhookID := range eventWebhookIDs {
+			event.Addresses = webhookAddresses[webhookID]
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("marshalling %s event %s: %w", event.Type, eventID, err)
+			}
+			deliveries = append(deliveries, data.AccountWebhookDelivery{WebhookID: webhookID, EventType: event.Type, EventID: eventID, Payload: payload})
+		}
+		return nil
+	}
+	for _, payment := range payments {
+		event := data.AccountWebhookEvent{Type: data.AccountWebhookPaymentEvent, Payment: &payment}
+		if err := enqueue(event, payment.ID(), []string{payment.FromAddress, payment.ToAddress}); err != nil {
+			return err
+		}
+	}
+	for _, operation := range operations {
+		event := data.AccountWebhookEvent{Type: data.AccountWebhookOperationEvent, Operation: &operation}
+		if err := enqueue(event, operation.OperationID, operation.Participants); err != nil {
+			return err
+		}
+	}
+
+	if err := p.models.AccountWebhooks.BatchInsertDeliveries(ctx, dbTx, deliveries); err != nil {
+		return fmt.Errorf("adding webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// tssProcessor updates the TSS transactions in the ledger database transaction and routes their final status once it
+// committed, so the webhooks are never notified of a status that was rolled back.
+type tssProcessor struct {
+	tssStore       tssstore.Store
+	tssRouter      tssrouter.Router
+	metricsService metrics.MetricsService
+	// payloads are the payloads of the ledger being processed, routed after it's committed.
+	payloads []tss.Payload
+}
+
+func (p *tssProcessor) Name() string {
+	return TSSLedgerProcessor
+}
+
+func (p *tssProcessor) liveOnly() {}
+
+// ProcessLedger updates the TSS transactions included in the ledger and collects their final status for the webhooks.
+func (p *tssProcessor) ProcessLedger(ctx context.Context, dbTx db.Transaction, ledger LedgerTransactions) error {
+	// a ledger processed again after a rollback starts over
+	p.payloads = nil
+	// Initialize a map to track counts by status
+	statusCounts := make(map[string]float64)
+
+	for _, tx := range ledger.Transactions {
+		if !tx.FeeBump {
+			// because all transactions submitted by TSS are fee bump transactions
+			continue
+		}
+		tssTry, err := p.tssStore.GetTry(ctx, tx.Hash)
+		if err != nil {
+			return fmt.Errorf("error when getting try: %w", err)
+		}
+		if tssTry == (tssstore.Try{}) {
+			continue
+		}
+
+		transaction, err := p.tssStore.GetTransaction(ctx, tssTry.OrigTxHash)
+		if err != nil {
+			return fmt.Errorf("error getting transaction: %w", err)
+		}
+		status := tss.RPCTXStatus{RPCStatus: tx.Status}
+		code, err := tss.TransactionResultXDRToCode(tx.ResultXDR)
+		if err != nil {
+			return fmt.Errorf("error unmarshaling resultxdr: %w", err)
+		}
+		err = p.tssStore.UpsertTryInTx(ctx, dbTx, tssTry.OrigTxHash, tssTry.Hash, tssTry.XDR, status, code, tx.ResultXDR)
+		if err != nil {
+			return fmt.Errorf("error updating try: %w", err)
+		}
+		err = p.tssStore.UpsertTransactionInTx(ctx, dbTx, transaction.WebhookURL, tssTry.OrigTxHash, transaction.XDR, status)
+		if err != nil {
+			return fmt.Errorf("error updating transaction: %w", err)
+		}
+
+		txCode, err := tss.TransactionResultXDRToCode(tx.ResultXDR)
+		if err != nil {
+			return fmt.Errorf("unable to extract tx code from result xdr string: %w", err)
+		}
+
+		tssGetIngestResponse := tss.RPCGetIngestTxResponse{
+			Status:      tx.Status,
+			Code:        txCode,
+			EnvelopeXDR: tx.EnvelopeXDR,
+			ResultXDR:   tx.ResultXDR,
+			CreatedAt:   int64(tx.CreatedAt),
+		}
+		p.payloads = append(p.payloads, tss.Payload{
+			RPCGetIngestTxResponse: tssGetIngestResponse,
+		})
+		// Record the transaction status transition
+		p.metricsService.RecordTSSTransactionStatusTransition(transaction.Status, string(tx.Status))
+
+		// Calculate and record the transaction inclusion time using the transaction's creation time in our system
+		inclusionTime := time.Since(transaction.CreatedAt).Seconds()
+		p.metricsService.ObserveTSSTransactionInclusionTime(string(tx.Status), inclusionTime)
+
+		// Increment the count for this status
+		statusCounts[string(tx.Status)]++
+	}
+
+	// Set the final counts for each status
+	for status, count := range statusCounts {
+		p.metricsService.SetNumTssTransactionsIngestedPerLedger(status, count)
+	}
+	return nil
+}
+
+// afterCommit routes the payloads of the committed ledger. The ledger can't be rolled back anymore, so routing errors
+// are only logged.
+func (p *tssProcessor) afterCommit(ctx context.Context) {
+	for _, payload := range p.payloads {
+		if err := p.tssRouter.Route(payload); err != nil {
+			log.Ctx(ctx).Errorf("routing tss payload: %v", err)
+		}
+	}
+	p.payloads = nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+// runLedgerProcessor runs a single processor on the transactions in its own database transaction.
+func runLedgerProcessor(ctx context.Context, m *ingestService, name string, transactions []entities.Transaction) error {
+	processor, err := m.newLedgerProcessor(name)
+	if err != nil {
+		return err
+	}
+	return m.processLedger(ctx, []LedgerProcessor{processor}, LedgerTransactions{Transactions: transactions}, func(db.Transaction) error {
+		return nil
+	})
+}
+
+func TestRegisterLedgerProcessors(t *testing.T) {
+	processorNames := func(processors []LedgerProcessor) []string {
+		names := make([]string, 0, len(processors))
+		for _, p := range processors {
+			names = append(names, p.Name())
+		}
+		return names
+	}
+
+	testCases := []struct {
+		name                   string
+		processors             []string
+		wantLiveProcessors     []string
+		wantBackfillProcessors []string
+		wantErrContains        string
+	}{
+		{
+			name:                   "🟢defaults",
+			wantLiveProcessors:     []string{"payment", "operations", "balance_changes", "fees", "account_webhooks", "tss"},
+			wantBackfillProcessors: []string{"payment", "operations", "balance_changes", "fees"},
+		},
+		{
+			name:                   "🟢configured_order",
+			processors:             []string{"tss", "balance_changes", "payment"},
+			wantLiveProcessors:     []string{"tss", "balance_changes", "payment"},
+			wantBackfillProcessors: []string{"balance_changes", "payment"},
+		},
+		{
+			name:            "🔴unknown",
+			processors:      []string{"payment", "trades"},
+			wantErrContains: `unknown ledger processor "trades"`,
+		},
+		{
+			name:            "🔴duplicated",
+			processors:      []string{"payment", "operations", "payment"},
+			wantErrContains: `ledger processor "payment" is enabled more than once`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &ingestService{models: &data.Models{}, metricsService: metrics.NewMockMetricsService()}
+			err := m.registerLedgerProcessors(tc.processors)
+			if tc.wantErrContains != "" {
+				assert.ErrorContains(t, err, tc.wantErrContains)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantLiveProcessors, processorNames(m.liveProcessors))
+			assert.Equal(t, tc.wantBackfillProcessors, processorNames(m.backfillProcessors))
+		})
+	}
+}
+
+func TestPaymentsProcessorSharesThePaymentPartitions(t *testing.T) {
+	partitions, err := NewPaymentPartitions(&data.Models{})
+	require.NoError(t, err)
+	m := &ingestService{models: &data.Models{}, paymentPartitions: partitions, metricsService: metrics.NewMockMetricsService()}
+	require.NoError(t, m.registerLedgerProcessors([]string{PaymentsLedgerProcessor}))
+
+	// the partitions detached by the maintenance job are forgotten by the processor too, so it creates them again
+	processor, ok := m.backfillProcessors[0].(*paymentsProcessor)
+	require.True(t, ok)
+	assert.Same(t, partitions, processor.partitions)
+}
+
+type fakeLedgerProcessor struct {
+	name string
+	err  error
+}
+
+func (p *fakeLedgerProcessor) Name() string {
+	return p.name
+}
+
+func (p *fakeLedgerProcessor) ProcessLedger(context.Context, db.Transaction, LedgerTransactions) error {
+	return p.err
+}
+
+func TestTimedLedgerProcessor(t *testing.T) {
+	t.Run("🟢observes_the_duration_under_the_processor_name", func(t *testing.T) {
+		mockMetricsService := metrics.NewMockMetricsService()
+		mockMetricsService.On("ObserveIngestionDuration", "payment", mock.AnythingOfType("float64")).Once()
+		defer mockMetricsService.AssertExpectations(t)
+
+		p := &timedLedgerProcessor{LedgerProcessor: &fakeLedgerProcessor{name: "payment"}, metricsService: mockMetricsService}
+		require.NoError(t, p.ProcessLedger(context.Background(), nil, LedgerTransactions{}))
+	})
+
+	t.Run("🔴failed_runs_are_not_observed", func(t *testing.T) {
+		mockMetricsService := metrics.NewMockMetricsService()
+		defer mockMetricsService.AssertExpectations(t)
+
+		processorErr := errors.New("boom")
+		p := &timedLedgerProcessor{LedgerProcessor: &fakeLedgerProcessor{name: "payment", err: processorErr}, metricsService: mockMetricsService}
+		assert.ErrorIs(t, p.ProcessLedger(context.Background(), nil, LedgerTransactions{}), processorErr)
+	})
+}
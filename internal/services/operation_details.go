@@ -0,0 +1,225 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+// operationDetails decodes the operation body into a JSON friendly map and returns it along with the accounts that
+// participate in the operation, the operation source account included. Operation types that don't have a dedicated
+// decoder still get their base64 encoded body so no information is lost.
+func operationDetails(op xdr.Operation, sourceAccount string) (map[string]any, []string, error) {
+	details := map[string]any{}
+	participants := []string{sourceAccount}
+
+	switch op.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		createAccountOp := op.Body.MustCreateAccountOp()
+		details["account"] = createAccountOp.Destination.Address()
+		details["startingBalance"] = amount.String(createAccountOp.StartingBalance)
+		participants = append(participants, createAccountOp.Destination.Address())
+	case xdr.OperationTypePayment:
+		paymentOp := op.Body.MustPaymentOp()
+		details["to"] = paymentOp.Destination.Address()
+		details["asset"] = paymentOp.Asset.StringCanonical()
+		details["amount"] = amount.String(paymentOp.Amount)
+		participants = append(participants, muxedAccountAddress(paymentOp.Destination))
+	case xdr.OperationTypePathPaymentStrictReceive:
+		pathOp := op.Body.MustPathPaymentStrictReceiveOp()
+		details["to"] = pathOp.Destination.Address()
+		details["sendAsset"] = pathOp.SendAsset.StringCanonical()
+		details["sendMax"] = amount.String(pathOp.SendMax)
+		details["destAsset"] = pathOp.DestAsset.StringCanonical()
+		details["destAmount"] = amount.String(pathOp.DestAmount)
+		details["path"] = assetsToStrings(pathOp.Path)
+		participants = append(participants, muxedAccountAddress(pathOp.Destination))
+	case xdr.OperationTypePathPaymentStrictSend:
+		pathOp := op.Body.MustPathPaymentStrictSendOp()
+		details["to"] = pathOp.Destination.Address()
+		details["sendAsset"] = pathOp.SendAsset.StringCanonical()
+		details["sendAmount"] = amount.String(pathOp.SendAmount)
+		details["destAsset"] = pathOp.DestAsset.StringCanonical()
+		details["destMin"] = amount.String(pathOp.DestMin)
+		details["path"] = assetsToStrings(pathOp.Path)
+		participants = append(participants, muxedAccountAddress(pathOp.Destination))
+	case xdr.OperationTypeManageSellOffer:
+		offerOp := op.Body.MustManageSellOfferOp()
+		details["offerId"] = int64(offerOp.OfferId)
+		details["selling"] = offerOp.Selling.StringCanonical()
+		details["buying"] = offerOp.Buying.StringCanonical()
+		details["amount"] = amount.String(offerOp.Amount)
+		details["price"] = priceString(offerOp.Price)
+	case xdr.OperationTypeManageBuyOffer:
+		offerOp := op.Body.MustManageBuyOfferOp()
+		details["offerId"] = int64(offerOp.OfferId)
+		details["selling"] = offerOp.Selling.StringCanonical()
+		details["buying"] = offerOp.Buying.StringCanonical()
+		details["buyAmount"] = amount.String(offerOp.BuyAmount)
+		details["price"] = priceString(offerOp.Price)
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		offerOp := op.Body.MustCreatePassiveSellOfferOp()
+		details["selling"] = offerOp.Selling.StringCanonical()
+		details["buying"] = offerOp.Buying.StringCanonical()
+		details["amount"] = amount.String(offerOp.Amount)
+		details["price"] = priceString(offerOp.Price)
+	case xdr.OperationTypeSetOptions:
+		setOptionsOp := op.Body.MustSetOptionsOp()
+		if setOptionsOp.InflationDest != nil {
+			details["inflationDest"] = setOptionsOp.InflationDest.Address()
+		}
+		if setOptionsOp.SetFlags != nil {
+			details["setFlags"] = uint32(*setOptionsOp.SetFlags)
+		}
+		if setOptionsOp.ClearFlags != nil {
+			details["clearFlags"] = uint32(*setOptionsOp.ClearFlags)
+		}
+		if setOptionsOp.MasterWeight != nil {
+			details["masterKeyWeight"] = uint32(*setOptionsOp.MasterWeight)
+		}
+		if setOptionsOp.LowThreshold != nil {
+			details["lowThreshold"] = uint32(*setOptionsOp.LowThreshold)
+		}
+		if setOptionsOp.MedThreshold != nil {
+			details["medThreshold"] = uint32(*setOptionsOp.MedThreshold)
+		}
+		if setOptionsOp.HighThreshold != nil {
+			details["highThreshold"] = uint32(*setOptionsOp.HighThreshold)
+		}
+		if setOptionsOp.HomeDomain != nil {
+			details["homeDomain"] = string(*setOptionsOp.HomeDomain)
+		}
+		if setOptionsOp.Signer != nil {
+			details["signerKey"] = setOptionsOp.Signer.Key.Address()
+			details["signerWeight"] = uint32(setOptionsOp.Signer.Weight)
+		}
+	case xdr.OperationTypeChangeTrust:
+		changeTrustOp := op.Body.MustChangeTrustOp()
+		if changeTrustOp.Line.Type == xdr.AssetTypeAssetTypePoolShare {
+			params := changeTrustOp.Line.MustLiquidityPool().MustConstantProduct()
+			poolID, err := xdr.NewPoolId(params.AssetA, params.AssetB, params.Fee)
+			if err != nil {
+				return nil, nil, fmt.Errorf("computing liquidity pool id: %w", err)
+			}
+			details["liquidityPoolId"] = hex.EncodeToString(poolID[:])
+		} else {
+			details["asset"] = changeTrustOp.Line.ToAsset().StringCanonical()
+		}
+		details["limit"] = amount.String(changeTrustOp.Limit)
+	case xdr.OperationTypeAllowTrust:
+		allowTrustOp := op.Body.MustAllowTrustOp()
+		details["trustor"] = allowTrustOp.Trustor.Address()
+		// the asset issuer is always the operation source account
+		details["asset"] = allowTrustOp.Asset.ToAsset(xdr.MustAddress(sourceAccount)).StringCanonical()
+		details["authorize"] = uint32(allowTrustOp.Authorize)
+		participants = append(participants, allowTrustOp.Trustor.Address())
+	case xdr.OperationTypeAccountMerge:
+		destination := op.Body.MustDestination()
+		details["into"] = destination.Address()
+		participants = append(participants, muxedAccountAddress(destination))
+	case xdr.OperationTypeManageData:
+		manageDataOp := op.Body.MustManageDataOp()
+		details["name"] = string(manageDataOp.DataName)
+		if manageDataOp.DataValue != nil {
+			details["value"] = base64.StdEncoding.EncodeToString(*manageDataOp.DataValue)
+		}
+	case xdr.OperationTypeBumpSequence:
+		details["bumpTo"] = int64(op.Body.MustBumpSequenceOp().BumpTo)
+	case xdr.OperationTypeCreateClaimableBalance:
+		createOp := op.Body.MustCreateClaimableBalanceOp()
+		claimants := make([]string, 0, len(createOp.Claimants))
+		for _, claimant := range createOp.Claimants {
+			destination := claimant.MustV0().Destination.Address()
+			claimants = append(claimants, destination)
+			participants = append(participants, destination)
+		}
+		details["asset"] = createOp.Asset.StringCanonical()
+		details["amount"] = amount.String(createOp.Amount)
+		details["claimants"] = claimants
+	case xdr.OperationTypeClaimClaimableBalance:
+		balanceID, err := op.Body.MustClaimClaimableBalanceOp().BalanceId.EncodeToStrkey()
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding claimable balance id: %w", err)
+		}
+		details["balanceId"] = balanceID
+	case xdr.OperationTypeClawbackClaimableBalance:
+		balanceID, err := op.Body.MustClawbackClaimableBalanceOp().BalanceId.EncodeToStrkey()
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding claimable balance id: %w", err)
+		}
+		details["balanceId"] = balanceID
+	case xdr.OperationTypeBeginSponsoringFutureReserves:
+		sponsoredID := op.Body.MustBeginSponsoringFutureReservesOp().SponsoredId.Address()
+		details["sponsoredId"] = sponsoredID
+		participants = append(participants, sponsoredID)
+	case xdr.OperationTypeClawback:
+		clawbackOp := op.Body.MustClawbackOp()
+		details["from"] = clawbackOp.From.Address()
+		details["asset"] = clawbackOp.Asset.StringCanonical()
+		details["amount"] = amount.String(clawbackOp.Amount)
+		participants = append(participants, muxedAccountAddress(clawbackOp.From))
+	case xdr.OperationTypeSetTrustLineFlags:
+		flagsOp := op.Body.MustSetTrustLineFlagsOp()
+		details["trustor"] = flagsOp.Trustor.Address()
+		details["asset"] = flagsOp.Asset.StringCanonical()
+		details["setFlags"] = uint32(flagsOp.SetFlags)
+		details["clearFlags"] = uint32(flagsOp.ClearFlags)
+		participants = append(participants, flagsOp.Trustor.Address())
+	case xdr.OperationTypeLiquidityPoolDeposit:
+		depositOp := op.Body.MustLiquidityPoolDepositOp()
+		details["liquidityPoolId"] = hex.EncodeToString(depositOp.LiquidityPoolId[:])
+		details["maxAmountA"] = amount.String(depositOp.MaxAmountA)
+		details["maxAmountB"] = amount.String(depositOp.MaxAmountB)
+		details["minPrice"] = priceString(depositOp.MinPrice)
+		details["maxPrice"] = priceString(depositOp.MaxPrice)
+	case xdr.OperationTypeLiquidityPoolWithdraw:
+		withdrawOp := op.Body.MustLiquidityPoolWithdrawOp()
+		details["liquidityPoolId"] = hex.EncodeToString(withdrawOp.LiquidityPoolId[:])
+		details["amount"] = amount.String(withdrawOp.Amount)
+		details["minAmountA"] = amount.String(withdrawOp.MinAmountA)
+		details["minAmountB"] = amount.String(withdrawOp.MinAmountB)
+	case xdr.OperationTypeEndSponsoringFutureReserves, xdr.OperationTypeInflation:
+		// these operations have no body
+	default:
+		bodyXDR, err := xdr.MarshalBase64(op.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding %s operation body: %w", op.Body.Type.String(), err)
+		}
+		details["bodyXdr"] = bodyXDR
+	}
+
+	return details, uniqueAddresses(participants), nil
+}
+
+func muxedAccountAddress(account xdr.MuxedAccount) string {
+	accountID := account.ToAccountId()
+	return accountID.Address()
+}
+
+func assetsToStrings(assets []xdr.Asset) []string {
+	result := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		result = append(result, asset.StringCanonical())
+	}
+	return result
+}
+
+func priceString(price xdr.Price) string {
+	return fmt.Sprintf("%d/%d", price.N, price.D)
+}
+
+func uniqueAddresses(addresses []string) []string {
+	seen := make(map[string]struct{}, len(addresses))
+	result := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if _, ok := seen[address]; ok {
+			continue
+		}
+		seen[address] = struct{}{}
+		result = append(result, address)
+	}
+	return result
+}
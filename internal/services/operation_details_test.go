@@ -0,0 +1,163 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationDetails(t *testing.T) {
+	source := keypair.MustRandom().Address()
+	destination := keypair.MustRandom().Address()
+	issuer := keypair.MustRandom().Address()
+	usdc := xdr.MustNewCreditAsset("USDC", issuer)
+
+	muxedDestination, err := xdr.MuxedAccountFromAccountId(destination, 123)
+	require.NoError(t, err)
+	homeDomain := xdr.String32("example.com")
+	dataValue := xdr.DataValue("value")
+
+	testCases := []struct {
+		name                 string
+		body                 xdr.OperationBody
+		expectedDetails      map[string]any
+		expectedParticipants []string
+	}{
+		{
+			name: "payment_to_muxed_account",
+			body: xdr.OperationBody{
+				Type:      xdr.OperationTypePayment,
+				PaymentOp: &xdr.PaymentOp{Destination: muxedDestination, Asset: usdc, Amount: 100_0000000},
+			},
+			expectedDetails: map[string]any{
+				"to":     muxedDestination.Address(),
+				"asset":  "USDC:" + issuer,
+				"amount": "100.0000000",
+			},
+			expectedParticipants: []string{source, destination},
+		},
+		{
+			name: "change_trust",
+			body: xdr.OperationBody{
+				Type: xdr.OperationTypeChangeTrust,
+				ChangeTrustOp: &xdr.ChangeTrustOp{
+					Line:  usdc.ToChangeTrustAsset(),
+					Limit: 1000_0000000,
+				},
+			},
+			expectedDetails: map[string]any{
+				"asset": "USDC:" + issuer,
+				"limit": "1000.0000000",
+			},
+			expectedParticipants: []string{source},
+		},
+		{
+			name: "set_options",
+			body: xdr.OperationBody{
+				Type:         xdr.OperationTypeSetOptions,
+				SetOptionsOp: &xdr.SetOptionsOp{HomeDomain: &homeDomain},
+			},
+			expectedDetails:      map[string]any{"homeDomain": "example.com"},
+			expectedParticipants: []string{source},
+		},
+		{
+			name: "manage_data",
+			body: xdr.OperationBody{
+				Type:         xdr.OperationTypeManageData,
+				ManageDataOp: &xdr.ManageDataOp{DataName: "key", DataValue: &dataValue},
+			},
+			expectedDetails:      map[string]any{"name": "key", "value": "dmFsdWU="},
+			expectedParticipants: []string{source},
+		},
+		{
+			name: "account_merge_into_self",
+			body: xdr.OperationBody{
+				Type:        xdr.OperationTypeAccountMerge,
+				Destination: muxedAccountPointer(t, source),
+			},
+			expectedDetails:      map[string]any{"into": source},
+			expectedParticipants: []string{source},
+		},
+		{
+			name: "manage_sell_offer",
+			body: xdr.OperationBody{
+				Type: xdr.OperationTypeManageSellOffer,
+				ManageSellOfferOp: &xdr.ManageSellOfferOp{
+					Selling: xdr.MustNewNativeAsset(),
+					Buying:  usdc,
+					Amount:  5_0000000,
+					Price:   xdr.Price{N: 1, D: 2},
+					OfferId: 7,
+				},
+			},
+			expectedDetails: map[string]any{
+				"offerId": int64(7),
+				"selling": "native",
+				"buying":  "USDC:" + issuer,
+				"amount":  "5.0000000",
+				"price":   "1/2",
+			},
+			expectedParticipants: []string{source},
+		},
+		{
+			name: "create_claimable_balance",
+			body: xdr.OperationBody{
+				Type: xdr.OperationTypeCreateClaimableBalance,
+				CreateClaimableBalanceOp: &xdr.CreateClaimableBalanceOp{
+					Asset:  usdc,
+					Amount: 1_0000000,
+					Claimants: []xdr.Claimant{{
+						Type: xdr.ClaimantTypeClaimantTypeV0,
+						V0: &xdr.ClaimantV0{
+							Destination: xdr.MustAddress(destination),
+							Predicate:   xdr.ClaimPredicate{Type: xdr.ClaimPredicateTypeClaimPredicateUnconditional},
+						},
+					}},
+				},
+			},
+			expectedDetails: map[string]any{
+				"asset":     "USDC:" + issuer,
+				"amount":    "1.0000000",
+				"claimants": []string{destination},
+			},
+			expectedParticipants: []string{source, destination},
+		},
+		{
+			name:                 "end_sponsoring_future_reserves",
+			body:                 xdr.OperationBody{Type: xdr.OperationTypeEndSponsoringFutureReserves},
+			expectedDetails:      map[string]any{},
+			expectedParticipants: []string{source},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			details, participants, err := operationDetails(xdr.Operation{Body: tc.body}, source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedDetails, details)
+			assert.Equal(t, tc.expectedParticipants, participants)
+		})
+	}
+
+	t.Run("undecoded_operation_keeps_its_xdr", func(t *testing.T) {
+		body := xdr.OperationBody{
+			Type:               xdr.OperationTypeRestoreFootprint,
+			RestoreFootprintOp: &xdr.RestoreFootprintOp{},
+		}
+		details, participants, err := operationDetails(xdr.Operation{Body: body}, source)
+		require.NoError(t, err)
+		expectedXDR, err := xdr.MarshalBase64(body)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"bodyXdr": expectedXDR}, details)
+		assert.Equal(t, []string{source}, participants)
+	})
+}
+
+func muxedAccountPointer(t *testing.T, address string) *xdr.MuxedAccount {
+	t.Helper()
+	muxedAccount := xdr.MustMuxedAddress(address)
+	return &muxedAccount
+}
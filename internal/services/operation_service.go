@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/entities"
+)
+
+type OperationService interface {
+	GetAccountOperationsPaginated(ctx context.Context, address string, beforeID, afterID string, sort data.SortOrder, limit int) ([]data.Operation, entities.Pagination, error)
+}
+
+var _ OperationService = (*operationService)(nil)
+
+type operationService struct {
+	models        *data.Models
+	serverBaseURL string
+}
+
+func NewOperationService(models *data.Models, serverBaseURL string) (*operationService, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+
+	if _, err := url.ParseRequestURI(serverBaseURL); err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", serverBaseURL, err)
+	}
+
+	return &operationService{
+		models:        models,
+		serverBaseURL: serverBaseURL,
+	}, nil
+}
+
+func (s *operationService) GetAccountOperationsPaginated(ctx context.Context, address string, beforeID, afterID string, sort data.SortOrder, limit int) ([]data.Operation, entities.Pagination, error) {
+	operations, prevExists, nextExists, err := s.models.Operations.GetOperationsPaginated(ctx, address, beforeID, afterID, sort, limit)
+	if err != nil {
+		return nil, entities.Pagination{}, fmt.Errorf("getting operations: %w", err)
+	}
+
+	self, prev, next := "", "", ""
+	self, err = buildAccountOperationsURL(s.serverBaseURL, address, beforeID, afterID, sort, limit)
+	if err != nil {
+		return nil, entities.Pagination{}, fmt.Errorf("building self link: %w", err)
+	}
+
+	if prevExists {
+		firstElementID := data.FirstOperationID(operations)
+		prev, err = buildAccountOperationsURL(s.serverBaseURL, address, firstElementID, "", sort, limit)
+		if err != nil {
+			return nil, entities.Pagination{}, fmt.Errorf("building prev link: %w", err)
+		}
+	}
+
+	if nextExists {
+		lastElementID := data.LastOperationID(operations)
+		next, err = buildAccountOperationsURL(s.serverBaseURL, address, "", lastElementID, sort, limit)
+		if err != nil {
+			return nil, entities.Pagination{}, fmt.Errorf("building next link: %w", err)
+		}
+	}
+
+	pagination := entities.Pagination{
+		Links: entities.PaginationLinks{
+			Self: self,
+			Prev: prev,
+			Next: next,
+		},
+	}
+	return operations, pagination, nil
+}
+
+func buildAccountOperationsURL(baseURL string, address string, beforeID, afterID string, sort data.SortOrder, limit int) (string, error) {
+	url, err := url.ParseRequestURI(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing base URL: %s: %w", baseURL, err)
+	}
+	url = url.JoinPath("accounts", address, "operations")
+
+	values := url.Query()
+	values.Add("sort", string(sort))
+	values.Add("limit", strconv.Itoa(limit))
+	if beforeID != "" {
+		values.Add("beforeId", beforeID)
+	}
+	if afterID != "" {
+		values.Add("afterId", afterID)
+	}
+	url.RawQuery = values.Encode()
+
+	return url.String(), nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/entities"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestOperationServiceGetAccountOperationsPaginated(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_operations", mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_operations")
+
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	service, err := NewOperationService(models, "http://testing.com")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	address := keypair.MustRandom().Address()
+	for i := 1; i <= 3; i++ {
+		_, err = dbConnectionPool.ExecContext(ctx, `
+			INSERT INTO ingest_operations (operation_id, operation_type, transaction_id, transaction_hash, source_account, details, participants, created_at)
+			VALUES ($1, 'OperationTypeBumpSequence', $1, 'hash', $2, '{}', ARRAY[$2], NOW())
+		`, i, address)
+		require.NoError(t, err)
+	}
+
+	t.Run("page_1", func(t *testing.T) {
+		operations, pagination, err := service.GetAccountOperationsPaginated(ctx, address, "", "", data.DESC, 2)
+		require.NoError(t, err)
+
+		require.Len(t, operations, 2)
+		assert.Equal(t, "3", operations[0].OperationID)
+		assert.Equal(t, "2", operations[1].OperationID)
+		assert.Equal(t, entities.Pagination{
+			Links: entities.PaginationLinks{
+				Self: fmt.Sprintf("http://testing.com/accounts/%s/operations?limit=2&sort=DESC", address),
+				Prev: "",
+				Next: fmt.Sprintf("http://testing.com/accounts/%s/operations?afterId=2&limit=2&sort=DESC", address),
+			},
+		}, pagination)
+	})
+
+	t.Run("page_2", func(t *testing.T) {
+		operations, pagination, err := service.GetAccountOperationsPaginated(ctx, address, "", "2", data.DESC, 2)
+		require.NoError(t, err)
+
+		require.Len(t, operations, 1)
+		assert.Equal(t, "1", operations[0].OperationID)
+		assert.Equal(t, entities.Pagination{
+			Links: entities.PaginationLinks{
+				Self: fmt.Sprintf("http://testing.com/accounts/%s/operations?afterId=2&limit=2&sort=DESC", address),
+				Prev: fmt.Sprintf("http://testing.com/accounts/%s/operations?beforeId=1&limit=2&sort=DESC", address),
+				Next: "",
+			},
+		}, pagination)
+	})
+}
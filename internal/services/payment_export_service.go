@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go/amount"
+
+	"github.com/stellar/wallet-backend/internal/data"
+)
+
+type PaymentExportFormat string
+
+const (
+	PaymentExportCSV   PaymentExportFormat = "csv"
+	PaymentExportJSONL PaymentExportFormat = "jsonl"
+)
+
+func (f PaymentExportFormat) IsValid() bool {
+	return f == PaymentExportCSV || f == PaymentExportJSONL
+}
+
+// ContentType is the media type of the exports in the format.
+func (f PaymentExportFormat) ContentType() string {
+	if f == PaymentExportJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// paymentExportBatchSize is how many payments are read and written at once.
+const paymentExportBatchSize = 1000
+
+// PaymentExportRecord is an exported payment. Amounts are decimal strings and assets are "CODE:ISSUER", or just the
+// code when there's no issuer, e.g. "XLM".
+type PaymentExportRecord struct {
+	OperationID      string  `json:"operationId"`
+	EventIndex       int32   `json:"eventIndex"`
+	OperationType    string  `json:"operationType"`
+	TransactionHash  string  `json:"transactionHash"`
+	CreatedAt        string  `json:"createdAt"`
+	FromAddress      string  `json:"fromAddress"`
+	FromMuxedAddress *string `json:"fromMuxedAddress"`
+	ToAddress        string  `json:"toAddress"`
+	ToMuxedAddress   *string `json:"toMuxedAddress"`
+	SrcAsset         string  `json:"srcAsset"`
+	SrcAmount        string  `json:"srcAmount"`
+	DestAsset        string  `json:"destAsset"`
+	DestAmount       string  `json:"destAmount"`
+	MemoType         string  `json:"memoType"`
+	Memo             *string `json:"memo"`
+}
+
+var paymentExportCSVHeader = []string{
+	"operation_id", "event_index", "operation_type", "transaction_hash", "created_at", "from_address", "from_muxed_address", "to_address",
+	"to_muxed_address", "src_asset", "src_amount", "dest_asset", "dest_amount", "memo_type", "memo",
+}
+
+func (r PaymentExportRecord) csvRow() []string {
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return []string{
+		r.OperationID, strconv.FormatInt(int64(r.EventIndex), 10), r.OperationType, r.TransactionHash, r.CreatedAt, r.FromAddress, optional(r.FromMuxedAddress), r.ToAddress,
+		optional(r.ToMuxedAddress), r.SrcAsset, r.SrcAmount, r.DestAsset, r.DestAmount, r.MemoType, optional(r.Memo),
+	}
+}
+
+func NewPaymentExportRecord(payment data.Payment) PaymentExportRecord {
+	return PaymentExportRecord{
+		OperationID:      payment.OperationID,
+		EventIndex:       payment.EventIndex,
+		OperationType:    payment.OperationType,
+		TransactionHash:  payment.TransactionHash,
+		CreatedAt:        payment.CreatedAt.UTC().Format(time.RFC3339),
+		FromAddress:      payment.FromAddress,
+		FromMuxedAddress: payment.FromMuxedAddress,
+		ToAddress:        payment.ToAddress,
+		ToMuxedAddress:   payment.ToMuxedAddress,
+		SrcAsset:         exportAsset(payment.SrcAssetCode, payment.SrcAssetIssuer),
+		SrcAmount:        amount.StringFromInt64(payment.SrcAmount),
+		DestAsset:        exportAsset(payment.DestAssetCode, payment.DestAssetIssuer),
+		DestAmount:       amount.StringFromInt64(payment.DestAmount),
+		MemoType:         payment.MemoType,
+		Memo:             payment.Memo,
+	}
+}
+
+func exportAsset(code, issuer string) string {
+	if issuer == "" {
+		return code
+	}
+	return code + ":" + issuer
+}
+
+type PaymentExportService interface {
+	// ExportPayments writes the successful payments matching the filter to w in ascending order, and returns how many
+	// were written. Nothing is written to w until the first batch of payments is read, so when none were written the
+	// export can still be reported as failed.
+	ExportPayments(ctx context.Context, w io.Writer, filter data.PaymentsFilter, format PaymentExportFormat) (int, error)
+}
+
+var _ PaymentExportService = (*paymentExportService)(nil)
+
+type paymentExportService struct {
+	models *data.Models
+}
+
+func NewPaymentExportService(models *data.Models) (*paymentExportService, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+
+	return &paymentExportService{
+		models: models,
+	}, nil
+}
+
+func (s *paymentExportService) ExportPayments(ctx context.Context, w io.Writer, filter data.PaymentsFilter, format PaymentExportFormat) (int, error) {
+	if !format.IsValid() {
+		return 0, fmt.Errorf("invalid export format %q", format)
+	}
+	filter.IncludeFailed = false
+
+	csvWriter := csv.NewWriter(w)
+	jsonEncoder := json.NewEncoder(w)
+	exported := 0
+	cursor := ""
+	for {
+		payments, err := s.models.Payments.GetPaymentsAfter(ctx, filter, cursor, paymentExportBatchSize)
+		if err != nil {
+			return exported, fmt.Errorf("getting payments after %q: %w", cursor, err)
+		}
+		if cursor == "" && format == PaymentExportCSV {
+			if err := csvWriter.Write(paymentExportCSVHeader); err != nil {
+				return exported, fmt.Errorf("writing the CSV header: %w", err)
+			}
+		}
+
+		for _, payment := range payments {
+			record := NewPaymentExportRecord(payment)
+			if format == PaymentExportCSV {
+				err = csvWriter.Write(record.csvRow())
+			} else {
+				err = jsonEncoder.Encode(record)
+			}
+			if err != nil {
+				return exported, fmt.Errorf("writing payment %s: %w", payment.ID(), err)
+			}
+			exported++
+			cursor = payment.ID()
+		}
+		if format == PaymentExportCSV {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return exported, fmt.Errorf("flushing payments: %w", err)
+			}
+		}
+
+		if len(payments) < paymentExportBatchSize {
+			return exported, nil
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestNewPaymentExportRecord(t *testing.T) {
+	memo := "invoice 42"
+	toMuxedAddress := "MA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJUAAAAAAAAAAAACJUQ"
+	record := NewPaymentExportRecord(data.Payment{
+		OperationID:     "4294967297",
+		OperationType:   xdr.OperationTypePathPaymentStrictSend.String(),
+		TransactionHash: "hash",
+		FromAddress:     "GFROM",
+		ToAddress:       "GTO",
+		ToMuxedAddress:  &toMuxedAddress,
+		SrcAssetCode:    "XLM",
+		SrcAmount:       10_000_000,
+		DestAssetCode:   "USDC",
+		DestAssetIssuer: "GISSUER",
+		DestAmount:      1_234_567,
+		CreatedAt:       time.Date(2024, 6, 21, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		MemoType:        xdr.MemoTypeMemoText.String(),
+		Memo:            &memo,
+	})
+
+	assert.Equal(t, PaymentExportRecord{
+		OperationID:     "4294967297",
+		OperationType:   "OperationTypePathPaymentStrictSend",
+		TransactionHash: "hash",
+		CreatedAt:       "2024-06-21T10:30:00Z",
+		FromAddress:     "GFROM",
+		ToAddress:       "GTO",
+		ToMuxedAddress:  &toMuxedAddress,
+		SrcAsset:        "XLM",
+		SrcAmount:       "1.0000000",
+		DestAsset:       "USDC:GISSUER",
+		DestAmount:      "0.1234567",
+		MemoType:        "MemoTypeMemoText",
+		Memo:            &memo,
+	}, record)
+	assert.Equal(t, []string{
+		"4294967297", "0", "OperationTypePathPaymentStrictSend", "hash", "2024-06-21T10:30:00Z", "GFROM", "", "GTO", toMuxedAddress,
+		"XLM", "1.0000000", "USDC:GISSUER", "0.1234567", "MemoTypeMemoText", "invoice 42",
+	}, record.csvRow())
+}
+
+func TestPaymentExportServiceExportPayments(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", "SELECT", "ingest_payments", mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments")
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	service, err := NewPaymentExportService(models)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	address := keypair.MustRandom().Address()
+	otherAddress := keypair.MustRandom().Address()
+	newPayment := func(operationID, from, to string, day int, successful bool) data.Payment {
+		return data.Payment{
+			OperationID:   operationID,
+			OperationType: xdr.OperationTypePayment.String(),
+			TransactionID: operationID,
+			FromAddress:   from,
+			ToAddress:     to,
+			SrcAssetCode:  "XLM",
+			SrcAssetType:  xdr.AssetTypeAssetTypeNative.String(),
+			SrcAmount:     25_000_000,
+			DestAssetCode: "XLM",
+			DestAssetType: xdr.AssetTypeAssetTypeNative.String(),
+			DestAmount:    25_000_000,
+			CreatedAt:     time.Date(2024, 6, day, 0, 0, 0, 0, time.UTC),
+			MemoType:      xdr.MemoTypeMemoNone.String(),
+			Successful:    successful,
+		}
+	}
+	data.InsertTestPayments(t, ctx, []data.Payment{
+		newPayment("1", address, otherAddress, 1, true),
+		newPayment("2", otherAddress, address, 2, true),
+		newPayment("3", address, otherAddress, 3, false),
+		newPayment("4", otherAddress, otherAddress, 4, true),
+		newPayment("5", otherAddress, address, 5, true),
+	}, dbConnectionPool)
+
+	t.Run("🟢csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		exported, err := service.ExportPayments(ctx, &buf, data.PaymentsFilter{Address: address}, PaymentExportCSV)
+		require.NoError(t, err)
+		assert.Equal(t, 3, exported)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 4)
+		assert.Equal(t, "operation_id,event_index,operation_type,transaction_hash,created_at,from_address,from_muxed_address,to_address,to_muxed_address,src_asset,src_amount,dest_asset,dest_amount,memo_type,memo", lines[0])
+		assert.Equal(t, "1,0,OperationTypePayment,,2024-06-01T00:00:00Z,"+address+",,"+otherAddress+",,XLM,2.5000000,XLM,2.5000000,MemoTypeMemoNone,", lines[1])
+		assert.True(t, strings.HasPrefix(lines[2], "2,"))
+		assert.True(t, strings.HasPrefix(lines[3], "5,"))
+	})
+
+	t.Run("🟢jsonl_time_range", func(t *testing.T) {
+		from := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)
+		var buf bytes.Buffer
+		exported, err := service.ExportPayments(ctx, &buf, data.PaymentsFilter{Address: address, From: &from, To: &to}, PaymentExportJSONL)
+		require.NoError(t, err)
+		assert.Equal(t, 1, exported)
+		assert.JSONEq(t, `{
+			"operationId": "2", "eventIndex": 0, "operationType": "OperationTypePayment", "transactionHash": "", "createdAt": "2024-06-02T00:00:00Z",
+			"fromAddress": "`+otherAddress+`", "fromMuxedAddress": null, "toAddress": "`+address+`", "toMuxedAddress": null,
+			"srcAsset": "XLM", "srcAmount": "2.5000000", "destAsset": "XLM", "destAmount": "2.5000000", "memoType": "MemoTypeMemoNone", "memo": null
+		}`, buf.String())
+	})
+
+	t.Run("🟢empty_csv_has_a_header", func(t *testing.T) {
+		var buf bytes.Buffer
+		exported, err := service.ExportPayments(ctx, &buf, data.PaymentsFilter{Address: keypair.MustRandom().Address()}, PaymentExportCSV)
+		require.NoError(t, err)
+		assert.Zero(t, exported)
+		assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	})
+
+	t.Run("🔴invalid_format", func(t *testing.T) {
+		_, err := service.ExportPayments(ctx, &bytes.Buffer{}, data.PaymentsFilter{}, "xlsx")
+		assert.ErrorContains(t, err, `invalid export format "xlsx"`)
+	})
+}
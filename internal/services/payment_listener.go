@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/internal/data"
+)
+
+const (
+	paymentListenerMinReconnectInterval = time.Second
+	paymentListenerMaxReconnectInterval = time.Minute
+	// paymentListenerPingInterval is how often the listener connection is checked, since a dropped connection is only
+	// noticed when it's used.
+	paymentListenerPingInterval = 90 * time.Second
+)
+
+// PaymentSubscriber wakes the payment streams up when payments are ingested.
+type PaymentSubscriber interface {
+	// Subscribe returns a channel receiving a value whenever payments may have been ingested, and a function ending the
+	// subscription. Wake-ups are coalesced, so subscribers must read every payment after the last one they've seen.
+	Subscribe() (<-chan struct{}, func())
+}
+
+var _ PaymentSubscriber = (*PaymentListener)(nil)
+
+// PaymentListener listens to the payments notified by the ingestion, which runs in another process, and wakes its
+// subscribers up.
+type PaymentListener struct {
+	listener *pq.Listener
+
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+func NewPaymentListener(databaseURL string) (*PaymentListener, error) {
+	listener := pq.NewListener(databaseURL, paymentListenerMinReconnectInterval, paymentListenerMaxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Errorf("payment listener event %d: %v", event, err)
+		}
+	})
+	if err := listener.Listen(data.PaymentsNotificationChannel); err != nil {
+		//nolint:errcheck // the listen error is the one worth returning
+		listener.Close()
+		return nil, fmt.Errorf("listening to %s: %w", data.PaymentsNotificationChannel, err)
+	}
+
+	return &PaymentListener{
+		listener:    listener,
+		subscribers: map[chan struct{}]struct{}{},
+	}, nil
+}
+
+// Run wakes the subscribers up on every notification until the context is done or the listener is closed.
+func (l *PaymentListener) Run(ctx context.Context) {
+	ticker := time.NewTicker(paymentListenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-l.listener.Notify:
+			if !ok {
+				return
+			}
+			// a nil notification is sent after reconnecting, notifications may have been missed in between so the
+			// subscribers are woken up too
+			l.broadcast()
+		case <-ticker.C:
+			go func() {
+				if err := l.listener.Ping(); err != nil {
+					log.Ctx(ctx).Warnf("pinging the payment listener connection: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+func (l *PaymentListener) Subscribe() (<-chan struct{}, func()) {
+	wakeUps := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.subscribers[wakeUps] = struct{}{}
+	l.mu.Unlock()
+
+	return wakeUps, func() {
+		l.mu.Lock()
+		delete(l.subscribers, wakeUps)
+		l.mu.Unlock()
+	}
+}
+
+func (l *PaymentListener) broadcast() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for wakeUps := range l.subscribers {
+		// a pending wake-up already covers this one
+		select {
+		case wakeUps <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (l *PaymentListener) Close() error {
+	if err := l.listener.Close(); err != nil {
+		return fmt.Errorf("closing the payment listener: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+)
+
+func TestPaymentListener(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := NewPaymentListener(dbt.DSN)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, listener.Close()) }()
+	go listener.Run(ctx)
+
+	wakeUps, unsubscribe := listener.Subscribe()
+	defer unsubscribe()
+	unsubscribedWakeUps, unsubscribeOther := listener.Subscribe()
+	unsubscribeOther()
+
+	_, err = dbConnectionPool.ExecContext(ctx, `SELECT pg_notify($1, '1')`, data.PaymentsNotificationChannel)
+	require.NoError(t, err)
+	select {
+	case <-wakeUps:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscriber wasn't woken up")
+	}
+	assert.Empty(t, unsubscribedWakeUps)
+}
+
+func TestPaymentListenerBroadcast(t *testing.T) {
+	listener := &PaymentListener{subscribers: map[chan struct{}]struct{}{}}
+	wakeUps, unsubscribe := listener.Subscribe()
+	defer unsubscribe()
+
+	// wake-ups are coalesced, so broadcasting never blocks on slow subscribers
+	listener.broadcast()
+	listener.broadcast()
+	assert.Len(t, wakeUps, 1)
+	<-wakeUps
+	assert.Empty(t, wakeUps)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/internal/data"
+)
+
+// PaymentPartitions creates the ingest_payments partitions before the transactions of their ledgers open, and
+// detaches the old ones. Payments ingested without a partition land in the default one, so a missing partition slows
+// queries down but never fails the ingestion.
+type PaymentPartitions struct {
+	models *data.Models
+
+	mu sync.Mutex
+	// attached caches the partitions known to be attached, so ingesting a ledger rarely hits the catalog.
+	attached map[string]struct{}
+}
+
+func NewPaymentPartitions(models *data.Models) (*PaymentPartitions, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+
+	return &PaymentPartitions{
+		models:   models,
+		attached: map[string]struct{}{},
+	}, nil
+}
+
+// Ensure creates the partition of the ledger unless it's attached already.
+func (p *PaymentPartitions) Ensure(ctx context.Context, ledger uint32) error {
+	partition := data.PaymentPartitionFor(ledger)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.attached[partition.Name]; ok {
+		return nil
+	}
+
+	created, err := p.models.Payments.CreatePaymentPartition(ctx, partition)
+	if err != nil {
+		return fmt.Errorf("creating the partition of ledger %d: %w", ledger, err)
+	}
+	if created {
+		log.Ctx(ctx).Infof("created payment partition %s for ledgers [%d, %d)", partition.Name, partition.StartLedger, partition.EndLedger)
+	}
+	p.attached[partition.Name] = struct{}{}
+	return nil
+}
+
+// Maintain creates the partition of latestLedger and the ahead partitions after it, and detaches the partitions older
+// than the retained most recent ones. Retaining zero partitions never detaches any.
+func (p *PaymentPartitions) Maintain(ctx context.Context, latestLedger uint32, ahead, retained int) error {
+	for i := 0; i <= ahead; i++ {
+		ledger := uint64(latestLedger) + uint64(i)*data.PaymentsPartitionLedgers
+		if ledger > uint64(^uint32(0)) {
+			break
+		}
+		if err := p.Ensure(ctx, uint32(ledger)); err != nil {
+			return err
+		}
+	}
+
+	if retained <= 0 {
+		return nil
+	}
+	// the oldest partition kept is retained-1 partitions before the current one
+	oldestRetainedLedger := int64(data.PaymentPartitionFor(latestLedger).StartLedger) - int64(retained-1)*data.PaymentsPartitionLedgers
+	partitions, err := p.models.Payments.GetPaymentPartitions(ctx)
+	if err != nil {
+		return fmt.Errorf("getting payment partitions: %w", err)
+	}
+	for _, partition := range partitions {
+		if int64(partition.StartLedger) >= oldestRetainedLedger {
+			break
+		}
+		if err := p.models.Payments.DetachPaymentPartition(ctx, partition); err != nil {
+			return fmt.Errorf("detaching old partitions: %w", err)
+		}
+		log.Ctx(ctx).Infof("detached payment partition %s with ledgers [%d, %d)", partition.Name, partition.StartLedger, partition.EndLedger)
+
+		p.mu.Lock()
+		delete(p.attached, partition.Name)
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+// Run maintains the partitions around the ledger of the cursor every interval until the context is done. Failed runs
+// are logged and retried on the next interval.
+func (p *PaymentPartitions) Run(ctx context.Context, cursorName string, interval time.Duration, ahead, retained int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		latestLedger, err := p.models.Payments.GetLatestLedgerSynced(ctx, cursorName)
+		if err != nil {
+			log.Ctx(ctx).Errorf("getting the latest ledger synced: %v", err)
+		} else if latestLedger > 0 {
+			if err := p.Maintain(ctx, latestLedger, ahead, retained); err != nil && ctx.Err() == nil {
+				log.Ctx(ctx).Errorf("maintaining payment partitions: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
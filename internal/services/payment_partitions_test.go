@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestPaymentPartitionsMaintain(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	partitions, err := NewPaymentPartitions(models)
+	require.NoError(t, err)
+
+	getPartitions := func() []data.PaymentPartition {
+		got, err := models.Payments.GetPaymentPartitions(ctx)
+		require.NoError(t, err)
+		return got
+	}
+
+	t.Run("🟢creates_the_current_and_ahead_partitions", func(t *testing.T) {
+		err := partitions.Maintain(ctx, 1_200_000, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []data.PaymentPartition{
+			data.PaymentPartitionFor(1_000_000),
+			data.PaymentPartitionFor(1_500_000),
+			data.PaymentPartitionFor(2_000_000),
+		}, getPartitions())
+	})
+
+	t.Run("🟢detaches_the_partitions_older_than_the_retained_ones", func(t *testing.T) {
+		err := partitions.Maintain(ctx, 2_100_000, 0, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []data.PaymentPartition{
+			data.PaymentPartitionFor(1_500_000),
+			data.PaymentPartitionFor(2_000_000),
+		}, getPartitions())
+	})
+
+	t.Run("🟢ensure_recreates_a_detached_partition", func(t *testing.T) {
+		err := partitions.Ensure(ctx, 1_000_000)
+		require.NoError(t, err)
+		assert.Equal(t, []data.PaymentPartition{
+			data.PaymentPartitionFor(1_000_000),
+			data.PaymentPartitionFor(1_500_000),
+			data.PaymentPartitionFor(2_000_000),
+		}, getPartitions())
+	})
+}
@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/stellar/wallet-backend/internal/data"
 	"github.com/stellar/wallet-backend/internal/entities"
 )
 
 type PaymentService interface {
-	GetPaymentsPaginated(ctx context.Context, address string, beforeID, afterID string, sort data.SortOrder, limit int) ([]data.Payment, entities.Pagination, error)
+	GetPaymentsPaginated(ctx context.Context, filter data.PaymentsFilter, beforeID, afterID string, sort data.SortOrder, limit int) ([]data.Payment, entities.Pagination, error)
+	GetPaymentsAfter(ctx context.Context, filter data.PaymentsFilter, afterID string, limit int) ([]data.Payment, error)
+	GetLatestPaymentID(ctx context.Context, filter data.PaymentsFilter) (string, error)
+	GetPaymentAggregates(ctx context.Context, filter data.PaymentAggregatesFilter) ([]data.PaymentAggregate, error)
 }
 
 var _ PaymentService = (*paymentService)(nil)
@@ -37,29 +41,29 @@ func NewPaymentService(models *data.Models, serverBaseURL string) (*paymentServi
 	}, nil
 }
 
-func (s *paymentService) GetPaymentsPaginated(ctx context.Context, address string, beforeID, afterID string, sort data.SortOrder, limit int) ([]data.Payment, entities.Pagination, error) {
-	payments, prevExists, nextExists, err := s.models.Payments.GetPaymentsPaginated(ctx, address, beforeID, afterID, sort, limit)
+func (s *paymentService) GetPaymentsPaginated(ctx context.Context, filter data.PaymentsFilter, beforeID, afterID string, sort data.SortOrder, limit int) ([]data.Payment, entities.Pagination, error) {
+	payments, prevExists, nextExists, err := s.models.Payments.GetPaymentsPaginated(ctx, filter, beforeID, afterID, sort, limit)
 	if err != nil {
 		return nil, entities.Pagination{}, fmt.Errorf("getting payments: %w", err)
 	}
 
 	self, prev, next := "", "", ""
-	self, err = buildURL(s.serverBaseURL, address, beforeID, afterID, sort, limit)
+	self, err = buildURL(s.serverBaseURL, filter, beforeID, afterID, sort, limit)
 	if err != nil {
 		return nil, entities.Pagination{}, fmt.Errorf("building self link: %w", err)
 	}
 
 	if prevExists {
-		firstElementID := data.FirstPaymentOperationID(payments)
-		prev, err = buildURL(s.serverBaseURL, address, firstElementID, "", sort, limit)
+		firstElementID := data.FirstPaymentID(payments)
+		prev, err = buildURL(s.serverBaseURL, filter, firstElementID, "", sort, limit)
 		if err != nil {
 			return nil, entities.Pagination{}, fmt.Errorf("building prev link: %w", err)
 		}
 	}
 
 	if nextExists {
-		lastElementID := data.LastPaymentOperationID(payments)
-		next, err = buildURL(s.serverBaseURL, address, "", lastElementID, sort, limit)
+		lastElementID := data.LastPaymentID(payments)
+		next, err = buildURL(s.serverBaseURL, filter, "", lastElementID, sort, limit)
 		if err != nil {
 			return nil, entities.Pagination{}, fmt.Errorf("building next link: %w", err)
 		}
@@ -75,7 +79,36 @@ func (s *paymentService) GetPaymentsPaginated(ctx context.Context, address strin
 	return payments, pagination, nil
 }
 
-func buildURL(baseURL string, address string, beforeID, afterID string, sort data.SortOrder, limit int) (string, error) {
+// GetPaymentsAfter returns the payments after the afterID cursor in ascending order, without the pagination links, for
+// the callers walking the payments as they're ingested.
+func (s *paymentService) GetPaymentsAfter(ctx context.Context, filter data.PaymentsFilter, afterID string, limit int) ([]data.Payment, error) {
+	payments, err := s.models.Payments.GetPaymentsAfter(ctx, filter, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("getting payments: %w", err)
+	}
+	return payments, nil
+}
+
+// GetLatestPaymentID returns the ID of the latest payment matching the filter, or an empty ID when there's none.
+func (s *paymentService) GetLatestPaymentID(ctx context.Context, filter data.PaymentsFilter) (string, error) {
+	id, err := s.models.Payments.GetLatestPaymentID(ctx, filter)
+	if err != nil {
+		return "", fmt.Errorf("getting the latest payment ID: %w", err)
+	}
+	return id, nil
+}
+
+// GetPaymentAggregates returns the volumes sent and received per time bucket and asset, by the account of the filter or
+// by every registered account.
+func (s *paymentService) GetPaymentAggregates(ctx context.Context, filter data.PaymentAggregatesFilter) ([]data.PaymentAggregate, error) {
+	aggregates, err := s.models.PaymentVolumes.GetAggregates(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting payment aggregates: %w", err)
+	}
+	return aggregates, nil
+}
+
+func buildURL(baseURL string, filter data.PaymentsFilter, beforeID, afterID string, sort data.SortOrder, limit int) (string, error) {
 	url, err := url.ParseRequestURI(baseURL)
 	if err != nil {
 		return "", fmt.Errorf("parsing base URL: %s: %w", baseURL, err)
@@ -84,8 +117,47 @@ func buildURL(baseURL string, address string, beforeID, afterID string, sort dat
 	values := url.Query()
 	values.Add("sort", string(sort))
 	values.Add("limit", strconv.Itoa(limit))
-	if address != "" {
-		values.Add("address", string(address))
+	if filter.Address != "" {
+		values.Add("address", filter.Address)
+	}
+	for _, address := range filter.Addresses {
+		values.Add("addresses[]", address)
+	}
+	if filter.ExternalUserID != "" {
+		values.Add("externalUserId", filter.ExternalUserID)
+	}
+	if filter.MuxedID != nil {
+		values.Add("muxedId", strconv.FormatUint(*filter.MuxedID, 10))
+	}
+	if filter.IncludeFailed {
+		values.Add("includeFailed", "true")
+	}
+	if filter.Direction != "" {
+		values.Add("direction", string(filter.Direction))
+	}
+	if filter.AssetCode != "" {
+		values.Add("assetCode", filter.AssetCode)
+	}
+	if filter.AssetIssuer != "" {
+		values.Add("assetIssuer", filter.AssetIssuer)
+	}
+	if filter.OperationType != "" {
+		values.Add("operationType", filter.OperationType)
+	}
+	if filter.Memo != "" {
+		values.Add("memo", filter.Memo)
+	}
+	if filter.MemoType != "" {
+		values.Add("memoType", filter.MemoType)
+	}
+	if filter.From != nil {
+		values.Add("from", filter.From.Format(time.RFC3339Nano))
+	}
+	if filter.To != nil {
+		values.Add("to", filter.To.Format(time.RFC3339Nano))
+	}
+	if filter.MinAmount != nil {
+		values.Add("minAmount", strconv.FormatInt(*filter.MinAmount, 10))
 	}
 	if beforeID != "" {
 		values.Add("beforeId", beforeID)
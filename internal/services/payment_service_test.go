@@ -35,11 +35,11 @@ func TestPaymentServiceGetPaymentsPaginated(t *testing.T) {
 	ctx := context.Background()
 
 	dbPayments := []data.Payment{
-		{OperationID: "1", OperationType: xdr.OperationTypePayment.String(), TransactionID: "11", TransactionHash: "c370ff20144e4c96b17432b8d14664c1", FromAddress: "GAZ37ZO4TU3H", ToAddress: "GDD2HQO6IOFT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 10, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 10, CreatedAt: time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "2", OperationType: xdr.OperationTypePayment.String(), TransactionID: "22", TransactionHash: "30850d8fc7d1439782885103390cd975", FromAddress: "GBZ5Q56JKHJQ", ToAddress: "GASV72SENBSY", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 20, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 20, CreatedAt: time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "3", OperationType: xdr.OperationTypePayment.String(), TransactionID: "33", TransactionHash: "d9521ed7057d4d1e9b9dd22ab515cbf1", FromAddress: "GAYFAYPOECBT", ToAddress: "GDWDPNMALNIT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 30, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 30, CreatedAt: time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "4", OperationType: xdr.OperationTypePayment.String(), TransactionID: "44", TransactionHash: "2af98496a86741c6a6814200e06027fd", FromAddress: "GACKTNR2QQXU", ToAddress: "GBZ5KUZHAAVI", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 40, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 40, CreatedAt: time.Date(2024, 6, 24, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
-		{OperationID: "5", OperationType: xdr.OperationTypePayment.String(), TransactionID: "55", TransactionHash: "edfab36f9f104c4fb74b549de44cfbcc", FromAddress: "GA4CMYJEC5W5", ToAddress: "GAZ37ZO4TU3H", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 50, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 50, CreatedAt: time.Date(2024, 6, 25, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String()},
+		{OperationID: "1", OperationType: xdr.OperationTypePayment.String(), TransactionID: "11", TransactionHash: "c370ff20144e4c96b17432b8d14664c1", FromAddress: "GAZ37ZO4TU3H", ToAddress: "GDD2HQO6IOFT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 10, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 10, CreatedAt: time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "2", OperationType: xdr.OperationTypePayment.String(), TransactionID: "22", TransactionHash: "30850d8fc7d1439782885103390cd975", FromAddress: "GBZ5Q56JKHJQ", ToAddress: "GASV72SENBSY", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 20, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 20, CreatedAt: time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "3", OperationType: xdr.OperationTypePayment.String(), TransactionID: "33", TransactionHash: "d9521ed7057d4d1e9b9dd22ab515cbf1", FromAddress: "GAYFAYPOECBT", ToAddress: "GDWDPNMALNIT", SrcAssetCode: "XLM", SrcAssetIssuer: "", SrcAssetType: xdr.AssetTypeAssetTypeNative.String(), SrcAmount: 30, DestAssetCode: "XLM", DestAssetIssuer: "", DestAssetType: xdr.AssetTypeAssetTypeNative.String(), DestAmount: 30, CreatedAt: time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "4", OperationType: xdr.OperationTypePayment.String(), TransactionID: "44", TransactionHash: "2af98496a86741c6a6814200e06027fd", FromAddress: "GACKTNR2QQXU", ToAddress: "GBZ5KUZHAAVI", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 40, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 40, CreatedAt: time.Date(2024, 6, 24, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
+		{OperationID: "5", OperationType: xdr.OperationTypePayment.String(), TransactionID: "55", TransactionHash: "edfab36f9f104c4fb74b549de44cfbcc", FromAddress: "GA4CMYJEC5W5", ToAddress: "GAZ37ZO4TU3H", SrcAssetCode: "USDC", SrcAssetIssuer: "GAHLU7PDIQMZ", SrcAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), SrcAmount: 50, DestAssetCode: "USDC", DestAssetIssuer: "GAHLU7PDIQMZ", DestAssetType: xdr.AssetTypeAssetTypeCreditAlphanum4.String(), DestAmount: 50, CreatedAt: time.Date(2024, 6, 25, 0, 0, 0, 0, time.UTC), Memo: nil, MemoType: xdr.MemoTypeMemoNone.String(), Successful: true},
 	}
 	data.InsertTestPayments(t, ctx, dbPayments, dbConnectionPool)
 
@@ -48,7 +48,7 @@ func TestPaymentServiceGetPaymentsPaginated(t *testing.T) {
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		defer mockMetricsService.AssertExpectations(t)
 
-		payments, pagination, err := service.GetPaymentsPaginated(ctx, "", "", "", data.DESC, 2)
+		payments, pagination, err := service.GetPaymentsPaginated(ctx, data.PaymentsFilter{}, "", "", data.DESC, 2)
 		require.NoError(t, err)
 
 		assert.Equal(t, []data.Payment{
@@ -69,7 +69,7 @@ func TestPaymentServiceGetPaymentsPaginated(t *testing.T) {
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		defer mockMetricsService.AssertExpectations(t)
 
-		payments, pagination, err := service.GetPaymentsPaginated(ctx, "", "", dbPayments[3].OperationID, data.DESC, 2)
+		payments, pagination, err := service.GetPaymentsPaginated(ctx, data.PaymentsFilter{}, "", dbPayments[3].OperationID, data.DESC, 2)
 		require.NoError(t, err)
 
 		assert.Equal(t, []data.Payment{
@@ -90,7 +90,7 @@ func TestPaymentServiceGetPaymentsPaginated(t *testing.T) {
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		defer mockMetricsService.AssertExpectations(t)
 
-		payments, pagination, err := service.GetPaymentsPaginated(ctx, "", "", dbPayments[1].OperationID, data.DESC, 2)
+		payments, pagination, err := service.GetPaymentsPaginated(ctx, data.PaymentsFilter{}, "", dbPayments[1].OperationID, data.DESC, 2)
 		require.NoError(t, err)
 
 		assert.Equal(t, []data.Payment{
@@ -110,7 +110,7 @@ func TestPaymentServiceGetPaymentsPaginated(t *testing.T) {
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		defer mockMetricsService.AssertExpectations(t)
 
-		payments, pagination, err := service.GetPaymentsPaginated(ctx, "", dbPayments[0].OperationID, "", data.DESC, 2)
+		payments, pagination, err := service.GetPaymentsPaginated(ctx, data.PaymentsFilter{}, dbPayments[0].OperationID, "", data.DESC, 2)
 		require.NoError(t, err)
 
 		assert.Equal(t, []data.Payment{
@@ -131,7 +131,7 @@ func TestPaymentServiceGetPaymentsPaginated(t *testing.T) {
 		mockMetricsService.On("IncDBQuery", "SELECT", "ingest_payments").Times(2)
 		defer mockMetricsService.AssertExpectations(t)
 
-		payments, pagination, err := service.GetPaymentsPaginated(ctx, "", dbPayments[2].OperationID, "", data.DESC, 2)
+		payments, pagination, err := service.GetPaymentsPaginated(ctx, data.PaymentsFilter{}, dbPayments[2].OperationID, "", data.DESC, 2)
 		require.NoError(t, err)
 
 		assert.Equal(t, []data.Payment{
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	tssstore "github.com/stellar/wallet-backend/internal/tss/store"
+)
+
+// RetentionPolicy is how long the rows of each table are kept. A zero retention keeps them forever.
+type RetentionPolicy struct {
+	// Payments also applies to the keys of the payments rolled up into the payment volumes, the volumes themselves are
+	// kept forever.
+	Payments time.Duration
+	// UnregisteredPayments applies to the payments whose accounts are no longer registered, and is measured from both the
+	// payment and the deregistration of its accounts.
+	UnregisteredPayments time.Duration
+	// TSSTransactions and TSSTransactionSubmissionTries only apply to transactions whose webhook was sent.
+	TSSTransactions               time.Duration
+	TSSTransactionSubmissionTries time.Duration
+}
+
+// PruneResult is the number of rows a prune run deleted from a table.
+type PruneResult struct {
+	Table   string
+	Deleted int64
+}
+
+type pruneTarget struct {
+	table     string
+	retention time.Duration
+	prune     func(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// Pruner deletes the rows older than the retention policy. Rows are deleted in batches of batchSize, each in its own
+// statement, so locks are held briefly and an interrupted run loses no more than a batch of work.
+type Pruner struct {
+	targets        []pruneTarget
+	batchSize      int
+	metricsService metrics.MetricsService
+}
+
+func NewPruner(models *data.Models, tssStore tssstore.Store, policy RetentionPolicy, batchSize int, metricsService metrics.MetricsService) (*Pruner, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+	if tssStore == nil {
+		return nil, errors.New("tssStore cannot be nil")
+	}
+	if batchSize <= 0 {
+		return nil, errors.New("batchSize must be greater than zero")
+	}
+	if metricsService == nil {
+		return nil, errors.New("metricsService cannot be nil")
+	}
+
+	pruneUnregisteredPayments := func(ctx context.Context, before time.Time, limit int) (int64, error) {
+		return models.Payments.PrunePayments(ctx, before, true, limit)
+	}
+	prunePayments := func(ctx context.Context, before time.Time, limit int) (int64, error) {
+		return models.Payments.PrunePayments(ctx, before, false, limit)
+	}
+	var targets []pruneTarget
+	for _, target := range []pruneTarget{
+		{table: "tss_transaction_submission_tries", retention: policy.TSSTransactionSubmissionTries, prune: tssStore.PruneTries},
+		{table: "tss_transactions", retention: policy.TSSTransactions, prune: tssStore.PruneTransactions},
+		{table: "ingest_payments", retention: policy.UnregisteredPayments, prune: pruneUnregisteredPayments},
+		{table: "ingest_payments", retention: policy.Payments, prune: prunePayments},
+		{table: "payment_volume_payments", retention: policy.Payments, prune: models.PaymentVolumes.PruneRolledUpPayments},
+	} {
+		if target.retention > 0 {
+			targets = append(targets, target)
+		}
+	}
+
+	return &Pruner{
+		targets:        targets,
+		batchSize:      batchSize,
+		metricsService: metricsService,
+	}, nil
+}
+
+// Enabled reports whether the policy retains any table for a limited time.
+func (p *Pruner) Enabled() bool {
+	return len(p.targets) > 0
+}
+
+// Prune deletes the rows of every table older than its retention.
+func (p *Pruner) Prune(ctx context.Context) ([]PruneResult, error) {
+	results := make([]PruneResult, 0, len(p.targets))
+	now := time.Now()
+	for _, target := range p.targets {
+		deleted, err := p.pruneTarget(ctx, target, now.Add(-target.retention))
+		results = append(results, PruneResult{Table: target.table, Deleted: deleted})
+		if err != nil {
+			return results, fmt.Errorf("pruning %s: %w", target.table, err)
+		}
+	}
+	return results, nil
+}
+
+func (p *Pruner) pruneTarget(ctx context.Context, target pruneTarget, before time.Time) (int64, error) {
+	start := time.Now()
+	defer func() {
+		p.metricsService.ObservePruneDuration(target.table, time.Since(start).Seconds())
+	}()
+
+	var total int64
+	for {
+		deleted, err := target.prune(ctx, before, p.batchSize)
+		if err != nil {
+			return total, fmt.Errorf("deleting rows older than %s: %w", before, err)
+		}
+		total += deleted
+		p.metricsService.AddPrunedRows(target.table, deleted)
+		if deleted < int64(p.batchSize) {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, fmt.Errorf("pruning interrupted: %w", err)
+		}
+	}
+}
+
+// Run prunes every interval until the context is done. Failed runs are logged and retried on the next interval.
+func (p *Pruner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		results, err := p.Prune(ctx)
+		for _, result := range results {
+			log.Ctx(ctx).Infof("pruned %d rows from %s", result.Deleted, result.Table)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Ctx(ctx).Errorf("pruning: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
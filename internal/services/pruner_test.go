@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+	tssstore "github.com/stellar/wallet-backend/internal/tss/store"
+)
+
+func TestPruner(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	tssStore, err := tssstore.NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	registeredAccount := keypair.MustRandom().Address()
+	require.NoError(t, models.Account.Insert(ctx, registeredAccount))
+	unregisteredAccount := keypair.MustRandom().Address()
+	// its payments are old, but it was only deregistered now
+	recentlyDeregisteredAccount := keypair.MustRandom().Address()
+	require.NoError(t, models.Account.Insert(ctx, recentlyDeregisteredAccount))
+	require.NoError(t, models.Account.Delete(ctx, recentlyDeregisteredAccount))
+
+	daysAgo := func(days int) time.Time {
+		return time.Now().Add(-time.Duration(days) * 24 * time.Hour).UTC().Truncate(time.Second)
+	}
+	newPayment := func(operationID, address string, createdAt time.Time) data.Payment {
+		return data.Payment{
+			OperationID:   operationID,
+			OperationType: xdr.OperationTypePayment.String(),
+			TransactionID: operationID,
+			FromAddress:   address,
+			ToAddress:     keypair.MustRandom().Address(),
+			SrcAssetType:  xdr.AssetTypeAssetTypeNative.String(),
+			DestAssetType: xdr.AssetTypeAssetTypeNative.String(),
+			CreatedAt:     createdAt,
+			MemoType:      xdr.MemoTypeMemoNone.String(),
+			Successful:    true,
+		}
+	}
+	data.InsertTestPayments(t, ctx, []data.Payment{
+		newPayment("1", unregisteredAccount, daysAgo(10)),
+		newPayment("2", unregisteredAccount, daysAgo(9)),
+		newPayment("3", unregisteredAccount, daysAgo(8)),
+		newPayment("4", unregisteredAccount, daysAgo(1)),
+		newPayment("5", registeredAccount, daysAgo(10)),
+		newPayment("6", recentlyDeregisteredAccount, daysAgo(10)),
+	}, dbConnectionPool)
+
+	t.Run("🔴invalid_batch_size", func(t *testing.T) {
+		_, err := NewPruner(models, tssStore, RetentionPolicy{}, 0, mockMetricsService)
+		assert.EqualError(t, err, "batchSize must be greater than zero")
+	})
+
+	t.Run("🟢empty_policy_prunes_nothing", func(t *testing.T) {
+		pruner, err := NewPruner(models, tssStore, RetentionPolicy{}, 2, mockMetricsService)
+		require.NoError(t, err)
+		assert.False(t, pruner.Enabled())
+
+		results, err := pruner.Prune(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("🟢prunes_unregistered_payments_in_batches", func(t *testing.T) {
+		prunerMetricsService := metrics.NewMockMetricsService()
+		prunerMetricsService.On("AddPrunedRows", "ingest_payments", int64(2)).Once()
+		prunerMetricsService.On("AddPrunedRows", "ingest_payments", int64(1)).Once()
+		prunerMetricsService.On("ObservePruneDuration", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		defer prunerMetricsService.AssertExpectations(t)
+
+		pruner, err := NewPruner(models, tssStore, RetentionPolicy{UnregisteredPayments: 7 * 24 * time.Hour}, 2, prunerMetricsService)
+		require.NoError(t, err)
+		assert.True(t, pruner.Enabled())
+
+		results, err := pruner.Prune(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []PruneResult{{Table: "ingest_payments", Deleted: 3}}, results)
+
+		var operationIDs []string
+		err = dbConnectionPool.SelectContext(ctx, &operationIDs, `SELECT operation_id FROM ingest_payments ORDER BY operation_id`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"4", "5", "6"}, operationIDs)
+	})
+
+	t.Run("🟢keeps_old_payments_of_recently_deregistered_accounts", func(t *testing.T) {
+		prunerMetricsService := metrics.NewMockMetricsService()
+		prunerMetricsService.On("AddPrunedRows", "ingest_payments", int64(0)).Once()
+		prunerMetricsService.On("ObservePruneDuration", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		defer prunerMetricsService.AssertExpectations(t)
+
+		pruner, err := NewPruner(models, tssStore, RetentionPolicy{UnregisteredPayments: 7 * 24 * time.Hour}, 2, prunerMetricsService)
+		require.NoError(t, err)
+		results, err := pruner.Prune(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []PruneResult{{Table: "ingest_payments", Deleted: 0}}, results)
+
+		// once the retention passed since the deregistration, they're pruned too
+		_, err = dbConnectionPool.ExecContext(ctx, `UPDATE deregistered_accounts SET deregistered_at = $1 WHERE stellar_address = $2`, daysAgo(8), recentlyDeregisteredAccount)
+		require.NoError(t, err)
+		deleted, err := models.Payments.PrunePayments(ctx, daysAgo(7), true, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+
+	t.Run("🟢prunes_the_rolled_up_payment_keys_with_the_payments", func(t *testing.T) {
+		_, err := dbConnectionPool.ExecContext(ctx, `INSERT INTO payment_volume_payments (operation_id, event_index, created_at) VALUES (1, 0, $1), (4, 0, $2)`, daysAgo(10), daysAgo(1))
+		require.NoError(t, err)
+
+		prunerMetricsService := metrics.NewMockMetricsService()
+		prunerMetricsService.On("AddPrunedRows", "ingest_payments", int64(1)).Once()
+		prunerMetricsService.On("AddPrunedRows", "payment_volume_payments", int64(1)).Once()
+		prunerMetricsService.On("ObservePruneDuration", "ingest_payments", mock.AnythingOfType("float64")).Once()
+		prunerMetricsService.On("ObservePruneDuration", "payment_volume_payments", mock.AnythingOfType("float64")).Once()
+		defer prunerMetricsService.AssertExpectations(t)
+
+		pruner, err := NewPruner(models, tssStore, RetentionPolicy{Payments: 7 * 24 * time.Hour}, 2, prunerMetricsService)
+		require.NoError(t, err)
+		results, err := pruner.Prune(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []PruneResult{{Table: "ingest_payments", Deleted: 1}, {Table: "payment_volume_payments", Deleted: 1}}, results)
+
+		var operationIDs []string
+		err = dbConnectionPool.SelectContext(ctx, &operationIDs, `SELECT operation_id FROM payment_volume_payments`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"4"}, operationIDs)
+	})
+}
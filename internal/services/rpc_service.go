@@ -226,7 +226,6 @@ func (r *rpcService) TrackRPCServiceHealth(ctx context.Context, healthCheckTrigg
 	defer func() {
 		healthCheckTicker.Stop()
 		warningTicker.Stop()
-		close(r.heartbeatChannel)
 	}()
 
 	performHealthCheck := func() {
@@ -237,6 +236,12 @@ func (r *rpcService) TrackRPCServiceHealth(ctx context.Context, healthCheckTrigg
 			return
 		}
 
+		// the heartbeat channel outlives the tracker and may not be read for a while, e.g. by ingestion standbys, so a
+		// stale heartbeat nobody read is replaced by the latest one instead of blocking
+		select {
+		case <-r.heartbeatChannel:
+		default:
+		}
 		r.heartbeatChannel <- result
 		r.metricsService.SetRPCServiceHealth(true)
 		r.metricsService.SetRPCLatestLedger(int64(result.LatestLedger))
@@ -814,10 +814,13 @@ func TestTrackRPCService_ContextCancelled(t *testing.T) {
 
 	rpcService.TrackRPCServiceHealth(ctx, nil)
 
-	// Verify channel is closed after context cancellation
-	time.Sleep(100 * time.Millisecond)
-	_, ok := <-rpcService.GetHeartbeatChannel()
-	assert.False(t, ok, "channel should be closed")
+	// the heartbeat channel outlives the tracker, so it's left open for the next one
+	select {
+	case _, ok := <-rpcService.GetHeartbeatChannel():
+		assert.True(t, ok, "channel should not be closed")
+		t.Fatal("no heartbeat should be sent once the context is cancelled")
+	case <-time.After(100 * time.Millisecond):
+	}
 
 	mockHTTPClient.AssertNotCalled(t, "Post")
 }
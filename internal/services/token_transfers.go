@@ -0,0 +1,103 @@
+package services
+
+import (
+	"math"
+
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	tokenTransferEventType = "transfer"
+	tokenMintEventType     = "mint"
+	tokenBurnEventType     = "burn"
+	tokenClawbackEventType = "clawback"
+	// contractAssetType is stored as the asset type of token transfers, whose asset code is the token contract ID.
+	contractAssetType = "Contract"
+)
+
+// tokenTransfer is a balance movement decoded from a SEP-41 token event. Events that only have one side (mint, burn
+// and clawback without an admin) use the token contract ID as the other side.
+type tokenTransfer struct {
+	eventType  string
+	contractID string
+	from       string
+	to         string
+	amount     int64
+}
+
+// parseTokenTransferEvent decodes the SEP-41 `transfer`, `mint`, `burn` and `clawback` contract events. The Stellar
+// Asset Contract emits the same events with the SEP-11 asset name as an extra trailing topic, so both are handled by
+// reading the leading address topics. The second return value is false when the event is not a token transfer.
+func parseTokenTransferEvent(event xdr.ContractEvent) (tokenTransfer, bool) {
+	if event.Type != xdr.ContractEventTypeContract || event.ContractId == nil {
+		return tokenTransfer{}, false
+	}
+	body, ok := event.Body.GetV0()
+	if !ok || len(body.Topics) < 2 {
+		return tokenTransfer{}, false
+	}
+	eventType, ok := body.Topics[0].GetSym()
+	if !ok {
+		return tokenTransfer{}, false
+	}
+
+	var addresses []string
+	for _, topic := range body.Topics[1:] {
+		scAddress, ok := topic.GetAddress()
+		if !ok {
+			break
+		}
+		address, err := scAddress.String()
+		if err != nil {
+			return tokenTransfer{}, false
+		}
+		addresses = append(addresses, address)
+	}
+	// at most one trailing topic that is not an address is allowed: the SAC asset name
+	if len(body.Topics) > len(addresses)+2 {
+		return tokenTransfer{}, false
+	}
+
+	contractID, err := strkey.Encode(strkey.VersionByteContract, event.ContractId[:])
+	if err != nil {
+		return tokenTransfer{}, false
+	}
+	transfer := tokenTransfer{
+		eventType:  string(eventType),
+		contractID: contractID,
+	}
+
+	switch {
+	case transfer.eventType == tokenTransferEventType && len(addresses) == 2:
+		transfer.from, transfer.to = addresses[0], addresses[1]
+	case transfer.eventType == tokenMintEventType && len(addresses) == 2:
+		// admin, to
+		transfer.from, transfer.to = addresses[0], addresses[1]
+	case transfer.eventType == tokenMintEventType && len(addresses) == 1:
+		transfer.from, transfer.to = contractID, addresses[0]
+	case transfer.eventType == tokenBurnEventType && len(addresses) == 1:
+		transfer.from, transfer.to = addresses[0], contractID
+	case transfer.eventType == tokenClawbackEventType && len(addresses) == 2:
+		// admin, from
+		transfer.from, transfer.to = addresses[1], addresses[0]
+	case transfer.eventType == tokenClawbackEventType && len(addresses) == 1:
+		transfer.from, transfer.to = addresses[0], contractID
+	default:
+		return tokenTransfer{}, false
+	}
+
+	amount, ok := body.Data.GetI128()
+	if !ok {
+		return tokenTransfer{}, false
+	}
+	// amounts are stored as bigint, so the rare SEP-41 amounts that don't fit in it are left out
+	if amount.Hi != 0 || uint64(amount.Lo) > math.MaxInt64 {
+		log.Warnf("skipping %s event of contract %s with an amount that does not fit in int64", transfer.eventType, contractID)
+		return tokenTransfer{}, false
+	}
+	transfer.amount = int64(amount.Lo)
+
+	return transfer, true
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSymbolTopic(t *testing.T, sym string) xdr.ScVal {
+	t.Helper()
+	symbol := xdr.ScSymbol(sym)
+	return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &symbol}
+}
+
+func makeStringTopic(t *testing.T, s string) xdr.ScVal {
+	t.Helper()
+	str := xdr.ScString(s)
+	return xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &str}
+}
+
+func makeAddressTopic(t *testing.T, address string) xdr.ScVal {
+	t.Helper()
+	var scAddress xdr.ScAddress
+	switch address[0] {
+	case 'G':
+		accountID := xdr.MustAddress(address)
+		scAddress = xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeAccount, AccountId: &accountID}
+	case 'C':
+		decoded, err := strkey.Decode(strkey.VersionByteContract, address)
+		require.NoError(t, err)
+		var contractID xdr.Hash
+		copy(contractID[:], decoded)
+		scAddress = xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID}
+	default:
+		t.Fatalf("unsupported address %s", address)
+	}
+	return xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &scAddress}
+}
+
+func makeI128(hi int64, lo uint64) xdr.ScVal {
+	amount := xdr.Int128Parts{Hi: xdr.Int64(hi), Lo: xdr.Uint64(lo)}
+	return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &amount}
+}
+
+func makeContractEvent(t *testing.T, contractID string, data xdr.ScVal, topics ...xdr.ScVal) xdr.ContractEvent {
+	t.Helper()
+	decoded, err := strkey.Decode(strkey.VersionByteContract, contractID)
+	require.NoError(t, err)
+	var hash xdr.Hash
+	copy(hash[:], decoded)
+	return xdr.ContractEvent{
+		ContractId: &hash,
+		Type:       xdr.ContractEventTypeContract,
+		Body: xdr.ContractEventBody{
+			V:  0,
+			V0: &xdr.ContractEventV0{Topics: topics, Data: data},
+		},
+	}
+}
+
+func TestParseTokenTransferEvent(t *testing.T) {
+	contractID := strkey.MustEncode(strkey.VersionByteContract, make([]byte, 32))
+	otherContractID := strkey.MustEncode(strkey.VersionByteContract, []byte("11111111111111111111111111111111"))
+	from := keypair.MustRandom().Address()
+	to := keypair.MustRandom().Address()
+	admin := keypair.MustRandom().Address()
+	sacAsset := "USDC:" + admin
+	amount := makeI128(0, 100)
+
+	testCases := []struct {
+		name     string
+		event    xdr.ContractEvent
+		wantOK   bool
+		expected tokenTransfer
+	}{
+		{
+			name:     "sep41_transfer",
+			event:    makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from), makeAddressTopic(t, to)),
+			wantOK:   true,
+			expected: tokenTransfer{eventType: "transfer", contractID: contractID, from: from, to: to, amount: 100},
+		},
+		{
+			name:     "sac_transfer_to_contract",
+			event:    makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from), makeAddressTopic(t, otherContractID), makeStringTopic(t, sacAsset)),
+			wantOK:   true,
+			expected: tokenTransfer{eventType: "transfer", contractID: contractID, from: from, to: otherContractID, amount: 100},
+		},
+		{
+			name:     "sac_mint_with_admin",
+			event:    makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "mint"), makeAddressTopic(t, admin), makeAddressTopic(t, to), makeStringTopic(t, sacAsset)),
+			wantOK:   true,
+			expected: tokenTransfer{eventType: "mint", contractID: contractID, from: admin, to: to, amount: 100},
+		},
+		{
+			name:     "mint_without_admin",
+			event:    makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "mint"), makeAddressTopic(t, to), makeStringTopic(t, sacAsset)),
+			wantOK:   true,
+			expected: tokenTransfer{eventType: "mint", contractID: contractID, from: contractID, to: to, amount: 100},
+		},
+		{
+			name:     "burn",
+			event:    makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "burn"), makeAddressTopic(t, from)),
+			wantOK:   true,
+			expected: tokenTransfer{eventType: "burn", contractID: contractID, from: from, to: contractID, amount: 100},
+		},
+		{
+			name:     "clawback_with_admin",
+			event:    makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "clawback"), makeAddressTopic(t, admin), makeAddressTopic(t, from), makeStringTopic(t, sacAsset)),
+			wantOK:   true,
+			expected: tokenTransfer{eventType: "clawback", contractID: contractID, from: from, to: admin, amount: 100},
+		},
+		{
+			name:     "clawback_without_admin",
+			event:    makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "clawback"), makeAddressTopic(t, from), makeStringTopic(t, sacAsset)),
+			wantOK:   true,
+			expected: tokenTransfer{eventType: "clawback", contractID: contractID, from: from, to: contractID, amount: 100},
+		},
+		{
+			name:   "unknown_event",
+			event:  makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "approve"), makeAddressTopic(t, from), makeAddressTopic(t, to)),
+			wantOK: false,
+		},
+		{
+			name:   "transfer_missing_destination",
+			event:  makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from)),
+			wantOK: false,
+		},
+		{
+			name:   "too_many_trailing_topics",
+			event:  makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from), makeAddressTopic(t, to), makeStringTopic(t, "a"), makeStringTopic(t, "b")),
+			wantOK: false,
+		},
+		{
+			name:   "amount_is_not_i128",
+			event:  makeContractEvent(t, contractID, makeSymbolTopic(t, "100"), makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from), makeAddressTopic(t, to)),
+			wantOK: false,
+		},
+		{
+			name:   "amount_overflows_int64",
+			event:  makeContractEvent(t, contractID, makeI128(0, math.MaxInt64+1), makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from), makeAddressTopic(t, to)),
+			wantOK: false,
+		},
+		{
+			name:   "negative_amount",
+			event:  makeContractEvent(t, contractID, makeI128(-1, math.MaxUint64), makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from), makeAddressTopic(t, to)),
+			wantOK: false,
+		},
+		{
+			name: "system_event",
+			event: func() xdr.ContractEvent {
+				event := makeContractEvent(t, contractID, amount, makeSymbolTopic(t, "transfer"), makeAddressTopic(t, from), makeAddressTopic(t, to))
+				event.Type = xdr.ContractEventTypeSystem
+				return event
+			}(),
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transfer, ok := parseTokenTransferEvent(tc.event)
+			require.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.expected, transfer)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/wallet-backend/internal/data"
+)
+
+// TransactionDetails is what was ingested of a transaction. Transaction and Operations are only stored by the
+// operations ledger processor, so Transaction is nil when it's disabled and just the payments were ingested.
+type TransactionDetails struct {
+	Transaction *data.Transaction `json:"transaction"`
+	Operations  []data.Operation  `json:"operations"`
+	Payments    []data.Payment    `json:"payments"`
+}
+
+type TransactionService interface {
+	GetTransaction(ctx context.Context, hash string) (*TransactionDetails, error)
+}
+
+var _ TransactionService = (*transactionService)(nil)
+
+type transactionService struct {
+	models *data.Models
+}
+
+func NewTransactionService(models *data.Models) (*transactionService, error) {
+	if models == nil {
+		return nil, errors.New("models cannot be nil")
+	}
+
+	return &transactionService{
+		models: models,
+	}, nil
+}
+
+// GetTransaction returns the transaction with the hash along with its ingested operations and payments, or
+// data.ErrTransactionNotFound when nothing of it was ingested.
+func (s *transactionService) GetTransaction(ctx context.Context, hash string) (*TransactionDetails, error) {
+	transaction, err := s.models.Transactions.GetByHash(ctx, hash)
+	if err != nil && !errors.Is(err, data.ErrTransactionNotFound) {
+		return nil, fmt.Errorf("getting transaction: %w", err)
+	}
+	operations, err := s.models.Operations.GetTransactionOperations(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("getting operations: %w", err)
+	}
+	payments, err := s.models.Payments.GetTransactionPayments(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("getting payments: %w", err)
+	}
+
+	if transaction == nil && len(operations) == 0 && len(payments) == 0 {
+		return nil, data.ErrTransactionNotFound
+	}
+	return &TransactionDetails{
+		Transaction: transaction,
+		Operations:  operations,
+		Payments:    payments,
+	}, nil
+}
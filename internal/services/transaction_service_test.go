@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/wallet-backend/internal/data"
+	"github.com/stellar/wallet-backend/internal/db"
+	"github.com/stellar/wallet-backend/internal/db/dbtest"
+	"github.com/stellar/wallet-backend/internal/metrics"
+)
+
+func TestTransactionServiceGetTransaction(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	models, err := data.NewModels(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	service, err := NewTransactionService(models)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	account := keypair.MustRandom().Address()
+	require.NoError(t, models.Account.Insert(ctx, account))
+	const (
+		hash             = "a3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887"
+		paymentsOnlyHash = "b3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887"
+	)
+	createdAt := time.Date(2023, 12, 15, 1, 0, 0, 0, time.UTC)
+	err = db.RunInTransaction(ctx, dbConnectionPool, nil, func(dbTx db.Transaction) error {
+		err := models.Transactions.BatchAddTransactions(ctx, dbTx, []data.Transaction{{
+			TransactionID: "2120562792996864", TransactionHash: hash, LedgerNumber: 493733, SourceAccount: account,
+			MemoType: xdr.MemoTypeMemoNone.String(), Details: json.RawMessage(`{}`), Participants: []string{account}, CreatedAt: createdAt,
+		}})
+		if err != nil {
+			return err
+		}
+		return models.Operations.BatchAddOperations(ctx, dbTx, []data.Operation{
+			{
+				OperationID: "2120562792996866", OperationType: xdr.OperationTypeSetOptions.String(), TransactionID: "2120562792996864",
+				TransactionHash: hash, SourceAccount: account, Details: json.RawMessage(`{}`), Participants: []string{account}, CreatedAt: createdAt,
+			},
+			{
+				OperationID: "2120562792996865", OperationType: xdr.OperationTypePayment.String(), TransactionID: "2120562792996864",
+				TransactionHash: hash, SourceAccount: account, Details: json.RawMessage(`{}`), Participants: []string{account}, CreatedAt: createdAt,
+			},
+		})
+	})
+	require.NoError(t, err)
+	newPayment := func(operationID, transactionHash string) data.Payment {
+		return data.Payment{
+			OperationID: operationID, OperationType: xdr.OperationTypePayment.String(), TransactionID: operationID, TransactionHash: transactionHash,
+			FromAddress: account, ToAddress: account, SrcAssetCode: "XLM", DestAssetCode: "XLM", CreatedAt: createdAt,
+			MemoType: xdr.MemoTypeMemoNone.String(), Successful: true,
+		}
+	}
+	data.InsertTestPayments(t, ctx, []data.Payment{
+		newPayment("2120562792996865", hash),
+		newPayment("2120562797191169", paymentsOnlyHash),
+	}, dbConnectionPool)
+
+	t.Run("🟢transaction_with_operations_and_payments", func(t *testing.T) {
+		details, err := service.GetTransaction(ctx, hash)
+		require.NoError(t, err)
+		require.NotNil(t, details.Transaction)
+		assert.Equal(t, "2120562792996864", details.Transaction.TransactionID)
+		require.Len(t, details.Operations, 2)
+		assert.Equal(t, "2120562792996865", details.Operations[0].OperationID)
+		assert.Equal(t, "2120562792996866", details.Operations[1].OperationID)
+		require.Len(t, details.Payments, 1)
+		assert.Equal(t, "2120562792996865", details.Payments[0].OperationID)
+	})
+
+	t.Run("🟢payments_only", func(t *testing.T) {
+		details, err := service.GetTransaction(ctx, paymentsOnlyHash)
+		require.NoError(t, err)
+		assert.Nil(t, details.Transaction)
+		assert.Empty(t, details.Operations)
+		require.Len(t, details.Payments, 1)
+	})
+
+	t.Run("🔴not_found", func(t *testing.T) {
+		_, err := service.GetTransaction(ctx, "c3daffa64dc46db84888b1206dc8014a480042e7fe8b19fd5d05465709f4e887")
+		assert.ErrorIs(t, err, data.ErrTransactionNotFound)
+	})
+}
@@ -8,12 +8,12 @@ import (
 
 	"github.com/alitto/pond"
 	"github.com/stellar/go/support/log"
-	"golang.org/x/exp/rand"
 
 	"github.com/stellar/wallet-backend/internal/metrics"
 	"github.com/stellar/wallet-backend/internal/tss"
 	"github.com/stellar/wallet-backend/internal/tss/router"
 	"github.com/stellar/wallet-backend/internal/tss/services"
+	"github.com/stellar/wallet-backend/internal/utils"
 )
 
 type ErrorJitterChannelConfigs struct {
@@ -39,12 +39,6 @@ var ErrorJitterChannelName = "ErrorJitterChannel"
 
 var _ tss.Channel = (*errorJitterPool)(nil)
 
-func jitter(dur time.Duration) time.Duration {
-	halfDur := int64(dur / 2)
-	delta := rand.Int63n(halfDur) - halfDur/2
-	return dur + time.Duration(delta)
-}
-
 func NewErrorJitterChannel(cfg ErrorJitterChannelConfigs) *errorJitterPool {
 	pool := pond.New(cfg.MaxBufferSize, cfg.MaxWorkers, pond.Strategy(pond.Balanced()))
 	jitterPool := &errorJitterPool{
@@ -72,7 +66,7 @@ func (p *errorJitterPool) Receive(payload tss.Payload) {
 	var i int
 	for i = 0; i < p.MaxRetries; i++ {
 		currentBackoff := p.MinWaitBtwnRetriesMS * (1 << i)
-		time.Sleep(jitter(time.Duration(currentBackoff)) * time.Millisecond)
+		time.Sleep(utils.Jitter(time.Duration(currentBackoff)) * time.Millisecond)
 
 		oldStatus := payload.RPCSubmitTxResponse.Status.Status()
 		rpcSendResp, err := p.TxManager.BuildAndSubmitTransaction(ctx, ErrorJitterChannelName, payload)
@@ -104,7 +104,7 @@ func (p *webhookPool) Receive(payload tss.Payload) {
 				break
 			}
 			currentBackoff := p.MinWaitBtwnRetriesMS * (1 << i)
-			time.Sleep(jitter(time.Duration(currentBackoff)) * time.Millisecond)
+			time.Sleep(utils.Jitter(time.Duration(currentBackoff)) * time.Millisecond)
 		}
 	}
 	if !sent {
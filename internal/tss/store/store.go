@@ -16,10 +16,16 @@ type Store interface {
 	GetTransaction(ctx context.Context, hash string) (Transaction, error)
 	UpsertTransaction(ctx context.Context, WebhookURL string, txHash string, txXDR string, status tss.RPCTXStatus) error
 	UpsertTry(ctx context.Context, transactionHash string, feeBumpTxHash string, feeBumpTxXDR string, status tss.RPCTXStatus, code tss.RPCTXCode, resultXDR string) error
+	// UpsertTransactionInTx and UpsertTryInTx are UpsertTransaction and UpsertTry run through sqlExec, e.g. the database
+	// transaction of an ingested ledger.
+	UpsertTransactionInTx(ctx context.Context, sqlExec db.SQLExecuter, WebhookURL string, txHash string, txXDR string, status tss.RPCTXStatus) error
+	UpsertTryInTx(ctx context.Context, sqlExec db.SQLExecuter, transactionHash string, feeBumpTxHash string, feeBumpTxXDR string, status tss.RPCTXStatus, code tss.RPCTXCode, resultXDR string) error
 	GetTry(ctx context.Context, hash string) (Try, error)
 	GetTryByXDR(ctx context.Context, xdr string) (Try, error)
 	GetTransactionsWithStatus(ctx context.Context, status tss.RPCTXStatus) ([]Transaction, error)
 	GetLatestTry(ctx context.Context, txHash string) (Try, error)
+	PruneTransactions(ctx context.Context, before time.Time, limit int) (int64, error)
+	PruneTries(ctx context.Context, before time.Time, limit int) (int64, error)
 }
 
 var _ Store = (*store)(nil)
@@ -63,6 +69,10 @@ func NewStore(db db.ConnectionPool, metricsService metrics.MetricsService) (Stor
 }
 
 func (s *store) UpsertTransaction(ctx context.Context, webhookURL string, txHash string, txXDR string, status tss.RPCTXStatus) error {
+	return s.UpsertTransactionInTx(ctx, s.DB, webhookURL, txHash, txXDR, status)
+}
+
+func (s *store) UpsertTransactionInTx(ctx context.Context, sqlExec db.SQLExecuter, webhookURL string, txHash string, txXDR string, status tss.RPCTXStatus) error {
 	const q = `
 	INSERT INTO 
 		tss_transactions (transaction_hash, transaction_xdr, webhook_url, current_status)
@@ -76,7 +86,7 @@ func (s *store) UpsertTransaction(ctx context.Context, webhookURL string, txHash
     	updated_at = NOW();
 	`
 	start := time.Now()
-	_, err := s.DB.ExecContext(ctx, q, txHash, txXDR, webhookURL, status.Status())
+	_, err := sqlExec.ExecContext(ctx, q, txHash, txXDR, webhookURL, status.Status())
 	duration := time.Since(start).Seconds()
 	s.MetricsService.ObserveDBQueryDuration("INSERT", "tss_transactions", duration)
 	s.MetricsService.IncDBQuery("INSERT", "tss_transactions")
@@ -87,6 +97,10 @@ func (s *store) UpsertTransaction(ctx context.Context, webhookURL string, txHash
 }
 
 func (s *store) UpsertTry(ctx context.Context, txHash string, feeBumpTxHash string, feeBumpTxXDR string, status tss.RPCTXStatus, code tss.RPCTXCode, resultXDR string) error {
+	return s.UpsertTryInTx(ctx, s.DB, txHash, feeBumpTxHash, feeBumpTxXDR, status, code, resultXDR)
+}
+
+func (s *store) UpsertTryInTx(ctx context.Context, sqlExec db.SQLExecuter, txHash string, feeBumpTxHash string, feeBumpTxXDR string, status tss.RPCTXStatus, code tss.RPCTXCode, resultXDR string) error {
 	const q = `
 	INSERT INTO 
 		tss_transaction_submission_tries (original_transaction_hash, try_transaction_hash, try_transaction_xdr, status, code, result_xdr)
@@ -102,7 +116,7 @@ func (s *store) UpsertTry(ctx context.Context, txHash string, feeBumpTxHash stri
     	updated_at = NOW();
 	`
 	start := time.Now()
-	_, err := s.DB.ExecContext(ctx, q, txHash, feeBumpTxHash, feeBumpTxXDR, status.Status(), code.Code(), resultXDR)
+	_, err := sqlExec.ExecContext(ctx, q, txHash, feeBumpTxHash, feeBumpTxXDR, status.Status(), code.Code(), resultXDR)
 	duration := time.Since(start).Seconds()
 	s.MetricsService.ObserveDBQueryDuration("INSERT", "tss_transaction_submission_tries", duration)
 	s.MetricsService.IncDBQuery("INSERT", "tss_transaction_submission_tries")
@@ -196,3 +210,57 @@ func (s *store) GetLatestTry(ctx context.Context, txHash string) (Try, error) {
 	}
 	return try, nil
 }
+
+// PruneTransactions deletes up to limit transactions whose webhook was sent before the given time and returns how many
+// were deleted. Transactions still being processed are never deleted.
+func (s *store) PruneTransactions(ctx context.Context, before time.Time, limit int) (int64, error) {
+	q := `
+	DELETE FROM tss_transactions
+	WHERE transaction_hash IN (
+		SELECT transaction_hash FROM tss_transactions WHERE current_status = $1 AND updated_at < $2 LIMIT $3
+	)
+	`
+	start := time.Now()
+	result, err := s.DB.ExecContext(ctx, q, string(tss.SentStatus), before, limit)
+	duration := time.Since(start).Seconds()
+	s.MetricsService.ObserveDBQueryDuration("DELETE", "tss_transactions", duration)
+	s.MetricsService.IncDBQuery("DELETE", "tss_transactions")
+	if err != nil {
+		return 0, fmt.Errorf("pruning transactions: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("getting the number of pruned transactions: %w", err)
+	}
+	return deleted, nil
+}
+
+// PruneTries deletes up to limit tries updated before the given time and returns how many were deleted. The tries of
+// transactions still being processed are never deleted.
+func (s *store) PruneTries(ctx context.Context, before time.Time, limit int) (int64, error) {
+	q := `
+	DELETE FROM tss_transaction_submission_tries
+	WHERE try_transaction_hash IN (
+		SELECT tries.try_transaction_hash FROM tss_transaction_submission_tries tries
+		WHERE tries.updated_at < $2
+			AND NOT EXISTS (
+				SELECT 1 FROM tss_transactions txs
+				WHERE txs.transaction_hash = tries.original_transaction_hash AND txs.current_status <> $1
+			)
+		LIMIT $3
+	)
+	`
+	start := time.Now()
+	result, err := s.DB.ExecContext(ctx, q, string(tss.SentStatus), before, limit)
+	duration := time.Since(start).Seconds()
+	s.MetricsService.ObserveDBQueryDuration("DELETE", "tss_transaction_submission_tries", duration)
+	s.MetricsService.IncDBQuery("DELETE", "tss_transaction_submission_tries")
+	if err != nil {
+		return 0, fmt.Errorf("pruning tries: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("getting the number of pruned tries: %w", err)
+	}
+	return deleted, nil
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stellar/go/xdr"
 	"github.com/stretchr/testify/assert"
@@ -369,3 +370,70 @@ func TestGetLatestTry(t *testing.T) {
 		assert.Equal(t, "feebumptxhash2", try.Hash)
 	})
 }
+
+func TestPruneTransactionsAndTries(t *testing.T) {
+	dbt := dbtest.Open(t)
+	defer dbt.Close()
+	dbConnectionPool, err := db.OpenDBConnectionPool(dbt.DSN)
+	require.NoError(t, err)
+	defer dbConnectionPool.Close()
+
+	mockMetricsService := metrics.NewMockMetricsService()
+	mockMetricsService.On("ObserveDBQueryDuration", mock.Anything, mock.Anything, mock.AnythingOfType("float64"))
+	mockMetricsService.On("IncDBQuery", mock.Anything, mock.Anything)
+	store, err := NewStore(dbConnectionPool, mockMetricsService)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, row := range []struct {
+		hash   string
+		status tss.RPCTXStatus
+	}{
+		{"sent", tss.RPCTXStatus{OtherStatus: tss.SentStatus}},
+		{"not_sent", tss.RPCTXStatus{OtherStatus: tss.NotSentStatus}},
+		{"pending", tss.RPCTXStatus{RPCStatus: entities.PendingStatus}},
+	} {
+		require.NoError(t, store.UpsertTransaction(ctx, "www.stellar.org", row.hash, row.hash+"_xdr", row.status))
+		require.NoError(t, store.UpsertTry(ctx, row.hash, row.hash+"_try", row.hash+"_try_xdr", row.status, tss.RPCTXCode{OtherCodes: tss.NewCode}, ""))
+	}
+	_, err = dbConnectionPool.ExecContext(ctx, `UPDATE tss_transactions SET updated_at = NOW() - INTERVAL '2 days'`)
+	require.NoError(t, err)
+	_, err = dbConnectionPool.ExecContext(ctx, `UPDATE tss_transaction_submission_tries SET updated_at = NOW() - INTERVAL '2 days'`)
+	require.NoError(t, err)
+
+	t.Run("recent_rows_are_kept", func(t *testing.T) {
+		before := time.Now().Add(-3 * 24 * time.Hour)
+		deleted, err := store.PruneTries(ctx, before, 10)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+		deleted, err = store.PruneTransactions(ctx, before, 10)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+	})
+
+	t.Run("only_sent_transactions_are_pruned", func(t *testing.T) {
+		before := time.Now().Add(-24 * time.Hour)
+		deleted, err := store.PruneTries(ctx, before, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		deleted, err = store.PruneTransactions(ctx, before, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		tx, err := store.GetTransaction(ctx, "sent")
+		require.NoError(t, err)
+		assert.Empty(t, tx.Hash)
+		try, err := store.GetTry(ctx, "sent_try")
+		require.NoError(t, err)
+		assert.Empty(t, try.Hash)
+
+		for _, hash := range []string{"not_sent", "pending"} {
+			tx, err = store.GetTransaction(ctx, hash)
+			require.NoError(t, err)
+			assert.Equal(t, hash, tx.Hash)
+			try, err = store.GetTry(ctx, hash+"_try")
+			require.NoError(t, err)
+			assert.Equal(t, hash+"_try", try.Hash)
+		}
+	})
+}
@@ -7,4 +7,5 @@ import (
 
 type HTTPClient interface {
 	Post(url string, t string, body io.Reader) (resp *http.Response, err error)
+	Do(req *http.Request) (resp *http.Response, err error)
 }
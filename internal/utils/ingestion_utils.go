@@ -2,6 +2,8 @@ package utils
 
 import (
 	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/stellar/go/toid"
 	"github.com/stellar/go/xdr"
@@ -11,12 +13,76 @@ func OperationID(ledgerNumber, txNumber, opNumber int32) string {
 	return toid.New(ledgerNumber, txNumber, opNumber).String()
 }
 
+// OperationResult returns the result of the operation, or nil when the transaction failed before its operations were
+// applied or the operation failed before reaching its own result, e.g. with op_bad_auth.
 func OperationResult(txResult xdr.TransactionResult, opNumber int) *xdr.OperationResultTr {
-	results, _ := txResult.OperationResults()
-	tr := results[opNumber-1].MustTr()
+	results, ok := txResult.OperationResults()
+	if !ok || opNumber < 1 || opNumber > len(results) {
+		return nil
+	}
+	tr, ok := results[opNumber-1].GetTr()
+	if !ok {
+		return nil
+	}
 	return &tr
 }
 
+// TransactionResultCode returns the code of the transaction result in snake case, e.g. tx_bad_seq. Fee bump
+// transactions return the code of their inner transaction.
+func TransactionResultCode(txResult xdr.TransactionResult) string {
+	code := txResult.Result.Code
+	if innerResultPair, ok := txResult.Result.GetInnerResultPair(); ok {
+		code = innerResultPair.Result.Result.Code
+	}
+	return "tx_" + snakeCase(strings.TrimPrefix(code.String(), "TransactionResultCodeTx"))
+}
+
+// OperationResultCode returns the code of the operation result in snake case, e.g. op_underfunded, or nil when the
+// transaction failed before its operations were applied. Only the codes of the operations ingested as payments are
+// detailed, the others are reported as op_inner.
+func OperationResultCode(txResult xdr.TransactionResult, opNumber int) *string {
+	results, ok := txResult.OperationResults()
+	if !ok || opNumber < 1 || opNumber > len(results) {
+		return nil
+	}
+
+	result := results[opNumber-1]
+	name := strings.TrimPrefix(result.Code.String(), "OperationResultCodeOp")
+	if tr, ok := result.GetTr(); ok {
+		switch tr.Type {
+		case xdr.OperationTypePayment:
+			name = strings.TrimPrefix(tr.MustPaymentResult().Code.String(), "PaymentResultCodePayment")
+		case xdr.OperationTypePathPaymentStrictSend:
+			name = strings.TrimPrefix(tr.MustPathPaymentStrictSendResult().Code.String(), "PathPaymentStrictSendResultCodePathPaymentStrictSend")
+		case xdr.OperationTypePathPaymentStrictReceive:
+			name = strings.TrimPrefix(tr.MustPathPaymentStrictReceiveResult().Code.String(), "PathPaymentStrictReceiveResultCodePathPaymentStrictReceive")
+		case xdr.OperationTypeCreateAccount:
+			name = strings.TrimPrefix(tr.MustCreateAccountResult().Code.String(), "CreateAccountResultCodeCreateAccount")
+		case xdr.OperationTypeAccountMerge:
+			name = strings.TrimPrefix(tr.MustAccountMergeResult().Code.String(), "AccountMergeResultCodeAccountMerge")
+		case xdr.OperationTypeClaimClaimableBalance:
+			name = strings.TrimPrefix(tr.MustClaimClaimableBalanceResult().Code.String(), "ClaimClaimableBalanceResultCodeClaimClaimableBalance")
+		}
+	}
+	code := "op_" + snakeCase(name)
+	return &code
+}
+
+// snakeCase converts a CamelCase result code name like SrcNoTrust to src_no_trust.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 func TransactionID(ledgerNumber, txNumber int32) string {
 	return toid.New(int32(ledgerNumber), int32(txNumber), 0).String()
 }
@@ -58,11 +124,16 @@ func Memo(memo xdr.Memo, txHash string) (*string, string) {
 }
 
 func SourceAccount(op xdr.Operation, txEnvelope xdr.TransactionEnvelope) string {
-	account := op.SourceAccount
-	if account != nil {
-		return account.ToAccountId().Address()
+	account := SourceMuxedAccount(op, txEnvelope)
+	return account.ToAccountId().Address()
+}
+
+// SourceMuxedAccount returns the operation source account, which defaults to the transaction one, keeping its mux ID.
+func SourceMuxedAccount(op xdr.Operation, txEnvelope xdr.TransactionEnvelope) xdr.MuxedAccount {
+	if op.SourceAccount != nil {
+		return *op.SourceAccount
 	}
-	return txEnvelope.SourceAccount().ToAccountId().Address()
+	return txEnvelope.SourceAccount()
 }
 
 func AssetCode(asset xdr.Asset) string {
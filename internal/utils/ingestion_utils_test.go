@@ -69,3 +69,53 @@ func TestMemo(t *testing.T) {
 		assert.Equal(t, xdr.MemoTypeMemoReturn.String(), memoType)
 	})
 }
+
+func TestResultCodes(t *testing.T) {
+	t.Run("transaction_failed_before_its_operations", func(t *testing.T) {
+		txResult := xdr.TransactionResult{Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxBadSeq}}
+
+		assert.Equal(t, "tx_bad_seq", TransactionResultCode(txResult))
+		assert.Nil(t, OperationResultCode(txResult, 1))
+		assert.Nil(t, OperationResult(txResult, 1))
+	})
+
+	t.Run("failed_operations", func(t *testing.T) {
+		txResult := xdr.TransactionResult{Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFailed,
+			Results: &[]xdr.OperationResult{
+				{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+					Type:          xdr.OperationTypePayment,
+					PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSrcNoTrust},
+				}},
+				{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+					Type:                xdr.OperationTypeCreateAccount,
+					CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess},
+				}},
+				{Code: xdr.OperationResultCodeOpBadAuth},
+				{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+					Type:                  xdr.OperationTypeManageSellOffer,
+					ManageSellOfferResult: &xdr.ManageSellOfferResult{Code: xdr.ManageSellOfferResultCodeManageSellOfferUnderfunded},
+				}},
+			},
+		}}
+
+		assert.Equal(t, "tx_failed", TransactionResultCode(txResult))
+		assert.Equal(t, PointOf("op_src_no_trust"), OperationResultCode(txResult, 1))
+		assert.Equal(t, PointOf("op_success"), OperationResultCode(txResult, 2))
+		assert.Equal(t, PointOf("op_bad_auth"), OperationResultCode(txResult, 3))
+		assert.Nil(t, OperationResult(txResult, 3))
+		assert.Equal(t, PointOf("op_inner"), OperationResultCode(txResult, 4))
+		assert.Nil(t, OperationResultCode(txResult, 5))
+	})
+
+	t.Run("fee_bump_transaction", func(t *testing.T) {
+		txResult := xdr.TransactionResult{Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFeeBumpInnerFailed,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				Result: xdr.InnerTransactionResult{Result: xdr.InnerTransactionResultResult{Code: xdr.TransactionResultCodeTxTooLate}},
+			},
+		}}
+
+		assert.Equal(t, "tx_too_late", TransactionResultCode(txResult))
+	})
+}
@@ -18,3 +18,11 @@ func (s *MockHTTPClient) Post(url, contentType string, body io.Reader) (resp *ht
 	}
 	return args.Get(0).(*http.Response), args.Error(1)
 }
+
+func (s *MockHTTPClient) Do(req *http.Request) (resp *http.Response, err error) {
+	args := s.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*http.Response), args.Error(1)
+}
@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/log"
@@ -71,3 +73,13 @@ func DeferredClose(ctx context.Context, closer io.Closer, errMsg string) {
 		log.Ctx(ctx).Errorf("%s: %v", errMsg, err)
 	}
 }
+
+// Jitter randomizes the duration by up to a quarter of it either way, so retries of several clients don't line up.
+func Jitter(dur time.Duration) time.Duration {
+	halfDur := int64(dur / 2)
+	if halfDur <= 0 {
+		return dur
+	}
+	delta := rand.Int63n(halfDur) - halfDur/2
+	return dur + time.Duration(delta)
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -103,3 +104,18 @@ func TestIsEmpty(t *testing.T) {
 		})
 	}
 }
+
+func TestJitter(t *testing.T) {
+	t.Run("within_a_quarter", func(t *testing.T) {
+		for range 100 {
+			result := Jitter(100 * time.Millisecond)
+			assert.GreaterOrEqual(t, result, 75*time.Millisecond)
+			assert.LessOrEqual(t, result, 125*time.Millisecond)
+		}
+	})
+
+	t.Run("too_short_to_jitter", func(t *testing.T) {
+		assert.Equal(t, time.Duration(1), Jitter(1))
+		assert.Equal(t, time.Duration(0), Jitter(0))
+	})
+}